@@ -6,12 +6,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mitchellh/mapstructure"
-	"github.com/rs/zerolog/log"
 
 	"github.com/fakeyanss/jt808-server-go/internal/config"
 	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
 	"github.com/fakeyanss/jt808-server-go/internal/server"
 	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 func Run(serv *server.TCPServer, cfg *config.Config) {
@@ -91,12 +91,69 @@ func Run(serv *server.TCPServer, cfg *config.Config) {
 		serv.Send(session.ID, &msg)
 	})
 
+	router.PUT("/device/:phone/name", adminAuth(cfg), setDeviceName)
+	router.GET("/search", searchDevice)
+
+	router.GET("/device/:phone/track/replay", replayTrack)
+
+	router.GET("/device/:phone/quality", getDeviceQuality)
+	router.DELETE("/device/:phone/quality", adminAuth(cfg), resetDeviceQuality)
+	router.GET("/quality/ranking", getQualityRanking)
+
+	router.POST("/device/:phone/raw", adminAuth(cfg), sendRawMsg(serv, cfg))
+
+	securityGroup := router.Group("/security", adminAuth(cfg), securityEnabled)
+	securityGroup.GET("/events", listSecurityEvents)
+	securityGroup.GET("/quarantine", listQuarantine)
+	securityGroup.POST("/quarantine/:phone", quarantineDevice)
+	securityGroup.DELETE("/quarantine/:phone", releaseQuarantine)
+
+	videoGroup := router.Group("/video", adminAuth(cfg), videoEnabled)
+	videoGroup.POST("/realtime", startRealtimeVideo)
+	videoGroup.POST("/playback", startPlaybackVideo)
+	videoGroup.GET("/sessions", listVideoSessions)
+	videoGroup.DELETE("/sessions/:id", stopVideoSession)
+
+	grafanaGroup := router.Group("/grafana", grafanaEnabled)
+	grafanaGroup.GET("/", grafanaTest)
+	grafanaGroup.POST("/search", grafanaSearch)
+	grafanaGroup.POST("/query", grafanaQuery)
+	grafanaGroup.POST("/annotations", grafanaAnnotations)
+
+	router.GET("/incidents", outageEnabled, listIncidents)
+
+	replicationGroup := router.Group("/replication", adminAuth(cfg), replicationEnabled)
+	replicationGroup.GET("", getReplicationStatus)
+	replicationGroup.POST("/promote", promoteStandby)
+
+	osmandGroup := router.Group("/osmand", adminAuth(cfg), osmandEnabled)
+	osmandGroup.POST("/devices", registerTracker)
+	osmandGroup.DELETE("/devices/:phone", removeTracker)
+
+	mediaGroup := router.Group("/media", blobEnabled)
+	mediaGroup.GET("", adminAuth(cfg), listMedia)
+	mediaGroup.PUT("/objects/*key", adminAuth(cfg), putMedia)
+	mediaGroup.GET("/objects/*key", adminAuth(cfg), presignMedia)
+	mediaGroup.DELETE("/objects/*key", adminAuth(cfg), deleteMedia)
+	mediaGroup.GET("/download/*key", downloadMedia)
+
+	router.GET("/log/levels", adminAuth(cfg), listLogLevels)
+	router.PUT("/log/levels", adminAuth(cfg), updateLogLevels)
+
+	router.GET("/bans", adminAuth(cfg), listBans)
+	router.DELETE("/bans/:ip", adminAuth(cfg), liftBan)
+
+	router.GET("/places/candidates", listCandidatePlaces)
+	router.POST("/places/candidates/:id/promote", adminAuth(cfg), promoteCandidatePlace)
+	router.GET("/geofences", listGeofences)
+	router.DELETE("/geofences/:id", adminAuth(cfg), delGeofence)
+
 	httpAddr := ":" + cfg.Server.Port.HTTPPort
 
-	log.Debug().Msgf("Listening and serving HTTP on :%s", cfg.Server.Port.HTTPPort)
+	logger.For(logger.ModuleAPI).Debug().Msgf("Listening and serving HTTP on :%s", cfg.Server.Port.HTTPPort)
 	err := router.Run(httpAddr)
 	if err != nil {
-		log.Error().Err(err).Str("addr", httpAddr).Msg("Fail to run gin router")
+		logger.For(logger.ModuleAPI).Error().Err(err).Str("addr", httpAddr).Msg("Fail to run gin router")
 		os.Exit(1)
 	}
 }
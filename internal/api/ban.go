@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fakeyanss/jt808-server-go/internal/ban"
+)
+
+func listBans(c *gin.Context) {
+	jail := ban.Default()
+	if jail == nil {
+		c.JSON(http.StatusOK, []*ban.Ban{})
+		return
+	}
+	c.JSON(http.StatusOK, jail.List(time.Now()))
+}
+
+func liftBan(c *gin.Context) {
+	jail := ban.Default()
+	if jail == nil || !jail.Lift(c.Param("ip")) {
+		c.JSON(http.StatusNotFound, gin.H{"err": "Ip is not banned"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
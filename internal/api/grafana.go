@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fakeyanss/jt808-server-go/internal/timeline"
+)
+
+// Grafana Simple JSON和Infinity数据源的请求格式
+
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type grafanaSearchReq struct {
+	Target string `json:"target"` // 指标名前缀
+}
+
+type grafanaQueryReq struct {
+	Range      grafanaRange `json:"range" binding:"required"`
+	IntervalMs int64        `json:"intervalMs"`
+	Targets    []struct {
+		Target string `json:"target"`
+		RefID  string `json:"refId"`
+	} `json:"targets"`
+}
+
+type grafanaSeries struct {
+	Target     string           `json:"target"`
+	RefID      string           `json:"refId,omitempty"`
+	Datapoints []timeline.Point `json:"datapoints"`
+}
+
+// 注释的query为<kind>[:<phone>]，kind可选alarm、status、incident，为空或all时返回全部
+type grafanaAnnotationReq struct {
+	Range      grafanaRange   `json:"range" binding:"required"`
+	Annotation map[string]any `json:"annotation"`
+}
+
+type grafanaAnnotation struct {
+	Annotation map[string]any `json:"annotation,omitempty"` // 原样返回请求中的注释定义
+	Time       int64          `json:"time"`
+	Title      string         `json:"title"`
+	Text       string         `json:"text"`
+	Tags       []string       `json:"tags"`
+}
+
+// 未启用Grafana数据源时返回404
+func grafanaEnabled(c *gin.Context) {
+	if timeline.Default() == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": timeline.ErrTimelineDisabled.Error()})
+		return
+	}
+	c.Next()
+}
+
+// 数据源连通性测试
+func grafanaTest(c *gin.Context) {
+	c.String(http.StatusOK, "OK")
+}
+
+func grafanaSearch(c *gin.Context) {
+	req := &grafanaSearchReq{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, timeline.Default().Targets(req.Target))
+}
+
+func grafanaQuery(c *gin.Context) {
+	req := &grafanaQueryReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	interval := time.Duration(req.IntervalMs) * time.Millisecond
+	ans := make([]*grafanaSeries, 0, len(req.Targets))
+	for _, t := range req.Targets {
+		if t.Target == "" {
+			continue // 面板还未选择指标
+		}
+		points, err := timeline.Default().Series(t.Target, req.Range.From, req.Range.To, interval)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+		ans = append(ans, &grafanaSeries{Target: t.Target, RefID: t.RefID, Datapoints: points})
+	}
+	c.JSON(http.StatusOK, ans)
+}
+
+func grafanaAnnotations(c *gin.Context) {
+	req := &grafanaAnnotationReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	query, _ := req.Annotation["query"].(string)
+	kind, phone, _ := strings.Cut(query, ":")
+	if kind == "all" {
+		kind = ""
+	}
+	events := timeline.Default().Events(kind, phone, req.Range.From, req.Range.To)
+	ans := make([]*grafanaAnnotation, 0, len(events))
+	for _, e := range events {
+		text := e.Name
+		if e.Reason != "" {
+			text += ", reason=" + e.Reason
+		}
+		ans = append(ans, &grafanaAnnotation{
+			Annotation: req.Annotation,
+			Time:       e.Time.UnixMilli(),
+			Title:      e.Title(),
+			Text:       text,
+			Tags:       []string{e.Kind, e.Phone, e.Name},
+		})
+	}
+	c.JSON(http.StatusOK, ans)
+}
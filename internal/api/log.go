@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
+)
+
+func listLogLevels(c *gin.Context) {
+	c.JSON(http.StatusOK, logger.Levels())
+}
+
+// 运行时调整模块的日志级别，请求体为 {"<module>": "<level>"}
+func updateLogLevels(c *gin.Context) {
+	levels := map[string]string{}
+	if err := c.ShouldBindJSON(&levels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	// 先校验全部模块和级别，避免部分生效
+	for module, level := range levels {
+		if err := logger.ValidateLevel(module, level); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+	}
+	for module, level := range levels {
+		_ = logger.SetLevel(module, level)
+	}
+	c.JSON(http.StatusOK, logger.Levels())
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/blobstore"
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
+)
+
+// 未启用对象存储时返回404
+func blobEnabled(c *gin.Context) {
+	if blobstore.Default() == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": blobstore.ErrBlobDisabled.Error()})
+		return
+	}
+	c.Next()
+}
+
+func blobErrStatus(err error) int {
+	switch {
+	case errors.Is(err, blob.ErrBadKey):
+		return http.StatusBadRequest
+	case errors.Is(err, blob.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// 通配参数带有前导的/
+func objectKey(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("key"), "/")
+}
+
+// 按前缀列出对象，默认为多媒体文件
+func listMedia(c *gin.Context) {
+	objects, err := blobstore.Default().List(c.Request.Context(), c.DefaultQuery("prefix", blobstore.PrefixMedia))
+	if err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	if objects == nil {
+		objects = []*blob.Object{}
+	}
+	c.JSON(http.StatusOK, objects)
+}
+
+// 上传对象，请求体为文件内容，大文件在对象存储上分片上传
+func putMedia(c *gin.Context) {
+	key := objectKey(c)
+	if err := blobstore.Default().Put(c.Request.Context(), key, c.Request.Body); err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	obj, err := blobstore.Default().Stat(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, obj)
+}
+
+type presignResp struct {
+	*blob.Object
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// 返回对象信息和预签名的下载地址，下载不经过本服务
+func presignMedia(c *gin.Context) {
+	store := blobstore.Default()
+	obj, err := store.Stat(c.Request.Context(), objectKey(c))
+	if err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	ttl := blobstore.PresignTTL()
+	url, err := store.PresignGet(obj.Key, ttl)
+	if err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, &presignResp{Object: obj, URL: url, ExpiresAt: time.Now().Add(ttl)})
+}
+
+func deleteMedia(c *gin.Context) {
+	if err := blobstore.Default().Delete(c.Request.Context(), objectKey(c)); err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// 本地存储的预签名下载，校验签名后返回文件，无需管理令牌
+func downloadMedia(c *gin.Context) {
+	store, ok := blobstore.Default().(*blob.LocalStore)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"err": blob.ErrPresignDisabled.Error()})
+		return
+	}
+	key := objectKey(c)
+	err := store.VerifyPresigned(key, c.Query("expires"), c.Query("signature"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"err": err.Error()})
+		return
+	}
+	obj, err := store.Stat(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	r, err := store.Get(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(blobErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	defer r.Close()
+	c.DataFromReader(http.StatusOK, obj.Size, "application/octet-stream", r, nil)
+}
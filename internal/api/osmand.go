@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/osmand"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 未启用OsmAnd接入时返回404
+func osmandEnabled(c *gin.Context) {
+	if osmand.Default() == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": osmand.ErrOsmAndDisabled.Error()})
+		return
+	}
+	c.Next()
+}
+
+type trackerReq struct {
+	Phone string `json:"phone" binding:"required"` // 应用中填写的设备标识
+	Plate string `json:"plate"`
+	Name  string `json:"name"`
+}
+
+// 登记手机定位终端
+func registerTracker(c *gin.Context) {
+	req := &trackerReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	device, err := protocol.RegisterTracker(req.Phone, req.Plate)
+	if errors.Is(err, protocol.ErrTrackerExists) {
+		c.JSON(http.StatusConflict, gin.H{"err": err.Error()})
+		return
+	}
+	if errors.Is(err, protocol.ErrBadTrackerPhone) {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	if req.Name != "" {
+		device.Name = req.Name
+		storage.GetDeviceCache().CacheDevice(device)
+	}
+	c.JSON(http.StatusCreated, device)
+}
+
+func removeTracker(c *gin.Context) {
+	err := protocol.RemoveTracker(c.Param("phone"))
+	if errors.Is(err, storage.ErrDeviceNotFound) || errors.Is(err, protocol.ErrNotTracker) {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	if errors.Is(err, protocol.ErrBadTrackerPhone) {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fakeyanss/jt808-server-go/internal/outage"
+)
+
+// 未启用大面积掉线检测时返回404
+func outageEnabled(c *gin.Context) {
+	if outage.Default() == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": outage.ErrOutageDisabled.Error()})
+		return
+	}
+	c.Next()
+}
+
+// 查询平台级故障，active=true时只返回未恢复的故障
+func listIncidents(c *gin.Context) {
+	c.JSON(http.StatusOK, outage.Default().List(c.Query("active") == "true"))
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 提升候选地点的请求，为空时沿用候选地点的名称和半径
+type promoteReq struct {
+	Name   string  `json:"name"`
+	Radius float64 `json:"radius"` // 单位m
+}
+
+func listCandidatePlaces(c *gin.Context) {
+	c.JSON(http.StatusOK, storage.GetPlaceCache().ListCandidates())
+}
+
+// 将候选地点提升为电子围栏，围栏ID与候选地点ID相同
+func promoteCandidatePlace(c *gin.Context) {
+	req := &promoteReq{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+	}
+	if req.Radius < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid radius"})
+		return
+	}
+
+	cache := storage.GetPlaceCache()
+	p, err := cache.GetCandidate(c.Param("id"))
+	if errors.Is(err, storage.ErrCandidatePlaceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	if p.GeofenceID != "" {
+		c.JSON(http.StatusConflict, gin.H{"err": "Candidate place already promoted", "geofenceId": p.GeofenceID})
+		return
+	}
+
+	g := &model.Geofence{
+		ID:        p.ID,
+		Name:      p.Name,
+		Kind:      p.Kind,
+		Latitude:  p.Latitude,
+		Longitude: p.Longitude,
+		Radius:    p.Radius,
+		CreatedAt: time.Now(),
+	}
+	if req.Name != "" {
+		g.Name = req.Name
+	}
+	if req.Radius > 0 {
+		g.Radius = req.Radius
+	}
+	err = cache.PromoteCandidate(p.ID, g)
+	if errors.Is(err, storage.ErrCandidatePlaceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+func listGeofences(c *gin.Context) {
+	c.JSON(http.StatusOK, storage.GetPlaceCache().ListGeofences())
+}
+
+func delGeofence(c *gin.Context) {
+	cache := storage.GetPlaceCache()
+	id := c.Param("id")
+	if _, err := cache.GetGeofence(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	if err := cache.DelGeofence(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/quality"
+)
+
+func getDeviceQuality(c *gin.Context) {
+	report, err := quality.GetReport(c.Param("phone"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// 清除终端的质量统计，如更换终端后重新统计
+func resetDeviceQuality(c *gin.Context) {
+	tracker := quality.Default()
+	if tracker == nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": quality.ErrQualityDisabled.Error()})
+		return
+	}
+	if !tracker.Reset(c.Param("phone")) {
+		c.JSON(http.StatusNotFound, gin.H{"err": quality.ErrQualityNotFound.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// 质量分从低到高的终端排名，limit默认返回全部
+func getQualityRanking(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid limit"})
+		return
+	}
+	reports, err := quality.GetRanking(limit)
+	if errors.Is(err, quality.ErrQualityDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/server"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+const defaultRawMsgTimeout = 10 // 单位s
+
+// 管理接口鉴权，请求头需要携带 Authorization: Bearer <token>
+func adminAuth(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin := cfg.Server.Admin
+		if admin == nil || admin.Token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"err": "Admin api is disabled"})
+			return
+		}
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(admin.Token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"err": "Invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// 透传消息请求，消息体二选一：hex字符串或字段列表
+type rawMsgReq struct {
+	MsgID   string            `json:"msgId" binding:"required"` // 消息ID，支持0x前缀的十六进制
+	Body    string            `json:"body"`                     // 消息体hex字符串
+	Fields  []*model.RawField `json:"fields"`                   // 消息体字段列表，按顺序编码
+	ReplyID string            `json:"replyMsgId"`               // 期望的应答消息ID，为空时等待任一终端应答消息
+	Timeout int               `json:"timeout"`                  // 等待应答的超时时间，单位s，0使用配置的默认值
+}
+
+type rawMsgReply struct {
+	MsgID string         `json:"msgId"`
+	Hex   string         `json:"hex"`           // 消息头和消息体，不含标识位和校验码
+	Msg   model.JT808Msg `json:"msg,omitempty"` // 能够解析时的消息内容
+}
+
+// 下发尚未建模的消息，等待终端按应答消息ID和流水号应答
+func sendRawMsg(serv *server.TCPServer, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := &rawMsgReq{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+		msgID, err := strconv.ParseUint(req.MsgID, 0, 16)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid msgId"})
+			return
+		}
+		var replyIDs []uint16
+		if req.ReplyID != "" {
+			replyID, err := strconv.ParseUint(req.ReplyID, 0, 16)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid replyMsgId"})
+				return
+			}
+			replyIDs = append(replyIDs, uint16(replyID))
+		}
+		phone := c.Param("phone")
+		device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+		session, err := storage.GetSession(device.SessionID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+
+		var body []byte
+		switch {
+		case req.Body != "" && len(req.Fields) > 0:
+			c.JSON(http.StatusBadRequest, gin.H{"err": "Only one of body and fields is allowed"})
+			return
+		case len(req.Fields) > 0:
+			body, err = model.EncodeRawFields(req.Fields, device.Charset)
+		default:
+			body, err = model.DecodeHexString(req.Body)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+		if len(body) > model.MaxBodyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"err": fmt.Sprintf("Body too long, length=%d", len(body))})
+			return
+		}
+
+		msg := &model.RawMsg{
+			Header: model.GenMsgHeader(device, uint16(msgID), session.GetNextSerialNum()),
+			Body:   body,
+		}
+		serial := msg.Header.SerialNumber
+		replyCh, cancel := protocol.WaitReply(device.Phone, serial, replyIDs...)
+		defer cancel()
+		if err = serv.Send(session.ID, msg); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"serialNumber": serial, "err": err.Error()})
+			return
+		}
+
+		timeout := req.Timeout
+		if timeout <= 0 && cfg.Server.Admin != nil {
+			timeout = cfg.Server.Admin.RawMsgTimeout
+		}
+		if timeout <= 0 {
+			timeout = defaultRawMsgTimeout
+		}
+		select {
+		case pkt := <-replyCh:
+			c.JSON(http.StatusOK, gin.H{"serialNumber": serial, "reply": genRawMsgReply(pkt)})
+		case <-time.After(time.Duration(timeout) * time.Second):
+			c.JSON(http.StatusGatewayTimeout, gin.H{"serialNumber": serial, "err": "Wait reply timeout"})
+		case <-c.Request.Context().Done():
+		}
+	}
+}
+
+func genRawMsgReply(pkt *model.PacketData) *rawMsgReply {
+	reply := &rawMsgReply{MsgID: fmt.Sprintf("0x%04x", pkt.Header.MsgID)}
+	headerPkt, _ := pkt.Header.Encode()
+	reply.Hex = hex.Byte2Str(append(headerPkt, pkt.Body...))
+	msg, err := protocol.NewJT808MsgProcessor().DecodeIncoming(pkt)
+	if err == nil {
+		reply.Msg = msg
+	}
+	return reply
+}
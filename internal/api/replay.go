@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/replay"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
+)
+
+// 其他接口也未校验来源，这里同样允许跨域的前端连接
+var replayUpgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// 以WebSocket回放历史轨迹，参数start、end为RFC3339时间，speed为倍速，默认1。
+// 连接后客户端可以发送pause、resume、seek、speed指令
+func replayTrack(c *gin.Context) {
+	phone := c.Param("phone")
+	start, err := time.Parse(time.RFC3339, c.Query("start"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid start"})
+		return
+	}
+	end, err := time.Parse(time.RFC3339, c.Query("end"))
+	if err != nil || end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid end"})
+		return
+	}
+	speed, err := strconv.ParseFloat(c.DefaultQuery("speed", "1"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid speed"})
+		return
+	}
+
+	// 缓存中的定位时间是终端上报时间的字面值
+	from, to := hex.FromDeviceZone(start), hex.FromDeviceZone(end)
+	geos := storage.GetGeoCache().ListGeosBetween(phone, from, to)
+	if len(geos) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"err": "No positions in time range"})
+		return
+	}
+	stops := storage.GetPlaceCache().ListStopsBetween(phone, from, to)
+	player, err := replay.NewPlayer(replay.BuildFrames(geos, stops), speed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+
+	conn, err := replayUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade已经回复了错误
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	commands := make(chan *replay.Command)
+	go func() {
+		defer cancel()
+		for {
+			cmd := &replay.Command{}
+			if err := conn.ReadJSON(cmd); err != nil {
+				if _, ok := err.(*websocket.CloseError); !ok {
+					logger.For(logger.ModuleAPI).Debug().Err(err).Str("device", phone).Msg("Stop reading replay commands")
+				}
+				return
+			}
+			select {
+			case commands <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err = player.Run(ctx, commands, func(frame *replay.Frame) error {
+		return conn.WriteJSON(frame)
+	})
+	if err != nil {
+		logger.For(logger.ModuleAPI).Debug().Err(err).Str("device", phone).Msg("Stop track replay")
+	}
+}
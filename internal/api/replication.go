@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/replication"
+)
+
+// 未启用主备复制时返回404
+func replicationEnabled(c *gin.Context) {
+	if replication.Default() == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": replication.ErrReplicationDisabled.Error()})
+		return
+	}
+	c.Next()
+}
+
+func getReplicationStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, replication.Default().Status())
+}
+
+// 将备节点提升为主节点，之后开始接入终端
+func promoteStandby(c *gin.Context) {
+	node := replication.Default()
+	err := node.Promote()
+	if errors.Is(err, replication.ErrNotStandby) || errors.Is(err, replication.ErrAlreadyPromoted) {
+		c.JSON(http.StatusConflict, gin.H{"err": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, node.Status())
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type deviceNameReq struct {
+	Name string `json:"name"`
+}
+
+// 按车牌、手机号、终端ID和车辆名称模糊搜索终端
+func searchDevice(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"err": "Query q is required"})
+		return
+	}
+	limit := defaultSearchLimit
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"err": "Invalid limit, should be in [1, 100]"})
+			return
+		}
+		limit = n
+	}
+	c.JSON(http.StatusOK, storage.GetDeviceCache().Search(q, limit))
+}
+
+// 设置车辆名称，重新缓存以更新搜索字段
+func setDeviceName(c *gin.Context) {
+	req := &deviceNameReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	cache := storage.GetDeviceCache()
+	device, err := cache.GetDeviceByPhone(c.Param("phone"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	device.Name = req.Name
+	cache.CacheDevice(device)
+	c.Status(http.StatusNoContent)
+}
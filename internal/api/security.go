@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/security"
+)
+
+// 未启用异常检测时返回404
+func securityEnabled(c *gin.Context) {
+	if security.Default() == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": security.ErrSecurityDisabled.Error()})
+		return
+	}
+	c.Next()
+}
+
+// 查询安全事件，可按phone过滤
+func listSecurityEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, security.Default().Bus.List(c.Query("phone")))
+}
+
+func listQuarantine(c *gin.Context) {
+	c.JSON(http.StatusOK, security.Default().Quarantined.List())
+}
+
+func quarantineDevice(c *gin.Context) {
+	if err := protocol.QuarantineDevice(c.Param("phone")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func releaseQuarantine(c *gin.Context) {
+	if !security.Default().Quarantined.Release(c.Param("phone")) {
+		c.JSON(http.StatusNotFound, gin.H{"err": "Device is not quarantined"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/internal/video"
+)
+
+// 未启用视频转发时返回404
+func videoEnabled(c *gin.Context) {
+	if video.Default() == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"err": video.ErrVideoDisabled.Error()})
+		return
+	}
+	c.Next()
+}
+
+// 监管平台请求实时视频，返回拉流地址
+func startRealtimeVideo(c *gin.Context) {
+	req := &video.RealtimeReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	s, err := video.Default().StartRealtime(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(videoErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// 监管平台请求录像回放，返回拉流地址
+func startPlaybackVideo(c *gin.Context) {
+	req := &video.PlaybackReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	s, err := video.Default().StartPlayback(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(videoErrStatus(err), gin.H{"err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// 查询视频会话，可按platform过滤
+func listVideoSessions(c *gin.Context) {
+	c.JSON(http.StatusOK, video.Default().List(c.Query("platform")))
+}
+
+func stopVideoSession(c *gin.Context) {
+	err := video.Default().Stop(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, video.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"err": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func videoErrStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrDeviceNotFound), errors.Is(err, storage.ErrSessionClosed):
+		return http.StatusNotFound
+	case errors.Is(err, video.ErrReplyTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, video.ErrTerminalRefused):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
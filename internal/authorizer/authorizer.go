@@ -0,0 +1,38 @@
+// Package authorizer 将终端注册和鉴权的判定委托给外部业务平台。
+//
+// Gateway模式下车辆档案由业务平台维护，平台只负责协议接入，
+// 配置Authorizer后processMsg0100和processMsg0102不再使用本地规则判定。
+package authorizer
+
+import (
+	"context"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 判定结果
+type Decision struct {
+	Result      uint8  `json:"result"`      // 注册时为0x8100的结果码，鉴权时为0x8001的结果码
+	AuthCode    string `json:"authCode"`    // 注册成功时下发的鉴权码，为空时由平台生成
+	VehicleName string `json:"vehicleName"` // 注册成功时车辆档案中的车辆名称，可为空
+}
+
+// 终端连接信息
+type SessionInfo struct {
+	ID         string                  `json:"id"`
+	RemoteAddr string                  `json:"remoteAddr"`
+	TransProto model.TransportProtocol `json:"transProto"`
+}
+
+func NewSessionInfo(session *model.Session) *SessionInfo {
+	info := &SessionInfo{ID: session.ID, TransProto: session.GetTransProto()}
+	if session.Conn != nil {
+		info.RemoteAddr = session.Conn.RemoteAddr().String()
+	}
+	return info
+}
+
+type Authorizer interface {
+	Register(ctx context.Context, msg *model.Msg0100, session *SessionInfo) (*Decision, error)
+	Authenticate(ctx context.Context, msg *model.Msg0102, session *SessionInfo) (*Decision, error)
+}
@@ -0,0 +1,137 @@
+package authorizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var ErrCallbackFailed = errors.New("Authorizer callback failed")
+
+// 回调请求，msg为完整解析后的0x0100或0x0102消息
+type callbackReq struct {
+	MsgID   uint16         `json:"msgId"`
+	Msg     model.JT808Msg `json:"msg"`
+	Session *SessionInfo   `json:"session"`
+}
+
+type cacheEntry struct {
+	decision *Decision
+	expireAt time.Time
+}
+
+// 通过HTTP回调外部服务判定注册和鉴权
+type HTTPAuthorizer struct {
+	url      string
+	client   *http.Client
+	failOpen bool
+	cacheTTL time.Duration
+
+	mutex *sync.Mutex
+	cache map[string]*cacheEntry
+}
+
+func NewHTTPAuthorizer(conf *config.AuthorizerConf) *HTTPAuthorizer {
+	return &HTTPAuthorizer{
+		url:      conf.URL,
+		client:   &http.Client{Timeout: time.Duration(conf.Timeout) * time.Millisecond},
+		failOpen: conf.FailOpen,
+		cacheTTL: time.Duration(conf.CacheTTL) * time.Second,
+		mutex:    &sync.Mutex{},
+		cache:    make(map[string]*cacheEntry),
+	}
+}
+
+func (a *HTTPAuthorizer) Register(ctx context.Context, msg *model.Msg0100, session *SessionInfo) (*Decision, error) {
+	key := fmt.Sprintf("0100|%s|%s|%s|%s", msg.Header.PhoneNumber, msg.PlateNumber, msg.DeviceID, msg.ManufacturerID)
+	fallback := &Decision{Result: uint8(model.ResDeviceNotExist)}
+	if a.failOpen {
+		fallback = &Decision{Result: uint8(model.ResSuccess)}
+	}
+	return a.decide(ctx, key, &callbackReq{MsgID: model.MsgID0100, Msg: msg, Session: session}, fallback)
+}
+
+func (a *HTTPAuthorizer) Authenticate(ctx context.Context, msg *model.Msg0102, session *SessionInfo) (*Decision, error) {
+	key := fmt.Sprintf("0102|%s|%s|%s", msg.Header.PhoneNumber, msg.AuthCode, msg.IMEI)
+	fallback := &Decision{Result: uint8(model.ResultFail)}
+	if a.failOpen {
+		fallback = &Decision{Result: uint8(model.ResultSuccess)}
+	}
+	return a.decide(ctx, key, &callbackReq{MsgID: model.MsgID0102, Msg: msg, Session: session}, fallback)
+}
+
+// 命中缓存直接返回；回调失败时按策略返回fallback，且不缓存
+func (a *HTTPAuthorizer) decide(ctx context.Context, key string, req *callbackReq, fallback *Decision) (*Decision, error) {
+	if d := a.getCache(key); d != nil {
+		return d, nil
+	}
+	d, err := a.callback(ctx, req)
+	if err != nil {
+		log.Error().Err(err).Str("device", req.Msg.GetHeader().PhoneNumber).Bool("failOpen", a.failOpen).
+			Msgf("Fail to call authorizer, msgId=0x%04x", req.MsgID)
+		return fallback, nil
+	}
+	a.putCache(key, d)
+	return d, nil
+}
+
+func (a *HTTPAuthorizer) callback(ctx context.Context, req *callbackReq) (*Decision, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to serialize authorizer request")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to create authorizer request")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to request authorizer")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(ErrCallbackFailed, "status=%d", resp.StatusCode)
+	}
+	d := &Decision{}
+	if err = json.NewDecoder(resp.Body).Decode(d); err != nil {
+		return nil, errors.Wrap(err, "Fail to deserialize authorizer response")
+	}
+	return d, nil
+}
+
+func (a *HTTPAuthorizer) getCache(key string) *Decision {
+	if a.cacheTTL <= 0 {
+		return nil
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	entry, ok := a.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expireAt) {
+		delete(a.cache, key)
+		return nil
+	}
+	return entry.decision
+}
+
+func (a *HTTPAuthorizer) putCache(key string, d *Decision) {
+	if a.cacheTTL <= 0 {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.cache[key] = &cacheEntry{decision: d, expireAt: time.Now().Add(a.cacheTTL)}
+}
@@ -0,0 +1,111 @@
+package authorizer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func genMsg0100(phone string) *model.Msg0100 {
+	return &model.Msg0100{
+		Header:      &model.MsgHeader{MsgID: model.MsgID0100, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2019}, PhoneNumber: phone},
+		DeviceID:    "1234567",
+		PlateNumber: "京A12345",
+	}
+}
+
+func TestHTTPAuthorizer_Register(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		req := make(map[string]any)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, float64(model.MsgID0100), req["msgId"])
+		require.Equal(t, "127.0.0.1:1234", req["session"].(map[string]any)["remoteAddr"])
+		msg := req["msg"].(map[string]any)
+		switch msg["header"].(map[string]any)["phoneNumber"] {
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&Decision{Result: 0, AuthCode: "code-" + msg["plateNumber"].(string)})
+	}))
+	defer srv.Close()
+
+	session := &SessionInfo{ID: "127.0.0.1:1234", RemoteAddr: "127.0.0.1:1234", TransProto: model.TCPProto}
+	tests := []struct {
+		name      string
+		phone     string
+		failOpen  bool
+		want      *Decision
+		wantCalls int32
+	}{
+		{
+			name:      "case1: accepted and cached",
+			phone:     "13012345678",
+			want:      &Decision{Result: 0, AuthCode: "code-京A12345"},
+			wantCalls: 1,
+		},
+		{
+			name:      "case2: timeout, fail closed",
+			phone:     "slow",
+			want:      &Decision{Result: uint8(model.ResDeviceNotExist)},
+			wantCalls: 2,
+		},
+		{
+			name:      "case3: server error, fail open",
+			phone:     "broken",
+			failOpen:  true,
+			want:      &Decision{Result: uint8(model.ResSuccess)},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			a := NewHTTPAuthorizer(&config.AuthorizerConf{URL: srv.URL, Timeout: 50, FailOpen: tt.failOpen, CacheTTL: 60})
+			for i := 0; i < 2; i++ {
+				got, err := a.Register(context.Background(), genMsg0100(tt.phone), session)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+			// 失败的判定不缓存，每次都会回调
+			require.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestStubAuthorizer(t *testing.T) {
+	a := NewStubAuthorizer()
+	a.Reject("13000000000", model.ResCarNotExist)
+	ctx := context.Background()
+
+	d, err := a.Register(ctx, genMsg0100("13000000000"), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(model.ResCarNotExist), d.Result)
+
+	d, err = a.Register(ctx, genMsg0100("13012345678"), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(model.ResSuccess), d.Result)
+
+	auth := &model.Msg0102{Header: genMsg0100("13012345678").Header, AuthCode: d.AuthCode}
+	d, err = a.Authenticate(ctx, auth, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(model.ResultSuccess), d.Result)
+
+	auth.AuthCode = "wrong"
+	d, err = a.Authenticate(ctx, auth, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(model.ResultFail), d.Result)
+}
@@ -0,0 +1,52 @@
+package authorizer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 本地桩实现，用于测试。注册时按手机号分配鉴权码，鉴权时校验鉴权码
+type StubAuthorizer struct {
+	mutex     *sync.Mutex
+	authCodes map[string]string // <phone, authCode>
+	rejects   map[string]uint8  // 指定拒绝注册的终端，<phone, 0x8100结果码>
+}
+
+func NewStubAuthorizer() *StubAuthorizer {
+	return &StubAuthorizer{
+		mutex:     &sync.Mutex{},
+		authCodes: make(map[string]string),
+		rejects:   make(map[string]uint8),
+	}
+}
+
+// 拒绝指定终端的注册
+func (a *StubAuthorizer) Reject(phone string, result model.ResultCodeType) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.rejects[phone] = uint8(result)
+}
+
+func (a *StubAuthorizer) Register(_ context.Context, msg *model.Msg0100, _ *SessionInfo) (*Decision, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	phone := msg.Header.PhoneNumber
+	if result, ok := a.rejects[phone]; ok {
+		return &Decision{Result: result}, nil
+	}
+	authCode := "stub" + phone
+	a.authCodes[phone] = authCode
+	return &Decision{Result: uint8(model.ResSuccess), AuthCode: authCode}, nil
+}
+
+func (a *StubAuthorizer) Authenticate(_ context.Context, msg *model.Msg0102, _ *SessionInfo) (*Decision, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	authCode, ok := a.authCodes[msg.Header.PhoneNumber]
+	if !ok || authCode != msg.AuthCode {
+		return &Decision{Result: uint8(model.ResultFail)}, nil
+	}
+	return &Decision{Result: uint8(model.ResultSuccess)}, nil
+}
@@ -0,0 +1,278 @@
+// Package ban, 按远端IP统计协议错误和鉴权失败，超过阈值时封禁，类似fail2ban。
+//
+// 同一IP再次被封禁时，封禁时长翻倍，直到上限。
+package ban
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fakeyanss/gron"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+)
+
+var ErrInvalidAllowlist = errors.New("Invalid ban allowlist")
+
+// 计数的失败类型
+type Reason string
+
+const (
+	ReasonAuthFailure   Reason = "auth_failure"   // 0x0102鉴权失败
+	ReasonChecksumError Reason = "checksum_error" // 校验码错误
+	ReasonUndecodable   Reason = "undecodable"    // 无法解析的帧
+	ReasonUnsupported   Reason = "unsupported"    // 平台未处理的消息，合法终端也会上报，阈值应远高于其他类型
+)
+
+const (
+	defaultWindow     = 60    // 单位s
+	defaultBanTime    = 600   // 单位s
+	defaultMaxBanTime = 86400 // 单位s
+	sweepJobID        = "ban-sweep"
+)
+
+type Ban struct {
+	IP       string    `json:"ip"`
+	Reason   Reason    `json:"reason"`
+	Offenses int       `json:"offenses"` // 第几次被封禁
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+}
+
+// 单个IP的失败记录
+type record struct {
+	failures map[Reason][]time.Time // 窗口内各类失败的时间
+	offenses int                    // 累计封禁次数，用于递增封禁时长
+	ban      *Ban
+	lastBan  time.Time // 最近一次封禁结束的时间
+}
+
+type Jail struct {
+	window     time.Duration
+	banTime    time.Duration
+	maxBanTime time.Duration
+	thresholds map[Reason]int
+	allowlist  []*net.IPNet
+
+	mutex   *sync.Mutex
+	records map[string]*record
+}
+
+func NewJail(conf *config.BanConf) (*Jail, error) {
+	j := &Jail{
+		window:     time.Duration(conf.Window) * time.Second,
+		banTime:    time.Duration(conf.BanTime) * time.Second,
+		maxBanTime: time.Duration(conf.MaxBanTime) * time.Second,
+		thresholds: map[Reason]int{
+			ReasonAuthFailure:   conf.MaxAuthFailures,
+			ReasonChecksumError: conf.MaxChecksumErrors,
+			ReasonUndecodable:   conf.MaxUndecodable,
+			ReasonUnsupported:   conf.MaxUnsupportedMsgs,
+		},
+		mutex:   &sync.Mutex{},
+		records: make(map[string]*record),
+	}
+	if j.window <= 0 {
+		j.window = defaultWindow * time.Second
+	}
+	if j.banTime <= 0 {
+		j.banTime = defaultBanTime * time.Second
+	}
+	if j.maxBanTime < j.banTime {
+		j.maxBanTime = defaultMaxBanTime * time.Second
+	}
+	for _, entry := range conf.Allowlist {
+		ipNet, err := parseIPNet(entry)
+		if err != nil {
+			return nil, err
+		}
+		j.allowlist = append(j.allowlist, ipNet)
+	}
+	return j, nil
+}
+
+// 支持单个IP或CIDR
+func parseIPNet(entry string) (*net.IPNet, error) {
+	if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+		return ipNet, nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, errors.Wrapf(ErrInvalidAllowlist, "entry=%s", entry)
+	}
+	bits := 8 * len(ip)
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func (j *Jail) allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range j.allowlist {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// 记录一次失败，窗口内次数达到阈值时封禁，返回该IP当前是否被封禁
+func (j *Jail) Record(ip string, reason Reason, now time.Time) bool {
+	threshold := j.thresholds[reason]
+	if threshold <= 0 || j.allowed(ip) {
+		return false
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	r, ok := j.records[ip]
+	if !ok {
+		r = &record{failures: make(map[Reason][]time.Time)}
+		j.records[ip] = r
+	}
+	if r.ban != nil {
+		if now.Before(r.ban.Until) {
+			return true
+		}
+		r.ban = nil
+	}
+
+	failures := append(prune(r.failures[reason], now.Add(-j.window)), now)
+	if len(failures) < threshold {
+		r.failures[reason] = failures
+		return false
+	}
+
+	// 距上次封禁结束超过最长封禁时长，重新计算封禁时长
+	if r.ban == nil && !r.lastBan.IsZero() && now.Sub(r.lastBan) > j.maxBanTime {
+		r.offenses = 0
+	}
+	r.offenses++
+	banTime := j.banTime
+	for i := 1; i < r.offenses && banTime < j.maxBanTime; i++ {
+		banTime *= 2
+	}
+	if banTime > j.maxBanTime {
+		banTime = j.maxBanTime
+	}
+	r.ban = &Ban{IP: ip, Reason: reason, Offenses: r.offenses, Since: now, Until: now.Add(banTime)}
+	r.lastBan = r.ban.Until
+	r.failures = make(map[Reason][]time.Time)
+	log.Warn().Str("ip", ip).Str("reason", string(reason)).Int("offenses", r.offenses).
+		Dur("duration", banTime).Msg("Ban remote ip")
+	return true
+}
+
+func prune(times []time.Time, since time.Time) []time.Time {
+	i := sort.Search(len(times), func(i int) bool { return !times[i].Before(since) })
+	return times[i:]
+}
+
+func (j *Jail) IsBanned(ip string, now time.Time) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	r, ok := j.records[ip]
+	return ok && r.ban != nil && now.Before(r.ban.Until)
+}
+
+// 当前生效的封禁，按开始时间排列
+func (j *Jail) List(now time.Time) []*Ban {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	ans := []*Ban{}
+	for _, r := range j.records {
+		if r.ban != nil && now.Before(r.ban.Until) {
+			ans = append(ans, r.ban)
+		}
+	}
+	sort.Slice(ans, func(i, k int) bool { return ans[i].Since.Before(ans[k].Since) })
+	return ans
+}
+
+// 解除封禁，累计次数保留，再次封禁时仍按递增的时长
+func (j *Jail) Lift(ip string) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	r, ok := j.records[ip]
+	if !ok || r.ban == nil {
+		return false
+	}
+	r.ban = nil
+	r.lastBan = time.Now()
+	return true
+}
+
+// 清理过期的封禁和长时间没有失败的记录
+func (j *Jail) Sweep(now time.Time) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	for ip, r := range j.records {
+		if r.ban != nil && !now.Before(r.ban.Until) {
+			r.ban = nil
+		}
+		active := false
+		for reason, failures := range r.failures {
+			r.failures[reason] = prune(failures, now.Add(-j.window))
+			active = active || len(r.failures[reason]) > 0
+		}
+		if r.ban == nil && !active && now.Sub(r.lastBan) > j.maxBanTime {
+			delete(j.records, ip)
+		}
+	}
+}
+
+func (j *Jail) JobID() string {
+	return sweepJobID
+}
+
+func (j *Jail) Run() {
+	j.Sweep(time.Now())
+}
+
+var jailInstance *Jail
+
+// 启用封禁，定时清理过期记录
+func Start(conf *config.BanConf) error {
+	j, err := NewJail(conf)
+	if err != nil {
+		return err
+	}
+	cron := gron.New()
+	cron.Add(gron.Every(j.window), j)
+	cron.Start()
+	jailInstance = j
+	return nil
+}
+
+// 返回启用的封禁组件，未启用时为nil
+func Default() *Jail {
+	return jailInstance
+}
+
+// 按连接地址记录失败，未启用时忽略，返回该IP当前是否被封禁
+func Record(addr string, reason Reason) bool {
+	if jailInstance == nil {
+		return false
+	}
+	return jailInstance.Record(Host(addr), reason, time.Now())
+}
+
+func IsBanned(addr string) bool {
+	return jailInstance != nil && jailInstance.IsBanned(Host(addr), time.Now())
+}
+
+// 连接地址中的IP
+func Host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return h
+}
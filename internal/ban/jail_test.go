@@ -0,0 +1,102 @@
+package ban
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+)
+
+func TestJail_Record(t *testing.T) {
+	j, err := NewJail(&config.BanConf{
+		Window:            60,
+		MaxAuthFailures:   3,
+		MaxChecksumErrors: 2,
+		BanTime:           100,
+		MaxBanTime:        300,
+		Allowlist:         []string{"10.0.0.0/8", "192.168.1.1"},
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	ip := "1.2.3.4"
+
+	// 窗口外的失败不计数
+	require.False(t, j.Record(ip, ReasonAuthFailure, now))
+	require.False(t, j.Record(ip, ReasonAuthFailure, now.Add(61*time.Second)))
+	require.False(t, j.Record(ip, ReasonAuthFailure, now.Add(62*time.Second)))
+	// 不同类型分别计数，未配置阈值的类型不计数
+	require.False(t, j.Record(ip, ReasonChecksumError, now.Add(62*time.Second)))
+	require.False(t, j.Record(ip, ReasonUndecodable, now.Add(62*time.Second)))
+	require.False(t, j.Record(ip, ReasonUnsupported, now.Add(62*time.Second)))
+	require.True(t, j.Record(ip, ReasonAuthFailure, now.Add(63*time.Second)))
+	require.True(t, j.IsBanned(ip, now.Add(162*time.Second)))
+	require.False(t, j.IsBanned(ip, now.Add(163*time.Second)))
+
+	bans := j.List(now.Add(100 * time.Second))
+	require.Len(t, bans, 1)
+	require.Equal(t, &Ban{IP: ip, Reason: ReasonAuthFailure, Offenses: 1, Since: now.Add(63 * time.Second), Until: now.Add(163 * time.Second)}, bans[0])
+
+	// 再次封禁时长翻倍，不超过上限
+	next := now.Add(200 * time.Second)
+	for i, want := range []time.Duration{200 * time.Second, 300 * time.Second, 300 * time.Second} {
+		require.False(t, j.Record(ip, ReasonChecksumError, next))
+		require.True(t, j.Record(ip, ReasonChecksumError, next))
+		ban := j.List(next)[0]
+		require.Equal(t, i+2, ban.Offenses)
+		require.Equal(t, want, ban.Until.Sub(ban.Since))
+		next = ban.Until
+	}
+
+	// 解除封禁
+	require.True(t, j.Lift(ip))
+	require.False(t, j.IsBanned(ip, next.Add(-time.Second)))
+	require.False(t, j.Lift(ip))
+
+	// 白名单
+	for _, allowed := range []string{"10.1.2.3", "192.168.1.1"} {
+		for i := 0; i < 5; i++ {
+			require.False(t, j.Record(allowed, ReasonAuthFailure, now))
+		}
+	}
+	require.False(t, j.Record("192.168.1.2", ReasonChecksumError, now))
+	require.True(t, j.Record("192.168.1.2", ReasonChecksumError, now))
+}
+
+func TestJail_Sweep(t *testing.T) {
+	j, err := NewJail(&config.BanConf{Window: 60, MaxAuthFailures: 2, BanTime: 100, MaxBanTime: 300})
+	require.NoError(t, err)
+	now := time.Now()
+	j.Record("1.1.1.1", ReasonAuthFailure, now)
+	j.Record("2.2.2.2", ReasonAuthFailure, now)
+	j.Record("2.2.2.2", ReasonAuthFailure, now)
+
+	j.Sweep(now.Add(61 * time.Second))
+	require.NotContains(t, j.records, "1.1.1.1")
+	require.Contains(t, j.records, "2.2.2.2")
+	j.Sweep(now.Add(101 * time.Second))
+	require.Contains(t, j.records, "2.2.2.2") // 保留累计封禁次数
+	j.Sweep(now.Add(500 * time.Second))
+	require.NotContains(t, j.records, "2.2.2.2")
+}
+
+func TestNewJail_InvalidAllowlist(t *testing.T) {
+	_, err := NewJail(&config.BanConf{Allowlist: []string{"not-an-ip"}})
+	require.ErrorIs(t, err, ErrInvalidAllowlist)
+}
+
+func TestJail_RecordUnsupported(t *testing.T) {
+	j, err := NewJail(&config.BanConf{Window: 60, MaxUndecodable: 2, MaxUnsupportedMsgs: 5, BanTime: 100, MaxBanTime: 300})
+	require.NoError(t, err)
+	now := time.Now()
+	ip := "1.2.3.4"
+
+	// 未处理的消息单独计数，达到较高的阈值才封禁
+	require.False(t, j.Record(ip, ReasonUndecodable, now))
+	for i := 0; i < 4; i++ {
+		require.False(t, j.Record(ip, ReasonUnsupported, now))
+	}
+	require.True(t, j.Record(ip, ReasonUnsupported, now))
+	require.Equal(t, ReasonUnsupported, j.List(now)[0].Reason)
+}
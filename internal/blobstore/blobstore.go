@@ -0,0 +1,152 @@
+// Package blobstore, 按配置创建对象存储，并定时按生命周期规则清理过期的对象。
+package blobstore
+
+import (
+	"context"
+	"crypto/rand"
+	"sort"
+	"time"
+
+	"github.com/fakeyanss/gron"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
+)
+
+var ErrBlobDisabled = errors.New("Blob store is disabled")
+
+// 对象的key前缀
+const (
+	PrefixMedia   = "media/"
+	PrefixArchive = "archive/"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+
+	DownloadPath = "/media/download" // 本地存储的预签名下载接口
+
+	cleanJobID           = "blob-lifecycle"
+	defaultPresignTTL    = 900  // 单位s
+	defaultCleanInterval = 3600 // 单位s
+)
+
+type Lifecycle struct {
+	store blob.Store
+	rules map[string]time.Duration // <key前缀, 保留时长>
+}
+
+var (
+	storeInstance blob.Store
+	presignTTL    = defaultPresignTTL * time.Second
+)
+
+// 创建对象存储，存在生命周期规则时定时清理
+func Start(conf *config.BlobConf) (blob.Store, error) {
+	store, err := newStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	if conf.PresignTTL > 0 {
+		presignTTL = time.Duration(conf.PresignTTL) * time.Second
+	}
+	if len(conf.Lifecycle) > 0 {
+		interval := time.Duration(conf.CleanInterval) * time.Second
+		if interval <= 0 {
+			interval = defaultCleanInterval * time.Second
+		}
+		lc := NewLifecycle(store, conf.Lifecycle)
+		cron := gron.New()
+		cron.Add(gron.Every(interval), lc)
+		cron.Start()
+	}
+	storeInstance = store
+	return store, nil
+}
+
+// 返回启用的对象存储，未启用时为nil
+func Default() blob.Store {
+	return storeInstance
+}
+
+// 下载地址的有效期
+func PresignTTL() time.Duration {
+	return presignTTL
+}
+
+func newStore(conf *config.BlobConf) (blob.Store, error) {
+	switch conf.Backend {
+	case BackendLocal, "":
+		local := conf.Local
+		if local == nil || local.Directory == "" {
+			return nil, errors.New("Missing blob local directory")
+		}
+		secret := []byte(local.Secret)
+		if len(secret) == 0 {
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, errors.Wrap(err, "Fail to generate blob presign secret")
+			}
+		}
+		return blob.NewLocalStore(local.Directory, local.PublicURL+DownloadPath, secret)
+	case BackendS3:
+		s3 := conf.S3
+		if s3 == nil {
+			return nil, errors.New("Missing blob s3 config")
+		}
+		return blob.NewS3Store(&blob.S3Options{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			PathStyle: s3.PathStyle,
+			PartSize:  int64(s3.PartSize) * 1024 * 1024,
+		})
+	}
+	return nil, errors.Errorf("Unknown blob backend, backend=%s", conf.Backend)
+}
+
+// rules为<key前缀, 保留天数>，天数不大于0的规则被忽略
+func NewLifecycle(store blob.Store, rules map[string]int) *Lifecycle {
+	lc := &Lifecycle{store: store, rules: make(map[string]time.Duration)}
+	for prefix, days := range rules {
+		if days > 0 {
+			lc.rules[prefix] = time.Duration(days) * 24 * time.Hour
+		}
+	}
+	return lc
+}
+
+func (lc *Lifecycle) JobID() string {
+	return cleanJobID
+}
+
+func (lc *Lifecycle) Run() {
+	lc.Clean(time.Now())
+}
+
+// 按前缀清理过期的对象，返回删除的个数
+func (lc *Lifecycle) Clean(now time.Time) int {
+	prefixes := make([]string, 0, len(lc.rules))
+	for prefix := range lc.rules {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	total := 0
+	for _, prefix := range prefixes {
+		n, err := blob.Expire(context.Background(), lc.store, prefix, lc.rules[prefix], now)
+		total += n
+		if err != nil {
+			log.Error().Err(err).Str("prefix", prefix).Msg("Fail to clean expired blobs")
+			continue
+		}
+		if n > 0 {
+			log.Info().Str("prefix", prefix).Int("deleted", n).Msg("Clean expired blobs")
+		}
+	}
+	return total
+}
@@ -0,0 +1,46 @@
+package blobstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
+)
+
+func TestNewStore(t *testing.T) {
+	store, err := newStore(&config.BlobConf{Backend: BackendLocal, Local: &config.LocalBlobConf{Directory: t.TempDir()}})
+	require.NoError(t, err)
+	require.IsType(t, &blob.LocalStore{}, store)
+
+	store, err = newStore(&config.BlobConf{Backend: BackendS3, S3: &config.S3BlobConf{Endpoint: "http://127.0.0.1:9000", Bucket: "jt808"}})
+	require.NoError(t, err)
+	require.IsType(t, &blob.S3Store{}, store)
+
+	_, err = newStore(&config.BlobConf{Backend: BackendS3, S3: &config.S3BlobConf{Endpoint: "127.0.0.1:9000", Bucket: "jt808"}})
+	require.Error(t, err)
+	_, err = newStore(&config.BlobConf{Backend: "ftp"})
+	require.Error(t, err)
+}
+
+func TestLifecycle_Clean(t *testing.T) {
+	store, err := blob.NewLocalStore(t.TempDir(), "", nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	for _, key := range []string{"media/1.jpg", "archive/part-00000.parquet", "other/1.bin"} {
+		require.NoError(t, store.Put(ctx, key, strings.NewReader("x")))
+	}
+	lc := NewLifecycle(store, map[string]int{PrefixMedia: 30, PrefixArchive: 365, "other/": 0})
+
+	require.Equal(t, 0, lc.Clean(time.Now()))
+	require.Equal(t, 1, lc.Clean(time.Now().AddDate(0, 0, 31)))
+	require.Equal(t, 1, lc.Clean(time.Now().AddDate(0, 0, 366)))
+	objects, err := store.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	require.Equal(t, "other/1.bin", objects[0].Key)
+}
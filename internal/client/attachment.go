@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var (
+	ErrAttachmentRejected  = errors.New("Attachment server rejected")
+	ErrRetransmitExhausted = errors.New("Retransmit times exhausted")
+)
+
+// 主动安全报警的附件文件
+type AttachmentFile struct {
+	Name string
+	Type uint8 // 文件类型，0图片，1音频，2视频，3文本，4其他
+	Data []byte
+}
+
+// 收到0x9208后，连接附件服务器上传报警附件
+type AttachmentUploader struct {
+	ChunkSize     int           // 每个码流帧的数据长度
+	GapRate       float64       // 首次上传时故意丢弃数据帧的比例，用于测试0x9212补传
+	Timeout       time.Duration // 等待附件服务器应答的超时时间
+	MaxRetransmit int           // 单个文件最多补传次数
+}
+
+// 附件服务器上的一次上传会话
+type attachmentSession struct {
+	uploader *AttachmentUploader
+	conn     net.Conn
+	fh       *protocol.JT808FrameHandler
+	pc       *protocol.JT808PacketCodec
+	ctx      context.Context
+	tmpl     *model.MsgHeader // 消息头模板，与0x9208的版本和手机号一致
+	serial   uint16
+}
+
+func (u *AttachmentUploader) Upload(req *model.Msg9208, terminalID string, files []*AttachmentFile) error {
+	addr := net.JoinHostPort(req.ServerIP, fmt.Sprint(req.TCPPort))
+	conn, err := net.DialTimeout("tcp", addr, u.Timeout)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to dial attachment server, addr=%s", addr)
+	}
+	defer conn.Close()
+
+	s := &attachmentSession{
+		uploader: u,
+		conn:     conn,
+		fh:       protocol.NewJT808FrameHandler(conn),
+		pc:       protocol.NewJT808PacketCodec(),
+		ctx:      context.WithValue(context.Background(), model.SessionCtxKey{}, &model.Session{ID: conn.LocalAddr().String(), Conn: conn}),
+		tmpl:     req.Header,
+	}
+
+	info := &model.Msg1210{
+		Header:        s.header(model.MsgID1210),
+		TerminalID:    terminalID,
+		AlarmIdentity: req.AlarmIdentity,
+		AlarmNumber:   req.AlarmNumber,
+	}
+	for _, f := range files {
+		info.Attachments = append(info.Attachments, &model.AttachmentInfo{FileName: f.Name, FileSize: uint32(len(f.Data))})
+	}
+	if err = s.request(info); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err = s.uploadFile(f); err != nil {
+			return errors.Wrapf(err, "Fail to upload attachment, name=%s", f.Name)
+		}
+	}
+	log.Debug().Str("device", req.Header.PhoneNumber).Str("alarmNumber", req.AlarmNumber).Int("files", len(files)).
+		Msg("Uploaded alarm attachments")
+	return nil
+}
+
+func (s *attachmentSession) uploadFile(f *AttachmentFile) error {
+	size := uint32(len(f.Data))
+	err := s.request(&model.Msg1211{Header: s.header(model.MsgID1211), FileName: f.Name, FileType: f.Type, FileSize: size})
+	if err != nil {
+		return err
+	}
+
+	// 首次上传按比例丢弃数据帧，模拟网络丢包
+	ranges := []*model.DataRange{{Offset: 0, Length: size}}
+	if err = s.sendRanges(f, ranges, s.uploader.GapRate); err != nil {
+		return err
+	}
+	for retransmit := 0; ; retransmit++ {
+		err = s.send(&model.Msg1212{Header: s.header(model.MsgID1212), FileName: f.Name, FileType: f.Type, FileSize: size})
+		if err != nil {
+			return err
+		}
+		ack := &model.Msg9212{}
+		if err = s.wait(model.MsgID9212, ack); err != nil {
+			return err
+		}
+		if ack.Result == model.UploadResultCompleted {
+			return nil
+		}
+		if retransmit >= s.uploader.MaxRetransmit {
+			return ErrRetransmitExhausted
+		}
+		log.Debug().Str("file", f.Name).Int("ranges", len(ack.Retransmits)).Msg("Attachment server requires retransmission")
+		if err = s.sendRanges(f, ack.Retransmits, 0); err != nil {
+			return err
+		}
+	}
+}
+
+// 按ChunkSize切分数据段，以码流帧发送
+func (s *attachmentSession) sendRanges(f *AttachmentFile, ranges []*model.DataRange, gapRate float64) error {
+	chunk := uint32(s.uploader.ChunkSize)
+	for _, r := range ranges {
+		end := r.Offset + r.Length
+		if end > uint32(len(f.Data)) {
+			end = uint32(len(f.Data))
+		}
+		for off := r.Offset; off < end; off += chunk {
+			n := chunk
+			if off+n > end {
+				n = end - off
+			}
+			if gapRate > 0 && rand.Float64() < gapRate {
+				continue
+			}
+			frame := &model.FileStreamFrame{FileName: f.Name, Offset: off, Data: f.Data[off : off+n]}
+			if _, err := s.conn.Write(frame.Encode()); err != nil {
+				return errors.Wrap(err, "Fail to send file stream")
+			}
+		}
+	}
+	return nil
+}
+
+// 发送消息并等待附件服务器的通用应答
+func (s *attachmentSession) request(msg model.JT808Msg) error {
+	msgID := msg.GetHeader().MsgID
+	if err := s.send(msg); err != nil {
+		return err
+	}
+	ack := &model.Msg8001{}
+	if err := s.wait(model.MsgID8001, ack); err != nil {
+		return err
+	}
+	if ack.AnswerMessageID != msgID || ack.Result != model.ResultSuccess {
+		return errors.Wrapf(ErrAttachmentRejected, "msgID=0x%04x, result=%d", ack.AnswerMessageID, ack.Result)
+	}
+	return nil
+}
+
+func (s *attachmentSession) send(msg model.JT808Msg) error {
+	payload, err := s.pc.Encode(msg)
+	if err != nil {
+		return err
+	}
+	return s.fh.Send(payload)
+}
+
+// 读取下一条指定ID的消息，忽略其他消息
+func (s *attachmentSession) wait(msgID uint16, msg model.JT808Msg) error {
+	for {
+		err := s.conn.SetReadDeadline(time.Now().Add(s.uploader.Timeout))
+		if err != nil {
+			return err
+		}
+		frame, err := s.fh.Recv(s.ctx)
+		if err != nil {
+			return err
+		}
+		pkt, err := s.pc.Decode(frame)
+		if err != nil {
+			return err
+		}
+		if pkt.Header.MsgID != msgID {
+			log.Debug().Str("RawMsgID", fmt.Sprintf("0x%04x", pkt.Header.MsgID)).Msg("Ignore unexpected msg from attachment server")
+			continue
+		}
+		return msg.Decode(pkt)
+	}
+}
+
+func (s *attachmentSession) header(msgID uint16) *model.MsgHeader {
+	s.serial++
+	return &model.MsgHeader{
+		MsgID: msgID,
+		Attr: &model.MsgBodyAttr{
+			VersionSign: s.tmpl.Attr.VersionSign,
+			VersionDesc: s.tmpl.Attr.VersionDesc,
+		},
+		ProtocolVersion: s.tmpl.ProtocolVersion,
+		PhoneNumber:     s.tmpl.PhoneNumber,
+		SerialNumber:    s.serial,
+	}
+}
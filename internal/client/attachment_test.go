@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 模拟附件服务器，记录收到的文件数据，文件上传完成时按缺失的数据段要求补传
+type fakeAttachmentServer struct {
+	ln    net.Listener
+	files map[string][]byte
+	recv  map[string][]bool // 每个字节是否收到
+	done  chan struct{}
+}
+
+func newFakeAttachmentServer(t *testing.T) *fakeAttachmentServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeAttachmentServer{
+		ln:    ln,
+		files: make(map[string][]byte),
+		recv:  make(map[string][]bool),
+		done:  make(chan struct{}),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *fakeAttachmentServer) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	pc := protocol.NewJT808PacketCodec()
+	for {
+		head, err := r.Peek(4)
+		if err != nil {
+			return
+		}
+		if model.IsFileStream(head) {
+			buf := make([]byte, model.FileStreamHeaderLen)
+			if _, err = io.ReadFull(r, buf); err != nil {
+				return
+			}
+			name, offset, length, _ := model.DecodeFileStreamHeader(buf)
+			data := make([]byte, length)
+			if _, err = io.ReadFull(r, data); err != nil {
+				return
+			}
+			copy(s.files[name][offset:], data)
+			for i := offset; i < offset+length; i++ {
+				s.recv[name][i] = true
+			}
+			continue
+		}
+
+		frame, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		pkt, err := pc.Decode(frame)
+		if err != nil {
+			return
+		}
+		var out model.JT808Msg
+		switch pkt.Header.MsgID {
+		case model.MsgID1210, model.MsgID1211:
+			if pkt.Header.MsgID == model.MsgID1211 {
+				in := &model.Msg1211{}
+				_ = in.Decode(pkt)
+				s.files[in.FileName] = make([]byte, in.FileSize)
+				s.recv[in.FileName] = make([]bool, in.FileSize)
+			}
+			ack := &model.Msg8001{}
+			_ = ack.GenOutgoing(&model.Msg1211{Header: pkt.Header})
+			out = ack
+		case model.MsgID1212:
+			in := &model.Msg1212{}
+			_ = in.Decode(pkt)
+			ack := &model.Msg9212{}
+			_ = ack.GenOutgoing(in)
+			ack.Retransmits = missingRanges(s.recv[in.FileName])
+			if len(ack.Retransmits) > 0 {
+				ack.Result = model.UploadResultRetransmit
+			}
+			out = ack
+		}
+		payload, _ := pc.Encode(out)
+		if _, err = conn.Write(payload); err != nil {
+			return
+		}
+	}
+}
+
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	start, err := r.ReadBytes(0x7e)
+	if err != nil {
+		return nil, err
+	}
+	rest, err := r.ReadBytes(0x7e)
+	if err != nil {
+		return nil, err
+	}
+	return append(start[len(start)-1:], rest...), nil
+}
+
+func missingRanges(recv []bool) []*model.DataRange {
+	var ranges []*model.DataRange
+	for i := 0; i < len(recv); i++ {
+		if recv[i] {
+			continue
+		}
+		r := &model.DataRange{Offset: uint32(i)}
+		for i < len(recv) && !recv[i] {
+			r.Length++
+			i++
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+func genTestMsg9208(addr net.Addr) *model.Msg9208 {
+	tcpAddr := addr.(*net.TCPAddr)
+	return &model.Msg9208{
+		Header: &model.MsgHeader{
+			MsgID:           model.MsgID9208,
+			Attr:            &model.MsgBodyAttr{VersionSign: 1, VersionDesc: model.Version2019},
+			ProtocolVersion: 1,
+			PhoneNumber:     "00000000013012345678",
+		},
+		ServerIP:      tcpAddr.IP.String(),
+		TCPPort:       uint16(tcpAddr.Port),
+		AlarmIdentity: &model.AlarmIdentity{TerminalID: "1234567", Time: "230301123456", AttachmentCount: 2},
+		AlarmNumber:   "a1b2c3",
+	}
+}
+
+func TestAttachmentUploader_Upload(t *testing.T) {
+	files := []*AttachmentFile{
+		{Name: "00_64_6401_0_a1b2c3.jpg", Type: model.AttachmentTypeImage, Data: bytes.Repeat([]byte{0xff, 0xd8}, 5000)},
+		{Name: "02_64_6401_1_a1b2c3.mp4", Type: model.AttachmentTypeVideo, Data: bytes.Repeat([]byte{0x7e, 0x7d, 0x30}, 9000)},
+	}
+	tests := []struct {
+		name          string
+		gapRate       float64
+		maxRetransmit int
+		wantErr       error
+	}{
+		{
+			name:    "case1: upload without gaps",
+			gapRate: 0,
+		},
+		{
+			name:          "case2: retransmit deliberate gaps",
+			gapRate:       0.5,
+			maxRetransmit: 1,
+		},
+		{
+			name:          "case3: retransmit exhausted",
+			gapRate:       1,
+			maxRetransmit: 0,
+			wantErr:       ErrRetransmitExhausted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeAttachmentServer(t)
+			defer server.ln.Close()
+
+			u := &AttachmentUploader{ChunkSize: 1024, GapRate: tt.gapRate, Timeout: 5 * time.Second, MaxRetransmit: tt.maxRetransmit}
+			err := u.Upload(genTestMsg9208(server.ln.Addr()), "1234567", files)
+			<-server.done
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for _, f := range files {
+				require.Equal(t, f.Data, server.files[f.Name])
+			}
+		})
+	}
+}
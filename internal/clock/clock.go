@@ -0,0 +1,52 @@
+// Package clock 服务端时间相关逻辑使用的时间。
+//
+// 默认为服务器时间。测试模式下信任终端上报的定位时间，使用收到的最新定位时间作为当前时间，
+// 配合模拟终端的虚拟时钟，在短时间内测试按天导出、停留点识别、数据保留等依赖时间的逻辑。
+// 连接保活、掉线检测等与连接相关的逻辑始终使用服务器时间。
+package clock
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+var (
+	trustDevice atomic.Bool
+	deviceNow   atomic.Int64 // 收到的最新定位时间，unix纳秒
+)
+
+// 测试模式，时间相关的逻辑使用终端上报的定位时间
+func TrustDeviceTime(enable bool) {
+	trustDevice.Store(enable)
+}
+
+func DeviceTimeTrusted() bool {
+	return trustDevice.Load()
+}
+
+// 当前时间。测试模式下为收到的最新定位时间，尚未收到定位时为服务器时间
+func Now() time.Time {
+	if !trustDevice.Load() {
+		return time.Now()
+	}
+	if ns := deviceNow.Load(); ns > 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Now()
+}
+
+// 记录终端实时上报的定位时间（按字面值解析的终端本地时间），测试模式下推进当前时间，不会回退
+func ObserveDeviceTime(t time.Time) {
+	if !trustDevice.Load() {
+		return
+	}
+	ns := hex.InDeviceZone(t).UnixNano()
+	for {
+		prev := deviceNow.Load()
+		if ns <= prev || deviceNow.CompareAndSwap(prev, ns) {
+			return
+		}
+	}
+}
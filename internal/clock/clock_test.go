@@ -0,0 +1,31 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+func TestNow(t *testing.T) {
+	deviceTime := hex.ParseTime("230125145158")
+	ObserveDeviceTime(deviceTime)
+	require.WithinDuration(t, time.Now(), Now(), time.Second)
+
+	TrustDeviceTime(true)
+	defer func() {
+		TrustDeviceTime(false)
+		deviceNow.Store(0)
+	}()
+	require.WithinDuration(t, time.Now(), Now(), time.Second)
+
+	ObserveDeviceTime(deviceTime)
+	require.True(t, hex.InDeviceZone(deviceTime).Equal(Now()))
+	// 较早的定位时间不会使当前时间回退
+	ObserveDeviceTime(deviceTime.Add(-time.Hour))
+	require.True(t, hex.InDeviceZone(deviceTime).Equal(Now()))
+	ObserveDeviceTime(deviceTime.Add(time.Hour))
+	require.True(t, hex.InDeviceZone(deviceTime).Add(time.Hour).Equal(Now()))
+}
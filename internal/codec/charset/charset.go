@@ -0,0 +1,175 @@
+// Package charset, JT808字符串字段的字符集编解码。
+//
+// 标准要求STRING类型使用GBK编码，但部分2019版终端会上报GBK之外的GB18030字符，
+// 部分海外终端直接使用UTF-8。未指定字符集时按内容自动识别。
+package charset
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
+)
+
+var ErrUnknownCharset = errors.New("Unknown charset")
+
+type Charset string
+
+const (
+	Auto    Charset = ""        // 自动识别，解码时按内容判断，编码时优先GBK
+	GBK     Charset = "GBK"     // 标准默认
+	GB18030 Charset = "GB18030" // GBK的超集，兼容GBK
+	UTF8    Charset = "UTF-8"
+)
+
+// 解析配置中的字符集名称，不区分大小写，auto或空表示自动识别
+func Parse(name string) (Charset, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "", "AUTO":
+		return Auto, nil
+	case "GBK", "GB2312", "CP936":
+		return GBK, nil
+	case "GB18030":
+		return GB18030, nil
+	case "UTF-8", "UTF8":
+		return UTF8, nil
+	}
+	return Auto, errors.Wrapf(ErrUnknownCharset, "name=%s", name)
+}
+
+// 根据内容识别字符集。纯ASCII返回Auto；含3字节及以上UTF-8序列（UTF-8的汉字均为3字节）时视为UTF-8；
+// 能完整解码为汉字的按GBK/GB18030处理。短的GBK车牌常常也是合法的UTF-8，如鲁A的c2b3会被解成"³A"，
+// 所以只有2字节UTF-8序列时优先GBK，GBK解码失败才视为UTF-8
+func Detect(src []byte) Charset {
+	ascii := true
+	for _, b := range src {
+		if b >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return Auto
+	}
+	valid := utf8.Valid(src)
+	if valid && hasLongUTF8Rune(src) {
+		return UTF8
+	}
+	fourBytes := hasGB18030FourBytes(src)
+	if decodesToCJK(src) {
+		if fourBytes {
+			return GB18030
+		}
+		return GBK
+	}
+	if valid {
+		return UTF8
+	}
+	if fourBytes {
+		return GB18030
+	}
+	return GBK
+}
+
+func hasLongUTF8Rune(src []byte) bool {
+	for i := 0; i < len(src); {
+		_, size := utf8.DecodeRune(src[i:])
+		if size >= 3 {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// 按GB18030解码，非ASCII字符全部为汉字或中文标点时返回true
+func decodesToCJK(src []byte) bool {
+	dst, err := simplifiedchinese.GB18030.NewDecoder().Bytes(src)
+	if err != nil {
+		return false
+	}
+	for _, r := range string(dst) {
+		if r < utf8.RuneSelf {
+			continue
+		}
+		if r == utf8.RuneError || !isCJK(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		(r >= 0x3000 && r <= 0x303f) || // 中文标点
+		(r >= 0xff00 && r <= 0xffef) // 全角字符
+}
+
+// GB18030的四字节序列，第二个字节为0x30-0x39，GBK中不存在
+func hasGB18030FourBytes(src []byte) bool {
+	for i := 0; i < len(src); i++ {
+		if src[i] < 0x81 || src[i] == 0xff {
+			continue
+		}
+		if i+1 < len(src) && src[i+1] >= 0x30 && src[i+1] <= 0x39 {
+			return true
+		}
+		i++ // 跳过双字节字符的第二个字节
+	}
+	return false
+}
+
+func codec(cs Charset) encoding.Encoding {
+	switch cs {
+	case GB18030:
+		return simplifiedchinese.GB18030
+	case UTF8:
+		return nil
+	default:
+		return simplifiedchinese.GBK
+	}
+}
+
+// 按字符集解码为UTF-8字符串，Auto时先识别字符集
+func Decode(cs Charset, src []byte) (string, error) {
+	if cs == Auto {
+		cs = Detect(src)
+		if cs == GBK {
+			cs = GB18030 // GB18030兼容GBK，识别为GBK时也按GB18030解码
+		}
+	}
+	enc := codec(cs)
+	if enc == nil {
+		return string(src), nil
+	}
+	dst, err := io.ReadAll(transform.NewReader(bytes.NewReader(src), enc.NewDecoder()))
+	if err != nil {
+		return "", errors.Wrapf(err, "Fail to decode %s", cs)
+	}
+	return string(dst), nil
+}
+
+// 将UTF-8字符串按字符集编码，Auto时优先GBK，含GBK之外的字符时使用GB18030
+func Encode(cs Charset, str string) ([]byte, error) {
+	if cs == Auto {
+		if dst, err := Encode(GBK, str); err == nil {
+			return dst, nil
+		}
+		cs = GB18030
+	}
+	enc := codec(cs)
+	if enc == nil {
+		return []byte(str), nil
+	}
+	dst, err := io.ReadAll(transform.NewReader(strings.NewReader(str), enc.NewEncoder()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to encode %s", cs)
+	}
+	return dst, nil
+}
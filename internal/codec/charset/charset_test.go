@@ -0,0 +1,75 @@
+package charset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want Charset
+	}{
+		{name: "case1: ascii", src: []byte("A12345"), want: Auto},
+		{name: "case2: gbk 京A", src: []byte{0xbe, 0xa9, 0x41}, want: GBK},
+		{name: "case3: utf8 京A", src: []byte("京A"), want: UTF8},
+		{name: "case4: gb18030 four bytes 㐀", src: []byte{0x81, 0x39, 0xee, 0x39}, want: GB18030},
+		// 以下GBK车牌同时也是合法的UTF-8，如鲁A会被当作UTF-8解成"³A"
+		{name: "case5: gbk 鲁A12345", src: []byte{0xc2, 0xb3, 0x41, 0x31, 0x32, 0x33, 0x34, 0x35}, want: GBK},
+		{name: "case6: gbk 鲁Q12345", src: []byte{0xc2, 0xb3, 0x51, 0x31, 0x32, 0x33, 0x34, 0x35}, want: GBK},
+		{name: "case7: gbk 豫B12345", src: []byte{0xd4, 0xa5, 0x42, 0x31, 0x32, 0x33, 0x34, 0x35}, want: GBK},
+		{name: "case8: gbk 豫A12345", src: []byte{0xd4, 0xa5, 0x41, 0x31, 0x32, 0x33, 0x34, 0x35}, want: GBK},
+		{name: "case9: utf8 鲁A12345", src: []byte("鲁A12345"), want: UTF8},
+		{name: "case10: utf8 豫B12345", src: []byte("豫B12345"), want: UTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Detect(tt.src))
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		cs      Charset
+		str     string
+		wantPkt []byte
+	}{
+		{name: "case1: gbk", cs: GBK, str: "京A", wantPkt: []byte{0xbe, 0xa9, 0x41}},
+		{name: "case2: gb18030", cs: GB18030, str: "㐀", wantPkt: []byte{0x81, 0x39, 0xee, 0x39}},
+		{name: "case3: utf8", cs: UTF8, str: "京A", wantPkt: []byte("京A")},
+		{name: "case4: auto prefer gbk", cs: Auto, str: "京A", wantPkt: []byte{0xbe, 0xa9, 0x41}},
+		{name: "case5: auto fallback gb18030", cs: Auto, str: "京㐀", wantPkt: []byte{0xbe, 0xa9, 0x81, 0x39, 0xee, 0x39}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkt, err := Encode(tt.cs, tt.str)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPkt, pkt)
+
+			str, err := Decode(tt.cs, pkt)
+			require.NoError(t, err)
+			require.Equal(t, tt.str, str)
+
+			str, err = Decode(Auto, pkt)
+			require.NoError(t, err)
+			require.Equal(t, tt.str, str)
+		})
+	}
+
+	_, err := Encode(GBK, "㐀")
+	require.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	for name, want := range map[string]Charset{"": Auto, "auto": Auto, "gbk": GBK, "GB18030": GB18030, "utf8": UTF8, "UTF-8": UTF8} {
+		got, err := Parse(name)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+	_, err := Parse("big5")
+	require.ErrorIs(t, err, ErrUnknownCharset)
+}
@@ -8,9 +8,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/rs/zerolog/log"
-
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
 	GBK "github.com/fakeyanss/jt808-server-go/internal/codec/gbk"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 const (
@@ -24,9 +24,9 @@ func Str2Byte(src string) []byte {
 	dst, err := hex.DecodeString(src)
 	if err != nil {
 		if errors.Is(err, hex.ErrLength) {
-			log.Warn().Err(err).Str("src", src).Msg("Source str invalid, will ignore extra byte")
+			logger.For(logger.ModuleCodec).Warn().Err(err).Str("src", src).Msg("Source str invalid, will ignore extra byte")
 		} else {
-			log.Error().Err(err).Msg("Fail to transform hex str to byte array")
+			logger.For(logger.ModuleCodec).Error().Err(err).Msg("Fail to transform hex str to byte array")
 		}
 	}
 	return dst
@@ -175,6 +175,25 @@ func WriteGBK(pkt []byte, str string) []byte {
 	return append(pkt, gbk...)
 }
 
+// 对应JT808类型String，按指定字符集解码
+func ReadText(pkt []byte, idx *int, n int, cs charset.Charset) string {
+	str, err := charset.Decode(cs, pkt[*idx:*idx+n])
+	*idx += n
+	if err != nil {
+		return ""
+	}
+	return str
+}
+
+// 对应JT808类型String，按指定字符集编码
+func WriteText(pkt []byte, str string, cs charset.Charset) []byte {
+	b, err := charset.Encode(cs, str)
+	if err != nil {
+		return pkt
+	}
+	return append(pkt, b...)
+}
+
 // 输入JT808协议定义的时间format, 转换为time.Time
 func ReadTime(pkt []byte, idx *int) *time.Time {
 	timeStr := ReadBCD(pkt, idx, timeBCDLen)
@@ -190,12 +209,27 @@ func WriteTime(pkt []byte, timeIns time.Time) []byte {
 func ParseTime(timeStr string) time.Time {
 	timeIns, err := time.Parse(timeBCDLayout, timeStr)
 	if err != nil {
-		log.Warn().Msg("Fail to parse time str")
+		logger.For(logger.ModuleCodec).Warn().Msg("Fail to parse time str")
 		timeIns = time.Now()
 	}
 	return timeIns
 }
 
+// JT808协议规定终端时间为GMT+8，与服务器所在时区无关
+var DeviceZone = time.FixedZone("CST", 8*3600)
+
+// ParseTime按字面值解析为UTC，与服务器时间比较前需要转换为GMT+8
+func InDeviceZone(timeIns time.Time) time.Time {
+	return time.Date(timeIns.Year(), timeIns.Month(), timeIns.Day(), timeIns.Hour(), timeIns.Minute(),
+		timeIns.Second(), timeIns.Nanosecond(), DeviceZone)
+}
+
+// InDeviceZone的逆转换，将服务器时间按GMT+8的字面值转为UTC，用于与ParseTime的结果比较
+func FromDeviceZone(timeIns time.Time) time.Time {
+	t := timeIns.In(DeviceZone)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
 func FormatTime(timeIns time.Time) string {
 	year := timeIns.Year()     // 年
 	month := timeIns.Month()   // 月
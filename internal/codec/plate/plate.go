@@ -0,0 +1,79 @@
+package plate
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 车牌首字的拼音，包括省份简称和使、领等特殊号牌
+var pinyins = map[rune]string{
+	'京': "JING", '津': "JIN", '沪': "HU", '渝': "YU", '冀': "JI", '豫': "YU", '云': "YUN", '辽': "LIAO",
+	'黑': "HEI", '湘': "XIANG", '皖': "WAN", '鲁': "LU", '新': "XIN", '苏': "SU", '浙': "ZHE", '赣': "GAN",
+	'鄂': "E", '桂': "GUI", '甘': "GAN", '晋': "JIN", '蒙': "MENG", '陕': "SHAN", '吉': "JI", '闽': "MIN",
+	'贵': "GUI", '粤': "YUE", '青': "QING", '藏': "ZANG", '川': "CHUAN", '宁': "NING", '琼': "QIONG",
+	'使': "SHI", '领': "LING", '港': "GANG", '澳': "AO", '学': "XUE", '警': "JING", '挂': "GUA",
+}
+
+// 匹配程度，值越小越精确
+type MatchType int
+
+const (
+	MatchNone MatchType = iota
+	MatchExact
+	MatchPrefix
+	MatchSubstring
+)
+
+func (m MatchType) String() string {
+	switch m {
+	case MatchExact:
+		return "exact"
+	case MatchPrefix:
+		return "prefix"
+	case MatchSubstring:
+		return "substring"
+	default:
+		return "none"
+	}
+}
+
+// 归一化车牌等搜索字段：全角转半角，字母转大写，去掉空白、点和横线
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0xFF01 && r <= 0xFF5E { // 全角ASCII
+			r -= 0xFEE0
+		}
+		if unicode.IsSpace(r) || strings.ContainsRune("·•.-_", r) {
+			continue
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// 将归一化车牌的首字转为拼音，如粤B12345转为YUEB12345，首字不是省份简称时返回空
+func Pinyin(normalized string) string {
+	r, size := utf8.DecodeRuneInString(normalized)
+	py, ok := pinyins[r]
+	if !ok {
+		return ""
+	}
+	return py + normalized[size:]
+}
+
+// 归一化的查询词在归一化字段中的匹配程度
+func Match(query, key string) MatchType {
+	switch {
+	case query == "" || key == "":
+		return MatchNone
+	case key == query:
+		return MatchExact
+	case strings.HasPrefix(key, query):
+		return MatchPrefix
+	case strings.Contains(key, query):
+		return MatchSubstring
+	}
+	return MatchNone
+}
@@ -0,0 +1,40 @@
+package plate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "case1: space", in: "粤B 12345", want: "粤B12345"},
+		{name: "case2: lower case and dot", in: "粤b·12345", want: "粤B12345"},
+		{name: "case3: full width", in: "粤Ｂ１２３４５", want: "粤B12345"},
+		{name: "case4: pinyin", in: "yue b", want: "YUEB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Normalize(tt.in))
+		})
+	}
+}
+
+func TestPinyin(t *testing.T) {
+	require.Equal(t, "YUEB12345", Pinyin("粤B12345"))
+	require.Equal(t, "JINGA00001", Pinyin("京A00001"))
+	require.Equal(t, "", Pinyin("B12345"))
+	require.Equal(t, "", Pinyin(""))
+}
+
+func TestMatch(t *testing.T) {
+	require.Equal(t, MatchExact, Match("粤B12345", "粤B12345"))
+	require.Equal(t, MatchPrefix, Match("YUEB", Pinyin("粤B12345")))
+	require.Equal(t, MatchSubstring, Match("B123", "粤B12345"))
+	require.Equal(t, MatchNone, Match("B124", "粤B12345"))
+	require.Equal(t, MatchNone, Match("", "粤B12345"))
+}
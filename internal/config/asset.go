@@ -3,6 +3,7 @@
 // configs/banner.txt
 // configs/default.yaml
 // test/client/configs/default.yaml
+// test/client/configs/identities.csv
 package config
 
 import (
@@ -99,7 +100,7 @@ func configsBannerTxt() (*asset, error) {
 	return a, nil
 }
 
-var _configsDefaultYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xd0\xc1\x4a\x03\x31\x10\x06\xe0\xfb\x3e\xc5\x90\x7b\x77\xb7\x82\x18\x72\x6b\x69\x15\x44\xb0\x28\x3e\x40\x1a\x67\xd3\xe8\x34\xb3\x24\xb3\xa5\xfa\xf4\x12\xa3\xb8\xa2\xc7\x7c\xff\x64\x7e\x12\x62\x6f\x1a\x00\xc7\x31\x33\xe1\x36\xda\x3d\xa1\x01\x49\x13\x36\x00\x43\xf8\x43\x63\x0a\x51\x56\xf9\x36\x73\x34\x30\x58\xca\x05\x89\xfd\x1d\x9e\x90\x0c\xa8\xcd\x76\xfd\x74\xa3\xaa\x6d\x42\x42\x27\x9c\xde\x0c\xa8\xb6\x23\xf6\xb9\xfb\x4a\xae\x43\x59\xa9\x5e\x44\xf7\x7a\x91\x31\x9d\x30\x2d\x3c\xb7\xc4\xbe\x0c\x1c\xed\xf9\x31\xbc\xe3\xfd\xf0\xc0\x44\x21\x7a\x03\x97\x7d\xe5\xb5\x75\xaf\xd3\x98\x67\xc9\xf2\x42\xd7\x68\xe5\xe7\x17\xae\x9a\xa6\xae\x2d\x8f\x8b\xf6\xf8\x4f\x5b\x69\x1a\x39\x49\x99\x00\x10\x37\xee\xca\x01\x94\xee\x75\xaf\x3e\x6d\x7a\x9e\xd9\xb2\xda\x41\xe4\x07\x7b\x5d\x70\x6f\x63\xac\x45\x00\xf8\xfb\xb7\xbe\xc3\x9d\x95\x83\x01\xe5\x38\x0e\xc1\xe7\xae\x62\x2b\x67\x51\xcd\x47\x00\x00\x00\xff\xff\x4d\x9b\x24\x20\x81\x01\x00\x00")
+var _configsDefaultYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x3a\x5d\x53\x1a\x59\xda\xf7\xfe\x8a\x2e\x72\xbb\x89\x6d\x8c\x8a\xd4\xd4\x54\x25\x93\x99\x7d\x9d\x9d\x4c\xac\x89\xa9\xbd\x78\x6b\x2f\x5a\x68\x95\x0d\x76\xb3\xdd\x4d\x12\x77\x6b\xab\xc0\x28\xa2\x82\xa0\x41\x51\x82\x51\x13\xfc\x88\x89\x80\x13\x63\x08\x1f\xf2\x63\xd2\xe7\x74\x73\x95\xbf\xf0\xd6\x73\x9e\xa6\x69\x14\xdf\x99\x4a\x55\x9a\x3e\x7d\x9e\xe7\x3c\xdf\x5f\xc7\x80\x3c\xe9\xe9\xe1\x38\xaf\x2c\xa9\x72\x40\xfc\x51\x12\xc6\x03\xa2\x87\xd3\x94\x90\xd8\xc3\x71\x13\xfe\x2b\x4b\x41\xc5\x2f\x69\x77\xd5\x9f\x55\x59\xf2\x70\x13\x42\x40\x85\x7d\x01\x79\xf2\x17\xf1\xa9\x18\xf0\x70\xae\xfb\x3f\xde\x7b\xfc\x57\x17\xae\xdd\xf7\x2b\xa2\x57\x93\x95\x19\x0f\xe7\xba\xd5\x1b\x90\x27\xd5\x5e\xeb\xcb\x4f\x7e\x40\xe9\xfa\xa7\xe6\xe6\xdd\x37\x55\x51\x79\x2a\x2a\x37\x27\xe5\x5b\x01\x79\x12\x36\x4c\x0b\xcf\x1f\xf9\xff\x2d\x3e\x9c\xf8\x4d\x0e\x04\xfc\xd2\xa4\x87\x1b\xe0\x71\xf9\x9e\xe0\x7d\x12\x0a\xaa\x8e\x2f\x7d\xb7\xdd\xf8\xe9\xee\xa4\x13\x60\x08\x16\x65\x5f\x28\x20\xaa\x1e\xee\x06\x47\xe3\x8b\xf4\x68\x8f\x6c\x67\xcc\x83\xa8\xf1\x6a\x83\x66\xf6\x49\x23\x63\x54\x0e\x49\xec\xfd\xb7\x5a\x9c\x24\x8b\xcd\x70\xd6\x6c\x2c\x18\x85\x3d\x23\x15\xa5\x2b\xfb\x24\xf9\x96\x1b\x7d\x3c\xc6\x01\xd1\xbd\x01\x60\x4d\xe5\x48\xee\xc8\x6c\xa4\xcc\xbd\x38\xcd\x9c\x9b\xa5\x17\x74\xfd\xac\x87\xe3\x38\x6e\x42\x11\xa6\x19\x25\xae\x91\x5f\x7f\x7a\xe8\xe2\x6e\x70\xa4\x7c\x68\x16\xab\x24\xba\xf5\xad\x16\x67\xe2\xa0\x99\x73\xba\xf8\x92\x24\x4a\xb4\x98\xd4\xcb\x61\x52\x3e\x64\x90\x5e\xd9\x27\x7a\x5b\x70\x6c\x25\xa8\xc8\x5e\x51\x55\x65\xc5\x29\x48\x8e\x13\x82\xfe\x8e\x6d\xaa\x26\x2b\xc2\xa4\xe8\x58\x53\x85\xe9\x20\x32\x7e\x83\x6b\x2e\x24\x48\x3e\xe1\x13\xc7\x43\x93\x16\x9f\xd9\xb9\xe6\xc2\x02\xdd\xfd\xcc\x80\xc7\x43\x8a\xaa\x79\xb8\x3e\x9e\x07\xb9\x00\x41\xc7\x64\xf5\x88\xe6\x76\x18\x71\xc7\x28\x26\x9a\x0b\x93\x7c\xd6\xbc\x78\x49\x16\x2a\x46\x76\xce\x81\x8c\x6e\xef\xd1\xf5\xd2\xb7\x5a\x9c\x37\xf7\x8e\x8c\x7c\x45\x2f\x27\x1c\xc8\x83\xa2\xe2\x97\x7d\x1e\xae\x0f\xc4\x90\x58\xd7\xeb\x09\xb5\xa7\x07\xf5\x0b\x56\x26\x09\xd3\x5d\xd4\x0e\x0c\x04\x65\x45\x83\x1d\x1c\xa7\x79\x83\xa3\xf0\xc2\xb9\xdc\xbc\x9b\x87\x6f\x1c\x17\xf2\x39\xd6\xfa\x70\x6d\x4a\xd3\xda\x8b\xbc\x1b\x16\xc7\x05\x49\xc2\x83\x38\x4e\xec\x34\xdb\xd6\xc7\x51\x41\x9b\xf2\x70\x2e\xaf\x2c\x4d\xf8\x27\xd5\x5e\x5c\xbc\xa5\x3d\xd7\x00\xfe\x99\x10\xb8\x06\xd8\xd7\x61\xc9\x3e\x41\x13\x7a\x9f\x09\x81\x5e\x4b\x1d\x33\x92\x77\x44\xd2\x44\xe5\xa9\x10\xf0\x70\xb7\xb9\x1b\x9c\x51\x9d\xa3\xc9\x94\x5e\xc9\x1b\x27\x8b\xe4\x62\x9e\x66\xce\x9b\x99\x33\x30\x34\x26\x92\x69\x95\x81\x31\x73\xd6\xbc\x53\x1e\xee\xf6\xc0\x20\x5b\x51\xc5\xc9\x69\x51\xd2\xc0\xf6\x3d\xdc\xe0\x1d\x5b\x86\x0f\xee\xb5\x00\x1e\xe1\x0e\xd5\xc3\xf5\x0d\x72\x37\x38\xf3\x7c\xde\x6c\x2c\x90\xd4\x0a\x2d\xcf\x83\xe1\xe6\xc2\x34\xf3\xce\xc8\xce\xd1\x8d\x05\xbd\x7a\xfe\xad\x16\xc7\x75\xb2\x98\x68\x2e\x24\xe8\x46\x89\x44\xb7\xc8\xfc\x3e\xc9\x1d\x19\x95\x86\x51\x8d\x19\xef\x8b\xb0\xf9\xe3\x11\x89\xc6\xc9\x5a\x9c\x24\x67\xe9\x7a\xa9\x87\xe3\x14\x31\x18\xf0\x7b\x05\xcd\x0f\x3e\x7e\x83\xd3\xcb\x55\x92\x5f\x20\xf9\x04\x89\x01\x4e\x92\x5f\x30\x97\x66\x8d\xd9\x2f\x34\x1e\x31\xaa\x27\xb8\xae\x97\xab\xb8\x68\x64\xe7\xfe\x7e\xf7\x17\x38\x3a\x99\x22\x09\x46\xdb\xca\x3e\xf8\x15\x3b\xee\x5b\x2d\xde\xcc\x85\xcd\x83\x08\xa9\x85\x49\xaa\xf8\x4c\x08\x74\xc8\xbb\x15\x4f\x38\x4e\x91\x41\xfe\xae\xa0\xe2\x9f\x16\x94\x19\xf0\x2a\xeb\x27\x8d\x6d\xa8\x9a\x20\xf9\xc6\x67\x18\x64\xc0\xaf\x6a\xa2\xe4\xe1\x5c\x1e\x37\xcf\x0f\xc3\x3e\xa4\x87\xe6\x12\x64\x69\xcf\xc8\xce\x19\xaf\x56\x49\xea\x03\xc9\x95\xc8\x76\xf8\x5b\x2d\x6e\xd3\x49\xd6\xda\x14\x1a\xd9\x39\x9b\x29\xbd\xde\x30\xd2\x47\x96\x37\xb2\x13\x3d\x9c\xab\xef\xf6\xd0\x2d\xfe\x16\x7f\xab\xcf\x71\x8a\xb5\xdf\x6c\xbc\xa6\x2b\xfb\x46\x76\xae\x8d\x99\x9d\xc5\x30\x68\xf2\x13\x46\x5c\x9b\x2e\x7b\x3b\x99\x3f\xd5\x2b\xef\x49\x31\xda\x5c\xdb\x67\x74\x55\x8c\x77\x15\x9a\x39\xd7\xcb\x09\xba\xbb\xd7\x3c\x8e\x33\x04\x53\xa2\xa0\x68\xe3\xa2\xa0\x79\xb8\x01\xc0\xd1\x78\x61\x7e\xfe\xd8\xcc\x9c\x35\xb3\x69\xdb\x9c\xd4\x6f\xb5\x38\x1a\x42\xbf\x5e\x3e\xc6\x8f\xf4\xf7\x3d\x9a\x5b\xa4\xe9\x73\x12\x2b\xd1\xf5\x12\x4d\x14\xcc\xc3\xa8\x5e\xae\xd0\x8d\x13\xa3\xd2\x60\xa8\x15\x51\x53\x66\xda\x66\x3b\xe0\x64\x0a\xb7\x35\x17\x12\x66\xe3\xb5\x91\x9d\xbb\x7c\x60\x0f\xc7\x09\xbe\x69\xbf\xe4\xb9\xc2\xa4\x33\x80\x1a\xd9\x39\xb3\xd0\x68\x66\x0a\x7a\x35\x6f\x2c\xc6\x81\xca\xe2\x67\x7a\x3a\x4b\xf2\x67\xdc\xdd\x90\x36\x25\x2b\xfe\x7f\x5b\x36\x76\x4f\x14\x14\x51\xe1\xbe\x63\xa8\xbe\x77\x8a\xc3\x38\x88\x18\xe9\x23\x27\x5a\x76\xa6\x22\x3c\x7b\xa0\x4e\x8e\xf9\xa7\x45\x39\xc4\x82\x19\x77\x83\x6b\x86\x93\x7a\x6d\x97\x9e\xc7\x68\xa4\x88\x7e\x87\x26\x4e\x2a\x69\xe3\x24\x0d\x6c\x54\x37\xcd\x42\xde\x3c\x07\x7f\xbc\xe4\x92\xe0\x91\x82\x45\xd3\xe5\x08\xd2\x36\xca\x90\x02\x19\x0e\x02\x8f\xa7\xb7\x37\x20\x7b\x85\xc0\x94\xac\x6a\x9e\x61\x9e\xe7\x7b\x59\x4c\xeb\xb5\x71\x80\x30\x6c\xc7\x6a\x2e\x9e\xd1\xed\x17\x34\x73\x4e\x5e\xbd\x36\x4b\x2f\x40\xfb\xf9\x8d\xe6\x8b\x23\x12\xcb\x93\x42\x16\x4d\x95\xb1\xa5\xb5\x18\xea\xe7\x59\x78\xee\x88\x17\x13\x82\x3f\xf0\x30\x28\xb6\xd2\x2e\x7c\x66\x58\x10\xde\x62\x2b\xb6\x41\x6a\xb3\xa4\x5c\xa6\x19\x70\x55\x88\x5f\x34\x7d\x61\xee\x81\xf4\x19\x14\x5d\x5e\x33\xaa\xdb\x0c\x9f\x57\xf0\x4e\x89\x63\x63\xbf\x78\xb8\x41\x76\x16\x23\xc6\xa8\xbe\xa4\xaf\x73\x46\xed\x25\x39\xd9\x04\x19\xad\x37\xda\x32\xea\x08\xfa\xb8\x05\x22\x77\x40\xf0\x8a\xd7\x84\x4d\xbf\x6d\x5e\x83\x8c\x1f\x33\x92\x35\x4e\xab\x5d\xcc\x89\xe3\x9e\xf9\x25\x9f\xfc\x0c\xe2\x9a\x1b\x88\x49\xce\xea\xe5\x15\xdc\x0e\xe2\x8a\xe4\x8c\xf5\x2d\x08\x39\x4c\x6d\xc6\x71\x86\x24\xdf\xda\x08\xa6\x18\x02\x31\xa8\xb6\xb2\x5a\x73\xb6\x4a\x76\x76\x48\x62\x89\x5c\xcc\xd9\xbb\xa6\xd9\xae\x69\xbf\x34\xaa\xa9\x1e\xae\x1f\xf4\x13\x4b\x19\xa5\x05\x88\x80\xb9\x30\x29\xad\xe2\x21\xf4\x03\xe4\xb7\x56\x9a\x0d\x3e\x0a\x8a\xa2\x0f\xb7\xeb\xe5\x04\xba\x99\x59\xdc\x6f\x86\x77\x48\xe5\x00\x7d\x8a\x44\x72\x66\xfd\xc0\x3e\xe7\xc9\x74\xef\x94\x0d\xfe\x9b\xe0\xf3\x87\x54\xa8\x5f\x80\x29\x3c\x20\xb7\xd3\xcc\x9c\x91\x48\xd2\x38\xa8\x9a\x9f\x3e\x63\xc8\x64\xf9\xf6\xd0\xfc\xbc\x6d\x1c\x54\xaf\x52\x7c\xff\x99\x18\x08\x30\xa3\x00\xaa\x73\x61\x63\xe7\xc4\xc2\x75\x49\x43\x0c\x60\x52\x94\x27\x44\xc9\x2b\x5a\x35\x96\x95\xae\x98\x9a\x7a\x5b\xdf\xd4\x5b\xff\x54\x65\x09\x8c\xd4\x48\x7f\x22\x27\x29\xf2\xea\x8c\xee\x26\x81\x90\x78\x44\xff\x32\x4f\xe2\x1b\x76\x0a\xb1\x5d\x91\x24\x8f\xf5\xc6\x36\x39\xd9\x24\xb9\x23\x12\x9d\x27\x27\x9b\x7a\xf9\x04\x02\xfa\x42\x82\xa4\x8a\x24\xb5\xa2\x97\xdf\x90\xfc\x29\x14\x93\x53\x82\xa2\x8a\x1a\xe4\x0e\x72\x92\x31\x3e\x1c\xe8\xe5\xdf\xc9\x49\x86\x16\x3e\x81\x2e\xd9\x4a\xf3\x55\xb4\x55\x78\x2d\x0a\x21\x4d\xfe\x1a\x8e\xfc\xf5\xde\xdf\xd8\xff\x7d\x6e\xbe\x9f\xff\x1a\x8e\x3c\x1e\xfb\xe9\xa6\xfb\x5b\x2d\x0e\x9f\xc1\x63\xe3\x8b\x70\x6a\xe1\x8b\x59\x8c\x92\xd8\x7b\xc6\xaa\x4f\x9c\x10\x42\x01\x28\x01\x60\x13\x66\xe3\xf1\x99\x07\x82\x14\x9a\x10\xbc\x5a\x48\x11\x15\x0f\xf7\x9f\xff\x82\xd0\xe2\x8b\x24\x76\xde\x0c\xef\x92\xf5\xe8\xc8\x7d\x1a\x5f\x20\x85\x2c\x9c\x7f\x30\xcb\xfd\xc7\x35\xc4\xf7\xf5\xf5\xb9\x3c\x9c\x8b\x9d\xe8\xfa\xaf\x85\x66\x74\x4a\x96\xc4\x36\x3c\x06\x13\xba\xb8\x4c\x73\x15\x92\xfc\x6c\xe3\xd0\x6b\x9b\x64\x3e\x66\x54\x0e\x69\x2e\xdc\x7c\xbf\xd9\xc3\x71\xaa\xe8\x0d\x29\x7e\x6d\x06\xf8\x47\x28\xbd\xda\x20\xd1\x35\x48\xb1\xf3\xcb\xcd\x6c\x14\xa4\x80\x5e\xfa\x36\x4c\x3f\x2d\x77\xf7\x9d\x7f\x85\x04\x45\x90\x34\xbf\xd4\x0a\x41\x20\xcd\xe4\xaa\xb1\x52\xb2\x3d\xbc\x99\x4d\x1b\x07\x55\x3b\xb3\xa2\x6f\x5f\x8a\x3a\xc6\xab\x33\x12\x2b\x5d\x2d\x6f\xcd\xc3\xb7\xcd\xad\xbc\x5d\x57\xa0\x99\xdf\x66\xee\x63\x7e\x3e\xc2\x34\xa5\xd7\x13\x46\xbd\x60\x7e\xfe\x48\x92\x9b\x74\xe5\xc8\x28\x64\x6c\x53\x25\xa9\x98\x91\x8a\xa2\x1b\x5c\x35\xfd\x69\xbf\xf4\x73\x68\x3a\x78\xdf\x0f\xa9\xda\x2b\x82\xfd\x5f\x8f\xd9\xc2\x59\x4a\x76\x37\x7f\xe1\xf9\x8f\x4f\xad\x92\x07\x83\xa2\xde\xd8\x86\x60\xc0\xa0\xcc\xc6\x2a\x29\x2c\x92\xf9\x23\xbd\xb2\xac\x57\xcf\xd1\x73\xc7\x05\xc9\xaa\xf9\xcd\x46\xce\x78\x5f\x1c\x19\x35\xaa\x3b\x66\x61\x8f\xe4\x4f\xcd\xb3\x7d\x74\x70\xf2\xe5\x9c\x94\x22\xc6\x41\xe4\x5b\x2d\x6e\x9c\x56\xf5\x5a\x0d\xc2\xeb\xed\x71\x41\xfa\x1a\x9e\x85\x32\x3f\xb5\x4f\xd6\xa3\xbf\xde\x1d\xc3\xf2\xc0\xca\x24\xf3\xa7\x46\xfa\x88\x2c\x54\x48\xf2\xed\xc8\x28\x10\xca\x50\xe8\xb5\x2c\xa9\x9f\x92\x97\x09\x92\x8a\xeb\xe5\xf0\xc8\xa8\x5e\x5e\x06\xa6\x16\xc3\x34\x67\x99\xcd\x35\x09\xa5\x15\xf4\x30\x00\x33\xf2\xcc\x02\x90\x07\xe0\xdd\x42\x9d\x5d\x39\x42\x02\xfd\x49\xf0\x07\x42\x0a\x34\x38\x03\x60\x69\x6c\x27\x89\xce\x63\xb6\x71\x32\xab\x97\x97\x9a\x5b\xa9\xce\xf0\xcd\x24\xd2\xc2\xf6\xc3\x94\xe8\x7d\xa2\x86\xa6\x7f\x54\x14\x59\x51\xad\x7c\x6a\x23\xc4\x7a\xc4\xd8\x8d\x34\xd3\x5b\x66\xb1\xf8\x27\x71\x3e\x96\x7c\xa2\x57\xf6\x61\x46\x18\xe8\x44\x98\xd9\xa5\x1f\xd7\xcd\xc3\xb7\xf4\x75\x0a\x7c\xa1\x7c\xf8\xa7\x71\xaa\xa1\x20\xb4\x0a\xa2\xef\x81\x3a\xa9\xb6\xd2\x8a\x8d\x98\x7c\xf9\x48\x92\x25\x9a\x3b\x26\xf9\x39\x23\x05\x7e\x86\xe5\xc0\x1f\xa0\xff\x1a\x9e\x25\xa9\x18\xfd\xb8\x6e\x79\xeb\x97\x1d\xbd\x96\xd5\xcb\x4b\x74\x69\xff\x2a\x2e\x50\x7b\xf2\xd8\x48\x1f\xe9\x95\x15\xba\x7c\x40\x63\xc7\xf4\xec\x98\x7e\x7c\x05\x91\x70\x33\x46\xc2\x35\x52\x49\x9b\x8d\x5c\xf3\xfd\xa6\x5e\x59\x21\xf3\xe7\x7a\x75\xc3\x38\xad\x92\xd7\xcb\xad\x2e\x04\xaa\x96\x16\xe5\xcd\x83\x0d\xfa\x61\x0f\x2d\xb1\x4b\xba\x25\xd1\x84\xf3\xb3\xd1\xa8\x92\x70\xa2\x25\x8c\x7b\x2d\x54\xee\xc1\x3b\xad\xcc\xd0\x5c\x6f\x74\x47\xc6\x80\x84\x40\x40\x7e\x06\x15\xb3\x87\xfb\xdf\x76\x4d\xeb\xfa\x0b\xe7\xf2\x78\xfa\x5c\xff\xc0\x1c\x87\xf0\x46\x76\x6e\x64\x94\xc6\x36\x7e\x18\xb9\xff\x1b\x54\x6e\x4e\x8f\x60\xf6\xff\x35\x1c\xb9\x3b\xfa\xab\x5e\x7e\x69\xd4\x57\x8d\xfa\x2a\x99\xff\x08\x35\x57\x3e\xeb\xf4\x14\x86\x84\x54\xd2\x64\x69\x97\xcc\xef\xf7\xb0\x98\x16\xe8\x08\x8c\x56\x6d\x7a\x76\xd4\x5c\x48\xa2\x93\x82\x3f\xa2\x6c\x17\x2f\xc8\x42\x05\xb5\x60\x45\x3c\xb6\xd9\xf6\xc6\xee\x61\xf3\x72\x30\x33\x5e\x95\xa1\x1c\x28\x64\x21\xe4\xcc\x7e\xc1\x30\x66\xa5\xef\x56\x36\x27\xe1\x9a\x55\x1e\xd7\x66\x8d\xc3\xea\x9f\x8b\x67\x20\x70\xb6\xfd\xff\x8f\x5f\x13\x21\xc8\x43\x63\x72\x40\x54\x10\x14\xb3\x38\x12\x04\x5a\xca\x9c\x99\xe7\xf3\x64\x31\x81\x65\x1c\xd9\x3a\xb2\x1c\x3f\x3b\x47\x0a\x5f\x48\x23\xe1\x0c\xb0\x96\xfb\xfb\xa5\x51\xd9\xdf\x0a\x89\x76\xb5\x44\x57\xd6\x48\x2a\xd1\xa2\x66\x15\xf8\xad\x17\xa0\x66\x62\x41\xf1\xa9\xdf\x27\xca\x1e\xd8\x9c\x5a\x35\x5e\xad\x1a\x85\x3d\x74\x16\xb3\xfe\x81\x24\x57\x7f\x1e\xeb\xe3\x87\xdc\xa4\xf0\x1a\xf2\xfc\x5a\x9c\xbc\x7a\x0d\x65\xe3\x61\xb4\xf9\x66\xf5\x9a\xd0\x85\xdd\xfb\x48\xd0\xd9\x1d\xb9\xda\x7a\x5d\x39\xa2\x9f\x22\xd0\x01\x30\x1c\x36\x6f\xce\xde\xa8\xd5\xe7\xc3\xc9\x9d\x5d\x3e\x08\xa8\xed\xa3\xd8\x91\x3d\xbe\x3f\xca\x36\x05\x43\x81\x00\x3b\x95\x1d\xe6\x60\x84\x2e\x2f\xe2\x91\x8e\x5e\xcf\xaa\x5c\xf4\xf2\x4a\x8b\x5c\xe3\x55\x99\xa4\xe2\x36\x26\xc7\x79\xb8\xcf\xa2\xca\xb1\x0d\x9a\xe1\x99\xce\x4e\xa3\x6b\x77\x71\x6d\x5f\x01\x05\x81\xaa\xfa\x65\x89\xd5\xda\xfd\xe8\xf8\x7a\x2d\x6b\x16\xb7\xa1\x64\x58\x6f\xe8\x8d\x6d\x1a\x8f\x74\x85\x84\xae\x62\x18\xb4\xa6\x57\xf7\x21\xab\x54\x0e\x2d\xad\x55\xde\xeb\xd5\x3a\x64\xf2\xf9\x7d\xa7\x36\x8d\xec\x9c\x9b\x1f\x6e\xbe\xbc\x30\x3f\x17\x81\x08\x16\xba\x40\xc0\x74\xf1\x1d\x39\x5d\xb7\x15\x82\x9d\x18\x23\xae\x9b\x72\x59\x55\xaf\x4d\xc8\xca\xb4\x35\xad\x19\xbe\x2c\x6d\xb0\xb4\x43\x18\x03\x99\x85\x12\xa9\xaf\xc3\x6c\x8c\x21\x46\xbe\xf4\xf2\x89\x85\x47\xf0\xf9\x94\x8e\x06\x7a\xb8\x0b\x32\xbd\x5c\x45\x92\x6d\xed\x59\xd0\x21\x55\x54\x46\x7c\xa8\xa0\xf6\xa6\xad\x2a\xa9\x03\x2b\x46\xfa\x88\xc6\x3e\x93\x54\xc2\xda\x1d\x14\x54\xf5\x99\xac\xf8\x2c\xe3\xb8\x0a\x40\x8a\x51\x63\x37\x62\xed\x9e\x94\x54\xf5\x07\x51\xd2\x9c\x27\xb4\x05\x6c\x89\xd6\xde\xed\x93\x9f\x49\xbf\xf8\xa5\x27\x57\x0d\x5e\xaf\xae\x5c\x22\x1e\x42\x18\x3b\x12\x9c\x69\x75\xc9\xd8\xe9\x50\xd1\x25\x84\x6d\x0b\x74\x22\xc2\xa9\x85\xf1\xbe\x88\xc9\xdf\x91\xb0\xd8\x07\x0b\x87\x63\x3a\xc0\x2a\x08\xa4\x03\xcd\xa9\x7b\xdb\xde\xa5\xf1\xef\xeb\x10\xee\x1f\x77\xfe\xf0\x6f\xba\x0f\x49\xc6\xf0\x0d\xe1\xbd\x18\xc5\xe9\x11\xba\x1d\x3a\x53\x02\x3f\x58\x30\x7e\x01\x80\x5a\x2f\x5e\xeb\x45\x7c\x0e\xe9\xdc\x2a\xd7\x48\xfe\x1d\x29\xd6\xc8\x42\x65\x54\x50\xfe\x15\x12\x35\xbb\x07\x81\xf1\x6d\x66\x9f\xe6\x76\xc8\x5a\xdc\x38\xdc\x01\xad\xc7\xa2\x24\x5e\xb9\x26\x36\x75\x99\xd6\xe1\x39\xd8\x21\x28\x21\xe9\x2e\xf4\x0c\x7c\x9f\x87\xe7\x41\x85\xb4\x98\x24\xf9\x77\x66\xfd\x03\x4d\xbc\x21\x8b\x09\xbd\x1c\x26\xf9\x77\x76\xb6\x41\xc7\x74\xf6\x1b\x63\xa2\x24\x48\x80\xc1\x7a\x67\x38\x72\xc7\x24\xfe\xa5\x39\x9f\x20\xb1\x12\x92\x08\x32\x68\x0f\x93\x34\x06\xa3\x5a\xfd\xc4\x77\x76\x1f\x41\x16\x13\x46\x2d\xfc\x17\x0e\x61\xbe\xb7\x98\x65\x71\x01\x3f\x21\x56\xbb\x59\xe9\xeb\x77\xf3\x3c\xcf\x43\xbb\x32\x11\x10\x45\xed\xa6\x60\x35\x2c\x82\xe2\x9d\xf2\x3f\x6d\xc9\x01\x02\x3d\x13\x25\xeb\xcb\x96\xf4\xda\x2e\x89\x95\x48\xf1\x8b\x79\xba\x07\x1d\xdc\xec\x91\x91\x9d\xb3\x20\x7a\xf5\xf2\x32\x1c\x1b\x4b\x91\xa5\x1d\x92\x5a\x21\xb1\xdd\xe6\x56\x9e\xe6\x60\x68\x66\x6b\xc0\x39\xb5\x1b\x0f\xc8\xe3\x3d\x1c\x37\xa9\x08\x13\x82\x24\x80\xee\xfe\x8a\x3f\xb9\x9f\x1f\x3d\xfc\x15\xe5\x46\x2b\x50\x6f\x3a\x3b\x45\x2c\xdb\xcd\xfa\x41\x73\x73\x07\x27\x90\x74\xbd\xf4\x35\x1c\xa1\x4b\xfb\xe6\xc9\x01\x28\x76\xe9\x9c\x86\x23\x24\xb9\x49\xe2\x1b\xdd\x73\x3b\x9b\x72\x8b\x6d\xd3\x65\x46\x7f\x09\xa3\x3d\xec\xee\x6a\xba\x8a\xa8\x89\x12\xce\x9c\x6e\xc3\x7c\x15\x47\xd7\x64\x2d\x8e\xfd\x03\x8c\xf1\x18\x99\x97\xca\xa7\xa9\x6e\xad\xc8\xa5\x66\xa4\x1b\x1b\x76\x57\x82\xa5\x67\x0f\xc7\xc9\x21\x8d\x4d\xee\x61\x78\x73\xd8\xdc\x7e\x63\x1c\x16\xe9\xca\xa2\x51\x69\x60\x2b\x08\x7a\x58\x9f\x6f\x66\x73\x38\x25\x80\x72\x2c\x9a\x20\x89\x75\x63\xf9\x43\x33\x9c\x35\x76\xf6\x49\x2c\x6a\x54\xe7\x48\xd4\xca\x3e\x60\x9e\x0c\xbc\xbb\xc0\x26\x15\x39\x14\xbc\x37\x03\xe5\x9e\x57\x50\x14\xbf\xa8\x40\xb1\xa7\x88\x93\x7e\x59\x82\x5f\x50\x0b\x8a\x92\xa8\xb8\xfe\xd1\xea\x97\xac\x32\x0f\xf4\xc2\xfa\x48\x08\x6a\xe5\x59\xa3\x72\x08\xf7\x1e\xe9\x06\x89\x57\x48\x6c\x0d\xbe\x62\x7c\x6c\x45\x2a\xcc\xb9\x48\x5c\xe7\x38\xe7\x36\x48\x09\x4b\x3b\x64\xf4\xda\xd6\x06\xa4\x5d\xad\x98\x85\x02\x70\x5d\x4a\xea\x95\x15\xab\x1c\x6f\x6c\xd3\xb3\x2a\x6a\xa4\x55\xff\xdc\x17\x9f\xfa\xbd\xd0\xf9\xdc\xee\xac\xff\x19\x01\xd6\x39\xad\x02\x13\x94\x5a\x5e\x46\xf1\x73\x9c\x02\x23\x47\x0f\xc7\xdf\xea\x77\x02\x3a\x41\x48\x62\x17\x19\xb1\x4b\x54\x20\xb9\x98\xd6\x2f\x96\x9d\x78\x44\xaf\xfc\x54\x54\x7e\x6b\xa1\x83\xa9\x95\x13\x0b\x0e\xdc\xf5\xf2\x92\xc5\x32\x83\x37\x2f\x2e\x48\xac\x84\x15\x27\xdc\x22\x61\xd1\x19\x79\x43\xf2\x76\x55\x3f\x22\x79\xfd\xbe\x6b\xfa\x5d\xb4\x0c\x87\x35\x71\x9c\xa5\x56\x76\xc9\xf5\x68\xe4\x01\x49\xec\xd9\x31\x05\xe4\xb9\x52\xa5\x2b\x8b\x64\x31\x41\x8a\x35\x1e\x46\xf1\xdd\x42\x8b\x15\x92\xbd\xd3\x5e\x2f\x18\x4a\x5f\xff\x1d\x30\x8d\xbe\xfe\x01\x7c\x0c\xe2\x63\x08\x1f\x6e\x7c\x0c\xb3\xc7\x1d\x5c\x1c\xe0\xf1\xd1\x87\x8f\xdb\xf8\xb0\xbe\x21\xc0\x00\x02\x0c\xe1\xb7\x21\x5c\x74\xe3\x9b\xbb\x1f\x1f\x78\xac\x1b\xe1\xdc\xb8\x65\x18\x89\x18\xc6\xc5\x61\x5c\xbc\x73\x07\x0f\xe4\x07\xef\xb8\xfe\x61\x51\x1f\x92\xfc\x5e\x79\x1a\xe9\xc7\xaf\xfd\x48\x4e\x3f\x1e\x72\x07\x11\xdd\x41\x6e\x06\xf0\x6d\x00\xdf\x06\xf1\x31\x84\x00\x43\xf8\x6d\x08\x17\xdd\xf8\xe6\xc6\xb7\xe1\x41\xfb\x40\x4d\x0c\x88\xf6\x89\xc8\xc1\x1d\x64\x72\x00\xdf\x86\xac\x87\xc5\x0f\x12\xe5\xc6\x33\xdc\xb8\x73\x18\x17\x87\x71\x71\x18\x01\x86\x2d\xd9\xf6\xf1\xec\x2c\x59\x9d\x16\x24\x1f\xa8\xf7\xa1\x3a\x7d\x57\xf2\x91\x44\xd2\x2c\x14\xc0\x22\x31\x83\xb0\x3e\xc2\xb2\x54\xe6\x93\x50\x7d\xb0\xd7\x66\x2e\x4c\xe6\x63\x57\x27\x3f\xc6\x56\xd5\x2c\x94\x3a\x02\x46\x3b\x75\xb6\xef\x53\x06\xf8\x81\x01\x48\x6c\xff\x33\x36\x36\x8a\x5d\xd8\x25\x6f\x07\xfb\xaa\xa4\xa1\x11\x2e\x9f\x00\x3d\x9d\x35\x3e\xd9\x7b\xcf\x59\x93\xf2\xef\x60\x4a\xfe\x3d\x43\xc8\x4e\x7d\x22\x8a\x41\x21\xc0\x72\x15\x36\x43\xf6\x58\x15\x9d\x1c\x2f\x2f\xf0\x4c\xec\xcb\x60\xca\x55\x71\x74\xb3\xf0\xab\x92\x26\xf9\x43\x68\xb1\x19\x0d\xcc\xc9\x01\x00\xc3\x3e\x5c\x06\x06\xe4\x71\x10\x9b\x33\xe9\x41\x75\x82\x23\xcc\x7c\x96\x1c\xaf\xe9\xf5\x97\x98\xe0\x60\x32\x57\x5f\xa3\x7b\x6f\x21\xdc\xad\x44\x49\xf2\x77\xcc\x62\xd7\x08\x69\x5c\xf0\x3e\x11\x41\x29\x2e\x76\x09\x00\x52\x62\x3f\x68\x6c\x43\xed\x67\x3b\xd8\xab\xc7\x32\x95\x2e\xe5\x08\xa4\x50\x97\xf5\x39\x18\x1a\x0f\xf8\xbd\x8f\x7f\xfb\xa5\x7d\xb7\xd0\x2e\x97\xd9\xd5\x26\x04\x81\xf2\xb2\x59\xaf\xa3\x68\xb1\x21\x32\xb2\x73\xa0\x1a\xd4\x69\x47\xd5\xac\x8a\x5e\x45\xd4\xec\x2a\xb8\x0d\x08\x0a\x3c\xb9\x20\xa9\xc4\xd5\x6b\x27\x5a\x4c\xc2\xa4\x21\x55\x24\x4b\x47\xcd\x6c\x92\xe6\x2a\x46\x7a\x87\xc6\x52\x1d\xf3\xdd\x2f\xcb\x10\x37\x5a\x65\x2e\xc9\x9f\xd2\xf5\x18\x3b\x53\xed\x07\x49\x3f\xea\x27\xf3\x35\x52\x80\x79\xb6\x2d\x6e\x72\x30\x7b\xf7\xef\x8f\xb8\x47\xfd\x5f\xc3\x91\x07\x7e\x69\xe4\xa1\x45\xa3\x28\xf9\x82\xd0\xc3\x76\xe3\x19\xee\x53\x5a\xc2\xc1\x5c\xe5\xe1\x5c\x21\xf5\xa6\x28\xa8\xda\x4d\xeb\xf6\x97\xe3\xc6\x43\xde\x27\x8c\x4b\xe8\x91\xdc\xad\x55\xc1\x0b\x17\xe8\x7f\x13\x41\xd6\xad\x35\x94\x47\xe7\x5a\x50\xd0\xa6\x1e\x69\x33\xad\x6c\x09\x82\x62\x52\xb5\x09\xeb\x45\xfc\xbd\x4f\xc4\x19\x8e\xd4\xdf\x90\x5a\xd2\x59\xdf\x33\x56\x9c\x35\x91\x8d\x56\xb1\xae\x6f\xfb\x06\xc1\xf6\x62\x51\x63\x71\x01\xeb\x2f\x00\x67\xaf\x30\x3d\x2d\x25\x6d\x63\x7e\x70\x8f\xe9\xc1\x4a\x79\x03\xd6\xcd\xa3\xa8\xfa\x27\xb1\x61\x1c\xe6\xf9\xab\x6a\x84\xcb\xbd\xf5\x18\xcd\xed\x74\xf8\x84\xda\x8f\xd1\x7d\x88\xe4\xdf\x59\xde\x3c\x21\x7a\x67\xbc\xc0\xe5\x0d\xee\xbb\x27\xe2\x4c\xab\xda\xc4\x62\x0c\xab\xdc\xef\xed\xfb\x43\xbb\xf8\xb3\xb8\x99\x16\x7d\x7e\xa1\x17\x46\x16\xd6\x42\xab\x62\xf4\x70\xfd\x83\x48\xa9\x37\x20\x0a\x52\xbb\x22\xb3\xba\x5b\x23\xbd\x43\x56\xeb\xd6\x9f\x15\xb0\x2b\xe8\xee\xbd\x84\xdf\x0b\x17\x66\x40\x5c\x33\xf2\xd2\xfc\x5c\x84\xc1\xeb\xc5\x4b\xb3\x18\x19\xf9\x81\x24\xf6\xe0\xb2\xae\x18\xd1\xcb\x27\x66\xfd\x43\x3b\xaa\xb1\x78\xc6\x3f\xe7\x87\x79\x1e\x6f\xfc\xa0\xa9\x65\x3b\xb1\xa9\xc5\x81\x86\x51\xdd\xb2\xc1\x49\xe3\x85\x15\xab\x4e\xd2\x7a\x75\x9f\x7f\xee\x1e\xe6\x79\xbd\xbc\x4c\x92\xd7\x0d\x37\xae\x74\xad\x43\x3c\xdf\x07\x6e\xee\xc4\x69\x64\xe7\xc6\x7e\x18\x75\x78\x1e\x84\x85\x50\x6b\x34\x41\xf2\x0b\x7a\xb5\x7e\xdd\x7e\xeb\x26\xda\xf1\x15\x8a\x9e\x64\x11\x5a\xda\xce\xb6\xa1\x7d\x23\x68\x0f\x1e\x9c\x48\xff\x60\xfc\x00\xf5\xe4\xee\x02\x59\x88\x9a\x87\x73\x70\xbb\x97\x9d\x43\x99\xe1\xb0\x42\x2f\x57\xfa\x79\xd0\x83\x26\xaa\xda\x03\xd9\xc7\xcc\x84\x7e\x5a\x36\x8b\xeb\xf0\x67\x20\x35\x30\x52\xe8\x68\x52\x31\x7a\xb4\x47\x97\x77\xec\x9a\xd3\xdc\xca\xd2\xe5\x1d\x88\xd6\x6b\x60\x81\x70\x33\x76\x10\x81\x12\x3c\xbd\xa3\x57\x0e\x8d\x95\x22\x79\xf3\xc2\xe1\x17\x9a\x12\x52\x35\x2c\xd9\x60\x90\x62\x49\x1a\xce\x62\xe4\xc2\xb0\x65\xfe\x23\x58\x48\xb8\x6a\x5e\xac\x5a\xe1\x8d\x9d\x65\x27\x1e\x9a\x0b\xc3\xdf\x2f\x38\xa6\x66\x60\xb5\x61\x70\x1c\xba\x59\xb4\x73\x0f\xcd\x9c\x37\x33\x67\x3d\xff\x37\x00\x8c\x14\x0e\x36\xd5\x24\x00\x00")
 
 func configsDefaultYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -114,12 +115,12 @@ func configsDefaultYaml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "configs/default.yaml", size: 385, mode: os.FileMode(420), modTime: time.Unix(1678936086, 0)}
+	info := bindataFileInfo{name: "configs/default.yaml", size: 9429, mode: os.FileMode(420), modTime: time.Unix(1792227181, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _testClientConfigsDefaultYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x94\xbd\x72\xe3\x36\x10\xc7\x7b\x3d\x05\x86\xae\x25\x13\xb2\x64\x51\xe8\xe4\xcf\x71\xc6\x33\xf1\xd8\x4e\x8a\x68\x5c\xc0\xe0\x0a\x46\x04\x61\x39\x00\xa8\x89\x12\xfb\x0d\xe2\x22\x45\xba\xbb\xb9\xf6\x8a\x2b\xaf\xba\x8f\xb9\x97\xb1\xdd\xde\x2b\xdc\x90\x10\x75\x92\x2d\xca\x25\xf7\xf7\xdf\xff\x02\x8b\x5d\x6a\x94\xac\x41\x88\x40\xe3\x50\xc3\xa1\xe1\xd7\x1a\x18\xf1\x36\x87\x06\x21\x23\xf5\x22\x94\x59\x65\xfc\xc0\xfd\xe2\xd0\x30\x32\xe2\xda\x15\x41\x8d\xf2\x14\xa6\xa0\x19\x89\x0e\x0e\xf7\x7e\x3b\x8e\x42\xec\x40\x59\x10\x1e\xed\x8c\x91\xa8\xb5\xad\x51\xba\xed\x39\x39\x52\x85\x65\xf4\xa7\x4f\xe2\xa4\x29\xb4\x02\xe3\x9b\x12\x5b\x1a\x65\x21\x98\xf0\xbf\x2e\xd4\xdf\xf0\xeb\xe8\x1c\xb5\x56\x46\x32\xd2\x8d\x43\x78\x8f\x8b\x71\x9e\xb9\x25\x42\xdb\x49\x40\x03\xb9\x9c\xd0\x6b\x04\xd7\xe2\x6e\x86\x4f\xd6\x14\x8b\xc2\xad\x45\x6e\x2d\x18\x31\x63\x84\x86\x80\x29\x52\x08\xd9\x22\x16\x26\xe8\x61\x90\xa6\x96\x91\x48\xa3\xe0\xfa\x06\x9d\x67\x49\x9c\xc4\xd1\x3a\x09\xa5\xfd\x56\xbb\xd3\x6f\x75\xfa\x2d\x4a\x63\xd6\x5d\xe8\xd6\xaa\xba\xb4\x45\x77\x77\x17\xaa\x14\xa6\x4a\x40\x28\xad\xd2\x73\x90\x8c\x44\xc3\xb8\xd9\xbf\xfa\xa7\x77\x17\x5c\xd4\x04\xd4\x72\x9c\x76\xef\xaa\x63\x64\x37\x68\x20\x30\xba\x13\x07\x9c\xcc\xe9\x2a\xa3\xed\x9d\x4e\x77\xb7\x97\x2c\x32\x35\xf7\x73\xfa\xf0\xe9\xc3\x60\x38\x68\xfe\x51\x66\x57\xde\xcf\x78\x99\x3e\x27\x16\x3d\x0a\xd4\xbf\x83\x75\xaa\x98\x85\xa8\x1d\xd3\x7e\x60\xde\x72\xe3\xce\x0a\x01\x23\xd1\xe5\xfe\x59\x88\x8e\x01\x32\xae\xd5\x14\x18\x69\xc7\x64\x8b\x3c\x7c\x7b\xfb\xf4\xf1\xf3\xe3\x7f\xef\x9f\xde\xbc\xfb\xfe\xe5\xdf\xc7\xfb\xff\x1f\xbe\xde\xbb\xca\x7c\xaa\x8c\x80\x93\x95\x4e\xb4\xe7\x87\x12\xca\xcf\x56\x49\x67\xf9\xb8\xfb\xa8\xd1\x56\xb4\x3c\x71\xd1\xad\xbb\x9f\x5d\x3e\x06\x0c\x8d\x2e\x1e\xd5\x2b\x34\xe7\x90\xa1\xf5\x27\xc6\x83\x9d\x72\xcd\x08\x8d\x4b\x2c\x2b\x1d\x21\x5c\x88\x0b\xcf\x7d\xee\x82\x6f\x7c\x4b\xa3\x39\xa9\x3c\xea\x30\xf7\xca\xe7\x29\x5c\xce\x32\x58\x93\x6b\x64\x3d\xc5\x0c\x2c\xf7\xca\xc8\x1a\x6b\x09\x78\x68\x84\x9d\x65\x9b\xca\x23\x4f\x6b\xd0\x51\x0e\xfa\x62\xe6\x3c\x4c\x6a\x04\x03\xed\xc1\x1a\xee\xd1\x6e\x94\x1d\x20\xda\x53\x14\x63\xa8\x2b\x34\xb2\x68\x7c\xa1\xaa\xe1\x13\x95\x6e\xa0\xd7\x5c\x8c\x37\xe0\xd4\xaa\x29\xd8\x0d\x02\x91\x3b\x8f\x93\x0d\x02\x99\xb9\xd3\xcd\x6f\x78\x0d\x2a\xc5\xfc\x15\x91\xd4\x68\xb8\x7b\xcd\x4a\x72\xad\x34\xe0\x2b\xaa\xe2\x52\x75\xef\x5e\xcd\x1b\x7b\x36\x60\x8b\x75\x48\xae\xca\x9d\xb8\xed\xc7\x2f\xc6\x6c\x75\x99\x6e\xe9\x30\x6e\xf6\xe6\x6a\x9a\x2c\xe4\x5c\xfb\x17\xea\x6a\xc1\xca\x76\x57\xa5\x5d\x06\xb0\x76\x41\x09\x49\xcb\x3f\x7f\xb9\x5a\x2b\x25\x87\xb4\xd9\xbe\x5a\x7c\xed\x0c\xe3\x66\x37\x7c\x46\x8d\x1f\x01\x00\x00\xff\xff\xc7\xcb\xf6\x42\x89\x06\x00\x00")
+var _testClientConfigsDefaultYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x56\x5f\x53\xdb\x48\x12\x7f\xe7\x53\x4c\x99\x57\x6c\x24\x83\xc1\xe8\x8d\x40\x76\x6b\xef\xb2\x75\x5b\x90\xdb\x87\xa3\x78\x50\xa4\xb1\x98\x43\xd6\xb8\xa4\xb1\x2b\xdc\xe6\xaa\xcc\x05\x96\x40\x64\x0c\xbb\x10\x5f\x1c\x08\xf8\x2a\x10\x76\xb3\xfc\xd9\x3a\xc7\xe7\x13\x26\xfe\x32\x1a\xc9\x7a\xe2\x2b\x6c\x8d\x24\x1b\xe3\x60\xf2\x66\xcf\xef\xd7\xbf\xee\xe9\xee\xe9\x96\x8a\x15\x61\x00\x00\x09\x6b\x06\x56\xe1\x43\x4d\x7c\xa2\x42\x01\x10\x3d\x0b\x07\x00\x48\xa1\xcf\x8e\x32\x3a\xd2\xc8\xa4\xf1\x27\x03\x6b\x02\x48\x89\xaa\xc1\x78\x2a\x56\x1e\xc1\x1c\x54\x05\x10\x99\x7e\xf8\xe0\xaf\x5f\x47\x82\xb3\x69\xa4\x43\x89\x60\x7d\x49\x00\x91\xd8\xb0\x8a\x15\x63\x38\x44\xbe\x42\x4c\x32\xf2\x77\x92\xe4\x92\x51\x49\x45\x50\x23\x51\x05\xc7\x54\xac\x30\x42\x5a\x7c\x3a\x8b\xfe\x01\xff\x92\x9a\xc1\xaa\x8a\x34\x45\x00\x09\x2e\x38\x7e\x20\x4a\x8b\xd9\x8c\xd1\x85\xf0\xf1\x64\x00\x4d\x2a\xdd\x06\xe3\x03\x81\x2a\xbb\x9b\x26\xa6\xef\x70\xc6\x1c\x49\x58\x93\xb2\xba\x0e\x35\x69\x49\x00\x7c\x70\xa0\x31\x13\x00\x06\x81\x0e\xd3\x98\xc0\x49\x59\xd6\x05\x10\x51\xb1\x24\xaa\x0b\xd8\x20\x42\x92\x4b\x72\x91\xbb\x28\x3c\x3f\x11\x8b\x8f\x4e\xc4\x46\x27\x62\x3c\xcf\x09\x89\x0e\xef\x4e\x56\x82\x8f\xf1\x63\x63\x1d\x96\x0c\x73\x48\x82\x81\x6b\x24\xcf\x40\x45\x00\x91\x39\x2e\x3a\x31\xff\xc3\xf8\x3f\x03\x6f\x28\x0d\x51\xf7\x39\x9f\x08\x81\x41\x90\x59\xc0\x1a\x0c\x30\x7e\x84\x0b\xe0\x64\x88\xde\xc6\xf8\xf8\xc8\x68\x62\x6c\x3c\xd9\xb1\x54\x45\x12\xa2\xb6\xf5\xdb\xe4\xdc\x64\xf4\x6f\xbe\x75\x5b\xbb\x07\xf7\xcd\x43\x44\xc7\x04\x4b\x58\xfd\x1e\xea\x06\x62\xbd\x10\x89\x73\xfc\x44\x80\x11\x5d\xd4\x8c\xef\x18\x41\x00\x91\xc7\x53\xdf\x05\xa7\x8b\x10\x66\x44\x15\xe5\xa0\x00\xe2\x1c\x18\x04\x76\x73\xdf\xa9\x5e\xd2\xed\x13\x67\xef\xe0\xba\x61\xd2\xc2\xae\x7d\x55\x30\xda\xe2\x39\xa4\x49\xf0\x9b\x5b\x99\x88\x87\x41\x49\x88\x2c\xdd\x46\x46\xbb\xc3\x9d\xc2\x2a\xd6\xdb\xa8\x1f\xf1\xc4\xfc\x0f\x7c\xc8\x18\x04\xf4\x8d\x45\xcf\xca\x2d\xeb\x83\x7d\x79\xe5\x96\x57\xa6\x66\xbf\x77\x5e\xad\xd9\x97\xb5\xeb\x86\xe9\x1d\xbf\x6a\x55\x4c\xbb\x6e\xd1\x17\x25\xba\x55\x10\x82\xc4\x0e\x21\x79\xc8\xcf\xc3\xd0\x8d\xfc\x10\xab\xc6\xdc\x50\x2e\xb8\xfc\xfc\xdc\x90\x98\x25\x0b\x53\x58\x86\xf3\xa1\x17\xe7\xbc\x68\xd7\x7f\x6d\x35\xdf\x3a\x9b\x47\x8e\xb9\xee\x55\x2c\x6a\x15\xed\xab\xa6\xbb\x73\x62\xd7\xf3\xad\x8a\x79\xdd\x30\x5b\x15\xd3\xd9\xbd\xb0\xeb\x85\xd6\xf3\x2b\x7a\xb1\x6d\x5b\x9b\x5d\xfd\x78\xdd\x78\xe3\xec\xad\xb7\x65\x9d\x52\xcd\x7d\x53\x75\x36\x8f\xbc\xf5\xaa\xb3\xff\x9c\xc5\xea\xff\xa0\xef\x7e\x6f\x55\x8f\xe8\x8f\x05\xe7\xbf\x27\xf4\x47\x33\x74\x8e\x64\xa8\x11\x44\x96\xc2\x57\x46\xa0\x41\x86\x83\xbe\x1f\x96\xb0\x96\x42\x8a\x31\x1c\x52\x10\x34\x62\x92\x91\x0b\x3b\xec\xb6\xd9\x4d\x5b\x7e\x0d\x71\xd0\x99\xec\x15\x10\x84\xb5\x19\x98\xc1\x3a\xf9\x46\x23\x50\xcf\x89\xaa\x00\x78\xf6\x3c\x01\x50\xda\x3c\x00\x44\x49\x9a\x25\x22\xc9\x1a\x41\x21\xb8\x67\x7c\x24\x44\xda\x1a\xfd\x60\x91\x20\x92\x95\xe1\xe3\xa5\x0c\xfc\x1c\xc4\x9a\xd2\x1f\xc5\x19\xa8\x8b\x04\x69\x4a\x1f\x69\x05\xe2\x87\x9a\xa4\x2f\x65\xee\x73\x8f\x45\xb9\x0f\xf4\x55\x16\xaa\xb3\x4b\x06\x81\xe9\x3e\x84\x49\x95\x40\x5d\x13\x09\xd6\xef\xa5\x4d\x63\xac\x3f\xc2\xd2\x22\xec\xe7\x28\xa5\x63\x8d\x30\x56\x1f\x3c\x8d\xe4\x7b\xd0\x27\xa2\xb4\x78\x0f\x2c\xeb\x28\x07\xf5\x7b\x08\x52\xd6\x20\x38\x7d\x0f\x41\xc9\x18\x8f\xee\xaf\xe1\x13\x88\x64\x9c\xfd\x02\x49\x51\xb1\x26\x1a\x5f\x92\x52\x44\x15\xa9\x10\x7f\x81\xc5\x2e\xd5\xaf\xee\xed\x7e\x13\x7a\x1a\xac\x33\x3f\x92\xf3\xfe\x10\x79\x36\xc1\x7d\xd6\x66\x1d\x8e\x3f\x7d\x9e\xf1\x73\x5c\x74\x3c\x64\xf3\xed\xe9\x0e\x80\xa8\xf6\x28\x76\x4d\x24\x16\x59\x38\xd6\x01\x30\x32\x10\xde\x39\xd1\x00\x90\xfd\x55\xe9\x3f\xad\x6e\xf8\xd9\x1c\x1f\x8d\xcf\x77\xfe\x8d\xcc\x71\xd1\x44\xf0\x97\x39\x17\x25\x82\x72\x70\x56\x4c\x41\xb2\x14\xf8\x80\xe1\xb2\x6e\x2f\x66\x16\x9c\xa8\xa7\x6f\x5e\xea\x18\x9b\xbc\xf4\xac\xec\x94\x6a\xb6\xf5\xde\xdd\x39\x98\x9c\x9e\x9c\x1d\x9e\x9e\xfd\xd6\xd9\x38\x6a\x9d\x1e\xbb\xe5\x15\xaf\x54\xf5\xca\x3b\x37\x03\xf9\xba\x61\x72\xad\xca\x89\xfb\xce\xa2\xc5\x5f\xbd\x7c\xb9\xd5\x5c\xa3\xdb\x57\xf6\xe5\xbb\xd6\xfb\x63\x5a\xdc\x0e\x77\x93\xa8\xc0\x29\x9c\xd5\x88\x00\x46\x6e\x4e\xd8\x12\x67\xc3\xc1\xf7\xe9\xab\xfd\xf9\x81\x8f\xe6\x90\x0c\x71\xc8\xe7\x7d\xbe\x7f\x12\xf0\xe3\xdc\x68\xb2\xd7\x40\x5a\xc8\x6a\x8b\x01\x3c\x36\x0a\x06\x81\x7b\xb8\xec\x7c\x5c\xa6\xf5\xf7\x6e\x79\xc5\xd9\xbd\x70\x0a\x67\xde\x6e\x93\x5a\xc7\x9d\xb0\x43\x3b\x45\xcc\xcc\x88\x04\x0a\x80\x85\xe0\x1d\xbf\x72\x7e\xab\xd8\xf5\x0d\xbb\x71\xc8\xee\x5f\xff\x0f\x6d\x3c\x0f\xac\x43\xa5\xf3\x1d\xfb\xd3\xcb\xeb\x86\xc9\xa6\xb4\xb5\xe9\x7c\x7c\xd9\x3a\xdf\xe5\x9e\x4e\xc4\xf9\x78\xab\x72\x64\x37\x0e\x7d\x4d\x82\xd2\x10\x67\x59\xe4\x4c\xd4\x3d\x5d\xa7\x9f\x56\xbd\xd7\x2b\xf6\x65\xcd\xd9\x2b\xd0\x8d\x0a\x7d\x7d\x42\xad\x1d\xf7\x74\xc7\x2d\xaf\xb4\x6a\xab\x4e\xa9\xe6\x94\x6a\x5e\xa9\x7a\x93\x52\x5f\x26\x2d\x3e\x9d\x81\xfe\xa6\x4c\xa3\x30\x6d\x38\x95\x52\x91\x06\xfb\x95\x52\x87\x12\xd6\x34\x28\x75\x0d\xde\x04\x5b\x35\xaf\x4e\x5d\xab\x49\xb7\x36\xbd\xb5\x42\xab\xf9\xf6\x8e\x12\xfa\xd6\x4f\xb2\xa9\x14\xd4\x3b\x25\xf1\x63\x3f\xbe\x74\xad\x26\x0b\x70\x2f\x4f\xdf\x95\xdd\xc6\xcf\xf4\xf4\xdf\x6e\x79\xc5\xbe\x2a\xb8\x57\x67\xce\x7e\xc5\xd9\xbd\x60\x7b\xaa\xb6\x4a\xd7\xac\x9b\x8c\xed\xe5\x9d\xd2\x2f\x1d\x5a\x20\x2e\x12\x69\xa1\xad\xcd\x62\x3a\x2f\x3a\xfb\x15\xee\x29\x37\xce\x8d\x06\xe2\xd4\x5c\xa5\x5b\x1f\x7a\xc4\x7d\x5b\x19\x19\xe1\xbd\x1e\xe6\x20\xfb\x56\x1c\xf1\x83\xb3\xeb\x97\x74\xe3\x24\xb8\xdc\xbd\x6d\x69\xd7\x0b\xdd\xdc\x1e\xcd\xe9\x2c\xdb\x09\xec\x03\x65\xac\x57\x95\x6e\x6d\xb2\x6f\x10\x73\x39\xc8\x03\x6b\xa5\x52\xcd\xdb\x6d\xde\x4a\x9c\xa4\x62\x69\x51\x00\x83\xa0\xf5\xba\xec\xbc\x3c\x60\xa5\xfc\x89\x7d\xae\x04\x39\xa2\x3f\x99\xc1\xc3\xb1\xeb\xa7\xa1\x7d\xa9\xea\x98\xeb\x34\x5f\xf0\xf2\x07\xce\xc7\x65\x2f\xbf\x7f\xdd\x30\x83\xc6\x70\x3f\x9c\xd3\xe2\x39\x6d\xe4\xe9\xd6\xb9\x01\xf5\x1c\xd4\x63\x6c\x35\x7f\x8b\x65\x18\x23\x7a\xd6\x20\xd3\xfe\x47\xe0\x63\x94\x86\xde\x6a\x81\x6e\xbd\x08\x1a\x70\xa0\x33\x3a\x04\xc0\xb3\x87\xe1\x6b\x5f\x37\x4c\xde\xae\x5b\x76\xbd\x40\x37\x0e\xbd\xfc\x41\xc0\x22\xa2\x4e\x04\x10\x89\xdc\x8a\xb7\x54\x65\x9d\xf8\xf1\x7f\xee\xbf\xfe\xcf\x62\x39\x6c\xd0\x46\x31\xce\x71\x63\x51\x8e\x8f\x72\x71\xc0\x27\x04\x6e\x54\xe0\x12\xec\x52\x75\xcb\xfd\xc5\xaf\xf4\xe5\x26\xbd\xfa\x99\xae\x17\xd8\x7d\x4b\x55\x16\xf3\xfb\x97\xbe\x8b\x34\xd2\x66\xa1\x26\xdf\xb4\x60\xd8\x4b\x61\x59\x7f\x5f\x73\x36\x8e\x58\x26\xf6\xf2\xf4\xa2\xe8\xee\x1d\xd0\xb3\xb7\x3d\xa5\x4b\xb3\xda\x05\x41\xd3\xad\x4d\xc7\x5c\xef\x89\x34\xd0\x08\x8c\x68\x71\xdb\xcb\x2f\xb3\x91\xd3\xfc\xe0\x94\x6a\xee\xce\x81\xb3\xfb\x62\xe0\x8f\x01\x00\x90\xc4\x81\x10\xb4\x0c\x00\x00")
 
 func testClientConfigsDefaultYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -134,7 +135,27 @@ func testClientConfigsDefaultYaml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "test/client/configs/default.yaml", size: 1673, mode: os.FileMode(420), modTime: time.Unix(1688644570, 0)}
+	info := bindataFileInfo{name: "test/client/configs/default.yaml", size: 3252, mode: os.FileMode(420), modTime: time.Unix(1792222624, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _testClientConfigsIdentitiesCsv = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x52\x56\x78\xb6\x62\xe1\xb3\xee\xf9\xcf\x77\x77\x3c\x5f\xbd\xfe\xf9\xac\x96\xa7\xb3\x77\x3d\x5d\x37\xeb\xc5\xae\xd5\x4f\x76\xef\x7d\xbf\xa7\xe7\xd9\xfa\xfe\x27\x3b\x56\xbd\xd8\x3f\xef\x59\xdf\xd2\x67\x3d\x9d\x2f\x17\xee\x7a\xba\xab\xff\xc9\xde\xfd\xcf\xa7\xac\x78\xb2\xa3\xe1\xc5\xc2\x1e\xae\x82\x8c\xfc\xbc\x54\x9d\xcc\x14\x9d\x82\x9c\xc4\x92\x54\x08\xe9\x9c\x9f\x93\x5f\xa4\x93\x99\x9b\x9a\xa9\x53\x96\x5a\x54\x9c\x99\x9f\xa7\x93\x58\x5a\x92\xe1\x9c\x9f\x92\xca\x65\x68\x6c\x60\x68\x64\x6c\x62\x6a\x66\x6e\xa1\x03\x65\xe8\x3c\xd9\xb5\xc6\x11\xcc\xd6\x31\xd4\xb1\x30\x33\x40\x02\x86\x3a\x46\x06\x86\x96\x3a\x48\xba\x2c\xa1\xba\x2c\xe0\xba\xcc\x74\x8c\xd0\x74\x19\x81\x74\x19\x23\xe9\xb2\x30\x80\xea\xb2\xd4\x79\xb6\x69\x95\x93\xa9\x89\xb1\x91\x21\x86\x5d\xc6\x3a\x3a\x5c\x80\x01\x00\xeb\x7c\xfe\x07\x10\x01\x00\x00")
+
+func testClientConfigsIdentitiesCsvBytes() ([]byte, error) {
+	return bindataRead(
+		_testClientConfigsIdentitiesCsv,
+		"test/client/configs/identities.csv",
+	)
+}
+
+func testClientConfigsIdentitiesCsv() (*asset, error) {
+	bytes, err := testClientConfigsIdentitiesCsvBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "test/client/configs/identities.csv", size: 272, mode: os.FileMode(420), modTime: time.Unix(1792222624, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -191,9 +212,10 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"configs/banner.txt":               configsBannerTxt,
-	"configs/default.yaml":             configsDefaultYaml,
-	"test/client/configs/default.yaml": testClientConfigsDefaultYaml,
+	"configs/banner.txt":                 configsBannerTxt,
+	"configs/default.yaml":               configsDefaultYaml,
+	"test/client/configs/default.yaml":   testClientConfigsDefaultYaml,
+	"test/client/configs/identities.csv": testClientConfigsIdentitiesCsv,
 }
 
 // AssetDir returns the file names below a certain
@@ -244,7 +266,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"test": &bintree{nil, map[string]*bintree{
 		"client": &bintree{nil, map[string]*bintree{
 			"configs": &bintree{nil, map[string]*bintree{
-				"default.yaml": &bintree{testClientConfigsDefaultYaml, map[string]*bintree{}},
+				"default.yaml":   &bintree{testClientConfigsDefaultYaml, map[string]*bintree{}},
+				"identities.csv": &bintree{testClientConfigsIdentitiesCsv, map[string]*bintree{}},
 			}},
 		}},
 	}},
@@ -3,7 +3,9 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/mix-go/xfmt"
 	"github.com/pkg/errors"
@@ -11,6 +13,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/fakeyanss/jt808-server-go/pkg/logger"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
 )
 
 type LogLevelType string
@@ -40,12 +43,84 @@ type logConf struct {
 	MaxSizeOfRolling    int          `yaml:"maxSizeOfRolling"`
 	MaxBackupsOfRolling int          `yaml:"maxBackupsOfRolling"`
 	MaxAgeOfRolling     int          `yaml:"maxAgeOfRolling"`
+
+	Modules  map[string]LogLevelType `yaml:"modules"`  // 按模块覆盖日志级别，模块为framing、codec、processor、api、storage
+	Sampling *logSamplingConf        `yaml:"sampling"` // 重复debug日志的采样
+}
+
+type logSamplingConf struct {
+	Burst  int `yaml:"burst"`  // 每个周期每个模块最多输出的debug日志条数，0表示不采样
+	Period int `yaml:"period"` // 采样周期，单位s
 }
 
 type serverConf struct {
-	Name   string      `yaml:"name"`
-	Port   *servPort   `yaml:"port"`
-	Banner *servBanner `yaml:"banner"`
+	Name        string           `yaml:"name"`
+	Port        *servPort        `yaml:"port"`
+	Banner      *servBanner      `yaml:"banner"`
+	WAL         *walConf         `yaml:"wal"`
+	Replication *ReplicationConf `yaml:"replication"`
+	Admin       *adminConf       `yaml:"admin"`
+	Authorizer  *AuthorizerConf  `yaml:"authorizer"`
+	Place       *PlaceConf       `yaml:"place"`
+	Charset     *CharsetConf     `yaml:"charset"`
+	Security    *SecurityConf    `yaml:"security"`
+	Ban         *BanConf         `yaml:"ban"`
+	Quality     *QualityConf     `yaml:"quality"`
+	Video       *VideoConf       `yaml:"video"`
+	Export      *ExportConf      `yaml:"export"`
+	Grafana     *GrafanaConf     `yaml:"grafana"`
+	Outage      *OutageConf      `yaml:"outage"`
+	OsmAnd      *OsmAndConf      `yaml:"osmand"`
+	Blob        *BlobConf        `yaml:"blob"`
+	ICAuth      *ICAuthConf      `yaml:"icauth"`
+	TestMode    *TestModeConf    `yaml:"testMode"`
+}
+
+// OsmAnd协议的手机定位终端接入，终端需先通过管理接口登记
+type OsmAndConf struct {
+	Enable    bool   `yaml:"enable"`
+	Listen    string `yaml:"listen"`    // HTTP上报的监听地址
+	Keepalive int    `yaml:"keepalive"` // 超过该时长没有上报视为离线，单位s
+}
+
+// 对象存储，保存多媒体文件和归档的历史数据
+type BlobConf struct {
+	Enable        bool           `yaml:"enable"`
+	Backend       string         `yaml:"backend"` // local或s3
+	Local         *LocalBlobConf `yaml:"local"`
+	S3            *S3BlobConf    `yaml:"s3"`
+	PresignTTL    int            `yaml:"presignTTL"`    // 下载地址的有效期，单位s
+	Lifecycle     map[string]int `yaml:"lifecycle"`     // <key前缀, 保留天数>，超过后删除
+	CleanInterval int            `yaml:"cleanInterval"` // 生命周期清理的间隔，单位s
+}
+
+type LocalBlobConf struct {
+	Directory string `yaml:"directory"`
+	PublicURL string `yaml:"publicURL"` // 下载地址使用的HTTP接口地址，如 http://127.0.0.1:8008
+	Secret    string `yaml:"secret"`    // 下载地址的签名密钥，为空时每次启动随机生成
+}
+
+type S3BlobConf struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+	PathStyle bool   `yaml:"pathStyle"` // 使用 endpoint/bucket/key 形式的地址，MinIO需要开启
+	PartSize  int    `yaml:"partSize"`  // 分片上传的分片大小，单位MB，不小于5
+}
+
+// 道路运输证IC卡认证中转，终端通过0x0900透传的认证请求转发给认证中心
+type ICAuthConf struct {
+	Enable  bool   `yaml:"enable"`
+	Addr    string `yaml:"addr"`    // 认证中心的TCP地址，host:port
+	Backup  string `yaml:"backup"`  // 备份认证中心的TCP地址，主认证中心不可用时使用，可为空
+	Timeout int    `yaml:"timeout"` // 等待认证中心应答的超时时间，单位s
+}
+
+// 测试模式，配合模拟终端的虚拟时钟加速测试依赖时间的逻辑，不要在生产环境开启
+type TestModeConf struct {
+	TrustDeviceTime bool `yaml:"trustDeviceTime"` // 时间相关的逻辑使用终端上报的定位时间，而不是服务器时间
 }
 
 type servPort struct {
@@ -59,6 +134,152 @@ type servBanner struct {
 	BannerPath string `yaml:"bannerPath"`
 }
 
+type walConf struct {
+	Enable       bool   `yaml:"enable"`
+	Directory    string `yaml:"directory"`
+	SyncInterval int    `yaml:"syncInterval"` // 组提交等待时间，单位ms
+	MaxBatch     int    `yaml:"maxBatch"`     // 单次组提交最大消息数
+	SegmentSize  int    `yaml:"segmentSize"`  // 单个文件大小，单位MB
+	MaxSegments  int    `yaml:"maxSegments"`  // 保留文件个数，0表示不清理
+}
+
+// 主备复制，备节点持续复制主节点的WAL，提升后接管终端
+type ReplicationConf struct {
+	Enable        bool   `yaml:"enable"`
+	Role          string `yaml:"role"`          // primary或standby
+	Listen        string `yaml:"listen"`        // 复制服务的监听地址，主节点和提升后的备节点使用
+	Primary       string `yaml:"primary"`       // 备节点连接的主节点地址
+	Token         string `yaml:"token"`         // 复制连接的共享密钥，为空时不校验
+	Heartbeat     int    `yaml:"heartbeat"`     // 心跳间隔，单位s，超过3个间隔没有收到数据视为断线
+	RetryInterval int    `yaml:"retryInterval"` // 备节点断线重连的间隔，单位s
+}
+
+type adminConf struct {
+	Token         string `yaml:"token"`         // 管理接口的访问令牌，为空时禁用管理接口
+	RawMsgTimeout int    `yaml:"rawMsgTimeout"` // 透传消息等待终端应答的默认超时时间，单位s
+}
+
+type AuthorizerConf struct {
+	Enable   bool   `yaml:"enable"`
+	URL      string `yaml:"url"`      // 外部判定服务地址，注册和鉴权都以POST请求该地址
+	Timeout  int    `yaml:"timeout"`  // 请求超时时间，单位ms
+	FailOpen bool   `yaml:"failOpen"` // 外部服务不可用时，true放行，false拒绝
+	CacheTTL int    `yaml:"cacheTTL"` // 判定结果缓存时长，单位s，0表示不缓存
+}
+
+// 字符串字段的字符集，可选auto、GBK、GB18030、UTF-8。
+// 优先级：按手机号 > 按制造商 > 注册时识别的结果 > 默认
+type CharsetConf struct {
+	Default        string            `yaml:"default"`
+	ByManufacturer map[string]string `yaml:"byManufacturer"` // <制造商ID, 字符集>，不区分大小写
+	ByPhone        map[string]string `yaml:"byPhone"`        // <手机号, 字符集>
+}
+
+// 终端仿冒和克隆的异常检测
+type SecurityConf struct {
+	Enable          bool    `yaml:"enable"`
+	Quarantine      bool    `yaml:"quarantine"`      // 发现异常时隔离终端，拒绝注册和鉴权直到人工解除
+	MaxSpeed        float64 `yaml:"maxSpeed"`        // 跨连接位置跳变推算的最大合理速度，单位km/h
+	MinJumpDistance float64 `yaml:"minJumpDistance"` // 跨连接位置跳变的最小距离，单位m，用于忽略定位漂移
+	MaxEvents       int     `yaml:"maxEvents"`       // 保留的最近安全事件数
+}
+
+// 按远端IP统计失败次数并封禁
+type BanConf struct {
+	Enable             bool     `yaml:"enable"`
+	Window             int      `yaml:"window"`             // 失败计数的时间窗口，单位s
+	MaxAuthFailures    int      `yaml:"maxAuthFailures"`    // 窗口内鉴权失败次数上限，0表示不统计
+	MaxChecksumErrors  int      `yaml:"maxChecksumErrors"`  // 窗口内校验码错误次数上限，0表示不统计
+	MaxUndecodable     int      `yaml:"maxUndecodable"`     // 窗口内无法解析的帧次数上限，0表示不统计
+	MaxUnsupportedMsgs int      `yaml:"maxUnsupportedMsgs"` // 窗口内平台未处理的消息次数上限，0表示不统计
+	BanTime            int      `yaml:"banTime"`            // 首次封禁时长，单位s，再次封禁时翻倍
+	MaxBanTime         int      `yaml:"maxBanTime"`         // 最长封禁时长，单位s
+	Allowlist          []string `yaml:"allowlist"`          // 不封禁的IP或CIDR
+}
+
+// 停留点聚类发现常去地点
+type PlaceConf struct {
+	Enable     bool    `yaml:"enable"`
+	Interval   int     `yaml:"interval"`   // 聚类间隔，单位s
+	Window     int     `yaml:"window"`     // 参与聚类的停留点时间窗口，单位h
+	Eps        float64 `yaml:"eps"`        // 邻域半径，单位m
+	MinPts     int     `yaml:"minPts"`     // 成簇的最少停留次数
+	StopSpeed  float64 `yaml:"stopSpeed"`  // 不超过该速度视为停车，单位km/h
+	StopRadius float64 `yaml:"stopRadius"` // 停留期间偏离起点的最大距离，单位m
+	MinDwell   int     `yaml:"minDwell"`   // 最短停留时长，单位s
+
+	GeofenceFile string `yaml:"geofenceFile"` // 电子围栏的持久化文件，为空时重启后围栏丢失
+}
+
+// 终端数据质量统计
+type QualityConf struct {
+	Enable          bool    `yaml:"enable"`
+	MaxSpeed        float64 `yaml:"maxSpeed"`        // 相邻定位点推算速度超过该值视为漂移，单位km/h
+	MinJumpDistance float64 `yaml:"minJumpDistance"` // 漂移的最小距离，单位m
+	FutureTolerance int     `yaml:"futureTolerance"` // 定位时间超前服务器时间的容忍度，单位s
+	MinPoints       int     `yaml:"minPoints"`       // 参与排名的最少位置点数
+}
+
+// 向监管平台转发JT1078实时和回放视频
+type VideoConf struct {
+	Enable       bool       `yaml:"enable"`
+	ServerIP     string     `yaml:"serverIp"`     // 终端推流的视频服务器地址，随0x9101/0x9201下发
+	TCPPort      uint16     `yaml:"tcpPort"`      // 视频服务器TCP端口，0表示不使用TCP
+	UDPPort      uint16     `yaml:"udpPort"`      // 视频服务器UDP端口，0表示不使用UDP
+	PullIP       string     `yaml:"pullIp"`       // 监管平台拉流的地址，为空时与serverIp相同
+	PullPort     uint16     `yaml:"pullPort"`     // 监管平台拉流的端口，0时与tcpPort相同
+	ReplyTimeout int        `yaml:"replyTimeout"` // 等待终端应答的超时时间，单位s
+	SessionTTL   int        `yaml:"sessionTTL"`   // 会话最长保持时间，单位s，超时后通知终端停止推流
+	JT809        *JT809Conf `yaml:"jt809"`        // 通过JT/T 809链路接收监管平台的视频请求
+}
+
+// 以下级平台身份接入监管平台的JT/T 809链路，处理JT/T 1078扩展的实时视频和录像回放请求
+type JT809Conf struct {
+	Enable        bool   `yaml:"enable"`
+	Platform      string `yaml:"platform"`      // 监管平台名称，记录在视频会话中
+	Addr          string `yaml:"addr"`          // 监管平台主链路的TCP地址，host:port
+	UserID        uint32 `yaml:"userId"`        // 主链路登录的用户名
+	Password      string `yaml:"password"`      // 主链路登录的密码，最长8字节
+	GNSSCenterID  uint32 `yaml:"gnssCenterId"`  // 下级平台接入码
+	DownLinkIP    string `yaml:"downLinkIp"`    // 从链路的地址，登录时告知监管平台
+	DownLinkPort  uint16 `yaml:"downLinkPort"`  // 从链路的监听端口，0表示不监听，下行消息都走主链路
+	Heartbeat     int    `yaml:"heartbeat"`     // 链路保持的间隔，单位s，超过3个间隔没有收到数据视为断线
+	RetryInterval int    `yaml:"retryInterval"` // 主链路断线重连的间隔，单位s
+	M1            uint32 `yaml:"m1"`            // 数据加密参数，为0时不加密
+	IA1           uint32 `yaml:"ia1"`
+	IC1           uint32 `yaml:"ic1"`
+}
+
+// 按天导出Parquet文件供数据湖使用
+type ExportConf struct {
+	Enable        bool              `yaml:"enable"`
+	Directory     string            `yaml:"directory"`     // 暂存和导出文件的目录
+	RunAt         string            `yaml:"runAt"`         // 每天转换前一天数据的时间，如01:00
+	DefaultTenant string            `yaml:"defaultTenant"` // 未匹配到租户时使用
+	Tenants       map[string]string `yaml:"tenants"`       // <手机号前缀, 租户>，按最长前缀匹配
+	Archive       bool              `yaml:"archive"`       // 导出后上传到对象存储，成功后删除本地文件，需要开启blob
+}
+
+// Grafana数据源，在内存中保留车队在线数、报警和状态变化
+type GrafanaConf struct {
+	Enable         bool `yaml:"enable"`
+	SampleInterval int  `yaml:"sampleInterval"` // 车队在线数的采样间隔，单位s
+	Retention      int  `yaml:"retention"`      // 采样和事件的保留时长，单位h
+	MaxEvents      int  `yaml:"maxEvents"`      // 保留的报警和状态变化事件数上限
+}
+
+// 大面积掉线检测，按分组发现集中掉线并合并为一个平台级故障
+type OutageConf struct {
+	Enable       bool                `yaml:"enable"`
+	GroupBy      []string            `yaml:"groupBy"`      // 分组维度，可选region、carrier、listener
+	Window       int                 `yaml:"window"`       // 统计掉线的时间窗口，单位s
+	MinDevices   int                 `yaml:"minDevices"`   // 窗口内分组掉线终端数的下限
+	Ratio        float64             `yaml:"ratio"`        // 窗口内掉线终端占分组终端数的比例下限
+	RecoverRatio float64             `yaml:"recoverRatio"` // 掉线终端重新上线的比例达到该值时视为恢复
+	MaxIncidents int                 `yaml:"maxIncidents"` // 保留的故障数上限
+	Carriers     map[string][]string `yaml:"carriers"`     // <运营商, SIM卡号前缀>，按最长前缀匹配
+}
+
 type clientConf struct {
 	Name         string            `yaml:"name"`
 	Conn         *connection       `yaml:"conn"`
@@ -66,6 +287,16 @@ type clientConf struct {
 	Device       *DeviceConf       `yaml:"device"`
 	DeviceGeo    *DeviceGeoConf    `yaml:"deviceGeo"`
 	DeviceParams *DeviceParamsConf `yaml:"deviceParams"`
+	ActiveSafety *ActiveSafetyConf `yaml:"activeSafety"`
+	Offline      *OfflineConf      `yaml:"offline"`
+	Clock        *VirtualClockConf `yaml:"clock"`
+}
+
+// 模拟终端的虚拟时钟，消息中的时间按倍速流逝
+type VirtualClockConf struct {
+	Speed           float64 `yaml:"speed"`           // 虚拟时间相对真实时间的倍速，1为不加速
+	Start           string  `yaml:"start"`           // 虚拟时间的起点，格式2006-01-02 15:04:05，为空时从当前时间开始
+	MinSendInterval int     `yaml:"minSendInterval"` // 加速后位置汇报的最小真实间隔，单位ms
 }
 
 type connection struct {
@@ -83,6 +314,7 @@ type DeviceConf struct {
 	ProvinceIDReg   string `yaml:"provinceIdReg"`
 	CityIDReg       string `yaml:"cityIdReg"`
 	PlateColorReg   string `yaml:"plateColorReg"`
+	IdentityFile    string `yaml:"identityFile"` // 固定身份的CSV文件，每个连接使用一行，为空时按正则生成身份
 }
 
 type DeviceGeoConf struct {
@@ -129,6 +361,29 @@ type driveConf struct {
 type DeviceParamsConf struct {
 }
 
+// 主动安全报警和附件上传的模拟配置
+type ActiveSafetyConf struct {
+	Enable        bool    `yaml:"enable"`
+	AlarmInterval int     `yaml:"alarmInterval"` // 定时产生报警的间隔，单位s，0表示只通过命令触发
+	ImageCount    int     `yaml:"imageCount"`    // 每个报警的图片附件个数
+	ImageSize     int     `yaml:"imageSize"`     // 图片大小，单位KB
+	VideoCount    int     `yaml:"videoCount"`    // 每个报警的视频附件个数
+	VideoSize     int     `yaml:"videoSize"`     // 视频大小，单位KB
+	ChunkSize     int     `yaml:"chunkSize"`     // 码流帧的数据长度，单位KB
+	GapRate       float64 `yaml:"gapRate"`       // 首次上传时丢弃数据帧的比例，用于测试补传，0表示不丢弃
+	Timeout       int     `yaml:"timeout"`       // 等待附件服务器应答的超时时间，单位s
+	MaxRetransmit int     `yaml:"maxRetransmit"` // 单个文件最多补传次数
+}
+
+type OfflineConf struct {
+	Enable             bool `yaml:"enable"`
+	ReconnectInterval  int  `yaml:"reconnectInterval"`  // 断线后重连的间隔，单位s
+	BufferSize         int  `yaml:"bufferSize"`         // 离线时最多缓存的位置条数，超出时丢弃最早的位置
+	BatchSize          int  `yaml:"batchSize"`          // 每条0x0704最多包含的位置条数
+	DisconnectEvery    int  `yaml:"disconnectEvery"`    // 主动断线的间隔，单位s，0表示不主动断线
+	DisconnectDuration int  `yaml:"disconnectDuration"` // 主动断线后保持离线的时长，单位s
+}
+
 type Config struct {
 	Log    *logConf    `yaml:"log"`
 	Server *serverConf `yaml:"server"`
@@ -177,10 +432,9 @@ func Load(confFilePath string) *Config {
 	return config
 }
 
-func (c *Config) ParseLogConf() *logger.Config {
-	logCfg := c.Log
+func parseLogLevel(level LogLevelType) int8 {
 	var logLevel int8
-	switch logCfg.LogLevel {
+	switch strings.ToUpper(string(level)) {
 	case "DEBUG":
 		logLevel = int8(zerolog.DebugLevel)
 	case "INFO":
@@ -189,18 +443,46 @@ func (c *Config) ParseLogConf() *logger.Config {
 		logLevel = int8(zerolog.WarnLevel)
 	case "ERROR":
 		logLevel = int8(zerolog.ErrorLevel)
-	case "FATAl":
+	case "FATAL":
 		logLevel = int8(zerolog.FatalLevel)
 	}
+	return logLevel
+}
+
+func (c *Config) ParseLogConf() *logger.Config {
+	logCfg := c.Log
+	moduleLevels := make(map[string]int8, len(logCfg.Modules))
+	for module, level := range logCfg.Modules {
+		moduleLevels[strings.ToLower(module)] = parseLogLevel(level)
+	}
+	var sampleBurst uint32
+	var samplePeriod time.Duration
+	if logCfg.Sampling != nil && logCfg.Sampling.Burst > 0 {
+		sampleBurst = uint32(logCfg.Sampling.Burst)
+		samplePeriod = time.Duration(logCfg.Sampling.Period) * time.Second
+	}
 	return &logger.Config{
 		ConsoleLoggingEnabled: logCfg.ConsoleEnable,
 		EncodeLogsAsJSON:      logCfg.PrintAsJSON,
 		FileLoggingEnabled:    logCfg.FileEnable,
-		LogLevel:              logLevel,
+		LogLevel:              parseLogLevel(logCfg.LogLevel),
 		Directory:             logCfg.LogDirectory,
 		Filename:              logCfg.LogFile,
 		MaxSize:               logCfg.MaxSizeOfRolling,
 		MaxBackups:            logCfg.MaxBackupsOfRolling,
 		MaxAge:                logCfg.MaxAgeOfRolling,
+		ModuleLevels:          moduleLevels,
+		SampleBurst:           sampleBurst,
+		SamplePeriod:          samplePeriod,
+	}
+}
+
+func (c *Config) ParseWALConf() *wal.Options {
+	walCfg := c.Server.WAL
+	return &wal.Options{
+		SyncInterval: time.Duration(walCfg.SyncInterval) * time.Millisecond,
+		MaxBatch:     walCfg.MaxBatch,
+		SegmentSize:  int64(walCfg.SegmentSize) * 1024 * 1024,
+		MaxSegments:  walCfg.MaxSegments,
 	}
 }
@@ -43,6 +43,14 @@ func TestLoad(t *testing.T) {
 						Enable:     true,
 						BannerPath: "./configs/banner.txt",
 					},
+					WAL: &walConf{
+						Enable:       true,
+						Directory:    "./data/wal/",
+						SyncInterval: 2,
+						MaxBatch:     256,
+						SegmentSize:  64,
+						MaxSegments:  16,
+					},
 				},
 			},
 		},
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
+)
+
+const archivePrefix = "archive/"
+
+// 将导出目录中的Parquet文件上传到对象存储的archive/<相对路径>，成功后删除本地文件，失败的文件下次继续上传。
+// 本地文件删除后，同一分区再次导出时part序号会重新开始，上传时跳过对象存储中已存在的序号
+func (e *Exporter) Archive(ctx context.Context, store blob.Store) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(e.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p == filepath.Join(e.dir, stagingDir) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".parquet") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to list export files")
+	}
+	sort.Strings(files)
+
+	var keys []string
+	for _, file := range files {
+		key, err := e.archiveFile(ctx, store, file)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (e *Exporter) archiveFile(ctx context.Context, store blob.Store, file string) (string, error) {
+	rel, err := filepath.Rel(e.dir, file)
+	if err != nil {
+		return "", errors.Wrapf(err, "Fail to resolve export file, path=%s", file)
+	}
+	key, err := nextArchiveKey(ctx, store, archivePrefix+filepath.ToSlash(rel))
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", errors.Wrapf(err, "Fail to open export file, path=%s", file)
+	}
+	err = store.Put(ctx, key, f)
+	f.Close()
+	if err != nil {
+		return "", errors.Wrapf(err, "Fail to archive export file, path=%s", file)
+	}
+	if err = os.Remove(file); err != nil {
+		return key, errors.Wrapf(err, "Fail to remove archived export file, path=%s", file)
+	}
+	_ = os.Remove(filepath.Dir(file)) // 目录非空时失败，忽略
+	return key, nil
+}
+
+// 从key的part序号开始，返回对象存储中第一个不存在的part
+func nextArchiveKey(ctx context.Context, store blob.Store, key string) (string, error) {
+	dir := path.Dir(key)
+	n := 0
+	if _, err := fmt.Sscanf(path.Base(key), "part-%05d.parquet", &n); err != nil {
+		return key, nil // 不是导出生成的文件名，直接覆盖
+	}
+	for {
+		key = fmt.Sprintf("%s/part-%05d.parquet", dir, n)
+		_, err := store.Stat(ctx, key)
+		if errors.Is(err, blob.ErrNotFound) {
+			return key, nil
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "Fail to stat archive key, key=%s", key)
+		}
+		n++
+	}
+}
@@ -0,0 +1,97 @@
+package export
+
+import (
+	"context"
+	"time"
+
+	"github.com/fakeyanss/gron"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+)
+
+const (
+	convertJobID = "export-convert"
+	defaultRunAt = "01:00"
+)
+
+var exporterInstance *Exporter
+
+// 启动导出，每天runAt转换之前日期的暂存数据，启动时先转换一次上次退出前遗留的数据。
+// archive非nil时，转换后将导出的文件上传到对象存储
+func Start(conf *config.ExportConf, archive blob.Store) *Exporter {
+	exporterInstance = NewExporter(conf)
+	exporterInstance.archive = archive
+	runAt := conf.RunAt
+	if runAt == "" {
+		runAt = defaultRunAt
+	}
+	cron := gron.New()
+	if clock.DeviceTimeTrusted() {
+		// 测试模式下终端的虚拟时间可能很快跨天，每分钟检查一次
+		cron.Add(gron.Every(time.Minute), exporterInstance)
+	} else {
+		cron.Add(gron.Every(24*time.Hour).At(runAt), exporterInstance)
+	}
+	cron.Start()
+	routines.GoSafe(exporterInstance.Run)
+	return exporterInstance
+}
+
+// 返回启用的导出，未启用时为nil
+func Default() *Exporter {
+	return exporterInstance
+}
+
+func (e *Exporter) JobID() string {
+	return convertJobID
+}
+
+func (e *Exporter) Run() {
+	files, err := e.Convert(clock.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Fail to convert export staging files")
+	}
+	if len(files) > 0 {
+		log.Info().Int("files", len(files)).Msg("Export parquet files")
+	}
+	if e.archive == nil {
+		return
+	}
+	keys, err := e.Archive(context.Background(), e.archive)
+	if err != nil {
+		log.Error().Err(err).Msg("Fail to archive export files")
+	}
+	if len(keys) > 0 {
+		log.Info().Int("files", len(keys)).Msg("Archive parquet files to blob store")
+	}
+}
+
+// 记录终端上报的位置，未启用时忽略
+func ObservePosition(dg *model.DeviceGeo, msg *model.Msg0200, source string) {
+	if exporterInstance == nil {
+		return
+	}
+	exporterInstance.ObservePosition(dg, msg, plateOf(dg.Phone), source, clock.Now())
+}
+
+// 记录终端状态变化，未启用时忽略
+func ObserveStatus(phone string, from, to model.DeviceStatus, reason string) {
+	if exporterInstance == nil {
+		return
+	}
+	exporterInstance.ObserveStatus(phone, plateOf(phone), from, to, reason, clock.Now())
+}
+
+func plateOf(phone string) string {
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	if err != nil {
+		return ""
+	}
+	return device.Plate
+}
@@ -0,0 +1,465 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
+)
+
+const (
+	defaultTenant = "default"
+	dateLayout    = "2006-01-02"
+	stagingDir    = "staging"
+	stagingExt    = ".jsonl"
+	convertingExt = ".converting" // 转换中的暂存文件，转换失败或进程退出后下次继续
+	parallelism   = 1
+)
+
+// 单个终端进行中的行程
+type tripState struct {
+	start    *model.DeviceGeo
+	last     *model.DeviceGeo
+	distance float64
+	maxSpeed float64
+	points   int32
+}
+
+// 当天的数据先按行追加到暂存文件，定时将之前日期的暂存文件转换为Parquet。
+// 暂存路径为<dir>/staging/<table>/<date>/<tenant>.jsonl，
+// 导出路径为<dir>/v<version>/<table>/date=<date>/tenant=<tenant>/part-<n>.parquet
+type Exporter struct {
+	dir           string
+	tenants       map[string]string // <手机号前缀, 租户>
+	defaultTenant string
+	archive       blob.Store // 归档导出文件的对象存储，为nil时保留在本地
+
+	mutex      *sync.Mutex
+	files      map[string]*os.File   // <暂存文件路径, 打开的文件>
+	trips      map[string]*tripState // <手机号, 进行中的行程>
+	alarmSigns map[string]uint32     // <手机号, 上一个实时位置的报警标志位>
+}
+
+func NewExporter(conf *config.ExportConf) *Exporter {
+	e := &Exporter{
+		dir:           conf.Directory,
+		tenants:       conf.Tenants,
+		defaultTenant: conf.DefaultTenant,
+		mutex:         &sync.Mutex{},
+		files:         make(map[string]*os.File),
+		trips:         make(map[string]*tripState),
+		alarmSigns:    make(map[string]uint32),
+	}
+	if e.defaultTenant == "" {
+		e.defaultTenant = defaultTenant
+	}
+	return e
+}
+
+// 按最长的手机号前缀匹配租户，未匹配时为默认租户
+func (e *Exporter) Tenant(phone string) string {
+	tenant, matched := e.defaultTenant, 0
+	for prefix, t := range e.tenants {
+		if len(prefix) > matched && strings.HasPrefix(phone, prefix) {
+			tenant, matched = t, len(prefix)
+		}
+	}
+	return tenant
+}
+
+// 记录一个位置，同时从中提取报警和行程。
+// 报警标志位和行程只按实时位置计算，盲区补报的位置只记录位置和主动安全报警
+func (e *Exporter) ObservePosition(dg *model.DeviceGeo, msg *model.Msg0200, plate, source string, now time.Time) {
+	tenant := e.Tenant(dg.Phone)
+	deviceTime := hex.InDeviceZone(dg.Time)
+	date := deviceTime.Format(dateLayout)
+
+	row := &PositionRow{
+		Phone:        dg.Phone,
+		Plate:        plate,
+		Tenant:       tenant,
+		Source:       source,
+		DeviceTime:   deviceTime.UnixMilli(),
+		ReceivedTime: now.UnixMilli(),
+		Latitude:     dg.Location.Latitude,
+		Longitude:    dg.Location.Longitude,
+		Altitude:     int32(dg.Location.Altitude),
+		Speed:        dg.Drive.Speed,
+		Direction:    int32(dg.Drive.Direction),
+		Located:      dg.Geo.LocationStatus == 1,
+		ACC:          dg.Geo.ACCStatus == 1,
+		AlarmSign:    int64(msg.AlarmSign),
+		StatusSign:   int64(msg.StatusSign),
+	}
+	decodeExtras(row, msg.Extras)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.append(TablePositions, date, tenant, row)
+
+	base := AlarmRow{
+		Phone:        dg.Phone,
+		Plate:        plate,
+		Tenant:       tenant,
+		DeviceTime:   row.DeviceTime,
+		ReceivedTime: row.ReceivedTime,
+		Latitude:     row.Latitude,
+		Longitude:    row.Longitude,
+		Speed:        row.Speed,
+	}
+	for _, alarm := range activeSafetyAlarms(msg.Extras, base) {
+		e.append(TableAlarms, date, tenant, alarm)
+	}
+	if source != SourceRealtime {
+		return
+	}
+
+	// 报警标志位在终端解除前每个位置都会置位，只记录新置位的报警
+	raised := msg.AlarmSign &^ e.alarmSigns[dg.Phone]
+	e.alarmSigns[dg.Phone] = msg.AlarmSign
+	for bit := 0; bit < 32; bit++ {
+		if raised&(1<<bit) == 0 {
+			continue
+		}
+		alarm := base
+		alarm.Source = AlarmSourceSign
+		alarm.Code = int32(bit)
+		alarm.Name = model.AlarmSignName(bit)
+		e.append(TableAlarms, date, tenant, &alarm)
+	}
+
+	e.observeTrip(dg, plate, tenant)
+}
+
+// ACC开始计入行程，ACC关闭时结束，只使用已定位且按时间递增的位置
+func (e *Exporter) observeTrip(dg *model.DeviceGeo, plate, tenant string) {
+	if dg.Geo.LocationStatus == 0 {
+		return
+	}
+	trip, ok := e.trips[dg.Phone]
+	if ok && !dg.Time.After(trip.last.Time) {
+		return
+	}
+	if !ok {
+		if dg.Geo.ACCStatus == 1 {
+			e.trips[dg.Phone] = &tripState{start: dg, last: dg, maxSpeed: dg.Drive.Speed, points: 1}
+		}
+		return
+	}
+
+	trip.distance += model.Distance(trip.last.Location.Latitude, trip.last.Location.Longitude, dg.Location.Latitude, dg.Location.Longitude)
+	if dg.Drive.Speed > trip.maxSpeed {
+		trip.maxSpeed = dg.Drive.Speed
+	}
+	trip.points++
+	trip.last = dg
+	if dg.Geo.ACCStatus == 1 {
+		return
+	}
+
+	delete(e.trips, dg.Phone)
+	start, end := hex.InDeviceZone(trip.start.Time), hex.InDeviceZone(dg.Time)
+	e.append(TableTrips, start.Format(dateLayout), tenant, &TripRow{
+		Phone:          dg.Phone,
+		Plate:          plate,
+		Tenant:         tenant,
+		StartTime:      start.UnixMilli(),
+		EndTime:        end.UnixMilli(),
+		Duration:       int64(end.Sub(start) / time.Second),
+		StartLatitude:  trip.start.Location.Latitude,
+		StartLongitude: trip.start.Location.Longitude,
+		EndLatitude:    dg.Location.Latitude,
+		EndLongitude:   dg.Location.Longitude,
+		Distance:       trip.distance,
+		MaxSpeed:       trip.maxSpeed,
+		Points:         trip.points,
+	})
+}
+
+// 记录终端状态变化，状态未变化时忽略
+func (e *Exporter) ObserveStatus(phone, plate string, from, to model.DeviceStatus, reason string, now time.Time) {
+	if from == to {
+		return
+	}
+	tenant := e.Tenant(phone)
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.append(TableStatus, now.Format(dateLayout), tenant, &StatusRow{
+		Phone:  phone,
+		Plate:  plate,
+		Tenant: tenant,
+		Time:   now.UnixMilli(),
+		From:   from.String(),
+		To:     to.String(),
+		Reason: reason,
+	})
+}
+
+// 追加一行到暂存文件，调用方需持有锁
+func (e *Exporter) append(table, date, tenant string, row interface{}) {
+	data, err := json.Marshal(row)
+	if err != nil {
+		log.Warn().Err(err).Str("table", table).Msg("Fail to marshal export row")
+		return
+	}
+	path := filepath.Join(e.dir, stagingDir, table, date, tenant+stagingExt)
+	f, ok := e.files[path]
+	if !ok {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Fail to create export staging dir")
+			return
+		}
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Fail to open export staging file")
+			return
+		}
+		e.files[path] = f
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("Fail to write export staging file")
+	}
+}
+
+// 将早于before所在日期的暂存文件转换为Parquet，返回生成的文件
+func (e *Exporter) Convert(before time.Time) ([]string, error) {
+	today := before.Format(dateLayout)
+	var converted []string
+	for table := range tableRows {
+		dates, err := os.ReadDir(filepath.Join(e.dir, stagingDir, table))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return converted, errors.Wrapf(err, "Fail to list export staging dir, table=%s", table)
+		}
+		for _, d := range dates {
+			if !d.IsDir() || d.Name() >= today {
+				continue
+			}
+			files, err := e.convertDate(table, d.Name())
+			converted = append(converted, files...)
+			if err != nil {
+				return converted, err
+			}
+		}
+	}
+	sort.Strings(converted)
+	return converted, nil
+}
+
+func (e *Exporter) convertDate(table, date string) ([]string, error) {
+	dateDir := filepath.Join(e.dir, stagingDir, table, date)
+	entries, err := os.ReadDir(dateDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to list export staging dir, table=%s, date=%s", table, date)
+	}
+	var converted []string
+	for _, entry := range entries {
+		name := entry.Name()
+		tenant := strings.TrimSuffix(strings.TrimSuffix(name, convertingExt), stagingExt)
+		staging := filepath.Join(dateDir, name)
+		if strings.HasSuffix(name, stagingExt) {
+			// 先改名，转换期间新写入的数据落到新的暂存文件，下次转换为下一个part
+			if staging, err = e.detach(staging); err != nil {
+				return converted, err
+			}
+		}
+		out, err := e.convertFile(table, date, tenant, staging)
+		if err != nil {
+			return converted, err
+		}
+		if out != "" {
+			converted = append(converted, out)
+		}
+		if err = os.Remove(staging); err != nil {
+			return converted, errors.Wrapf(err, "Fail to remove export staging file, path=%s", staging)
+		}
+	}
+	_ = os.Remove(dateDir) // 目录非空时失败，忽略
+	return converted, nil
+}
+
+func (e *Exporter) detach(staging string) (string, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if f, ok := e.files[staging]; ok {
+		_ = f.Close()
+		delete(e.files, staging)
+	}
+	target := staging + convertingExt
+	if err := os.Rename(staging, target); err != nil {
+		return "", errors.Wrapf(err, "Fail to detach export staging file, path=%s", staging)
+	}
+	return target, nil
+}
+
+// 转换一个暂存文件，没有数据时不生成文件
+func (e *Exporter) convertFile(table, date, tenant, staging string) (string, error) {
+	in, err := os.Open(staging)
+	if err != nil {
+		return "", errors.Wrapf(err, "Fail to open export staging file, path=%s", staging)
+	}
+	defer in.Close()
+
+	newRow := tableRows[table]
+	var rows []interface{}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		row := newRow()
+		if err = json.Unmarshal(scanner.Bytes(), row); err != nil {
+			// 进程退出时可能写了半行，跳过
+			log.Warn().Err(err).Str("path", staging).Msg("Skip malformed export staging row")
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if err = scanner.Err(); err != nil {
+		return "", errors.Wrapf(err, "Fail to read export staging file, path=%s", staging)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	partDir := filepath.Join(e.dir, fmt.Sprintf("v%d", SchemaVersion), table, "date="+date, "tenant="+tenant)
+	if err = os.MkdirAll(partDir, 0o755); err != nil {
+		return "", errors.Wrapf(err, "Fail to create export dir, path=%s", partDir)
+	}
+	out, err := nextPart(partDir)
+	if err != nil {
+		return "", err
+	}
+	tmp := out + ".tmp"
+	if err = writeParquet(tmp, newRow(), rows); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err = os.Rename(tmp, out); err != nil {
+		return "", errors.Wrapf(err, "Fail to rename export file, path=%s", out)
+	}
+	return out, nil
+}
+
+// 同一分区多次导出时，按序号生成新的part文件
+func nextPart(partDir string) (string, error) {
+	entries, err := os.ReadDir(partDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "Fail to list export dir, path=%s", partDir)
+	}
+	n := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".parquet") {
+			n++
+		}
+	}
+	return filepath.Join(partDir, fmt.Sprintf("part-%05d.parquet", n)), nil
+}
+
+func writeParquet(path string, schema interface{}, rows []interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to create export file, path=%s", path)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	pw, err := writer.NewParquetWriterFromWriter(w, schema, parallelism)
+	if err != nil {
+		return errors.Wrap(err, "Fail to create parquet writer")
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	version := fmt.Sprint(SchemaVersion)
+	pw.Footer.KeyValueMetadata = append(pw.Footer.KeyValueMetadata, &parquet.KeyValue{Key: schemaVersionKey, Value: &version})
+	for _, row := range rows {
+		if err = pw.Write(row); err != nil {
+			return errors.Wrapf(err, "Fail to write parquet row, path=%s", path)
+		}
+	}
+	if err = pw.WriteStop(); err != nil {
+		return errors.Wrapf(err, "Fail to finish parquet file, path=%s", path)
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Wrapf(err, "Fail to flush export file, path=%s", path)
+	}
+	return f.Sync()
+}
+
+// 关闭打开的暂存文件
+func (e *Exporter) Close() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for path, f := range e.files {
+		_ = f.Close()
+		delete(e.files, path)
+	}
+}
+
+// 解码常用的附加信息，全部附加信息以JSON保留原始数据
+func decodeExtras(row *PositionRow, extras []*model.LocationExtra) {
+	raw := make(map[string]string, len(extras))
+	for _, extra := range extras {
+		raw[fmt.Sprintf("0x%02x", extra.ID)] = fmt.Sprintf("%x", extra.Data)
+		idx := 0
+		switch {
+		case extra.ID == model.ExtraIDMileage && len(extra.Data) == 4:
+			v := float64(hex.ReadDoubleWord(extra.Data, &idx)) / 10
+			row.Mileage = &v
+		case extra.ID == model.ExtraIDFuel && len(extra.Data) == 2:
+			v := float64(hex.ReadWord(extra.Data, &idx)) / 10
+			row.Fuel = &v
+		case extra.ID == model.ExtraIDSpeed && len(extra.Data) == 2:
+			v := float64(hex.ReadWord(extra.Data, &idx)) / 10
+			row.RecorderSpeed = &v
+		case extra.ID == model.ExtraIDGNSS && len(extra.Data) == 1:
+			v := int32(extra.Data[0])
+			row.Satellites = &v
+		}
+	}
+	data, _ := json.Marshal(raw)
+	row.Extras = string(data)
+}
+
+// 从0x64和0x65附加信息解码主动安全报警，base为位置相关的公共字段
+func activeSafetyAlarms(extras []*model.LocationExtra, base AlarmRow) []*AlarmRow {
+	var alarms []*AlarmRow
+	newAlarm := func(source string, code, level, flag uint8, key string) *AlarmRow {
+		alarm := base
+		l, f := int32(level), int32(flag)
+		alarm.Source = source
+		alarm.Code = int32(code)
+		alarm.Level = &l
+		alarm.Flag = &f
+		alarm.AlarmKey = &key
+		return &alarm
+	}
+	for _, extra := range extras {
+		switch extra.ID {
+		case model.ExtraIDADAS:
+			a := &model.ADASAlarm{}
+			if a.Decode(extra.Data) == nil {
+				alarms = append(alarms, newAlarm(AlarmSourceADAS, a.AlarmType, a.AlarmLevel, a.FlagStatus, a.AlarmIdentifier.Key()))
+			}
+		case model.ExtraIDDSM:
+			a := &model.DSMAlarm{}
+			if a.Decode(extra.Data) == nil {
+				alarms = append(alarms, newAlarm(AlarmSourceDSM, a.AlarmType, a.AlarmLevel, a.FlagStatus, a.AlarmIdentifier.Key()))
+			}
+		}
+	}
+	return alarms
+}
@@ -0,0 +1,231 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
+)
+
+// 只读的本地文件，用于读回导出的Parquet
+type localFile struct {
+	*os.File
+}
+
+func (f *localFile) Open(name string) (source.ParquetFile, error) {
+	if name == "" {
+		name = f.Name()
+	}
+	file, err := os.Open(name)
+	return &localFile{file}, err
+}
+
+func (f *localFile) Create(string) (source.ParquetFile, error) {
+	return nil, os.ErrPermission
+}
+
+func readRows[T any](t *testing.T, path string) ([]T, string) {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	pf := &localFile{f}
+	defer pf.Close()
+	pr, err := reader.NewParquetReader(pf, new(T), 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+	rows := make([]T, pr.GetNumRows())
+	require.NoError(t, pr.Read(&rows))
+	version := ""
+	for _, kv := range pr.Footer.KeyValueMetadata {
+		if kv.Key == schemaVersionKey {
+			version = *kv.Value
+		}
+	}
+	return rows, version
+}
+
+func genPosition(phone string, acc, fix bool, lat, lon, speed float64, alarm uint32, t time.Time) (*model.DeviceGeo, *model.Msg0200) {
+	msg := &model.Msg0200{
+		Header:    &model.MsgHeader{PhoneNumber: phone},
+		AlarmSign: alarm,
+		Latitude:  uint32(lat * model.LocationAccuracy),
+		Longitude: uint32(lon * model.LocationAccuracy),
+		Speed:     uint16(speed * model.SpeedAccuracy),
+		Time:      t.Format("060102150405"),
+		Extras: []*model.LocationExtra{
+			{ID: model.ExtraIDMileage, Length: 4, Data: []byte{0x00, 0x00, 0x04, 0xd2}},
+			{ID: model.ExtraIDGNSS, Length: 1, Data: []byte{12}},
+			{ID: 0xe1, Length: 2, Data: []byte{0xab, 0xcd}},
+		},
+	}
+	if acc {
+		msg.StatusSign |= 0x01
+	}
+	if fix {
+		msg.StatusSign |= 0x02
+	}
+	dg := &model.DeviceGeo{}
+	_ = dg.Decode(phone, msg)
+	return dg, msg
+}
+
+func TestExporter_Convert(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(&config.ExportConf{Directory: dir, Tenants: map[string]string{"138": "fleet-a", "13800": "fleet-b"}})
+	defer e.Close()
+	require.Equal(t, "fleet-b", e.Tenant("13800000001"))
+	require.Equal(t, "fleet-a", e.Tenant("13811111111"))
+	require.Equal(t, defaultTenant, e.Tenant("13911111111"))
+
+	phone := "13800000001"
+	day := time.Now().AddDate(0, 0, -1)
+	base := time.Date(day.Year(), day.Month(), day.Day(), 8, 0, 0, 0, hex.DeviceZone)
+	received := base.Add(time.Second)
+	points := []struct {
+		acc   bool
+		lat   float64
+		speed float64
+		alarm uint32
+	}{
+		{true, 30.000, 0, 0},
+		{true, 30.001, 40, 0b10}, // 超速
+		{true, 30.002, 60, 0b10}, // 超速持续，不重复记录
+		{false, 30.003, 0, 0b11}, // 紧急报警，行程结束
+	}
+	for i, p := range points {
+		dg, msg := genPosition(phone, p.acc, true, p.lat, 120, p.speed, p.alarm, base.Add(time.Duration(i)*time.Minute))
+		e.ObservePosition(dg, msg, "京A00001", SourceRealtime, received)
+	}
+	dg, msg := genPosition(phone, true, true, 30.005, 120, 10, 0b10, base.Add(-time.Hour))
+	e.ObservePosition(dg, msg, "京A00001", SourceBlindArea, received)
+	e.ObserveStatus(phone, "京A00001", model.DeviceStatusOnline, model.DeviceStatusSleeping, model.StatusReasonACCOff, received)
+	e.ObserveStatus(phone, "京A00001", model.DeviceStatusSleeping, model.DeviceStatusSleeping, model.StatusReasonACCOff, received)
+	// 当天的数据不转换
+	dg, msg = genPosition(phone, true, true, 30.0, 120, 0, 0, time.Now())
+	e.ObservePosition(dg, msg, "京A00001", SourceRealtime, time.Now())
+
+	files, err := e.Convert(time.Now())
+	require.NoError(t, err)
+	date := day.Format(dateLayout)
+	partition := func(table string) string {
+		return filepath.Join(dir, "v1", table, "date="+date, "tenant=fleet-b", "part-00000.parquet")
+	}
+	require.ElementsMatch(t, []string{
+		partition(TableAlarms), partition(TablePositions), partition(TableStatus), partition(TableTrips),
+	}, files)
+
+	positions, version := readRows[PositionRow](t, partition(TablePositions))
+	require.Equal(t, "1", version)
+	require.Len(t, positions, 5)
+	require.Equal(t, "京A00001", positions[0].Plate)
+	require.Equal(t, SourceRealtime, positions[0].Source)
+	require.Equal(t, base.UnixMilli(), positions[0].DeviceTime)
+	require.Equal(t, received.UnixMilli(), positions[0].ReceivedTime)
+	require.Equal(t, 123.4, *positions[0].Mileage)
+	require.Equal(t, int32(12), *positions[0].Satellites)
+	require.Nil(t, positions[0].Fuel)
+	require.Equal(t, `{"0x01":"000004d2","0x31":"0c","0xe1":"abcd"}`, positions[0].Extras)
+	require.Equal(t, SourceBlindArea, positions[4].Source)
+
+	alarms, _ := readRows[AlarmRow](t, partition(TableAlarms))
+	require.Len(t, alarms, 2)
+	require.Equal(t, "overspeed", alarms[0].Name)
+	require.Equal(t, base.Add(time.Minute).UnixMilli(), alarms[0].DeviceTime)
+	require.Equal(t, "emergency", alarms[1].Name)
+
+	trips, _ := readRows[TripRow](t, partition(TableTrips))
+	require.Len(t, trips, 1)
+	require.Equal(t, int64(180), trips[0].Duration)
+	require.Equal(t, int32(4), trips[0].Points)
+	require.Equal(t, float64(60), trips[0].MaxSpeed)
+	require.InDelta(t, 333.6, trips[0].Distance, 1)
+
+	status, _ := readRows[StatusRow](t, partition(TableStatus))
+	require.Equal(t, []StatusRow{{
+		Phone: phone, Plate: "京A00001", Tenant: "fleet-b", Time: received.UnixMilli(), From: "online", To: "sleeping", Reason: model.StatusReasonACCOff,
+	}}, status)
+
+	// 转换后迟到的数据生成新的part
+	dg, msg = genPosition(phone, true, true, 30.0, 120, 0, 0, base.Add(time.Hour))
+	e.ObservePosition(dg, msg, "京A00001", SourceRealtime, received)
+	files, err = e.Convert(time.Now())
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(filepath.Dir(partition(TablePositions)), "part-00001.parquet")}, files)
+
+	// 当天的暂存数据保留
+	_, err = os.Stat(filepath.Join(dir, stagingDir, TablePositions, time.Now().Format(dateLayout), "fleet-b"+stagingExt))
+	require.NoError(t, err)
+}
+
+func TestExporter_ActiveSafetyAlarm(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(&config.ExportConf{Directory: dir})
+	defer e.Close()
+
+	day := time.Now().AddDate(0, 0, -1)
+	dg, msg := genPosition("13900000001", true, true, 30, 120, 50, 0, day)
+	adas := &model.ADASAlarm{
+		FlagStatus:      model.AlarmFlagStart,
+		AlarmType:       1,
+		AlarmLevel:      2,
+		Time:            day.Format("060102150405"),
+		AlarmIdentifier: &model.AlarmIdentity{TerminalID: "T000001", Time: day.Format("060102150405")},
+	}
+	msg.Extras = append(msg.Extras, adas.ToExtra())
+	e.ObservePosition(dg, msg, "", SourceBlindArea, time.Now())
+
+	_, err := e.Convert(time.Now())
+	require.NoError(t, err)
+	alarms, _ := readRows[AlarmRow](t, filepath.Join(dir, "v1", TableAlarms, "date="+hex.InDeviceZone(dg.Time).Format(dateLayout),
+		"tenant="+defaultTenant, "part-00000.parquet"))
+	require.Len(t, alarms, 1)
+	require.Equal(t, AlarmSourceADAS, alarms[0].Source)
+	require.Equal(t, int32(1), alarms[0].Code)
+	require.Equal(t, int32(2), *alarms[0].Level)
+	require.Equal(t, int32(model.AlarmFlagStart), *alarms[0].Flag)
+	require.Equal(t, adas.AlarmIdentifier.Key(), *alarms[0].AlarmKey)
+}
+
+func TestExporter_Archive(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(&config.ExportConf{Directory: dir})
+	defer e.Close()
+	store, err := blob.NewLocalStore(t.TempDir(), "", nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	day := time.Now().AddDate(0, 0, -1)
+	dg, msg := genPosition("13900000001", true, true, 30, 120, 50, 0, day)
+	e.ObservePosition(dg, msg, "", SourceRealtime, time.Now())
+	_, err = e.Convert(time.Now())
+	require.NoError(t, err)
+	keys, err := e.Archive(ctx, store)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, "part-00000.parquet", path.Base(keys[0]))
+
+	// 本地文件删除后再次导出同一分区，不覆盖已归档的part
+	e.ObservePosition(dg, msg, "", SourceBlindArea, time.Now())
+	files, err := e.Convert(time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "part-00000.parquet", filepath.Base(files[0]))
+	keys2, err := e.Archive(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, []string{path.Join(path.Dir(keys[0]), "part-00001.parquet")}, keys2)
+	_, err = os.Stat(files[0])
+	require.True(t, os.IsNotExist(err))
+
+	objects, err := store.List(ctx, archivePrefix)
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+}
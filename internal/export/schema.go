@@ -0,0 +1,110 @@
+// Package export 按天将位置、报警、行程和状态变化导出为Parquet文件，按日期和租户分区，供数据湖使用。
+package export
+
+// 导出文件的schema版本，字段有不兼容的变化时递增，写入目录名和文件元数据。
+// 新增可选字段不需要递增版本
+const SchemaVersion = 1
+
+// Parquet文件元数据中记录schema版本的key
+const schemaVersionKey = "jt808.export.schema.version"
+
+// 导出的表
+const (
+	TablePositions = "positions"
+	TableAlarms    = "alarms"
+	TableTrips     = "trips"
+	TableStatus    = "status"
+)
+
+// 位置来源
+const (
+	SourceRealtime  = "realtime"   // 0x0200实时上报
+	SourceBlindArea = "blind_area" // 0x0704盲区补报
+)
+
+// 报警来源
+const (
+	AlarmSourceSign = "alarm_sign" // 0x0200报警标志位
+	AlarmSourceADAS = "adas"       // 0x64附加信息
+	AlarmSourceDSM  = "dsm"        // 0x65附加信息
+)
+
+// 时间字段均为UTC毫秒时间戳，device_time由终端上报的本地时间换算
+
+// 位置，每个0x0200和0x0704中的位置一行
+type PositionRow struct {
+	Phone         string   `json:"phone" parquet:"name=phone, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Plate         string   `json:"plate" parquet:"name=plate, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Tenant        string   `json:"tenant" parquet:"name=tenant, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Source        string   `json:"source" parquet:"name=source, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	DeviceTime    int64    `json:"device_time" parquet:"name=device_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	ReceivedTime  int64    `json:"received_time" parquet:"name=received_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Latitude      float64  `json:"latitude" parquet:"name=latitude, type=DOUBLE"`
+	Longitude     float64  `json:"longitude" parquet:"name=longitude, type=DOUBLE"`
+	Altitude      int32    `json:"altitude" parquet:"name=altitude, type=INT32"`                                                 // 单位m
+	Speed         float64  `json:"speed" parquet:"name=speed, type=DOUBLE"`                                                      // 单位km/h
+	Direction     int32    `json:"direction" parquet:"name=direction, type=INT32"`                                               // 0-359，正北为0
+	Located       bool     `json:"located" parquet:"name=located, type=BOOLEAN"`                                                 // 是否已定位
+	ACC           bool     `json:"acc" parquet:"name=acc, type=BOOLEAN"`                                                         // ACC是否开
+	AlarmSign     int64    `json:"alarm_sign" parquet:"name=alarm_sign, type=INT64"`                                             // 报警标志位原值
+	StatusSign    int64    `json:"status_sign" parquet:"name=status_sign, type=INT64"`                                           // 状态标志位原值
+	Mileage       *float64 `json:"mileage,omitempty" parquet:"name=mileage, type=DOUBLE, repetitiontype=OPTIONAL"`               // 0x01里程，单位km
+	Fuel          *float64 `json:"fuel,omitempty" parquet:"name=fuel, type=DOUBLE, repetitiontype=OPTIONAL"`                     // 0x02油量，单位L
+	RecorderSpeed *float64 `json:"recorder_speed,omitempty" parquet:"name=recorder_speed, type=DOUBLE, repetitiontype=OPTIONAL"` // 0x03行驶记录速度，单位km/h
+	Satellites    *int32   `json:"satellites,omitempty" parquet:"name=satellites, type=INT32, repetitiontype=OPTIONAL"`          // 0x31定位卫星数
+	Extras        string   `json:"extras" parquet:"name=extras, type=BYTE_ARRAY, convertedtype=UTF8"`                            // 全部附加信息的JSON，{"0x01":"<hex>"}
+}
+
+// 报警，报警标志位在置位时记一行，主动安全报警每条附加信息记一行
+type AlarmRow struct {
+	Phone        string  `json:"phone" parquet:"name=phone, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Plate        string  `json:"plate" parquet:"name=plate, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Tenant       string  `json:"tenant" parquet:"name=tenant, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Source       string  `json:"source" parquet:"name=source, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	DeviceTime   int64   `json:"device_time" parquet:"name=device_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	ReceivedTime int64   `json:"received_time" parquet:"name=received_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Code         int32   `json:"code" parquet:"name=code, type=INT32"`                                                                       // 标志位的bit序号，或主动安全的报警类型
+	Name         string  `json:"name" parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`                   // 标志位报警的名称，主动安全报警为空
+	Level        *int32  `json:"level,omitempty" parquet:"name=level, type=INT32, repetitiontype=OPTIONAL"`                                  // 主动安全报警级别
+	Flag         *int32  `json:"flag,omitempty" parquet:"name=flag, type=INT32, repetitiontype=OPTIONAL"`                                    // 主动安全标志状态，0不可用，1开始，2结束
+	AlarmKey     *string `json:"alarm_key,omitempty" parquet:"name=alarm_key, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"` // 主动安全报警标识号，关联附件
+	Latitude     float64 `json:"latitude" parquet:"name=latitude, type=DOUBLE"`
+	Longitude    float64 `json:"longitude" parquet:"name=longitude, type=DOUBLE"`
+	Speed        float64 `json:"speed" parquet:"name=speed, type=DOUBLE"`
+}
+
+// 行程，ACC开到ACC关之间的实时位置为一次行程
+type TripRow struct {
+	Phone          string  `json:"phone" parquet:"name=phone, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Plate          string  `json:"plate" parquet:"name=plate, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Tenant         string  `json:"tenant" parquet:"name=tenant, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	StartTime      int64   `json:"start_time" parquet:"name=start_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	EndTime        int64   `json:"end_time" parquet:"name=end_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Duration       int64   `json:"duration" parquet:"name=duration, type=INT64"` // 单位s
+	StartLatitude  float64 `json:"start_latitude" parquet:"name=start_latitude, type=DOUBLE"`
+	StartLongitude float64 `json:"start_longitude" parquet:"name=start_longitude, type=DOUBLE"`
+	EndLatitude    float64 `json:"end_latitude" parquet:"name=end_latitude, type=DOUBLE"`
+	EndLongitude   float64 `json:"end_longitude" parquet:"name=end_longitude, type=DOUBLE"`
+	Distance       float64 `json:"distance" parquet:"name=distance, type=DOUBLE"`   // 相邻定位点直线距离之和，单位m
+	MaxSpeed       float64 `json:"max_speed" parquet:"name=max_speed, type=DOUBLE"` // 单位km/h
+	Points         int32   `json:"points" parquet:"name=points, type=INT32"`
+}
+
+// 终端状态变化
+type StatusRow struct {
+	Phone  string `json:"phone" parquet:"name=phone, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Plate  string `json:"plate" parquet:"name=plate, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Tenant string `json:"tenant" parquet:"name=tenant, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Time   int64  `json:"time" parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	From   string `json:"from" parquet:"name=from, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"` // offline、online、sleeping
+	To     string `json:"to" parquet:"name=to, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Reason string `json:"reason" parquet:"name=reason, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"` // auth、acc_off、acc_on、keepalive_expired、logout、tracker
+}
+
+// 表名到行类型的映射，转换时按此反序列化暂存的数据
+var tableRows = map[string]func() interface{}{
+	TablePositions: func() interface{} { return new(PositionRow) },
+	TableAlarms:    func() interface{} { return new(AlarmRow) },
+	TableTrips:     func() interface{} { return new(TripRow) },
+	TableStatus:    func() interface{} { return new(StatusRow) },
+}
@@ -0,0 +1,37 @@
+// Package icauth, 道路运输证IC卡认证中转。
+//
+// 驾驶员插卡后，终端通过0x0900透传（类型0x0B）上报认证请求，平台转发给认证中心，
+// 再将认证中心的应答通过0x8900透传下发给终端。认证内容由读卡器和认证中心处理，平台不解析。
+package icauth
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+)
+
+// 下发消息给终端，通常是server.TCPServer.Send
+type SendFunc func(sessionID string, msg model.JT808Msg) error
+
+var relayInstance *Relay
+
+// 启动认证中转
+func Start(conf *config.ICAuthConf, send SendFunc) *Relay {
+	relayInstance = NewRelay(conf, send)
+	return relayInstance
+}
+
+// 返回启用的认证中转，未启用时为nil
+func Default() *Relay {
+	return relayInstance
+}
+
+// 异步转发终端的认证请求，未启用时返回false
+func Forward(phone string, req []byte) bool {
+	r := relayInstance
+	if r == nil {
+		return false
+	}
+	routines.GoSafe(func() { _ = r.Authenticate(phone, req) })
+	return true
+}
@@ -0,0 +1,187 @@
+package icauth
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+var (
+	ErrServerUnavailable = errors.New("IC card authentication server is unavailable")
+	ErrAuthTimeout       = errors.New("IC card authentication timeout")
+	ErrBadFrame          = errors.New("Bad IC card authentication frame")
+)
+
+const (
+	defaultTimeout = 30 * time.Second        // 标准规定的IC卡认证透传超时
+	maxFrameLength = model.MaxBodyLength - 1 // 应答需要放进一个0x8900，去掉透传类型1字节
+)
+
+// 认证结果，记录在日志中
+const (
+	ResultRelayed     = "relayed"     // 认证中心已应答，并下发给终端
+	ResultTimeout     = "timeout"     // 认证中心未在超时时间内应答
+	ResultUnavailable = "unavailable" // 认证中心无法连接或应答有误
+	ResultOffline     = "offline"     // 收到应答时终端已下线
+)
+
+// 将终端的认证请求转发给认证中心。每个请求单独建立TCP连接，
+// 请求和应答均以2字节大端长度开头，后面是透传消息内容
+type Relay struct {
+	addrs   []string
+	timeout time.Duration
+	send    SendFunc
+}
+
+func NewRelay(conf *config.ICAuthConf, send SendFunc) *Relay {
+	r := &Relay{
+		addrs:   []string{conf.Addr},
+		timeout: time.Duration(conf.Timeout) * time.Second,
+		send:    send,
+	}
+	if conf.Backup != "" {
+		r.addrs = append(r.addrs, conf.Backup)
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultTimeout
+	}
+	return r
+}
+
+// 转发认证请求，并将认证中心的应答以0x8900下发给终端，结果记录在日志中
+func (r *Relay) Authenticate(phone string, req []byte) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	resp, addr, err := r.Exchange(ctx, req)
+	if err == nil {
+		err = r.reply(phone, resp)
+	}
+	result := resultOf(err)
+	l := log.Info()
+	if err != nil {
+		l = log.Warn().Err(err)
+	}
+	l.Str("device", phone).Str("server", addr).Str("result", result).Int("reqLen", len(req)).
+		Int("respLen", len(resp)).Dur("elapsed", time.Since(start)).Msg("Driver card authentication")
+	return err
+}
+
+func resultOf(err error) string {
+	switch {
+	case err == nil:
+		return ResultRelayed
+	case errors.Is(err, ErrAuthTimeout):
+		return ResultTimeout
+	case errors.Is(err, storage.ErrDeviceNotFound), errors.Is(err, storage.ErrSessionClosed):
+		return ResultOffline
+	}
+	return ResultUnavailable
+}
+
+// 依次请求主、备认证中心，返回应答和应答的认证中心地址。超时后不再重试
+func (r *Relay) Exchange(ctx context.Context, req []byte) ([]byte, string, error) {
+	if len(req) > maxFrameLength {
+		return nil, "", errors.Wrapf(ErrBadFrame, "request too long, len=%d", len(req))
+	}
+	var err error
+	for _, addr := range r.addrs {
+		var resp []byte
+		resp, err = exchange(ctx, addr, req)
+		if err == nil {
+			return resp, addr, nil
+		}
+		log.Warn().Err(err).Str("server", addr).Msg("Fail to request IC card authentication server")
+		if errors.Is(err, ErrAuthTimeout) {
+			return nil, addr, err
+		}
+	}
+	return nil, r.addrs[len(r.addrs)-1], err
+}
+
+func exchange(ctx context.Context, addr string, req []byte) ([]byte, error) {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Wrapf(ErrAuthTimeout, "Fail to dial, addr=%s", addr)
+		}
+		return nil, errors.Wrapf(ErrServerUnavailable, "Fail to dial, addr=%s, err=%s", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if err = WriteFrame(conn, req); err == nil {
+		var resp []byte
+		if resp, err = ReadFrame(conn); err == nil {
+			return resp, nil
+		}
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil, errors.Wrapf(ErrAuthTimeout, "addr=%s", addr)
+	}
+	if errors.Is(err, ErrBadFrame) {
+		return nil, errors.Wrapf(err, "addr=%s", addr)
+	}
+	return nil, errors.Wrapf(ErrServerUnavailable, "addr=%s, err=%s", addr, err)
+}
+
+// 以0x8900将认证中心的应答下发给终端
+func (r *Relay) reply(phone string, resp []byte) error {
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to find device cache, phone=%s", phone)
+	}
+	session, err := storage.GetSession(device.SessionID)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to find device session, phone=%s", phone)
+	}
+	err = r.send(session.ID, &model.Msg8900{
+		Header:          model.GenMsgHeader(device, 0x8900, session.GetNextSerialNum()),
+		PassthroughType: model.PassthroughICCard,
+		Data:            resp,
+	})
+	if err != nil {
+		// 下发失败视为终端已下线
+		return errors.Wrapf(storage.ErrSessionClosed, "phone=%s, err=%s", phone, err)
+	}
+	return nil
+}
+
+// 写入一帧，2字节大端长度 + 内容
+func WriteFrame(w io.Writer, data []byte) error {
+	if len(data) > maxFrameLength {
+		return errors.Wrapf(ErrBadFrame, "frame too long, len=%d", len(data))
+	}
+	frame := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(data)), uint16(len(data)))
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+// 读取一帧
+func ReadFrame(r io.Reader) ([]byte, error) {
+	lenBuf := make([]byte, 2)
+	if _, err := io.ReadFull(r, lenBuf); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint16(lenBuf))
+	if n > maxFrameLength {
+		return nil, errors.Wrapf(ErrBadFrame, "frame too long, len=%d", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
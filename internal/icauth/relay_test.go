@@ -0,0 +1,136 @@
+package icauth
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 模拟终端，记录下发的消息
+type fakeTerminal struct {
+	mutex *sync.Mutex
+	sent  []model.JT808Msg
+}
+
+func (ft *fakeTerminal) send(_ string, msg model.JT808Msg) error {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	ft.sent = append(ft.sent, msg)
+	return nil
+}
+
+func setupDevice(t *testing.T, phone string) {
+	session := &model.Session{ID: "127.0.0.1:" + phone}
+	storage.StoreSession(session)
+	storage.GetDeviceCache().CacheDevice(&model.Device{
+		Phone:       phone,
+		SessionID:   session.ID,
+		VersionDesc: model.Version2019,
+		Status:      model.DeviceStatusOnline,
+	})
+	t.Cleanup(func() {
+		storage.GetDeviceCache().DelDeviceByPhone(phone)
+		storage.ClearSession(session.ID)
+	})
+}
+
+func startStub(t *testing.T, handler StubHandler) *StubServer {
+	stub, err := NewStubServer(handler)
+	require.NoError(t, err)
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// 返回一个没有监听的地址
+func closedAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func TestRelay_Authenticate(t *testing.T) {
+	setupDevice(t, "13800000001")
+	stub := startStub(t, nil)
+	ft := &fakeTerminal{mutex: &sync.Mutex{}}
+	r := NewRelay(&config.ICAuthConf{Addr: stub.Addr()}, ft.send)
+
+	req := make([]byte, 64)
+	req[0] = 0x01
+	require.NoError(t, r.Authenticate("13800000001", req))
+	require.Equal(t, [][]byte{req}, stub.Requests())
+	require.Len(t, ft.sent, 1)
+	msg := ft.sent[0].(*model.Msg8900)
+	require.Equal(t, uint16(0x8900), msg.Header.MsgID)
+	require.Equal(t, "13800000001", msg.Header.PhoneNumber)
+	require.Equal(t, model.PassthroughICCard, msg.PassthroughType)
+	require.Equal(t, StubResponse(req), msg.Data)
+
+	// 应答时终端已下线
+	err := r.Authenticate("13800000009", req)
+	require.ErrorIs(t, err, storage.ErrDeviceNotFound)
+	require.Equal(t, ResultOffline, resultOf(err))
+	require.Len(t, ft.sent, 1)
+}
+
+func TestRelay_Backup(t *testing.T) {
+	setupDevice(t, "13800000002")
+	ft := &fakeTerminal{mutex: &sync.Mutex{}}
+	backup := startStub(t, func(req []byte) []byte {
+		return []byte("backup")
+	})
+	r := NewRelay(&config.ICAuthConf{Addr: closedAddr(t), Backup: backup.Addr()}, ft.send)
+	require.NoError(t, r.Authenticate("13800000002", []byte("card")))
+	require.Equal(t, []byte("backup"), ft.sent[0].(*model.Msg8900).Data)
+
+	// 主备都不可用
+	r = NewRelay(&config.ICAuthConf{Addr: closedAddr(t)}, ft.send)
+	err := r.Authenticate("13800000002", []byte("card"))
+	require.ErrorIs(t, err, ErrServerUnavailable)
+	require.Equal(t, ResultUnavailable, resultOf(err))
+	require.Len(t, ft.sent, 1)
+}
+
+func TestRelay_Timeout(t *testing.T) {
+	setupDevice(t, "13800000003")
+	ft := &fakeTerminal{mutex: &sync.Mutex{}}
+	silent := startStub(t, func(req []byte) []byte {
+		return nil
+	})
+	backup := startStub(t, nil)
+	r := NewRelay(&config.ICAuthConf{Addr: silent.Addr(), Backup: backup.Addr(), Timeout: 1}, ft.send)
+
+	err := r.Authenticate("13800000003", []byte("card"))
+	require.ErrorIs(t, err, ErrAuthTimeout)
+	require.Equal(t, ResultTimeout, resultOf(err))
+	require.Len(t, silent.Requests(), 1)
+	require.Empty(t, backup.Requests()) // 超时后不再请求备份认证中心
+	require.Empty(t, ft.sent)
+}
+
+func TestReadFrame(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteFrame(buf, []byte("card")))
+	require.Equal(t, []byte{0x00, 0x04, 'c', 'a', 'r', 'd'}, buf.Bytes())
+	data, err := ReadFrame(buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte("card"), data)
+
+	// 认证中心的应答超过0x8900的长度
+	_, err = ReadFrame(bytes.NewReader([]byte{0x04, 0x00}))
+	require.ErrorIs(t, err, ErrBadFrame)
+	require.ErrorIs(t, WriteFrame(buf, make([]byte, maxFrameLength+1)), ErrBadFrame)
+
+	r := NewRelay(&config.ICAuthConf{Addr: closedAddr(t)}, nil)
+	_, _, err = r.Exchange(context.Background(), make([]byte, maxFrameLength+1))
+	require.ErrorIs(t, err, ErrBadFrame)
+}
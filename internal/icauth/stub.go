@@ -0,0 +1,111 @@
+package icauth
+
+import (
+	"crypto/sha256"
+	"net"
+	"sync"
+)
+
+// 认证中心的处理逻辑，返回nil时不应答，用于测试超时
+type StubHandler func(req []byte) []byte
+
+// 本地认证中心桩，用于测试。处理逻辑可以替换，默认使用StubResponse
+type StubServer struct {
+	listener net.Listener
+	handler  StubHandler
+	wg       *sync.WaitGroup
+
+	mutex    *sync.Mutex
+	conns    map[net.Conn]struct{}
+	requests [][]byte
+}
+
+// 默认的应答，24字节，由请求内容确定，测试时可以直接比对
+func StubResponse(req []byte) []byte {
+	sum := sha256.Sum256(req)
+	return sum[:24]
+}
+
+// 在127.0.0.1的随机端口启动，handler为nil时使用StubResponse
+func NewStubServer(handler StubHandler) (*StubServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	if handler == nil {
+		handler = StubResponse
+	}
+	s := &StubServer{
+		listener: listener,
+		handler:  handler,
+		wg:       &sync.WaitGroup{},
+		mutex:    &sync.Mutex{},
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func (s *StubServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// 已收到的认证请求
+func (s *StubServer) Requests() [][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([][]byte(nil), s.requests...)
+}
+
+// 停止监听并关闭所有连接
+func (s *StubServer) Close() {
+	s.listener.Close()
+	s.mutex.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+	s.wg.Wait()
+}
+
+func (s *StubServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *StubServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		conn.Close()
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+	}()
+	for {
+		req, err := ReadFrame(conn)
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.requests = append(s.requests, req)
+		s.mutex.Unlock()
+		resp := s.handler(req)
+		if resp == nil {
+			continue
+		}
+		if err = WriteFrame(conn, resp); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package jt809
+
+import (
+	"bufio"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headFlag byte = 0x5b
+	tailFlag byte = 0x5d
+
+	headerLen   = 4 + 4 + 2 + 4 + 3 + 1 + 4 // 长度、流水号、业务类型、接入码、版本号、加密标识、密钥
+	minFrameLen = 1 + headerLen + 2 + 1
+	maxFrameLen = 64 * 1024
+)
+
+var ErrBadFrame = errors.New("Bad jt809 frame")
+
+// 转义的0x5a 0x01、0x5e 0x01还原后的字节
+var unescaped = map[byte]byte{0x5a: 0x5b, 0x5e: 0x5d}
+
+// 协议版本号，JT/T 809-2011
+var version = [3]byte{1, 0, 0}
+
+// 809消息，Body为解密后的消息体
+type Frame struct {
+	SN         uint32 // 报文序列号
+	MsgID      uint16 // 业务数据类型
+	CenterID   uint32 // 下级平台接入码
+	Version    [3]byte
+	Encrypted  bool
+	EncryptKey uint32
+	Body       []byte
+}
+
+// 809规定的数据加密算法，M1为0时不加密
+type Cipher struct {
+	M1  uint32
+	IA1 uint32
+	IC1 uint32
+}
+
+func (c *Cipher) enabled() bool {
+	return c != nil && c.M1 != 0
+}
+
+// 按密钥对数据做异或，加密和解密相同
+func (c *Cipher) crypt(key uint32, data []byte) {
+	if key == 0 {
+		key = 1
+	}
+	for i := range data {
+		key = c.IA1*(key%c.M1) + c.IC1
+		data[i] ^= byte(key >> 20)
+	}
+}
+
+// CRC-16/CCITT，多项式0x1021，初始值0xFFFF
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// 编码为完整的一帧，包括头尾标识。cipher启用时加密消息体
+func Encode(f *Frame, cipher *Cipher) []byte {
+	body := f.Body
+	encrypted, key := false, f.EncryptKey
+	if cipher.enabled() {
+		body = append([]byte(nil), f.Body...)
+		cipher.crypt(key, body)
+		encrypted = true
+	}
+	data := make([]byte, 0, headerLen+len(body)+2)
+	data = binary.BigEndian.AppendUint32(data, uint32(minFrameLen+len(body)))
+	data = binary.BigEndian.AppendUint32(data, f.SN)
+	data = binary.BigEndian.AppendUint16(data, f.MsgID)
+	data = binary.BigEndian.AppendUint32(data, f.CenterID)
+	data = append(data, f.Version[:]...)
+	if encrypted {
+		data = append(data, 1)
+	} else {
+		data = append(data, 0)
+	}
+	data = binary.BigEndian.AppendUint32(data, key)
+	data = append(data, body...)
+	data = binary.BigEndian.AppendUint16(data, crc16(data))
+
+	frame := make([]byte, 0, len(data)+8)
+	frame = append(frame, headFlag)
+	for _, b := range data {
+		switch b {
+		case 0x5b:
+			frame = append(frame, 0x5a, 0x01)
+		case 0x5a:
+			frame = append(frame, 0x5a, 0x02)
+		case 0x5d:
+			frame = append(frame, 0x5e, 0x01)
+		case 0x5e:
+			frame = append(frame, 0x5e, 0x02)
+		default:
+			frame = append(frame, b)
+		}
+	}
+	return append(frame, tailFlag)
+}
+
+// 解码头尾标识之间的内容，校验长度和CRC，加密的消息体用cipher解密
+func Decode(payload []byte, cipher *Cipher) (*Frame, error) {
+	data := make([]byte, 0, len(payload))
+	for i := 0; i < len(payload); i++ {
+		b := payload[i]
+		if (b == 0x5a || b == 0x5e) && i+1 < len(payload) && (payload[i+1] == 0x01 || payload[i+1] == 0x02) {
+			if payload[i+1] == 0x01 {
+				b = unescaped[b]
+			}
+			i++
+		}
+		data = append(data, b)
+	}
+	if len(data) < headerLen+2 {
+		return nil, errors.Wrapf(ErrBadFrame, "frame too short, len=%d", len(data))
+	}
+	length := binary.BigEndian.Uint32(data)
+	if int(length) != len(data)+2 {
+		return nil, errors.Wrapf(ErrBadFrame, "length mismatch, length=%d, actual=%d", length, len(data)+2)
+	}
+	crc := binary.BigEndian.Uint16(data[len(data)-2:])
+	data = data[:len(data)-2]
+	if crc16(data) != crc {
+		return nil, errors.Wrapf(ErrBadFrame, "crc mismatch, crc=0x%04x", crc)
+	}
+
+	f := &Frame{
+		SN:         binary.BigEndian.Uint32(data[4:]),
+		MsgID:      binary.BigEndian.Uint16(data[8:]),
+		CenterID:   binary.BigEndian.Uint32(data[10:]),
+		Encrypted:  data[17] == 1,
+		EncryptKey: binary.BigEndian.Uint32(data[18:]),
+		Body:       data[headerLen:],
+	}
+	copy(f.Version[:], data[14:17])
+	if f.Encrypted {
+		if !cipher.enabled() {
+			return nil, errors.Wrapf(ErrBadFrame, "encrypted frame without cipher, msgId=0x%04x", f.MsgID)
+		}
+		cipher.crypt(f.EncryptKey, f.Body)
+	}
+	return f, nil
+}
+
+// 读取一帧，返回头尾标识之间的内容，头标识之前的数据丢弃
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.ReadBytes(headFlag); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, 0, 64)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch b {
+		case tailFlag:
+			return payload, nil
+		case headFlag:
+			payload = payload[:0] // 上一帧不完整，从新的头标识开始
+			continue
+		}
+		if len(payload) >= maxFrameLen {
+			return nil, errors.Wrapf(ErrBadFrame, "frame too long, len=%d", len(payload))
+		}
+		payload = append(payload, b)
+	}
+}
@@ -0,0 +1,50 @@
+package jt809
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame_EncodeDecode(t *testing.T) {
+	f := &Frame{
+		SN:         0x5b5d5a5e,
+		MsgID:      msgUpConnectReq,
+		CenterID:   10001,
+		Version:    version,
+		EncryptKey: 0x5d,
+		Body:       []byte{0x5b, 0x5a, 0x5d, 0x5e, 0x01, 0x02},
+	}
+	for _, cipher := range []*Cipher{nil, {M1: 10000000, IA1: 20000000, IC1: 30000000}} {
+		frame := Encode(f, cipher)
+		require.Equal(t, headFlag, frame[0])
+		require.Equal(t, tailFlag, frame[len(frame)-1])
+		// 转义后头尾标识只出现在两端
+		require.Zero(t, bytes.Count(frame[1:len(frame)-1], []byte{headFlag}))
+		require.Zero(t, bytes.Count(frame[1:len(frame)-1], []byte{tailFlag}))
+
+		payload, err := readFrame(bufio.NewReader(bytes.NewReader(append([]byte{0x00, 0x01}, frame...))))
+		require.NoError(t, err)
+		got, err := Decode(payload, cipher)
+		require.NoError(t, err)
+		require.Equal(t, f.SN, got.SN)
+		require.Equal(t, f.MsgID, got.MsgID)
+		require.Equal(t, f.CenterID, got.CenterID)
+		require.Equal(t, version, got.Version)
+		require.Equal(t, cipher != nil, got.Encrypted)
+		require.Equal(t, f.Body, got.Body)
+	}
+
+	// 加密的报文需要配置加密参数
+	frame := Encode(f, &Cipher{M1: 1, IA1: 2, IC1: 3})
+	_, err := Decode(frame[1:len(frame)-1], nil)
+	require.ErrorIs(t, err, ErrBadFrame)
+
+	// CRC校验失败
+	frame = Encode(f, nil)
+	frame[len(frame)-4] ^= 0x01
+	_, err = Decode(frame[1:len(frame)-1], nil)
+	require.ErrorIs(t, err, ErrBadFrame)
+}
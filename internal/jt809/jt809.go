@@ -0,0 +1,30 @@
+// Package jt809, 以下级平台身份接入监管平台的JT/T 809链路。
+//
+// 目前只处理JT/T 1078扩展的视频请求：监管平台通过实时音视频请求（0x9801）和远程录像回放请求（0x9A01）
+// 指定车辆和通道，平台转换为0x9101/0x9201下发给终端，应答（0x1801/0x1A01）中带上监管平台拉流的地址。
+// 视频会话由video.Forwarder跟踪。报文按JT/T 809-2011编码，不校验视频请求中的时效口令。
+package jt809
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/video"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+)
+
+var platformInstance *Platform
+
+// 启动809链路，监听从链路并连接监管平台的主链路
+func Start(conf *config.JT809Conf, forwarder *video.Forwarder) (*Platform, error) {
+	p := NewPlatform(conf, forwarder)
+	if err := p.Listen(); err != nil {
+		return nil, err
+	}
+	routines.GoSafe(p.Run)
+	platformInstance = p
+	return p, nil
+}
+
+// 返回启用的809链路，未启用时为nil
+func Default() *Platform {
+	return platformInstance
+}
@@ -0,0 +1,173 @@
+package jt809
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 链路管理的业务数据类型
+const (
+	msgUpConnectReq      uint16 = 0x1001 // 主链路登录请求
+	msgUpConnectRsp      uint16 = 0x1002 // 主链路登录应答
+	msgUpDisconnectReq   uint16 = 0x1003 // 主链路注销请求
+	msgUpLinkTestReq     uint16 = 0x1005 // 主链路连接保持请求
+	msgUpLinkTestRsp     uint16 = 0x1006 // 主链路连接保持应答
+	msgDownConnectReq    uint16 = 0x9001 // 从链路连接请求
+	msgDownConnectRsp    uint16 = 0x9002 // 从链路连接应答
+	msgDownDisconnectReq uint16 = 0x9003 // 从链路注销请求
+	msgDownDisconnectRsp uint16 = 0x9004 // 从链路注销应答
+	msgDownLinkTestReq   uint16 = 0x9005 // 从链路连接保持请求
+	msgDownLinkTestRsp   uint16 = 0x9006 // 从链路连接保持应答
+)
+
+// JT/T 1078扩展的业务数据类型和子业务类型
+const (
+	msgUpRealVideo   uint16 = 0x1800 // 实时音视频交互
+	msgDownRealVideo uint16 = 0x9800
+	msgUpPlayback    uint16 = 0x1A00 // 远程录像回放交互
+	msgDownPlayback  uint16 = 0x9A00
+
+	subRealVideoStartup    uint16 = 0x9801 // 实时音视频请求
+	subRealVideoEnd        uint16 = 0x9802 // 主动请求停止实时音视频传输
+	subRealVideoStartupAck uint16 = 0x1801
+	subRealVideoEndAck     uint16 = 0x1802
+	subPlaybackStartup     uint16 = 0x9A01 // 远程录像回放请求
+	subPlaybackControl     uint16 = 0x9A02 // 远程录像回放控制
+	subPlaybackStartupAck  uint16 = 0x1A01
+	subPlaybackControlAck  uint16 = 0x1A02
+)
+
+// 视频请求的应答结果
+const (
+	resultSuccess     uint8 = 0
+	resultFailure     uint8 = 1
+	resultUnsupported uint8 = 2
+	resultSessionEnd  uint8 = 3
+)
+
+const (
+	plateLen           = 21 // 车牌号，GBK编码，不足补0
+	serverIPLen        = 32
+	passwordLen        = 8
+	playbackStartupLen = 1 + 1 + 1 + 1 + 8 + 8 // 回放请求中授权码之前的部分
+)
+
+var ErrLoginRejected = errors.New("Login rejected by supervision platform")
+
+// 车辆相关的消息体，1078扩展的视频请求和应答都是这种格式
+type vehicleMsg struct {
+	Plate    string
+	Color    uint8
+	DataType uint16 // 子业务类型
+	Data     []byte
+}
+
+func decodeVehicleMsg(body []byte) (*vehicleMsg, error) {
+	if len(body) < plateLen+1+2+4 {
+		return nil, errors.Wrapf(ErrBadFrame, "vehicle msg too short, len=%d", len(body))
+	}
+	idx := 0
+	m := &vehicleMsg{}
+	m.Plate = strings.TrimRight(hex.ReadGBK(body, &idx, plateLen), "\x00")
+	m.Color = hex.ReadByte(body, &idx)
+	m.DataType = hex.ReadWord(body, &idx)
+	dataLen := int(hex.ReadDoubleWord(body, &idx))
+	if dataLen > len(body)-idx {
+		return nil, errors.Wrapf(ErrBadFrame, "vehicle msg data too short, dataLen=%d", dataLen)
+	}
+	m.Data = hex.ReadBytes(body, &idx, dataLen)
+	return m, nil
+}
+
+func (m *vehicleMsg) encode() []byte {
+	body := writePadded(nil, hex.WriteGBK(nil, m.Plate), plateLen)
+	body = hex.WriteByte(body, m.Color)
+	body = hex.WriteWord(body, m.DataType)
+	body = hex.WriteDoubleWord(body, uint32(len(m.Data)))
+	return hex.WriteBytes(body, m.Data)
+}
+
+// 写入定长字段，超长截断，不足补0
+func writePadded(pkt, data []byte, n int) []byte {
+	if len(data) > n {
+		data = data[:n]
+	}
+	pkt = append(pkt, data...)
+	return append(pkt, make([]byte, n-len(data))...)
+}
+
+// 实时音视频请求，授权码和车辆定位信息不使用
+type realVideoStartup struct {
+	Channel uint8
+	AVType  uint8 // 0音视频，1音频，2视频
+}
+
+func decodeRealVideoStartup(data []byte) (*realVideoStartup, error) {
+	if len(data) < 2 {
+		return nil, errors.Wrapf(ErrBadFrame, "realtime video request too short, len=%d", len(data))
+	}
+	return &realVideoStartup{Channel: data[0], AVType: data[1]}, nil
+}
+
+// 实时音视频请求和回放请求的应答：结果、拉流地址、拉流端口
+func encodeStartupAck(result uint8, serverIP string, serverPort uint16) []byte {
+	data := hex.WriteByte(nil, result)
+	data = writePadded(data, []byte(serverIP), serverIPLen)
+	return hex.WriteWord(data, serverPort)
+}
+
+// 远程录像回放请求
+type playbackStartup struct {
+	Channel     uint8
+	AVType      uint8 // 0音视频，1音频，2视频，3视频或音视频
+	StreamType  uint8 // 0主码流或子码流，1主码流，2子码流
+	StorageType uint8 // 0主存储器或灾备存储器，1主存储器，2灾备存储器
+	StartTime   time.Time
+	EndTime     *time.Time // 为空表示一直回放
+}
+
+func decodePlaybackStartup(data []byte) (*playbackStartup, error) {
+	if len(data) < playbackStartupLen {
+		return nil, errors.Wrapf(ErrBadFrame, "playback request too short, len=%d", len(data))
+	}
+	m := &playbackStartup{Channel: data[0], AVType: data[1], StreamType: data[2], StorageType: data[3]}
+	// 回放时间为UTC秒数
+	m.StartTime = time.Unix(int64(binary.BigEndian.Uint64(data[4:])), 0)
+	if end := binary.BigEndian.Uint64(data[12:]); end != 0 {
+		endTime := time.Unix(int64(end), 0)
+		m.EndTime = &endTime
+	}
+	return m, nil
+}
+
+// 远程录像回放控制
+type playbackControl struct {
+	Control  uint8 // 与0x9202的回放控制相同
+	Multiple uint8 // 快进或快退倍数
+	SeekTime *time.Time
+}
+
+func decodePlaybackControl(data []byte) (*playbackControl, error) {
+	if len(data) < 1+1+8 {
+		return nil, errors.Wrapf(ErrBadFrame, "playback control too short, len=%d", len(data))
+	}
+	m := &playbackControl{Control: data[0], Multiple: data[1]}
+	if seek := binary.BigEndian.Uint64(data[2:]); seek != 0 {
+		seekTime := time.Unix(int64(seek), 0)
+		m.SeekTime = &seekTime
+	}
+	return m, nil
+}
+
+// 主链路登录请求：用户名、密码、从链路地址和端口
+func encodeConnectReq(userID uint32, password, downLinkIP string, downLinkPort uint16) []byte {
+	body := hex.WriteDoubleWord(nil, userID)
+	body = writePadded(body, []byte(password), passwordLen)
+	body = writePadded(body, []byte(downLinkIP), serverIPLen)
+	return hex.WriteWord(body, downLinkPort)
+}
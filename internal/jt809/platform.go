@@ -0,0 +1,322 @@
+package jt809
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/video"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+)
+
+const (
+	defaultHeartbeat     = 60 // 单位s
+	defaultRetryInterval = 10 // 单位s
+	defaultPlatform      = "jt809"
+	dialTimeout          = 10 * time.Second
+)
+
+// 809链路的一端，写入需要加锁，视频请求的应答和链路保持可能同时写入
+type link struct {
+	conn   net.Conn
+	mutex  sync.Mutex
+	reader *bufio.Reader
+}
+
+func newLink(conn net.Conn) *link {
+	return &link{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// 以下级平台身份接入监管平台。主链路由本平台连接监管平台，登录后保持连接，断线重连；
+// 从链路由监管平台连接本平台，监管平台的下行消息可能走任意一条链路，上行的应答优先走主链路
+type Platform struct {
+	conf      *config.JT809Conf
+	cipher    *Cipher
+	forwarder *video.Forwarder
+	platform  string
+	heartbeat time.Duration
+	retry     time.Duration
+	sn        atomic.Uint32
+
+	mutex      *sync.Mutex
+	main       *link
+	subs       map[*link]struct{}
+	verifyCode uint32
+	listener   net.Listener
+	videos     map[string]string // <车牌-颜色-通道-类型, 视频会话ID>
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewPlatform(conf *config.JT809Conf, forwarder *video.Forwarder) *Platform {
+	p := &Platform{
+		conf:      conf,
+		forwarder: forwarder,
+		platform:  conf.Platform,
+		heartbeat: time.Duration(conf.Heartbeat) * time.Second,
+		retry:     time.Duration(conf.RetryInterval) * time.Second,
+		mutex:     &sync.Mutex{},
+		subs:      make(map[*link]struct{}),
+		videos:    make(map[string]string),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if conf.M1 != 0 {
+		p.cipher = &Cipher{M1: conf.M1, IA1: conf.IA1, IC1: conf.IC1}
+	}
+	if p.platform == "" {
+		p.platform = defaultPlatform
+	}
+	if p.heartbeat <= 0 {
+		p.heartbeat = defaultHeartbeat * time.Second
+	}
+	if p.retry <= 0 {
+		p.retry = defaultRetryInterval * time.Second
+	}
+	return p
+}
+
+// 监听从链路，downLinkPort为0时不监听
+func (p *Platform) Listen() error {
+	if p.conf.DownLinkPort == 0 {
+		return nil
+	}
+	l, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(int(p.conf.DownLinkPort))))
+	if err != nil {
+		return errors.Wrapf(err, "Fail to listen jt809 down link, port=%d", p.conf.DownLinkPort)
+	}
+	p.listener = l
+	routines.GoSafe(p.serveSubs)
+	return nil
+}
+
+// 持续保持主链路，断线后按重连间隔重试，直到Close
+func (p *Platform) Run() {
+	defer close(p.done)
+	for {
+		err := p.serveMain()
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+		log.Warn().Err(err).Str("addr", p.conf.Addr).Dur("retry", p.retry).Msg("JT809 main link interrupted")
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(p.retry):
+		}
+	}
+}
+
+// 注销主链路并关闭全部连接
+func (p *Platform) Close() {
+	p.mutex.Lock()
+	select {
+	case <-p.stop:
+		p.mutex.Unlock()
+		return
+	default:
+	}
+	close(p.stop)
+	mainLink := p.main
+	if p.listener != nil {
+		p.listener.Close()
+	}
+	for l := range p.subs {
+		l.conn.Close()
+	}
+	p.mutex.Unlock()
+
+	if mainLink != nil {
+		body := hex.WriteDoubleWord(nil, p.conf.UserID)
+		body = writePadded(body, []byte(p.conf.Password), passwordLen)
+		_ = p.write(mainLink, msgUpDisconnectReq, body)
+		mainLink.conn.Close()
+	}
+	<-p.done
+}
+
+func (p *Platform) serveMain() error {
+	conn, err := net.DialTimeout("tcp", p.conf.Addr, dialTimeout)
+	if err != nil {
+		return errors.Wrap(err, "Fail to connect supervision platform")
+	}
+	defer conn.Close()
+	l := newLink(conn)
+
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	body := encodeConnectReq(p.conf.UserID, p.conf.Password, p.conf.DownLinkIP, p.conf.DownLinkPort)
+	if err = p.write(l, msgUpConnectReq, body); err != nil {
+		return errors.Wrap(err, "Fail to send login request")
+	}
+	f, err := p.read(l)
+	if err != nil {
+		return errors.Wrap(err, "Fail to read login response")
+	}
+	if f.MsgID != msgUpConnectRsp || len(f.Body) < 1+4 {
+		return errors.Wrapf(ErrBadFrame, "unexpected login response, msgId=0x%04x", f.MsgID)
+	}
+	if f.Body[0] != resultSuccess {
+		return errors.Wrapf(ErrLoginRejected, "result=%d", f.Body[0])
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	p.mutex.Lock()
+	select {
+	case <-p.stop:
+		p.mutex.Unlock()
+		return nil
+	default:
+	}
+	p.main, p.verifyCode = l, binary.BigEndian.Uint32(f.Body[1:])
+	p.mutex.Unlock()
+	defer func() {
+		p.mutex.Lock()
+		p.main = nil
+		p.mutex.Unlock()
+	}()
+	log.Info().Str("addr", p.conf.Addr).Msg("JT809 main link logged in")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	routines.GoSafe(func() {
+		ticker := time.NewTicker(p.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if err := p.write(l, msgUpLinkTestReq, nil); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	})
+	return p.serve(l)
+}
+
+// 接受监管平台的从链路连接
+func (p *Platform) serveSubs() {
+	for {
+		conn, err := p.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Fail to accept jt809 down link")
+			continue
+		}
+		l := newLink(conn)
+		p.mutex.Lock()
+		p.subs[l] = struct{}{}
+		p.mutex.Unlock()
+		routines.GoSafe(func() {
+			defer func() {
+				p.mutex.Lock()
+				delete(p.subs, l)
+				p.mutex.Unlock()
+				conn.Close()
+			}()
+			err := p.serve(l)
+			log.Info().Err(err).Str("addr", conn.RemoteAddr().String()).Msg("JT809 down link closed")
+		})
+	}
+}
+
+// 处理链路上收到的消息，超过3个链路保持间隔没有收到数据时断开
+func (p *Platform) serve(l *link) error {
+	for {
+		_ = l.conn.SetReadDeadline(time.Now().Add(3 * p.heartbeat))
+		f, err := p.read(l)
+		if errors.Is(err, ErrBadFrame) {
+			log.Warn().Err(err).Msg("Drop bad jt809 frame")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		switch f.MsgID {
+		case msgUpLinkTestRsp, msgDownLinkTestRsp:
+		case msgDownLinkTestReq:
+			err = p.write(l, msgDownLinkTestRsp, nil)
+		case msgDownConnectReq:
+			err = p.acceptSub(l, f)
+		case msgDownDisconnectReq:
+			_ = p.write(l, msgDownDisconnectRsp, nil)
+			return nil
+		case msgDownRealVideo, msgDownPlayback:
+			routines.GoSafe(func() { p.handleVideo(l, f) })
+		default:
+			log.Debug().Str("msgId", fmt.Sprintf("0x%04x", f.MsgID)).Msg("Ignore unsupported jt809 msg")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// 校验从链路连接请求中的校验码，与主链路登录应答的校验码一致才接受
+func (p *Platform) acceptSub(l *link, f *Frame) error {
+	p.mutex.Lock()
+	verifyCode := p.verifyCode
+	p.mutex.Unlock()
+	result := uint8(0)
+	if len(f.Body) < 4 || binary.BigEndian.Uint32(f.Body) != verifyCode {
+		result = 1 // 校验码错误
+	}
+	if err := p.write(l, msgDownConnectRsp, []byte{result}); err != nil {
+		return err
+	}
+	if result != 0 {
+		return errors.Wrap(ErrLoginRejected, "verify code mismatch on down link")
+	}
+	return nil
+}
+
+// 上行消息优先走主链路，主链路断开时走收到请求的链路
+func (p *Platform) reply(from *link, msgID uint16, body []byte) error {
+	p.mutex.Lock()
+	l := p.main
+	p.mutex.Unlock()
+	if l == nil {
+		l = from
+	}
+	return p.write(l, msgID, body)
+}
+
+func (p *Platform) write(l *link, msgID uint16, body []byte) error {
+	f := &Frame{
+		SN:         p.sn.Add(1),
+		MsgID:      msgID,
+		CenterID:   p.conf.GNSSCenterID,
+		Version:    version,
+		EncryptKey: uint32(time.Now().UnixNano()),
+		Body:       body,
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_ = l.conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+	_, err := l.conn.Write(Encode(f, p.cipher))
+	return err
+}
+
+func (p *Platform) read(l *link) (*Frame, error) {
+	payload, err := readFrame(l.reader)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(payload, p.cipher)
+}
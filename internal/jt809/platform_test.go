@@ -0,0 +1,222 @@
+package jt809
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/internal/video"
+)
+
+// 模拟终端，记录下发的消息并回复成功的通用应答
+type fakeTerminal struct {
+	mutex sync.Mutex
+	sent  []model.JT808Msg
+}
+
+func (ft *fakeTerminal) send(_ string, msg model.JT808Msg) error {
+	ft.mutex.Lock()
+	ft.sent = append(ft.sent, msg)
+	ft.mutex.Unlock()
+	header := msg.GetHeader()
+	reply := &model.PacketData{
+		Header: &model.MsgHeader{MsgID: 0x0001, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2019}, PhoneNumber: header.PhoneNumber},
+	}
+	reply.Body = hex.WriteWord(reply.Body, header.SerialNumber)
+	reply.Body = hex.WriteWord(reply.Body, header.MsgID)
+	reply.Body = hex.WriteByte(reply.Body, 0)
+	_, _ = protocol.NewJT808MsgProcessor().Process(context.Background(), reply)
+	return nil
+}
+
+func (ft *fakeTerminal) last() model.JT808Msg {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	return ft.sent[len(ft.sent)-1]
+}
+
+// 模拟监管平台的一条链路
+type fakeRegulator struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	cipher *Cipher
+}
+
+func (r *fakeRegulator) send(msgID uint16, body []byte) {
+	_, err := r.conn.Write(Encode(&Frame{MsgID: msgID, Version: version, EncryptKey: 7, Body: body}, r.cipher))
+	require.NoError(r.t, err)
+}
+
+// 读取指定类型的消息，跳过链路保持
+func (r *fakeRegulator) recv(msgID uint16) *Frame {
+	_ = r.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		payload, err := readFrame(r.reader)
+		require.NoError(r.t, err)
+		f, err := Decode(payload, r.cipher)
+		require.NoError(r.t, err)
+		if f.MsgID == msgID {
+			return f
+		}
+		require.Equal(r.t, msgUpLinkTestReq, f.MsgID)
+	}
+}
+
+func (r *fakeRegulator) request(down, up, dataType uint16, data []byte) *vehicleMsg {
+	r.send(down, (&vehicleMsg{Plate: "京A80901", Color: 2, DataType: dataType, Data: data}).encode())
+	resp, err := decodeVehicleMsg(r.recv(up).Body)
+	require.NoError(r.t, err)
+	require.Equal(r.t, "京A80901", resp.Plate)
+	require.Equal(r.t, uint8(2), resp.Color)
+	return resp
+}
+
+func TestPlatform_Video(t *testing.T) {
+	phone := "13800080901"
+	session := &model.Session{ID: "127.0.0.1:" + phone}
+	storage.StoreSession(session)
+	storage.GetDeviceCache().CacheDevice(&model.Device{
+		Phone:       phone,
+		Plate:       "京A80901",
+		SessionID:   session.ID,
+		VersionDesc: model.Version2019,
+		Status:      model.DeviceStatusOnline,
+	})
+	defer storage.GetDeviceCache().DelDeviceByPhone(phone)
+	defer storage.ClearSession(session.ID)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	ft := &fakeTerminal{}
+	forwarder := video.NewForwarder(&config.VideoConf{ServerIP: "10.0.0.1", TCPPort: 1078, PullIP: "media.example.com", PullPort: 8080}, ft.send)
+	cipher := &Cipher{M1: 10000000, IA1: 20000000, IC1: 30000000}
+	p := NewPlatform(&config.JT809Conf{
+		Platform:     "gov-a",
+		Addr:         ln.Addr().String(),
+		UserID:       1001,
+		Password:     "secret",
+		GNSSCenterID: 20001,
+		DownLinkIP:   "10.0.0.2",
+		Heartbeat:    1,
+		M1:           cipher.M1,
+		IA1:          cipher.IA1,
+		IC1:          cipher.IC1,
+	}, forwarder)
+	go p.Run()
+	defer p.Close()
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	r := &fakeRegulator{t: t, conn: conn, reader: bufio.NewReader(conn), cipher: cipher}
+
+	// 主链路登录
+	login := r.recv(msgUpConnectReq)
+	require.Equal(t, uint32(20001), login.CenterID)
+	require.Equal(t, uint32(1001), binary.BigEndian.Uint32(login.Body))
+	require.Equal(t, "secret\x00\x00", string(login.Body[4:12]))
+	r.send(msgUpConnectRsp, []byte{0, 0, 0, 0, 1})
+
+	// 实时视频请求，应答带上拉流地址
+	resp := r.request(msgDownRealVideo, msgUpRealVideo, subRealVideoStartup, append([]byte{1, 2}, make([]byte, 64+36)...))
+	require.Equal(t, subRealVideoStartupAck, resp.DataType)
+	require.Equal(t, resultSuccess, resp.Data[0])
+	require.Equal(t, "media.example.com", string(resp.Data[1:1+len("media.example.com")]))
+	require.Equal(t, uint16(8080), binary.BigEndian.Uint16(resp.Data[1+serverIPLen:]))
+	msg9101 := ft.last().(*model.Msg9101)
+	require.Equal(t, uint8(1), msg9101.LogicChannelID)
+	require.Equal(t, uint8(1), msg9101.DataType) // 809的视频对应0x9101的视频
+	sessions := forwarder.List("gov-a")
+	require.Len(t, sessions, 1)
+	require.Equal(t, phone, sessions[0].Phone)
+
+	// 停止实时视频
+	resp = r.request(msgDownRealVideo, msgUpRealVideo, subRealVideoEnd, []byte{1, 2})
+	require.Equal(t, subRealVideoEndAck, resp.DataType)
+	require.Equal(t, []byte{resultSuccess}, resp.Data)
+	require.Equal(t, model.AVControlClose, ft.last().(*model.Msg9102).Command)
+	require.Empty(t, forwarder.List(""))
+
+	// 录像回放请求和控制
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	data := []byte{2, 0, 1, 1}
+	data = binary.BigEndian.AppendUint64(data, uint64(start.Unix()))
+	data = binary.BigEndian.AppendUint64(data, 0)
+	data = append(data, make([]byte, 64+36)...)
+	resp = r.request(msgDownPlayback, msgUpPlayback, subPlaybackStartup, data)
+	require.Equal(t, subPlaybackStartupAck, resp.DataType)
+	require.Equal(t, resultSuccess, resp.Data[0])
+	msg9201 := ft.last().(*model.Msg9201)
+	require.Equal(t, uint8(2), msg9201.LogicChannelID)
+	require.True(t, start.Equal(*msg9201.StartTime))
+	require.Nil(t, msg9201.EndTime)
+
+	control := binary.BigEndian.AppendUint64([]byte{model.PlaybackControlFastForward, 2}, 0)
+	resp = r.request(msgDownPlayback, msgUpPlayback, subPlaybackControl, control)
+	require.Equal(t, []byte{resultSuccess}, resp.Data)
+	require.Equal(t, model.PlaybackControlFastForward, ft.last().(*model.Msg9202).Control)
+	control[0] = model.PlaybackControlEnd
+	resp = r.request(msgDownPlayback, msgUpPlayback, subPlaybackControl, control)
+	require.Equal(t, []byte{resultSuccess}, resp.Data)
+	require.Equal(t, model.PlaybackControlEnd, ft.last().(*model.Msg9202).Control)
+	require.Empty(t, forwarder.List(""))
+	resp = r.request(msgDownPlayback, msgUpPlayback, subPlaybackControl, control)
+	require.Equal(t, []byte{resultSessionEnd}, resp.Data)
+
+	// 终端不在线
+	resp = r.request(msgDownRealVideo, msgUpRealVideo, subRealVideoStartup, []byte{1, 0})
+	require.Equal(t, resultSuccess, resp.Data[0])
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+	resp = r.request(msgDownRealVideo, msgUpRealVideo, subRealVideoStartup, []byte{2, 0})
+	require.Equal(t, resultFailure, resp.Data[0])
+}
+
+func TestPlatform_DownLink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	port := uint16(ln.Addr().(*net.TCPAddr).Port)
+	ln.Close() // 释放端口给从链路使用
+
+	p := NewPlatform(&config.JT809Conf{Addr: "127.0.0.1:1", DownLinkPort: port, Heartbeat: 1, RetryInterval: 1}, nil)
+	require.NoError(t, p.Listen())
+	go p.Run()
+	defer p.Close()
+	p.mutex.Lock()
+	p.verifyCode = 9
+	p.mutex.Unlock()
+
+	dial := func() *fakeRegulator {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return &fakeRegulator{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	}
+
+	// 校验码与主链路登录应答一致才接受
+	r := dial()
+	r.send(msgDownConnectReq, []byte{0, 0, 0, 9})
+	require.Equal(t, []byte{0}, r.recv(msgDownConnectRsp).Body)
+	r.send(msgDownLinkTestReq, nil)
+	r.recv(msgDownLinkTestRsp)
+
+	r = dial()
+	r.send(msgDownConnectReq, []byte{0, 0, 0, 8})
+	require.Equal(t, []byte{1}, r.recv(msgDownConnectRsp).Body)
+	_, err = readFrame(r.reader)
+	require.Error(t, err) // 连接被关闭
+}
@@ -0,0 +1,160 @@
+package jt809
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/video"
+)
+
+// 809音视频类型对应的0x9101数据类型，0音视频，1音频（监听），2视频
+var realtimeDataTypes = map[uint8]uint8{0: 0, 1: 3, 2: 1}
+
+// 处理1078扩展的视频请求，转换为0x9101/0x9201下发给终端，应答中带上监管平台拉流的地址
+func (p *Platform) handleVideo(from *link, f *Frame) {
+	req, err := decodeVehicleMsg(f.Body)
+	if err != nil {
+		log.Warn().Err(err).Msg("Fail to decode jt809 video request")
+		return
+	}
+	ctx := context.Background()
+	resp := &vehicleMsg{Plate: req.Plate, Color: req.Color}
+	switch req.DataType {
+	case subRealVideoStartup:
+		resp.DataType, resp.Data = subRealVideoStartupAck, p.startRealtime(ctx, req)
+	case subRealVideoEnd:
+		resp.DataType, resp.Data = subRealVideoEndAck, p.stopRealtime(ctx, req)
+	case subPlaybackStartup:
+		resp.DataType, resp.Data = subPlaybackStartupAck, p.startPlayback(ctx, req)
+	case subPlaybackControl:
+		resp.DataType, resp.Data = subPlaybackControlAck, p.controlPlayback(ctx, req)
+	default:
+		log.Debug().Str("dataType", fmt.Sprintf("0x%04x", req.DataType)).Msg("Ignore unsupported jt809 video msg")
+		return
+	}
+
+	msgID := msgUpRealVideo
+	if f.MsgID == msgDownPlayback {
+		msgID = msgUpPlayback
+	}
+	if err = p.reply(from, msgID, resp.encode()); err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Msg("Fail to reply jt809 video request")
+	}
+}
+
+func (p *Platform) startRealtime(ctx context.Context, req *vehicleMsg) []byte {
+	m, err := decodeRealVideoStartup(req.Data)
+	if err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Msg("Fail to decode jt809 realtime video request")
+		return encodeStartupAck(resultFailure, "", 0)
+	}
+	dataType, ok := realtimeDataTypes[m.AVType]
+	if !ok {
+		return encodeStartupAck(resultUnsupported, "", 0)
+	}
+	s, err := p.forwarder.StartRealtime(ctx, &video.RealtimeReq{
+		Platform: p.platform,
+		Plate:    req.Plate,
+		Channel:  m.Channel,
+		DataType: dataType,
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Uint8("channel", m.Channel).Msg("Fail to start jt809 realtime video")
+		return encodeStartupAck(resultFailure, "", 0)
+	}
+	p.track(ctx, videoKey(req, m.Channel, m.AVType, video.KindRealtime), s.ID)
+	return encodeStartupAck(resultSuccess, s.ServerIP, s.ServerPort)
+}
+
+func (p *Platform) stopRealtime(ctx context.Context, req *vehicleMsg) []byte {
+	m, err := decodeRealVideoStartup(req.Data) // 停止请求与实时音视频请求的前两个字段相同
+	if err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Msg("Fail to decode jt809 realtime video end")
+		return []byte{resultFailure}
+	}
+	if id := p.untrack(videoKey(req, m.Channel, m.AVType, video.KindRealtime)); id != "" {
+		_ = p.forwarder.Stop(ctx, id)
+	}
+	return []byte{resultSuccess}
+}
+
+func (p *Platform) startPlayback(ctx context.Context, req *vehicleMsg) []byte {
+	m, err := decodePlaybackStartup(req.Data)
+	if err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Msg("Fail to decode jt809 playback request")
+		return encodeStartupAck(resultFailure, "", 0)
+	}
+	s, err := p.forwarder.StartPlayback(ctx, &video.PlaybackReq{
+		Platform:    p.platform,
+		Plate:       req.Plate,
+		Channel:     m.Channel,
+		MediaType:   m.AVType,
+		StreamType:  m.StreamType,
+		StorageType: m.StorageType,
+		StartTime:   &m.StartTime,
+		EndTime:     m.EndTime,
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Uint8("channel", m.Channel).Msg("Fail to start jt809 playback")
+		return encodeStartupAck(resultFailure, "", 0)
+	}
+	p.track(ctx, videoKey(req, 0, 0, video.KindPlayback), s.ID)
+	return encodeStartupAck(resultSuccess, s.ServerIP, s.ServerPort)
+}
+
+func (p *Platform) controlPlayback(ctx context.Context, req *vehicleMsg) []byte {
+	m, err := decodePlaybackControl(req.Data)
+	if err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Msg("Fail to decode jt809 playback control")
+		return []byte{resultFailure}
+	}
+	// 回放控制不带通道号，按车辆找到最近一次回放
+	key := videoKey(req, 0, 0, video.KindPlayback)
+	p.mutex.Lock()
+	id := p.videos[key]
+	p.mutex.Unlock()
+	if id == "" {
+		return []byte{resultSessionEnd}
+	}
+	if m.Control == model.PlaybackControlEnd {
+		p.untrack(key)
+	}
+	err = p.forwarder.ControlPlayback(ctx, id, m.Control, m.Multiple, m.SeekTime)
+	if errors.Is(err, video.ErrSessionNotFound) {
+		p.untrack(key) // 会话已超时结束
+		return []byte{resultSessionEnd}
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("plate", req.Plate).Uint8("control", m.Control).Msg("Fail to control jt809 playback")
+		return []byte{resultFailure}
+	}
+	return []byte{resultSuccess}
+}
+
+// 记录监管平台请求对应的视频会话，同一请求重复发起时结束之前的会话
+func (p *Platform) track(ctx context.Context, key, id string) {
+	p.mutex.Lock()
+	old := p.videos[key]
+	p.videos[key] = id
+	p.mutex.Unlock()
+	if old != "" {
+		_ = p.forwarder.Stop(ctx, old)
+	}
+}
+
+func (p *Platform) untrack(key string) string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	id := p.videos[key]
+	delete(p.videos, key)
+	return id
+}
+
+// 实时视频按车辆、通道和音视频类型区分，回放按车辆区分
+func videoKey(req *vehicleMsg, channel, avType uint8, kind string) string {
+	return fmt.Sprintf("%s-%d-%d-%d-%s", req.Plate, req.Color, channel, avType, kind)
+}
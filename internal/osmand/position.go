@@ -0,0 +1,229 @@
+// Package osmand, 接入通过OsmAnd HTTP协议上报位置的手机定位终端。
+//
+// 没有车载终端的司机使用OsmAnd、Traccar Client等手机应用定位，应用按固定间隔以HTTP请求上报位置，
+// 设备标识即管理接口登记的终端手机号。位置被转换为0x0200消息，与jt808终端一起落盘、缓存和统计。
+//
+// 支持两种请求格式:
+//
+//	查询参数或表单: ?id=13800138000&lat=30.24&lon=120.11&timestamp=1700000000&speed=10&bearing=90&altitude=12
+//	JSON: {"device_id": "13800138000", "location": {"timestamp": "2023-11-14T22:13:20Z", "coords": {"latitude": 30.24, ...}}}
+package osmand
+
+import (
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var (
+	ErrMissingID       = errors.New("Missing device id")
+	ErrMissingLocation = errors.New("Missing latitude or longitude")
+	ErrBadParam        = errors.New("Bad param")
+)
+
+const (
+	knotsToKmh = 1.852 // 查询参数的速度单位为节
+	mpsToKmh   = 3.6   // JSON的速度单位为m/s
+)
+
+// 手机上报的一个位置
+type Position struct {
+	ID        string
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	Altitude  float64  // 单位m
+	Speed     float64  // 单位km/h
+	Bearing   float64  // 方向，0-360，正北为0
+	Valid     bool     // 是否定位成功
+	Odometer  *float64 // 累计里程，单位m，未上报时为nil
+}
+
+// 解析查询参数或表单，字段名与Traccar的OsmAnd协议一致
+func ParseValues(values url.Values) (*Position, error) {
+	p := &Position{ID: values.Get("id"), Valid: true}
+	if p.ID == "" {
+		p.ID = values.Get("deviceid")
+	}
+	if p.ID == "" {
+		return nil, ErrMissingID
+	}
+
+	lat, lon := values.Get("lat"), values.Get("lon")
+	if loc := values.Get("location"); lat == "" && lon == "" && loc != "" {
+		lat, lon, _ = strings.Cut(loc, ",")
+	}
+	if lat == "" || lon == "" {
+		return nil, ErrMissingLocation
+	}
+	var err error
+	if p.Latitude, err = parseFloat("lat", lat); err != nil {
+		return nil, err
+	}
+	if p.Longitude, err = parseFloat("lon", lon); err != nil {
+		return nil, err
+	}
+
+	for _, f := range []struct {
+		keys []string
+		dst  *float64
+	}{
+		{[]string{"altitude"}, &p.Altitude},
+		{[]string{"speed"}, &p.Speed},
+		{[]string{"bearing", "heading"}, &p.Bearing},
+	} {
+		for _, key := range f.keys {
+			if v := values.Get(key); v != "" {
+				if *f.dst, err = parseFloat(key, v); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	p.Speed *= knotsToKmh
+
+	if v := values.Get("odometer"); v != "" {
+		odometer, err := parseFloat("odometer", v)
+		if err != nil {
+			return nil, err
+		}
+		p.Odometer = &odometer
+	}
+	if v := values.Get("valid"); v != "" {
+		p.Valid = v == "true" || v == "1"
+	}
+	if p.Time, err = parseTime(values.Get("timestamp")); err != nil {
+		return nil, err
+	}
+	return p, p.validate()
+}
+
+type jsonBody struct {
+	DeviceID string `json:"device_id"`
+	Location struct {
+		Timestamp string `json:"timestamp"`
+		Coords    struct {
+			Latitude  *float64 `json:"latitude"`
+			Longitude *float64 `json:"longitude"`
+			Altitude  float64  `json:"altitude"`
+			Speed     float64  `json:"speed"`
+			Heading   float64  `json:"heading"`
+		} `json:"coords"`
+		Odometer *float64 `json:"odometer"`
+	} `json:"location"`
+}
+
+// 解析Traccar Client的JSON请求
+func ParseJSON(data []byte) (*Position, error) {
+	body := &jsonBody{}
+	if err := json.Unmarshal(data, body); err != nil {
+		return nil, errors.Wrap(ErrBadParam, err.Error())
+	}
+	if body.DeviceID == "" {
+		return nil, ErrMissingID
+	}
+	coords := body.Location.Coords
+	if coords.Latitude == nil || coords.Longitude == nil {
+		return nil, ErrMissingLocation
+	}
+	p := &Position{
+		ID:        body.DeviceID,
+		Latitude:  *coords.Latitude,
+		Longitude: *coords.Longitude,
+		Altitude:  coords.Altitude,
+		Speed:     math.Max(coords.Speed, 0) * mpsToKmh, // 速度未知时为-1
+		Bearing:   math.Max(coords.Heading, 0),
+		Valid:     true,
+		Odometer:  body.Location.Odometer,
+	}
+	var err error
+	if p.Time, err = parseTime(body.Location.Timestamp); err != nil {
+		return nil, err
+	}
+	return p, p.validate()
+}
+
+func (p *Position) validate() error {
+	if math.Abs(p.Latitude) > 90 || math.Abs(p.Longitude) > 180 {
+		return errors.Wrapf(ErrBadParam, "lat=%f, lon=%f", p.Latitude, p.Longitude)
+	}
+	return nil
+}
+
+func parseFloat(key, v string) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errors.Wrapf(ErrBadParam, "%s=%s", key, v)
+	}
+	return f, nil
+}
+
+// 支持unix秒、unix毫秒、RFC3339和本地时间格式，为空时使用服务器时间
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Now(), nil
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.Wrapf(ErrBadParam, "timestamp=%s", v)
+}
+
+// 转换为位置信息汇报，手机没有车辆状态，状态位按ACC开、GPS定位合成
+func (p *Position) ToMsg0200() *model.Msg0200 {
+	geo := &model.GeoMeta{ACCStatus: 1}
+	if p.Valid {
+		geo.LocationStatus = 1
+		geo.GPSLocationStatus = 1
+	}
+	if p.Latitude < 0 {
+		geo.LatitudeType = 1
+	}
+	if p.Longitude < 0 {
+		geo.LongitudeType = 1
+	}
+	if p.Speed > 0 {
+		geo.DrivingStatus = 1
+	}
+	m := &model.Msg0200{
+		Header: &model.MsgHeader{
+			MsgID:       0x0200,
+			Attr:        &model.MsgBodyAttr{VersionDesc: model.Version2013},
+			PhoneNumber: p.ID,
+		},
+		StatusSign: geo.Encode(),
+		Latitude:   uint32(math.Round(math.Abs(p.Latitude) * model.LocationAccuracy)),
+		Longitude:  uint32(math.Round(math.Abs(p.Longitude) * model.LocationAccuracy)),
+		Altitude:   uint16(math.Min(math.Max(p.Altitude, 0), math.MaxUint16)),
+		Speed:      uint16(math.Min(math.Round(p.Speed*model.SpeedAccuracy), math.MaxUint16)),
+		Direction:  uint16(math.Round(p.Bearing)) % 360,
+		Time:       hex.FormatTime(p.Time.In(hex.DeviceZone)),
+	}
+	if p.Odometer != nil && *p.Odometer >= 0 {
+		mileage := uint32(math.Min(*p.Odometer/100, math.MaxUint32)) // 单位1/10km
+		m.Extras = append(m.Extras, &model.LocationExtra{
+			ID:     model.ExtraIDMileage,
+			Length: 4,
+			Data:   hex.WriteDoubleWord(nil, mileage),
+		})
+	}
+	return m
+}
@@ -0,0 +1,95 @@
+package osmand
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func TestParseValues(t *testing.T) {
+	values, err := url.ParseQuery("id=13800138000&lat=30.242718&lon=-120.111154&timestamp=1700000000&speed=10&bearing=90.6&altitude=12.4&odometer=12345")
+	require.NoError(t, err)
+	p, err := ParseValues(values)
+	require.NoError(t, err)
+	require.Equal(t, "13800138000", p.ID)
+	require.Equal(t, time.Unix(1700000000, 0), p.Time)
+	require.InDelta(t, 18.52, p.Speed, 0.001)
+	require.Equal(t, 90.6, p.Bearing)
+	require.Equal(t, 12345.0, *p.Odometer)
+
+	values, err = url.ParseQuery("deviceid=1&location=30.1,120.2&timestamp=1700000000123&heading=10")
+	require.NoError(t, err)
+	p, err = ParseValues(values)
+	require.NoError(t, err)
+	require.Equal(t, "1", p.ID)
+	require.Equal(t, 30.1, p.Latitude)
+	require.Equal(t, 120.2, p.Longitude)
+	require.Equal(t, time.UnixMilli(1700000000123), p.Time)
+	require.Equal(t, 10.0, p.Bearing)
+
+	for _, query := range []string{
+		"lat=30&lon=120",
+		"id=1&lat=30",
+		"id=1&lat=abc&lon=120",
+		"id=1&lat=91&lon=120",
+		"id=1&lat=30&lon=120&timestamp=yesterday",
+	} {
+		values, err = url.ParseQuery(query)
+		require.NoError(t, err)
+		_, err = ParseValues(values)
+		require.Error(t, err, query)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	p, err := ParseJSON([]byte(`{"device_id":"13800138000","location":{"timestamp":"2023-11-14T22:13:20Z",
+		"coords":{"latitude":30.242718,"longitude":120.111154,"altitude":12,"speed":5,"heading":-1},"odometer":100}}`))
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), p.Time.UTC())
+	require.Equal(t, 18.0, p.Speed)
+	require.Equal(t, 0.0, p.Bearing)
+	require.Equal(t, 100.0, *p.Odometer)
+
+	_, err = ParseJSON([]byte(`{"device_id":"1","location":{"coords":{"latitude":30}}}`))
+	require.ErrorIs(t, err, ErrMissingLocation)
+	_, err = ParseJSON([]byte(`{"location":{"coords":{"latitude":30,"longitude":120}}}`))
+	require.ErrorIs(t, err, ErrMissingID)
+	_, err = ParseJSON([]byte(`{`))
+	require.ErrorIs(t, err, ErrBadParam)
+}
+
+func TestPosition_ToMsg0200(t *testing.T) {
+	odometer := 12345.0
+	p := &Position{
+		ID:        "13800138000",
+		Time:      time.Unix(1700000000, 0),
+		Latitude:  -30.242718,
+		Longitude: 120.111154,
+		Altitude:  12.4,
+		Speed:     18.52,
+		Bearing:   359.6,
+		Valid:     true,
+		Odometer:  &odometer,
+	}
+	m := p.ToMsg0200()
+	require.Equal(t, uint32(30242718), m.Latitude)
+	require.Equal(t, uint32(120111154), m.Longitude)
+	require.Equal(t, uint16(185), m.Speed)
+	require.Equal(t, uint16(0), m.Direction)
+
+	dg := &model.DeviceGeo{}
+	require.NoError(t, dg.Decode("013800138000", m))
+	require.Equal(t, uint8(1), dg.Geo.ACCStatus)
+	require.Equal(t, uint8(1), dg.Geo.LocationStatus)
+	require.Equal(t, uint8(1), dg.Geo.LatitudeType)
+	require.Equal(t, uint8(0), dg.Geo.LongitudeType)
+	require.Equal(t, uint8(1), dg.Geo.GPSLocationStatus)
+	require.Equal(t, uint8(1), dg.Geo.DrivingStatus)
+	require.Equal(t, 12.3, *dg.Mileage)
+	require.Equal(t, hex.FromDeviceZone(p.Time), dg.Time) // 定位时间按GMT+8的字面值编码
+}
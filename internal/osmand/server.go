@@ -0,0 +1,115 @@
+package osmand
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+)
+
+var ErrOsmAndDisabled = errors.New("OsmAnd ingest is disabled")
+
+const (
+	defaultKeepalive = 300 // 单位s
+	maxBodySize      = 64 * 1024
+)
+
+type Server struct {
+	keepalive time.Duration
+	listener  net.Listener
+	handler   http.Handler
+}
+
+var serverInstance *Server
+
+// 启动OsmAnd接入服务，监听独立的端口
+func Start(conf *config.OsmAndConf) (*Server, error) {
+	s := NewServer(conf)
+	l, err := net.Listen("tcp", conf.Listen)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to listen osmand addr, addr=%s", conf.Listen)
+	}
+	s.listener = l
+	routines.GoSafe(func() {
+		err := http.Serve(l, s.handler)
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Error().Err(err).Msg("Fail to serve osmand ingest")
+		}
+	})
+	serverInstance = s
+	log.Info().Str("addr", conf.Listen).Msg("Serve OsmAnd position ingest")
+	return s, nil
+}
+
+// 返回启用的接入服务，未启用时为nil
+func Default() *Server {
+	return serverInstance
+}
+
+func NewServer(conf *config.OsmAndConf) *Server {
+	s := &Server{keepalive: time.Duration(conf.Keepalive) * time.Second}
+	if s.keepalive <= 0 {
+		s.keepalive = defaultKeepalive * time.Second
+	}
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.GET("/", s.ingest)
+	router.POST("/", s.ingest)
+	s.handler = router
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+// 上报成功返回200，请求不合法返回400，终端未登记返回404，应用收到非200时会重试
+func (s *Server) ingest(c *gin.Context) {
+	p, err := parseRequest(c.Request)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	err = protocol.ProcessTrackerPosition(p.ToMsg0200(), s.keepalive)
+	switch {
+	case err == nil:
+		c.Status(http.StatusOK)
+	case errors.Is(err, protocol.ErrBadTrackerPhone):
+		c.String(http.StatusBadRequest, err.Error())
+	case errors.Is(err, storage.ErrDeviceNotFound), errors.Is(err, protocol.ErrNotTracker):
+		log.Debug().Str("device", p.ID).Str("remote", c.ClientIP()).Msg("Reject position from unregistered tracker")
+		c.String(http.StatusNotFound, err.Error())
+	default:
+		log.Error().Err(err).Str("device", p.ID).Msg("Fail to process tracker position")
+		c.String(http.StatusInternalServerError, err.Error())
+	}
+}
+
+func parseRequest(r *http.Request) (*Position, error) {
+	if r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			return nil, errors.Wrap(err, "Fail to read request body")
+		}
+		return ParseJSON(data)
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBodySize)
+	if err := r.ParseForm(); err != nil {
+		return nil, errors.Wrap(ErrBadParam, err.Error())
+	}
+	return ParseValues(r.Form)
+}
@@ -0,0 +1,311 @@
+// Package outage 检测运营商故障等原因导致的大面积掉线。
+//
+// 运营商或机房故障时，大量终端会在短时间内同时掉线。检测器按运营商、行政区划和监听地址对终端分组，
+// 分组内集中掉线时发起一个平台级故障，故障期间不再单独通知该分组内终端的掉线，直到终端陆续恢复。
+package outage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 分组维度
+const (
+	DimensionRegion   = "region"   // 注册时上报的市级行政区划
+	DimensionCarrier  = "carrier"  // 按SIM卡号前缀识别的运营商
+	DimensionListener = "listener" // 终端接入的监听地址
+)
+
+const (
+	defaultWindow       = 120 // 单位s
+	defaultMinDevices   = 20
+	defaultRatio        = 0.3
+	defaultRecoverRatio = 0.8
+	defaultMaxIncidents = 1000
+)
+
+// 平台级故障
+type Incident struct {
+	ID        uint64          `json:"id"`
+	Dimension string          `json:"dimension"`
+	Group     string          `json:"group"` // 分组，如运营商名、行政区划代码、监听地址
+	Start     time.Time       `json:"start"`
+	End       *time.Time      `json:"end,omitempty"` // 恢复时间，未恢复时为空
+	Total     int             `json:"total"`         // 发起故障时分组内的终端数
+	Devices   map[string]bool `json:"devices"`       // <掉线的终端, 是否已恢复>
+	Recovered int             `json:"recovered"`     // 已恢复的终端数
+}
+
+func (i *Incident) Active() bool {
+	return i.End == nil
+}
+
+// 分组的键，如carrier:cmcc
+func (i *Incident) Key() string {
+	return i.Dimension + ":" + i.Group
+}
+
+func (i *Incident) Summary() string {
+	if i.Active() {
+		return fmt.Sprintf("%d/%d devices offline", len(i.Devices), i.Total)
+	}
+	return fmt.Sprintf("%d/%d devices recovered in %s", i.Recovered, len(i.Devices), i.End.Sub(i.Start).Round(time.Second))
+}
+
+func (i *Incident) clone() *Incident {
+	c := *i
+	c.Devices = make(map[string]bool, len(i.Devices))
+	for phone, recovered := range i.Devices {
+		c.Devices[phone] = recovered
+	}
+	return &c
+}
+
+type carrierPrefix struct {
+	prefix  string
+	carrier string
+}
+
+type offline struct {
+	phone string
+	time  time.Time
+}
+
+type Detector struct {
+	dimensions   []string
+	window       time.Duration
+	minDevices   int
+	ratio        float64
+	recoverRatio float64
+	maxIncidents int
+	carriers     []*carrierPrefix // 按前缀长度从长到短排序
+
+	mutex       *sync.Mutex
+	seq         uint64
+	offlines    map[string][]*offline // <分组的键, 窗口内的掉线>
+	active      map[string]*Incident  // <分组的键, 未恢复的故障>
+	incidents   []*Incident
+	subscribers []func(*Incident)
+}
+
+func NewDetector(conf *config.OutageConf) *Detector {
+	d := &Detector{
+		dimensions:   conf.GroupBy,
+		window:       time.Duration(conf.Window) * time.Second,
+		minDevices:   conf.MinDevices,
+		ratio:        conf.Ratio,
+		recoverRatio: conf.RecoverRatio,
+		maxIncidents: conf.MaxIncidents,
+		mutex:        &sync.Mutex{},
+		offlines:     make(map[string][]*offline),
+		active:       make(map[string]*Incident),
+	}
+	if len(d.dimensions) == 0 {
+		d.dimensions = []string{DimensionCarrier, DimensionRegion, DimensionListener}
+	}
+	if d.window <= 0 {
+		d.window = defaultWindow * time.Second
+	}
+	if d.minDevices <= 0 {
+		d.minDevices = defaultMinDevices
+	}
+	if d.ratio <= 0 {
+		d.ratio = defaultRatio
+	}
+	if d.recoverRatio <= 0 {
+		d.recoverRatio = defaultRecoverRatio
+	}
+	if d.maxIncidents <= 0 {
+		d.maxIncidents = defaultMaxIncidents
+	}
+	for carrier, prefixes := range conf.Carriers {
+		for _, prefix := range prefixes {
+			d.carriers = append(d.carriers, &carrierPrefix{prefix: prefix, carrier: carrier})
+		}
+	}
+	sort.Slice(d.carriers, func(i, j int) bool {
+		return len(d.carriers[i].prefix) > len(d.carriers[j].prefix)
+	})
+	return d
+}
+
+// 订阅故障的发起和恢复，回调在检测的协程中执行，不应阻塞
+func (d *Detector) Subscribe(fn func(*Incident)) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.subscribers = append(d.subscribers, fn)
+}
+
+// 按SIM卡号识别运营商，2013版本的手机号补齐了前导0
+func (d *Detector) Carrier(phone string) string {
+	phone = strings.TrimLeft(phone, "0")
+	for _, c := range d.carriers {
+		if strings.HasPrefix(phone, c.prefix) {
+			return c.carrier
+		}
+	}
+	return ""
+}
+
+// 终端在维度上的分组，无法分组时为空
+func (d *Detector) group(device *model.Device, dimension string) string {
+	switch dimension {
+	case DimensionCarrier:
+		return d.Carrier(device.Phone)
+	case DimensionRegion:
+		if len(device.RegionCode) != 6 || device.RegionCode == "000000" {
+			return ""
+		}
+		return device.RegionCode[:4] + "00"
+	case DimensionListener:
+		if device.Conn == nil {
+			return ""
+		}
+		return device.Conn.LocalAddr().String()
+	}
+	return ""
+}
+
+// 记录终端掉线，返回是否处于故障中，故障中的终端不再单独通知掉线
+func (d *Detector) ObserveOffline(device *model.Device, now time.Time) bool {
+	var raised []*Incident
+	suppressed := false
+	d.mutex.Lock()
+	for _, dimension := range d.dimensions {
+		g := d.group(device, dimension)
+		if g == "" {
+			continue
+		}
+		key := dimension + ":" + g
+		if incident, ok := d.active[key]; ok {
+			if recovered, ok := incident.Devices[device.Phone]; !ok || recovered {
+				if recovered {
+					incident.Recovered-- // 恢复后再次掉线
+				}
+				incident.Devices[device.Phone] = false
+			}
+			suppressed = true
+			continue
+		}
+		if incident := d.observeGroup(device, dimension, g, key, now); incident != nil {
+			raised = append(raised, incident.clone())
+			suppressed = true
+		}
+	}
+	subscribers := d.subscribers
+	d.mutex.Unlock()
+
+	for _, incident := range raised {
+		for _, fn := range subscribers {
+			fn(incident)
+		}
+	}
+	return suppressed
+}
+
+// 统计分组窗口内的掉线，达到阈值时发起故障
+func (d *Detector) observeGroup(device *model.Device, dimension, g, key string, now time.Time) *Incident {
+	expire := now.Add(-d.window)
+	offlines := d.offlines[key][:0]
+	for _, o := range d.offlines[key] {
+		if o.time.After(expire) && o.phone != device.Phone {
+			offlines = append(offlines, o)
+		}
+	}
+	offlines = append(offlines, &offline{phone: device.Phone, time: now})
+	d.offlines[key] = offlines
+	// 已在其他维度的故障中的终端不再计数，同一次故障只发起一个
+	phones := make([]string, 0, len(offlines))
+	for _, o := range offlines {
+		if !d.covered(o.phone) {
+			phones = append(phones, o.phone)
+		}
+	}
+	if len(phones) < d.minDevices {
+		return nil
+	}
+	total := 0
+	for _, other := range storage.GetDeviceCache().ListDevice() {
+		if d.group(other, dimension) == g {
+			total++
+		}
+	}
+	if float64(len(phones)) < d.ratio*float64(total) {
+		return nil
+	}
+
+	d.seq++
+	incident := &Incident{
+		ID:        d.seq,
+		Dimension: dimension,
+		Group:     g,
+		Start:     now,
+		Total:     total,
+		Devices:   make(map[string]bool, len(phones)),
+	}
+	for _, phone := range phones {
+		incident.Devices[phone] = false
+	}
+	delete(d.offlines, key)
+	d.active[key] = incident
+	d.incidents = append(d.incidents, incident)
+	if len(d.incidents) > d.maxIncidents {
+		d.incidents = d.incidents[len(d.incidents)-d.maxIncidents:]
+	}
+	return incident
+}
+
+func (d *Detector) covered(phone string) bool {
+	for _, incident := range d.active {
+		if _, ok := incident.Devices[phone]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 记录终端重新上线，故障中掉线的终端恢复比例达到recoverRatio时故障恢复
+func (d *Detector) ObserveOnline(phone string, now time.Time) {
+	var recovered []*Incident
+	d.mutex.Lock()
+	for key, incident := range d.active {
+		if done, ok := incident.Devices[phone]; !ok || done {
+			continue
+		}
+		incident.Devices[phone] = true
+		incident.Recovered++
+		if float64(incident.Recovered) >= d.recoverRatio*float64(len(incident.Devices)) {
+			incident.End = &now
+			delete(d.active, key)
+			recovered = append(recovered, incident.clone())
+		}
+	}
+	subscribers := d.subscribers
+	d.mutex.Unlock()
+
+	for _, incident := range recovered {
+		for _, fn := range subscribers {
+			fn(incident)
+		}
+	}
+}
+
+// 按发起时间从早到晚返回故障，activeOnly为true时只返回未恢复的故障
+func (d *Detector) List(activeOnly bool) []*Incident {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	ans := make([]*Incident, 0, len(d.incidents))
+	for _, incident := range d.incidents {
+		if !activeOnly || incident.Active() {
+			ans = append(ans, incident.clone())
+		}
+	}
+	return ans
+}
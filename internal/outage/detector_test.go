@@ -0,0 +1,92 @@
+package outage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+func cacheDevices(t *testing.T, prefix, regionCode string, n int) []*model.Device {
+	cache := storage.GetDeviceCache()
+	devices := make([]*model.Device, 0, n)
+	for i := 0; i < n; i++ {
+		d := &model.Device{Phone: fmt.Sprintf("%s%05d", prefix, i), RegionCode: regionCode, Status: model.DeviceStatusOnline}
+		cache.CacheDevice(d)
+		devices = append(devices, d)
+	}
+	t.Cleanup(func() {
+		for _, d := range devices {
+			cache.DelDeviceByPhone(d.Phone)
+		}
+	})
+	return devices
+}
+
+func TestDetector_Carrier(t *testing.T) {
+	d := NewDetector(&config.OutageConf{Carriers: map[string][]string{"cmcc": {"138", "1440"}, "iot": {"14401"}}})
+	require.Equal(t, "cmcc", d.Carrier("013800000001"))
+	require.Equal(t, "cmcc", d.Carrier("1440012345678"))
+	require.Equal(t, "iot", d.Carrier("1440112345678"))
+	require.Equal(t, "", d.Carrier("13300000001"))
+}
+
+func TestDetector_Outage(t *testing.T) {
+	d := NewDetector(&config.OutageConf{
+		GroupBy:      []string{DimensionCarrier, DimensionRegion},
+		Window:       60,
+		MinDevices:   3,
+		Ratio:        0.5,
+		RecoverRatio: 0.75,
+		Carriers:     map[string][]string{"cmcc": {"138"}, "unicom": {"130"}},
+	})
+	var notified []*Incident
+	d.Subscribe(func(i *Incident) { notified = append(notified, i) })
+
+	cmcc := cacheDevices(t, "138000", "110105", 5)
+	unicom := cacheDevices(t, "130000", "310101", 10)
+	base := time.Now()
+
+	// 分散的掉线不发起故障
+	require.False(t, d.ObserveOffline(unicom[0], base))
+	require.False(t, d.ObserveOffline(unicom[1], base.Add(time.Minute)))
+	require.False(t, d.ObserveOffline(unicom[2], base.Add(2*time.Minute)))
+	require.Empty(t, notified)
+
+	// 同一终端重复掉线只计一次
+	require.False(t, d.ObserveOffline(cmcc[0], base))
+	require.False(t, d.ObserveOffline(cmcc[0], base.Add(time.Second)))
+	require.False(t, d.ObserveOffline(cmcc[1], base.Add(2*time.Second)))
+	require.True(t, d.ObserveOffline(cmcc[2], base.Add(3*time.Second)))
+	require.Len(t, notified, 1)
+	require.Equal(t, "carrier:cmcc", notified[0].Key())
+	require.Equal(t, 5, notified[0].Total)
+	require.Len(t, notified[0].Devices, 3)
+	require.True(t, notified[0].Active())
+
+	// 故障期间分组内的掉线被抑制，不重复发起故障
+	require.True(t, d.ObserveOffline(cmcc[3], base.Add(4*time.Second)))
+	require.Len(t, notified, 1)
+	require.Len(t, d.List(true), 1)
+
+	// 4台中3台恢复后故障恢复，同时满足的地区分组不重复发起故障
+	d.ObserveOnline(cmcc[0].Phone, base.Add(time.Minute))
+	d.ObserveOnline(cmcc[1].Phone, base.Add(time.Minute))
+	d.ObserveOnline(unicom[0].Phone, base.Add(time.Minute))
+	require.Len(t, notified, 1)
+	d.ObserveOnline(cmcc[2].Phone, base.Add(2*time.Minute))
+	require.Len(t, notified, 2)
+	require.False(t, notified[1].Active())
+	require.Equal(t, 3, notified[1].Recovered)
+	require.Equal(t, "3/4 devices recovered in 1m57s", notified[1].Summary())
+	require.Empty(t, d.List(true))
+	require.Len(t, d.List(false), 1)
+
+	// 恢复后的掉线重新计数
+	require.False(t, d.ObserveOffline(cmcc[4], base.Add(3*time.Minute)))
+}
@@ -0,0 +1,66 @@
+package outage
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/internal/timeline"
+)
+
+var ErrOutageDisabled = errors.New("Outage detection is disabled")
+
+// 故障在Grafana注释中的名称
+const (
+	incidentRaised    = "outage"
+	incidentRecovered = "recovered"
+)
+
+var detectorInstance *Detector
+
+// 启动检测，故障的发起和恢复记录到日志和Grafana注释
+func Start(conf *config.OutageConf) *Detector {
+	detectorInstance = NewDetector(conf)
+	detectorInstance.Subscribe(notify)
+	return detectorInstance
+}
+
+// 返回启用的检测器，未启用时为nil
+func Default() *Detector {
+	return detectorInstance
+}
+
+func notify(i *Incident) {
+	if i.Active() {
+		log.Warn().Uint64("id", i.ID).Str("group", i.Key()).Int("devices", len(i.Devices)).Int("total", i.Total).
+			Msg("Outage detected, suppress offline notifications of the group")
+		timeline.ObserveIncident(i.Key(), incidentRaised, i.Summary(), i.Start)
+		return
+	}
+	log.Info().Uint64("id", i.ID).Str("group", i.Key()).Int("recovered", i.Recovered).Int("devices", len(i.Devices)).
+		Dur("duration", i.End.Sub(i.Start)).Msg("Outage recovered")
+	timeline.ObserveIncident(i.Key(), incidentRecovered, i.Summary(), *i.End)
+}
+
+// 记录终端掉线，返回是否处于故障中，未启用时返回false
+func ObserveOffline(phone string) bool {
+	if detectorInstance == nil {
+		return false
+	}
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	if err != nil {
+		return false
+	}
+	return detectorInstance.ObserveOffline(device, time.Now())
+}
+
+// 记录终端重新上线，未启用时忽略
+func ObserveOnline(phone string) {
+	if detectorInstance == nil {
+		return
+	}
+	detectorInstance.ObserveOnline(phone, time.Now())
+}
@@ -0,0 +1,103 @@
+// Package place 从车辆停留点中发现车场、客户点等常去地点，供运营人员确认后提升为电子围栏。
+package place
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 单个终端的停留状态
+type stopState struct {
+	anchor *model.DeviceGeo // 当前停留的起点，nil表示行驶中
+	last   *model.DeviceGeo // 最近一个停留中的位置
+	latest time.Time        // 最近处理的定位时间
+}
+
+// 停留点识别，速度不超过stopSpeed且距起点不超过stopRadius的连续位置视为一次停留，
+// 停留时长达到minDwell时记录停留点
+type StopDetector struct {
+	stopSpeed  float64
+	stopRadius float64
+	minDwell   time.Duration
+
+	mutex  *sync.Mutex
+	states map[string]*stopState
+}
+
+func NewStopDetector(stopSpeed, stopRadius float64, minDwell time.Duration) *StopDetector {
+	return &StopDetector{
+		stopSpeed:  stopSpeed,
+		stopRadius: stopRadius,
+		minDwell:   minDwell,
+		mutex:      &sync.Mutex{},
+		states:     make(map[string]*stopState),
+	}
+}
+
+// 按定位时间依次处理位置，早于已处理位置的补报数据忽略
+func (d *StopDetector) Observe(dg *model.DeviceGeo) {
+	if dg == nil || dg.Location == nil || dg.Drive == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state, ok := d.states[dg.Phone]
+	if !ok {
+		state = &stopState{}
+		d.states[dg.Phone] = state
+	}
+	if !dg.Time.After(state.latest) {
+		return
+	}
+	state.latest = dg.Time
+
+	if state.anchor != nil && dg.Drive.Speed <= d.stopSpeed &&
+		model.Distance(state.anchor.Location.Latitude, state.anchor.Location.Longitude,
+			dg.Location.Latitude, dg.Location.Longitude) <= d.stopRadius {
+		state.last = dg
+		return
+	}
+
+	// 停留结束
+	d.flush(state)
+	state.anchor, state.last = nil, nil
+	if dg.Drive.Speed <= d.stopSpeed {
+		state.anchor, state.last = dg, dg
+	}
+}
+
+func (d *StopDetector) flush(state *stopState) {
+	if state.anchor == nil || state.last.Time.Sub(state.anchor.Time) < d.minDwell {
+		return
+	}
+	storage.GetPlaceCache().AddStop(&model.Stop{
+		Phone:     state.anchor.Phone,
+		Latitude:  state.anchor.Location.Latitude,
+		Longitude: state.anchor.Location.Longitude,
+		Start:     state.anchor.Time,
+		End:       state.last.Time,
+	})
+}
+
+// 进行中且已满足时长的停留，如长时间停在车场的车辆，仅参与聚类不记录
+func (d *StopDetector) Ongoing() []*model.Stop {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var ans []*model.Stop
+	for _, state := range d.states {
+		if state.anchor == nil || state.last.Time.Sub(state.anchor.Time) < d.minDwell {
+			continue
+		}
+		ans = append(ans, &model.Stop{
+			Phone:     state.anchor.Phone,
+			Latitude:  state.anchor.Location.Latitude,
+			Longitude: state.anchor.Location.Longitude,
+			Start:     state.anchor.Time,
+			End:       state.last.Time,
+		})
+	}
+	return ans
+}
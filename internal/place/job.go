@@ -0,0 +1,157 @@
+package place
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fakeyanss/gron"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hash"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/cluster"
+)
+
+const (
+	depotDwell      = 4 * time.Hour // 停留时长中位数达到该值视为车场
+	minPlaceRadius  = 50            // 候选地点的最小半径，单位m
+	clusterJobID    = "place-cluster"
+	placeIDRounding = 1000   // 质心保留3位小数计算ID，约百米内的质心ID保持不变
+	meterPerDegree  = 111000 // 每纬度的长度，单位m，略小于实际值使网格偏大
+	minCosLatitude  = 0.01   // 避免极地附近经度网格过宽
+)
+
+var (
+	detector     *StopDetector
+	detectorOnce sync.Once
+)
+
+// 启动停留点识别和定时聚类
+func Start(conf *config.PlaceConf) {
+	detectorOnce.Do(func() {
+		if conf.GeofenceFile != "" {
+			if err := storage.GetPlaceCache().LoadGeofences(conf.GeofenceFile); err != nil {
+				log.Error().Err(err).Msg("Fail to load geofences")
+			}
+		}
+		detector = NewStopDetector(conf.StopSpeed, conf.StopRadius, time.Duration(conf.MinDwell)*time.Second)
+		job := &ClusterJob{
+			detector: detector,
+			window:   time.Duration(conf.Window) * time.Hour,
+			eps:      conf.Eps,
+			minPts:   conf.MinPts,
+		}
+		cron := gron.New()
+		cron.Add(gron.Every(time.Duration(conf.Interval)*time.Second), job)
+		cron.Start()
+	})
+}
+
+// 处理终端上报的位置，未启动时忽略
+func Observe(dg *model.DeviceGeo) {
+	if detector == nil {
+		return
+	}
+	detector.Observe(dg)
+}
+
+// 定时对时间窗口内的停留点聚类，刷新候选地点
+type ClusterJob struct {
+	detector *StopDetector
+	window   time.Duration
+	eps      float64
+	minPts   int
+}
+
+func (j *ClusterJob) JobID() string {
+	return clusterJobID
+}
+
+func (j *ClusterJob) Run() {
+	stops := storage.GetPlaceCache().ListStopsSince(clock.Now().Add(-j.window))
+	stops = append(stops, j.detector.Ongoing()...)
+	places := Cluster(stops, j.eps, j.minPts)
+	storage.GetPlaceCache().ReplaceCandidates(places)
+	log.Debug().Int("stops", len(stops)).Int("places", len(places)).Msg("Refresh candidate places")
+}
+
+// 对停留点做DBSCAN聚类，每个簇生成一个候选地点
+func Cluster(stops []*model.Stop, eps float64, minPts int) []*model.CandidatePlace {
+	labels := cluster.DBSCANGrid(len(stops), eps, minPts, func(i, j int) float64 {
+		return model.Distance(stops[i].Latitude, stops[i].Longitude, stops[j].Latitude, stops[j].Longitude)
+	}, gridCell(stops, eps))
+	groups := make(map[int][]*model.Stop)
+	for i, label := range labels {
+		if label != cluster.Noise {
+			groups[label] = append(groups[label], stops[i])
+		}
+	}
+	places := make([]*model.CandidatePlace, 0, len(groups))
+	for _, group := range groups {
+		places = append(places, newCandidatePlace(group))
+	}
+	return places
+}
+
+// 按经纬度划分边长不小于eps的网格。经度方向按纬度最高的停留点换算，保证各处的网格都不小于eps
+func gridCell(stops []*model.Stop, eps float64) func(i int) (int, int) {
+	minCos := 1.0
+	for _, s := range stops {
+		minCos = math.Min(minCos, math.Cos(s.Latitude*math.Pi/180))
+	}
+	minCos = math.Max(minCos, minCosLatitude)
+	eps = math.Max(eps, 1) // 网格大于eps不影响结果
+	latSize := eps / meterPerDegree
+	lonSize := eps / (meterPerDegree * minCos)
+	return func(i int) (int, int) {
+		return int(math.Floor(stops[i].Longitude / lonSize)), int(math.Floor(stops[i].Latitude / latSize))
+	}
+}
+
+func newCandidatePlace(stops []*model.Stop) *model.CandidatePlace {
+	p := &model.CandidatePlace{Visits: len(stops)}
+	vehicles := make(map[string]struct{})
+	dwells := make([]time.Duration, 0, len(stops))
+	for _, s := range stops {
+		p.Latitude += s.Latitude
+		p.Longitude += s.Longitude
+		vehicles[s.Phone] = struct{}{}
+		dwells = append(dwells, s.Dwell())
+		if p.FirstSeen.IsZero() || s.Start.Before(p.FirstSeen) {
+			p.FirstSeen = s.Start
+		}
+		if s.End.After(p.LastSeen) {
+			p.LastSeen = s.End
+		}
+	}
+	p.Latitude /= float64(len(stops))
+	p.Longitude /= float64(len(stops))
+	p.Vehicles = len(vehicles)
+
+	p.Radius = minPlaceRadius
+	for _, s := range stops {
+		p.Radius = math.Max(p.Radius, model.Distance(p.Latitude, p.Longitude, s.Latitude, s.Longitude))
+	}
+	p.Radius = math.Ceil(p.Radius)
+
+	sort.Slice(dwells, func(i, j int) bool { return dwells[i] < dwells[j] })
+	median := dwells[len(dwells)/2]
+	p.TypicalDwell = int64(median / time.Second)
+
+	p.ID = fmt.Sprintf("%08x", hash.FNV32(fmt.Sprintf("%.3f,%.3f",
+		math.Round(p.Latitude*placeIDRounding)/placeIDRounding, math.Round(p.Longitude*placeIDRounding)/placeIDRounding)))
+	if median >= depotDwell {
+		p.Kind = model.PlaceKindDepot
+		p.Name = "车场-" + p.ID[:4]
+	} else {
+		p.Kind = model.PlaceKindSite
+		p.Name = "站点-" + p.ID[:4]
+	}
+	return p
+}
@@ -0,0 +1,155 @@
+package place
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/cluster"
+)
+
+func genGeo(phone string, lat, lon, speed float64, t time.Time) *model.DeviceGeo {
+	return &model.DeviceGeo{
+		Phone:    phone,
+		Location: &model.Location{Latitude: lat, Longitude: lon},
+		Drive:    &model.Drive{Speed: speed},
+		Time:     t,
+	}
+}
+
+func TestStopDetector_Observe(t *testing.T) {
+	phone := "13800000001"
+	base := time.Now().Add(-time.Hour)
+	d := NewStopDetector(3, 50, 5*time.Minute)
+
+	// 行驶 -> 停留10分钟 -> 行驶，记录一个停留点
+	d.Observe(genGeo(phone, 30.0, 120.0, 40, base))
+	for i := 0; i <= 10; i++ {
+		d.Observe(genGeo(phone, 30.1, 120.1, 0, base.Add(time.Duration(i+1)*time.Minute)))
+	}
+	// 早于已处理位置的补报数据忽略
+	d.Observe(genGeo(phone, 31.0, 121.0, 40, base.Add(5*time.Minute)))
+	d.Observe(genGeo(phone, 30.2, 120.2, 40, base.Add(15*time.Minute)))
+	// 停留2分钟，时长不足
+	d.Observe(genGeo(phone, 30.3, 120.3, 0, base.Add(16*time.Minute)))
+	d.Observe(genGeo(phone, 30.3, 120.3, 0, base.Add(18*time.Minute)))
+	d.Observe(genGeo(phone, 30.4, 120.4, 40, base.Add(19*time.Minute)))
+
+	stops := storage.GetPlaceCache().ListStopsSince(base)
+	require.Len(t, stops, 1)
+	require.Equal(t, phone, stops[0].Phone)
+	require.Equal(t, 30.1, stops[0].Latitude)
+	require.Equal(t, 10*time.Minute, stops[0].Dwell())
+
+	// 进行中的停留
+	d.Observe(genGeo(phone, 30.5, 120.5, 0, base.Add(20*time.Minute)))
+	d.Observe(genGeo(phone, 30.5, 120.5, 1, base.Add(30*time.Minute)))
+	ongoing := d.Ongoing()
+	require.Len(t, ongoing, 1)
+	require.Equal(t, 30.5, ongoing[0].Latitude)
+	storage.GetPlaceCache().ListStopsSince(time.Now()) // 清理测试数据
+}
+
+func TestCluster(t *testing.T) {
+	base := time.Now().Add(-24 * time.Hour)
+	genStop := func(phone string, lat, lon float64, dwell time.Duration) *model.Stop {
+		return &model.Stop{Phone: phone, Latitude: lat, Longitude: lon, Start: base, End: base.Add(dwell)}
+	}
+	stops := []*model.Stop{
+		// 车场，夜间长时间停留
+		genStop("1", 30.0000, 120.0000, 8*time.Hour),
+		genStop("2", 30.0002, 120.0001, 9*time.Hour),
+		genStop("3", 30.0001, 120.0003, 10*time.Hour),
+		// 客户点，短时停留
+		genStop("1", 30.1000, 120.1000, 20*time.Minute),
+		genStop("1", 30.1003, 120.1000, 30*time.Minute),
+		genStop("2", 30.1001, 120.1002, 25*time.Minute),
+		genStop("2", 30.1002, 120.1001, 40*time.Minute),
+		// 噪声
+		genStop("3", 31.0000, 121.0000, time.Hour),
+	}
+	places := Cluster(stops, 100, 3)
+	require.Len(t, places, 2)
+	kinds := map[string]*model.CandidatePlace{}
+	for _, p := range places {
+		kinds[p.Kind] = p
+	}
+
+	depot := kinds[model.PlaceKindDepot]
+	require.NotNil(t, depot)
+	require.Equal(t, 3, depot.Visits)
+	require.Equal(t, 3, depot.Vehicles)
+	require.Equal(t, int64(9*3600), depot.TypicalDwell)
+	require.Equal(t, "车场-"+depot.ID[:4], depot.Name)
+	require.Equal(t, float64(minPlaceRadius), depot.Radius)
+
+	site := kinds[model.PlaceKindSite]
+	require.NotNil(t, site)
+	require.Equal(t, 4, site.Visits)
+	require.Equal(t, 2, site.Vehicles)
+	require.Equal(t, int64(30*60), site.TypicalDwell)
+	require.InDelta(t, 30.1, site.Latitude, 0.001)
+
+	// 质心稍有偏移时ID不变
+	again := Cluster(stops[:7], 100, 3)
+	for _, p := range again {
+		require.Equal(t, kinds[p.Kind].ID, p.ID)
+	}
+}
+
+func TestCluster_GridSameAsBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	base := time.Now()
+	stops := make([]*model.Stop, 0, 3000)
+	for i := 0; i < 3000; i++ {
+		lat := 52 + r.Float64()*0.05 // 高纬度经度方向的网格最宽
+		lon := 122 + r.Float64()*0.05
+		stops = append(stops, &model.Stop{Phone: "1", Latitude: lat, Longitude: lon, Start: base, End: base})
+	}
+	dist := func(i, j int) float64 {
+		return model.Distance(stops[i].Latitude, stops[i].Longitude, stops[j].Latitude, stops[j].Longitude)
+	}
+	want := cluster.DBSCAN(len(stops), 50, 3, dist)
+	require.Equal(t, want, cluster.DBSCANGrid(len(stops), 50, 3, dist, gridCell(stops, 50)))
+	require.Contains(t, want, 1)
+}
+
+func TestGeofencePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "place", "geofences.json")
+	saved := &model.Geofence{ID: "saved", Name: "车场-0001", CandidateID: "c1", Radius: 50, CreatedAt: time.Now()}
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	data, err := json.Marshal([]*model.Geofence{saved})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	cache := storage.GetPlaceCache()
+	require.NoError(t, cache.LoadGeofences(path))
+	_, err = cache.GetGeofence("saved")
+	require.NoError(t, err)
+
+	// 重启后重新聚类，已提升的候选地点保持关联
+	cache.ReplaceCandidates([]*model.CandidatePlace{{ID: "c1"}, {ID: "c2"}})
+	c1, err := cache.GetCandidate("c1")
+	require.NoError(t, err)
+	require.Equal(t, "saved", c1.GeofenceID)
+
+	require.NoError(t, cache.PromoteCandidate("c2", &model.Geofence{ID: "c2", CreatedAt: time.Now()}))
+	require.NoError(t, cache.DelGeofence("saved"))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	var geofences []*model.Geofence
+	require.NoError(t, json.Unmarshal(data, &geofences))
+	require.Len(t, geofences, 1)
+	require.Equal(t, "c2", geofences[0].ID)
+	require.Equal(t, "c2", geofences[0].CandidateID)
+
+	require.NoError(t, cache.DelGeofence("c2"))
+	require.NoError(t, cache.LoadGeofences(""))
+}
@@ -0,0 +1,83 @@
+package protocol
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 解析后的字符集配置
+type charsetRules struct {
+	def            charset.Charset
+	byManufacturer map[string]charset.Charset
+	byPhone        map[string]charset.Charset
+}
+
+var charsetRulesInstance = &charsetRules{}
+
+// 设置字符串字段的字符集规则，配置的字符集名称不合法时返回错误
+func SetCharsetConf(conf *config.CharsetConf) error {
+	rules := &charsetRules{
+		byManufacturer: make(map[string]charset.Charset),
+		byPhone:        make(map[string]charset.Charset),
+	}
+	var err error
+	if rules.def, err = charset.Parse(conf.Default); err != nil {
+		return err
+	}
+	for manu, name := range conf.ByManufacturer {
+		if rules.byManufacturer[strings.ToUpper(manu)], err = charset.Parse(name); err != nil {
+			return errors.Wrapf(err, "manufacturerId=%s", manu)
+		}
+	}
+	for phone, name := range conf.ByPhone {
+		if rules.byPhone[phone], err = charset.Parse(name); err != nil {
+			return errors.Wrapf(err, "phone=%s", phone)
+		}
+	}
+	charsetRulesInstance = rules
+	return nil
+}
+
+// 解码前按配置和注册时识别的结果确定字符集
+func resolveCharset(header *model.MsgHeader) {
+	rules := charsetRulesInstance
+	if cs, ok := rules.byPhone[header.PhoneNumber]; ok {
+		header.Charset = cs
+		return
+	}
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(header.PhoneNumber)
+	if err == nil && device.Charset != charset.Auto {
+		header.Charset = device.Charset
+		return
+	}
+	header.Charset = rules.def
+}
+
+// 按字符集配置解码消息。注册消息中含制造商ID，制造商配置了字符集时按该字符集重新解码
+func decodeWithCharset(in model.JT808Msg, pkt *model.PacketData) error {
+	resolveCharset(pkt.Header)
+	err := in.Decode(pkt)
+	if err != nil {
+		return err
+	}
+	msg, ok := in.(*model.Msg0100)
+	if !ok {
+		return nil
+	}
+	rules := charsetRulesInstance
+	if _, ok = rules.byPhone[pkt.Header.PhoneNumber]; ok {
+		return nil
+	}
+	cs, ok := rules.byManufacturer[strings.ToUpper(msg.ManufacturerID)]
+	if !ok || cs == pkt.Header.Charset {
+		return nil
+	}
+	pkt.Header.Charset = cs
+	return in.Decode(pkt)
+}
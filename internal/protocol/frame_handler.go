@@ -8,9 +8,8 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 
-	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 const (
@@ -72,14 +71,9 @@ func (fh *JT808FrameHandler) Recv(ctx context.Context) (FramePayload, error) {
 		return nil, ErrFrameReadEmpty
 	}
 
-	if log.Logger.GetLevel() == zerolog.DebugLevel {
-		var sessionID string
-		session := ctx.Value(model.SessionCtxKey{}).(*model.Session)
-		if session != nil {
-			sessionID = session.ID // client端不设置session
-		}
+	if l := logger.Ctx(ctx, logger.ModuleFraming); l.GetLevel() <= zerolog.DebugLevel {
 		// for debug
-		log.Debug().Str("id", sessionID).Int("frame_len", len(buf)).Hex("frame_payload", buf).Msg("Received frame.")
+		l.Debug().Int("frame_len", len(buf)).Hex("frame_payload", buf).Msg("Received frame.")
 	}
 
 	return FramePayload(buf), nil
@@ -88,7 +82,7 @@ func (fh *JT808FrameHandler) Recv(ctx context.Context) (FramePayload, error) {
 func (fh *JT808FrameHandler) Send(payload FramePayload) error {
 	var p = payload
 	if len(p) == 0 {
-		log.Debug().Msg("The payload is empty when sending, skip.")
+		logger.For(logger.ModuleFraming).Debug().Msg("The payload is empty when sending, skip.")
 		return nil
 	}
 	for {
@@ -104,6 +98,6 @@ func (fh *JT808FrameHandler) Send(payload FramePayload) error {
 		}
 	}
 	// for debug
-	log.Debug().Int("frame_len", len(payload)).Hex("frame_payload", payload).Msg("Sent frame.")
+	logger.For(logger.ModuleFraming).Debug().Int("frame_len", len(payload)).Hex("frame_payload", payload).Msg("Sent frame.")
 	return nil
 }
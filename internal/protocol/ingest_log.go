@@ -0,0 +1,183 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/place"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+// 将收到的消息写入WAL，返回时已落盘
+func persistPacket(pkt *model.PacketData) error {
+	return storage.AppendIngestRecord(&storage.IngestRecord{
+		RecvTime: time.Now(),
+		Header:   pkt.Header,
+		Body:     pkt.Body,
+	})
+}
+
+// 将注册成功的消息写入WAL，同时记录下发的鉴权码和车辆名称
+func persistRegister(pkt *model.PacketData, authCode, vehicleName string) error {
+	return storage.AppendIngestRecord(&storage.IngestRecord{
+		RecvTime:    time.Now(),
+		Header:      pkt.Header,
+		Body:        pkt.Body,
+		AuthCode:    authCode,
+		VehicleName: vehicleName,
+	})
+}
+
+var ErrFrameNotIngested = errors.New("Frame is not ingested") // 服务端不会持久化的消息
+
+// 按服务端入库的规则解码从日志中恢复的帧，服务端不会持久化的消息返回ErrFrameNotIngested
+func DecodeIngestFrame(payload []byte) (*model.PacketData, error) {
+	pkt, err := NewJT808PacketCodec().Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	if pkt.Header.IsFragmented() && !pkt.SegCompleted {
+		return nil, ErrFrameNotIngested
+	}
+	act, ok := NewJT808MsgProcessor().options[pkt.Header.MsgID]
+	if !ok || act.replay == nil {
+		return nil, ErrFrameNotIngested
+	}
+	if err = decodeWithCharset(act.genData().Incoming, pkt); err != nil {
+		return nil, errors.Wrap(err, "Fail to decode packet to jtmsg")
+	}
+	return pkt, nil
+}
+
+// 启动时重放WAL，恢复终端在崩溃前已经收到应答的数据
+func RecoverFromIngestLog() error {
+	mp := NewJT808MsgProcessor()
+	var replayed, skipped int
+	err := storage.ReplayIngestLog(func(rec *storage.IngestRecord) error {
+		ok, err := applyIngestRecord(mp, rec)
+		if ok {
+			replayed++
+		} else {
+			skipped++
+		}
+		return err
+	})
+	log.Info().Int("replayed", replayed).Int("skipped", skipped).Msg("Recovered from ingest log")
+	return err
+}
+
+// 已应用到缓存的复制记录的seq。一批记录应用失败时，备节点会从失败的记录重试整批，跳过已经应用过的记录
+var appliedSeq atomic.Uint64
+
+// 备节点写入并应用从主节点复制的记录，落盘后再更新缓存
+func ApplyReplicatedRecords(records []*wal.Record) error {
+	recs := make([]*storage.IngestRecord, 0, len(records))
+	for _, r := range records {
+		rec := &storage.IngestRecord{}
+		if err := json.Unmarshal(r.Data, rec); err != nil {
+			return errors.Wrapf(err, "Fail to deserialize replicated record, seq=%d", r.Seq)
+		}
+		rec.Seq = r.Seq
+		recs = append(recs, rec)
+	}
+	if err := storage.AppendIngestRecords(recs); err != nil {
+		return errors.Wrap(err, "Fail to persist replicated records")
+	}
+	mp := NewJT808MsgProcessor()
+	for _, rec := range recs {
+		if rec.Seq <= appliedSeq.Load() {
+			continue // 上次应用失败前已经应用过的记录
+		}
+		if _, err := applyIngestRecord(mp, rec); err != nil {
+			return err
+		}
+		appliedSeq.Store(rec.Seq)
+	}
+	return nil
+}
+
+// 将一条WAL记录应用到缓存，无需重放或无法解析的记录返回false
+func applyIngestRecord(mp *JT808MsgProcessor, rec *storage.IngestRecord) (bool, error) {
+	act, ok := mp.options[rec.Header.MsgID]
+	if !ok || act.replay == nil {
+		return false, nil
+	}
+	data := act.genData()
+	pkt := &model.PacketData{Header: rec.Header, Body: rec.Body, SegCompleted: true}
+	if err := decodeWithCharset(data.Incoming, pkt); err != nil {
+		// 单条记录无法解析时跳过，不影响其他数据恢复
+		log.Warn().Err(err).Str("device", rec.Header.PhoneNumber).Msg("Fail to decode ingest record, skip it")
+		return false, nil
+	}
+	if err := act.replay(data, rec); err != nil {
+		return false, errors.Wrapf(err, "Fail to replay ingest record, phoneNumber=%s", rec.Header.PhoneNumber)
+	}
+	return true, nil
+}
+
+// 重放注册，按在线注册的规则恢复设备缓存和下发的鉴权码。设备需要重新连接并鉴权后才会上线
+func replayMsg0100(data *model.ProcessData, rec *storage.IngestRecord) error {
+	in := data.Incoming.(*model.Msg0100)
+	if rec.AuthCode == "" {
+		// 手机定位终端登记和旧版本写入的记录没有鉴权码，旧版本也会写入被拒绝的注册，按本地规则判定
+		cache := storage.GetDeviceCache()
+		if cache.HasPlate(in.PlateNumber) || cache.HasPhone(in.Header.PhoneNumber) {
+			return nil
+		}
+	}
+	device := model.NewDevice(in, &model.Session{})
+	device.TransProto = ""
+	if isTracker(in) {
+		device.TransProto = model.HTTPProto
+	}
+	device.AuthCode = rec.AuthCode
+	if device.AuthCode == "" {
+		device.AuthCode = genAuthCode(device)
+	}
+	device.Name = rec.VehicleName
+	device.LastestComTime = rec.RecvTime
+	registerDevice(device)
+	return nil
+}
+
+func replayMsg0003(data *model.ProcessData, _ *storage.IngestRecord) error {
+	phone := data.Incoming.GetHeader().PhoneNumber
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+	return nil
+}
+
+func replayMsg0200(data *model.ProcessData, _ *storage.IngestRecord) error {
+	in := data.Incoming.(*model.Msg0200)
+	dg := &model.DeviceGeo{}
+	err := dg.Decode(in.Header.PhoneNumber, in)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to decode device geo, phoneNumber=%s", in.Header.PhoneNumber)
+	}
+	storage.GetGeoCache().GetGeoRingByPhone(dg.Phone).Write(dg)
+	place.Observe(dg)
+	return nil
+}
+
+func replayMsg0704(data *model.ProcessData, _ *storage.IngestRecord) error {
+	in := data.Incoming.(*model.Msg0704)
+	geos, err := decodeBatchGeos(in)
+	if err != nil {
+		return err
+	}
+	storage.GetGeoCache().InsertGeosByTime(in.Header.PhoneNumber, geos)
+	for _, dg := range geos {
+		place.Observe(dg)
+	}
+	return nil
+}
+
+func replayMsg0104(data *model.ProcessData, _ *storage.IngestRecord) error {
+	return processMsg0104(context.Background(), data)
+}
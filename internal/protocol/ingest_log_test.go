@@ -0,0 +1,326 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/authorizer"
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+func TestRecoverFromIngestLog(t *testing.T) {
+	pc := NewJT808PacketCodec()
+	phone := "223456789015"
+	msg0100 := &model.Msg0100{
+		Header: &model.MsgHeader{
+			MsgID:       0x0100,
+			Attr:        &model.MsgBodyAttr{VersionDesc: model.Version2013},
+			PhoneNumber: phone,
+		},
+		ProvinceID:     31,
+		CityID:         115,
+		ManufacturerID: "fake",
+		DeviceMode:     "fakeyanss",
+		DeviceID:       "1234ABC",
+		PlateColor:     1,
+		PlateNumber:    "京A12345",
+	}
+	payload0100, err := pc.Encode(msg0100)
+	require.NoError(t, err)
+	payload0200 := hex.Str2Byte("7E0200001C2234567890150000000000000002080301CD779E0728C032003C0000008F230125145158FB7E")
+
+	require.NoError(t, storage.OpenIngestLog(t.TempDir(), nil))
+	for _, payload := range [][]byte{payload0100, payload0200} {
+		pkt, err := pc.Decode(payload)
+		require.NoError(t, err)
+		require.NoError(t, persistPacket(pkt))
+	}
+
+	require.NoError(t, RecoverFromIngestLog())
+	require.NoError(t, storage.CloseIngestLog())
+
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, "京A12345", device.Plate)
+	require.Equal(t, model.DeviceStatusOffline, device.Status)
+
+	geo, err := storage.GetGeoCache().GetGeoLatestByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, 30.242718, geo.Location.Latitude)
+	require.Equal(t, 120.111154, geo.Location.Longitude)
+
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+	storage.GetGeoCache().DelGeoByPhone(phone)
+}
+
+func TestRecoverFromIngestLog_BlindArea(t *testing.T) {
+	pc := NewJT808PacketCodec()
+	phone := "223456789016"
+	header := func(msgID uint16) *model.MsgHeader {
+		return &model.MsgHeader{
+			MsgID:       msgID,
+			Attr:        &model.MsgBodyAttr{VersionDesc: model.Version2013},
+			PhoneNumber: phone,
+		}
+	}
+	msg0200 := &model.Msg0200{Header: header(0x0200), Latitude: 30242718, Longitude: 120111154, Time: "230125145158"}
+	// 盲区补报在实时位置之后到达，但定位时间更早
+	msg0704 := &model.Msg0704{
+		Header:       header(0x0704),
+		LocationType: model.LocationTypeBlindArea,
+		Items: []*model.Msg0200{
+			{Latitude: 30242000, Longitude: 120111000, Time: "230125145058"},
+			{Latitude: 30242100, Longitude: 120111100, Time: "230125145128"},
+		},
+	}
+
+	require.NoError(t, storage.OpenIngestLog(t.TempDir(), nil))
+	for _, msg := range []model.JT808Msg{msg0200, msg0704} {
+		payload, err := pc.Encode(msg)
+		require.NoError(t, err)
+		pkt, err := pc.Decode(payload)
+		require.NoError(t, err)
+		require.NoError(t, persistPacket(pkt))
+	}
+
+	require.NoError(t, RecoverFromIngestLog())
+	require.NoError(t, storage.CloseIngestLog())
+
+	geo, err := storage.GetGeoCache().GetGeoLatestByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, 30.242718, geo.Location.Latitude)
+
+	all := storage.GetGeoCache().GetGeoRingByPhone(phone).All()
+	require.Len(t, all, 3)
+	require.Equal(t, 30.242, all[0].(*model.DeviceGeo).Location.Latitude)
+	require.Equal(t, 30.2421, all[1].(*model.DeviceGeo).Location.Latitude)
+
+	storage.GetGeoCache().DelGeoByPhone(phone)
+}
+
+func TestRecoverFromIngestLog_PrunedSegments(t *testing.T) {
+	pc := NewJT808PacketCodec()
+	phone, expired := "223456789020", "223456789021"
+	register := func(phone, plate string) *model.PacketData {
+		msg := &model.Msg0100{
+			Header:      &model.MsgHeader{MsgID: 0x0100, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2013}, PhoneNumber: phone},
+			DeviceID:    "1234ABC",
+			PlateNumber: plate,
+		}
+		payload, err := pc.Encode(msg)
+		require.NoError(t, err)
+		pkt, err := pc.Decode(payload)
+		require.NoError(t, err)
+		return pkt
+	}
+	payload0200 := hex.Str2Byte("7E0200001C2234567890150000000000000002080301CD779E0728C032003C0000008F230125145158FB7E")
+	pkt0200, err := pc.Decode(payload0200)
+	require.NoError(t, err)
+
+	// 每条记录一个segment，只保留2个，注册所在的segment很快被清理
+	dir := t.TempDir()
+	require.NoError(t, storage.OpenIngestLog(dir, &wal.Options{SegmentSize: 1, MaxSegments: 2}))
+	require.NoError(t, persistPacket(register(phone, "京A00020")))
+	require.NoError(t, persistPacket(register(expired, "京A00021")))
+	require.NoError(t, RecoverFromIngestLog())
+	storage.GetDeviceCache().DelDeviceByPhone(expired) // 超时被清除的终端不再保留
+	for i := 0; i < 5; i++ {
+		require.NoError(t, persistPacket(pkt0200))
+	}
+	require.NoError(t, storage.CloseIngestLog())
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+
+	require.NoError(t, storage.OpenIngestLog(dir, &wal.Options{SegmentSize: 1, MaxSegments: 2}))
+	require.NoError(t, RecoverFromIngestLog())
+	require.NoError(t, storage.CloseIngestLog())
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, "京A00020", device.Plate)
+	require.False(t, storage.GetDeviceCache().HasPhone(expired))
+
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+	storage.GetGeoCache().DelGeoByPhone("223456789015")
+}
+
+func TestRecoverFromIngestLog_Register(t *testing.T) {
+	stub := authorizer.NewStubAuthorizer()
+	SetAuthorizer(stub)
+	defer SetAuthorizer(nil)
+
+	pc := NewJT808PacketCodec()
+	mp := NewJT808MsgProcessor()
+	old, phone, rejected := "223456789030", "223456789031", "223456789032"
+	stub.Reject(rejected, model.ResDeviceNotExist)
+	register := func(phone string) *model.Msg8100 {
+		msg := &model.Msg0100{
+			Header:      &model.MsgHeader{MsgID: 0x0100, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2013}, PhoneNumber: phone},
+			DeviceID:    "1234ABC",
+			PlateNumber: "京A00030",
+		}
+		payload, err := pc.Encode(msg)
+		require.NoError(t, err)
+		pkt, err := pc.Decode(payload)
+		require.NoError(t, err)
+		session := &model.Session{ID: "127.0.0.1:" + phone}
+		data, err := mp.Process(context.WithValue(context.Background(), model.SessionCtxKey{}, session), pkt)
+		require.NoError(t, err)
+		return data.Outgoing.(*model.Msg8100)
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, storage.OpenIngestLog(dir, nil))
+	require.Equal(t, model.ResSuccess, register(old).Result)
+	require.Equal(t, model.ResSuccess, register(phone).Result) // 外部服务判定时，车牌被占用也覆盖
+	require.Equal(t, model.ResDeviceNotExist, register(rejected).Result)
+	require.NoError(t, storage.CloseIngestLog())
+	for _, p := range []string{old, phone} {
+		NewKeepaliveTimer().Cancel(p)
+		storage.GetDeviceCache().DelDeviceByPhone(p)
+	}
+
+	// 重放不再请求外部服务，按WAL记录的鉴权码恢复
+	SetAuthorizer(nil)
+	require.NoError(t, storage.OpenIngestLog(dir, nil))
+	require.NoError(t, RecoverFromIngestLog())
+	require.NoError(t, storage.CloseIngestLog())
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, "stub"+phone, device.AuthCode)
+	require.Equal(t, "京A00030", device.Plate)
+	require.False(t, storage.GetDeviceCache().HasPhone(old))
+	require.False(t, storage.GetDeviceCache().HasPhone(rejected))
+
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+}
+
+func TestDecodeIngestFrame(t *testing.T) {
+	pc := NewJT808PacketCodec()
+	phone := "223456789017"
+	header := func(msgID uint16) *model.MsgHeader {
+		return &model.MsgHeader{
+			MsgID:       msgID,
+			Attr:        &model.MsgBodyAttr{VersionDesc: model.Version2013},
+			PhoneNumber: phone,
+		}
+	}
+	msg0100 := &model.Msg0100{
+		Header:         header(0x0100),
+		ManufacturerID: "fake",
+		DeviceMode:     "fakeyanss",
+		DeviceID:       "1234ABD",
+		PlateColor:     1,
+		PlateNumber:    "京A12346",
+	}
+	msg0200 := &model.Msg0200{Header: header(0x0200), Latitude: 30242718, Longitude: 120111154, Time: "230125145158"}
+	msg0002 := &model.Msg0002{Header: header(0x0002)}
+
+	require.NoError(t, storage.OpenIngestLog(t.TempDir(), nil))
+	logTime := time.Date(2023, 1, 25, 14, 51, 0, 0, time.Local)
+	for _, msg := range []model.JT808Msg{msg0100, msg0200, msg0002} {
+		payload, err := pc.Encode(msg)
+		require.NoError(t, err)
+		pkt, err := DecodeIngestFrame(payload)
+		if msg == msg0002 {
+			require.ErrorIs(t, err, ErrFrameNotIngested)
+			continue
+		}
+		require.NoError(t, err)
+		require.NoError(t, storage.AppendIngestRecord(&storage.IngestRecord{RecvTime: logTime, Header: pkt.Header, Body: pkt.Body}))
+	}
+	_, err := DecodeIngestFrame([]byte{0x7e, 0x01, 0x02, 0x7e})
+	require.Error(t, err)
+
+	require.NoError(t, RecoverFromIngestLog())
+	require.NoError(t, storage.CloseIngestLog())
+
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, "京A12346", device.Plate)
+	require.True(t, logTime.Equal(device.LastestComTime))
+	geo, err := storage.GetGeoCache().GetGeoLatestByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, 30.242718, geo.Location.Latitude)
+
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+	storage.GetGeoCache().DelGeoByPhone(phone)
+}
+
+func TestProcess_PersistAfterProcessed(t *testing.T) {
+	mp := NewJT808MsgProcessor()
+	phone := "223456789015"
+	process := func() error {
+		pkt, err := NewJT808PacketCodec().Decode(hex.Str2Byte("7E0200001C2234567890150000000000000002080301CD779E0728C032003C0000008F230125145158FB7E"))
+		require.NoError(t, err)
+		_, err = mp.Process(context.Background(), pkt)
+		return err
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, storage.OpenIngestLog(dir, nil))
+	// 设备未注册，处理失败不应答，也不写入WAL
+	require.ErrorIs(t, process(), storage.ErrDeviceNotFound)
+	storage.GetDeviceCache().CacheDevice(&model.Device{Phone: phone, Status: model.DeviceStatusOnline})
+	require.NoError(t, process())
+	require.NoError(t, storage.CloseIngestLog())
+
+	var msgIDs []uint16
+	require.NoError(t, storage.OpenIngestLog(dir, nil))
+	require.NoError(t, storage.ReplayIngestLog(func(rec *storage.IngestRecord) error {
+		msgIDs = append(msgIDs, rec.Header.MsgID)
+		return nil
+	}))
+	require.NoError(t, storage.CloseIngestLog())
+	require.Equal(t, []uint16{0x0200}, msgIDs)
+
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+	storage.GetGeoCache().DelGeoByPhone(phone)
+}
+
+func TestApplyReplicatedRecords_Retry(t *testing.T) {
+	pc := NewJT808PacketCodec()
+	phone := "223456789018"
+	var records []*wal.Record
+	for i, ts := range []string{"230125145158", "230125145228", "230125145258"} {
+		msg := &model.Msg0200{
+			Header:    &model.MsgHeader{MsgID: 0x0200, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2013}, PhoneNumber: phone},
+			Latitude:  30242718,
+			Longitude: 120111154,
+			Time:      ts,
+		}
+		payload, err := pc.Encode(msg)
+		require.NoError(t, err)
+		pkt, err := pc.Decode(payload)
+		require.NoError(t, err)
+		data, err := json.Marshal(&storage.IngestRecord{RecvTime: time.Now(), Header: pkt.Header, Body: pkt.Body})
+		require.NoError(t, err)
+		records = append(records, &wal.Record{Seq: uint64(i + 1), Data: data})
+	}
+
+	appliedSeq.Store(0)
+	dir := t.TempDir()
+	require.NoError(t, storage.OpenIngestLog(dir, nil))
+	require.NoError(t, ApplyReplicatedRecords(records[:2]))
+	// 备节点应用失败后从检查点重试，已写入和已应用的记录不再重复
+	require.NoError(t, ApplyReplicatedRecords(records))
+	require.Equal(t, uint64(3), storage.ReplicatedSeq())
+	require.Len(t, storage.GetGeoCache().GetGeoRingByPhone(phone).All(), 3)
+	require.NoError(t, storage.CloseIngestLog())
+
+	var seqs []uint64
+	require.NoError(t, storage.OpenIngestLog(dir, nil))
+	require.NoError(t, storage.ReplayIngestLog(func(rec *storage.IngestRecord) error {
+		seqs = append(seqs, rec.Seq)
+		return nil
+	}))
+	require.NoError(t, storage.CloseIngestLog())
+	require.Equal(t, []uint64{1, 2, 3}, seqs)
+
+	storage.GetGeoCache().DelGeoByPhone(phone)
+}
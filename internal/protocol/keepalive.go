@@ -62,6 +62,7 @@ func (j *CheckDeviceJob) Run() {
 // 检查设备保活
 // 1. 当前在线，保活失效，改为离线，断开tcp连接
 // 2. 当前离线，缓存保留3倍保活时间
+// 3. 手机定位终端离线后不清除缓存
 func checkDeviceKeepalive(t *KeepaliveTimer, devicePhone string) {
 	log.Debug().Str("device", devicePhone).Msg("Check device keepalive status")
 	cache := storage.GetDeviceCache()
@@ -70,14 +71,22 @@ func checkDeviceKeepalive(t *KeepaliveTimer, devicePhone string) {
 	if errors.Is(err, storage.ErrDeviceNotFound) {
 		log.Debug().Str("device", devicePhone).Msg("Fail to find device cache")
 		t.Cancel(devicePhone)
+		return
 	}
 	if d.ShouleTurnOffline() {
 		// 保活失效
+		observeStatus(d.Phone, d.Status, model.DeviceStatusOffline, model.StatusReasonKeepaliveExpired)
 		d.Status = model.DeviceStatusOffline
 		cache.CacheDevice(d)
 		log.Debug().Str("device", devicePhone).Msg("Turn offline for device keepalive expired")
+		if d.TransProto == model.HTTPProto {
+			// 手机定位终端由管理接口登记，离线后保留缓存，再次上报时重新注册保活任务
+			t.Cancel(devicePhone)
+		}
 	} else if d.ShouldClear() {
-		d.Conn.Close()
+		if d.Conn != nil {
+			d.Conn.Close()
+		}
 		cache.DelDeviceByPhone(devicePhone)
 		gisCache.DelGeoByPhone(devicePhone)
 		log.Debug().Str("device", d.Phone).Msg("Clear cache and close connection after device being offline for a long time")
@@ -0,0 +1,45 @@
+package model
+
+// 0x0200报警标志位的含义，按bit顺序，参考JT808-2019表25
+var alarmSignNames = [32]string{
+	"emergency",             // bit0 紧急报警
+	"overspeed",             // bit1 超速报警
+	"fatigue_driving",       // bit2 疲劳驾驶报警
+	"dangerous_driving",     // bit3 危险驾驶行为报警
+	"gnss_fault",            // bit4 GNSS模块发生故障
+	"gnss_antenna_cut",      // bit5 GNSS天线未接或被剪断
+	"gnss_antenna_short",    // bit6 GNSS天线短路
+	"power_undervoltage",    // bit7 终端主电源欠压
+	"power_off",             // bit8 终端主电源掉电
+	"display_fault",         // bit9 终端LCD或显示器故障
+	"tts_fault",             // bit10 TTS模块故障
+	"camera_fault",          // bit11 摄像头故障
+	"ic_card_fault",         // bit12 道路运输证IC卡模块故障
+	"overspeed_warning",     // bit13 超速预警
+	"fatigue_warning",       // bit14 疲劳驾驶预警
+	"illegal_driving",       // bit15 违规行驶报警
+	"tire_pressure_warning", // bit16 胎压预警
+	"right_blind_spot",      // bit17 右转盲区异常报警
+	"driving_timeout",       // bit18 当天累计驾驶超时
+	"parking_timeout",       // bit19 超时停车
+	"area_in_out",           // bit20 进出区域
+	"route_in_out",          // bit21 进出路线
+	"route_time_abnormal",   // bit22 路段行驶时间不足/过长
+	"route_deviation",       // bit23 路线偏离报警
+	"vss_fault",             // bit24 车辆VSS故障
+	"fuel_abnormal",         // bit25 车辆油量异常
+	"vehicle_stolen",        // bit26 车辆被盗
+	"illegal_ignition",      // bit27 车辆非法点火
+	"illegal_displacement",  // bit28 车辆非法位移
+	"collision_warning",     // bit29 碰撞预警
+	"rollover_warning",      // bit30 侧翻预警
+	"illegal_door_open",     // bit31 非法开门报警
+}
+
+// 报警标志位对应的报警名称
+func AlarmSignName(bit int) string {
+	if bit < 0 || bit >= len(alarmSignNames) {
+		return ""
+	}
+	return alarmSignNames[bit]
+}
@@ -4,6 +4,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
 )
 
@@ -15,11 +16,33 @@ const (
 	DeviceStatusSleeping DeviceStatus = 2
 )
 
+func (s DeviceStatus) String() string {
+	switch s {
+	case DeviceStatusOnline:
+		return "online"
+	case DeviceStatusSleeping:
+		return "sleeping"
+	default:
+		return "offline"
+	}
+}
+
+// 终端状态变化的原因
+const (
+	StatusReasonAuth             = "auth"
+	StatusReasonACCOff           = "acc_off"
+	StatusReasonACCOn            = "acc_on"
+	StatusReasonKeepaliveExpired = "keepalive_expired"
+	StatusReasonLogout           = "logout"
+	StatusReasonTracker          = "tracker" // 手机定位终端通过HTTP上报位置
+)
+
 // 终端设备的基础属性信息，用于数据缓存、持久化和保活相关流程处理
 type Device struct {
 	ID    string `json:"id"` // ID是否可重复？
 	Plate string `json:"plate"`
 	Phone string `json:"phone"` // 默认通过PhoneNumber来索引设备
+	Name  string `json:"name"`  // 车辆名称，由车辆档案或管理接口设置
 
 	// 连接信息
 
@@ -37,6 +60,9 @@ type Device struct {
 	AuthCode        string      `json:"authcode"`
 	IMEI            string      `json:"imei"`
 	SoftwareVersion string      `json:"softwareVersion"` // 终端软件版本号(非jt808协议版本)
+	RegionCode      string      `json:"regionCode"`      // 注册时上报的行政区划代码
+
+	Charset charset.Charset `json:"charset"` // 字符串字段的字符集，为空时自动识别
 }
 
 func NewDevice(in *Msg0100, session *Session) *Device {
@@ -52,6 +78,8 @@ func NewDevice(in *Msg0100, session *Session) *Device {
 		Status:          DeviceStatusOffline,
 		VersionDesc:     in.Header.Attr.VersionDesc,
 		ProtocolVersion: in.Header.ProtocolVersion,
+		RegionCode:      in.RegionCode(),
+		Charset:         in.Header.Charset,
 	}
 }
 
@@ -72,6 +100,11 @@ type DeviceGeo struct {
 	Location *Location `json:"location"`
 	Drive    *Drive    `json:"drive"`
 	Time     time.Time `json:"time"`
+
+	AlarmSign  uint32   `json:"alarmSign"`            // 报警标志位
+	Mileage    *float64 `json:"mileage,omitempty"`    // 里程，单位km，未上报时为nil
+	Fuel       *float64 `json:"fuel,omitempty"`       // 油量，单位L，未上报时为nil
+	Satellites *uint8   `json:"satellites,omitempty"` // GNSS定位卫星数，未上报时为nil
 }
 
 func (dg *DeviceGeo) Decode(phone string, m *Msg0200) error {
@@ -86,6 +119,21 @@ func (dg *DeviceGeo) Decode(phone string, m *Msg0200) error {
 	driveInstance.Decode(m)
 	dg.Drive = driveInstance
 	dg.Time = hex.ParseTime(m.Time)
+	dg.AlarmSign = m.AlarmSign
+	if extra := m.GetExtra(ExtraIDMileage); extra != nil && len(extra.Data) == 4 {
+		idx := 0
+		mileage := float64(hex.ReadDoubleWord(extra.Data, &idx)) / 10
+		dg.Mileage = &mileage
+	}
+	if extra := m.GetExtra(ExtraIDFuel); extra != nil && len(extra.Data) == 2 {
+		idx := 0
+		fuel := float64(hex.ReadWord(extra.Data, &idx)) / 10
+		dg.Fuel = &fuel
+	}
+	if extra := m.GetExtra(ExtraIDGNSS); extra != nil && len(extra.Data) == 1 {
+		satellites := extra.Data[0]
+		dg.Satellites = &satellites
+	}
 	return nil
 }
 
@@ -196,8 +244,8 @@ func (g *GeoMeta) Encode() uint32 {
 	var bitNum uint32
 	bitNum += uint32(g.ACCStatus)
 	bitNum += uint32(g.LocationStatus) << 1
-	bitNum += uint32(g.LongitudeType) << 2
-	bitNum += uint32(g.LatitudeType) << 3
+	bitNum += uint32(g.LatitudeType) << 2
+	bitNum += uint32(g.LongitudeType) << 3
 	bitNum += uint32(g.OperatingStatus) << 4
 	bitNum += uint32(g.GeoEncryptionStatus) << 5
 	bitNum += uint32(g.LoadStatus) << 8
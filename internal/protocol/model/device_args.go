@@ -2,9 +2,9 @@ package model
 
 import (
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 var (
@@ -38,7 +38,7 @@ func (a *DeviceArgs) Encode() (pkt []byte, err error) {
 		argBytes, err := arg.Encode()
 		if err != nil {
 			// skip this err
-			log.Error().Err(err).Str("device", a.devicePhone).Msg("Fail to encode device arg")
+			logger.For(logger.ModuleCodec).Error().Err(err).Str("device", a.devicePhone).Msg("Fail to encode device arg")
 			continue
 		}
 		pkt = hex.WriteBytes(pkt, argBytes)
@@ -5,9 +5,10 @@ import (
 	"sort"
 
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 var (
@@ -20,6 +21,8 @@ type DeviceParams struct {
 	DevicePhone string       `json:"-"`        // 关联device phone
 	ParamCnt    uint8        `json:"paramCnt"` // 参数项个数
 	Params      []*ParamData `json:"params"`   // 参数项列表
+
+	Charset charset.Charset `json:"-"` // 字符串参数的字符集
 }
 
 func (p *DeviceParams) Decode(phone string, cnt uint8, pkt []byte) error {
@@ -28,7 +31,7 @@ func (p *DeviceParams) Decode(phone string, cnt uint8, pkt []byte) error {
 	idx := 0
 	for i := 0; i < int(cnt); i++ {
 		param := &ParamData{}
-		err := param.Decode(pkt, &idx)
+		err := param.Decode(pkt, &idx, p.Charset)
 		if err != nil {
 			return err
 		}
@@ -41,10 +44,10 @@ func (p *DeviceParams) Decode(phone string, cnt uint8, pkt []byte) error {
 func (p *DeviceParams) Encode() (pkt []byte, err error) {
 	pkt = hex.WriteByte(pkt, p.ParamCnt)
 	for _, arg := range p.Params {
-		paramBytes, err := arg.Encode()
+		paramBytes, err := arg.Encode(p.Charset)
 		if err != nil {
 			// skip this err
-			log.Error().Err(err).Str("device", p.DevicePhone).Msg("Fail to encode device param")
+			logger.For(logger.ModuleCodec).Error().Err(err).Str("device", p.DevicePhone).Msg("Fail to encode device param")
 			continue
 		}
 		pkt = hex.WriteBytes(pkt, paramBytes)
@@ -74,38 +77,79 @@ func (p *DeviceParams) Update(newParams *DeviceParams) {
 	p.ParamCnt = uint8(len(mergeParams))
 }
 
+// 合并终端应答的参数，已有的参数项覆盖，新的参数项追加
+func (p *DeviceParams) Merge(newParams *DeviceParams) {
+	paramMap := make(map[uint32]*ParamData)
+	for _, param := range p.Params {
+		paramMap[param.ParamID] = param
+	}
+	for _, newParam := range newParams.Params {
+		paramMap[newParam.ParamID] = newParam
+	}
+	mergeParams := make([]*ParamData, 0, len(paramMap))
+	for _, param := range paramMap {
+		mergeParams = append(mergeParams, param)
+	}
+	sort.Slice(mergeParams, func(i, j int) bool {
+		return mergeParams[i].ParamID < mergeParams[j].ParamID
+	})
+	p.Params = mergeParams
+	p.ParamCnt = uint8(len(mergeParams))
+}
+
+// 读取数值类型的参数
+func (p *DeviceParams) GetUint32(id uint32) (uint32, bool) {
+	for _, param := range p.Params {
+		if param.ParamID != id {
+			continue
+		}
+		switch v := param.ParamValue.(type) {
+		case uint8:
+			return uint32(v), true
+		case uint16:
+			return uint32(v), true
+		case uint32:
+			return v, true
+		case float64: // 经过json反序列化
+			return uint32(v), true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
 type ParamData struct {
 	ParamID    uint32 `json:"paramId"`    // 参数ID
 	ParamLen   uint8  `json:"paramLen"`   // 参数长度
 	ParamValue any    `json:"paramValue"` // 参数值
 }
 
-func (p *ParamData) Decode(pkt []byte, idx *int) error {
+func (p *ParamData) Decode(pkt []byte, idx *int, cs charset.Charset) error {
 	p.ParamID = hex.ReadDoubleWord(pkt, idx)
 	p.ParamLen = hex.ReadByte(pkt, idx)
 	fn, ok := argTable[p.ParamID]
 	if !ok {
-		log.Warn().Str("ParamID", fmt.Sprintf("0x%04x", p.ParamID)).Err(ErrParamIDNotSupportted).Msg("skip it")
+		logger.For(logger.ModuleCodec).Warn().Str("ParamID", fmt.Sprintf("0x%04x", p.ParamID)).Err(ErrParamIDNotSupportted).Msg("skip it")
 	}
-	p.ParamValue = fn.decode(pkt, idx, int(p.ParamLen))
+	p.ParamValue = fn.decode(pkt, idx, int(p.ParamLen), cs)
 	return nil
 }
 
-func (p *ParamData) Encode() (pkt []byte, err error) {
+func (p *ParamData) Encode(cs charset.Charset) (pkt []byte, err error) {
 	pkt = hex.WriteDoubleWord(pkt, p.ParamID)
 	if fn, ok := argTable[p.ParamID]; ok {
-		value := fn.encode(p.ParamValue)
+		value := fn.encode(p.ParamValue, cs)
 		pkt = hex.WriteByte(pkt, uint8(len(value)))
 		pkt = hex.WriteBytes(pkt, value)
 		return pkt, nil
 	}
-	log.Warn().Str("ParamID", fmt.Sprintf("0x%04x", p.ParamID)).Err(ErrParamIDNotSupportted).Msg("skip it")
+	logger.For(logger.ModuleCodec).Warn().Str("ParamID", fmt.Sprintf("0x%04x", p.ParamID)).Err(ErrParamIDNotSupportted).Msg("skip it")
 	return nil, ErrParamIDNotSupportted
 }
 
 type paramFn struct {
-	decode func([]byte, *int, int) any
-	encode func(any) (pkt []byte)
+	decode func([]byte, *int, int, charset.Charset) any
+	encode func(any, charset.Charset) (pkt []byte)
 }
 
 // !!!特别注意，any类型被encoding/json Unmarshal后，会转为默认的类型，如下:
@@ -153,18 +197,20 @@ func writeDoubleWordAny(pkt []byte, num any) []byte {
 }
 
 var (
-	decodeByte       = func(b []byte, idx *int, paramLen int) any { return hex.ReadByte(b, idx) }
-	encodeByte       = func(a any) (pkt []byte) { return writeByteAny(pkt, a) }
-	decodeWord       = func(b []byte, idx *int, paramLen int) any { return hex.ReadWord(b, idx) }
-	encodeWord       = func(a any) (pkt []byte) { return writeWordAny(pkt, a) }
-	decodeDoubleWord = func(b []byte, idx *int, paramLen int) any { return hex.ReadDoubleWord(b, idx) }
-	encodeDoubleWord = func(a any) (pkt []byte) { return writeDoubleWordAny(pkt, a) }
-	decodeBytes      = func(b []byte, idx *int, paramLen int) any { return hex.ReadBCD(b, idx, paramLen) } // transform bytes to string
-	encodeBytes      = func(a any) (pkt []byte) { return hex.WriteBCD(pkt, a.(string)) }                   // transform string to bytes
-	decodeString     = func(b []byte, idx *int, paramLen int) any { return hex.ReadString(b, idx, paramLen) }
-	encodeString     = func(a any) (pkt []byte) { return hex.WriteString(pkt, a.(string)) }
-	decodeGBK        = func(b []byte, idx *int, paramLen int) any { return hex.ReadGBK(b, idx, paramLen) }
-	encodeGBK        = func(a any) (pkt []byte) { return hex.WriteGBK(pkt, a.(string)) }
+	decodeByte       = func(b []byte, idx *int, paramLen int, _ charset.Charset) any { return hex.ReadByte(b, idx) }
+	encodeByte       = func(a any, _ charset.Charset) (pkt []byte) { return writeByteAny(pkt, a) }
+	decodeWord       = func(b []byte, idx *int, paramLen int, _ charset.Charset) any { return hex.ReadWord(b, idx) }
+	encodeWord       = func(a any, _ charset.Charset) (pkt []byte) { return writeWordAny(pkt, a) }
+	decodeDoubleWord = func(b []byte, idx *int, paramLen int, _ charset.Charset) any { return hex.ReadDoubleWord(b, idx) }
+	encodeDoubleWord = func(a any, _ charset.Charset) (pkt []byte) { return writeDoubleWordAny(pkt, a) }
+	decodeBytes      = func(b []byte, idx *int, paramLen int, _ charset.Charset) any { return hex.ReadBCD(b, idx, paramLen) } // transform bytes to string
+	encodeBytes      = func(a any, _ charset.Charset) (pkt []byte) { return hex.WriteBCD(pkt, a.(string)) }                   // transform string to bytes
+	decodeString     = func(b []byte, idx *int, paramLen int, _ charset.Charset) any { return hex.ReadString(b, idx, paramLen) }
+	encodeString     = func(a any, _ charset.Charset) (pkt []byte) { return hex.WriteString(pkt, a.(string)) }
+	decodeGBK        = func(b []byte, idx *int, paramLen int, cs charset.Charset) any {
+		return hex.ReadText(b, idx, paramLen, cs)
+	}
+	encodeGBK = func(a any, cs charset.Charset) (pkt []byte) { return hex.WriteText(pkt, a.(string), cs) }
 )
 
 var argTable = map[uint32]*paramFn{
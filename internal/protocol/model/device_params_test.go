@@ -38,3 +38,25 @@ func TestDeviceArgs_Decode(t *testing.T) {
 		})
 	}
 }
+
+func TestDeviceParams_Merge(t *testing.T) {
+	p := &DeviceParams{Params: []*ParamData{
+		{ParamID: 0x0001, ParamLen: 4, ParamValue: uint32(10)},
+		{ParamID: 0x0029, ParamLen: 4, ParamValue: uint32(30)},
+	}}
+	p.Merge(&DeviceParams{Params: []*ParamData{
+		{ParamID: 0x0029, ParamLen: 4, ParamValue: float64(10)},
+		{ParamID: 0x0027, ParamLen: 4, ParamValue: uint32(600)},
+	}})
+	require.Equal(t, uint8(3), p.ParamCnt)
+	require.Equal(t, uint32(0x0027), p.Params[1].ParamID)
+
+	v, ok := p.GetUint32(0x0029)
+	require.True(t, ok)
+	require.Equal(t, uint32(10), v)
+	v, ok = p.GetUint32(0x0027)
+	require.True(t, ok)
+	require.Equal(t, uint32(600), v)
+	_, ok = p.GetUint32(0x0030)
+	require.False(t, ok)
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+const (
+	FileStreamMagic     uint32 = 0x30316364 // 码流帧头标识
+	fileStreamNameLen          = 50
+	FileStreamHeaderLen        = 4 + fileStreamNameLen + 4 + 4
+)
+
+var ErrFileStreamMagic = errors.New("Invalid file stream magic")
+
+// 主动安全 附件文件数据码流，不经过JT808的转义和校验，直接写入连接
+type FileStreamFrame struct {
+	FileName string `json:"fileName"` // 文件名称，50个字节
+	Offset   uint32 `json:"offset"`   // 数据偏移量
+	Data     []byte `json:"-"`        // 数据体
+}
+
+// 判断数据是否以码流帧头开始，用于和0x7e开始的JT808消息区分
+func IsFileStream(head []byte) bool {
+	return len(head) >= 4 && binary.BigEndian.Uint32(head) == FileStreamMagic
+}
+
+// 解析码流帧头，返回文件名、偏移量和数据长度
+func DecodeFileStreamHeader(head []byte) (name string, offset, length uint32, err error) {
+	if len(head) < FileStreamHeaderLen || !IsFileStream(head) {
+		return "", 0, 0, ErrFileStreamMagic
+	}
+	idx := 4
+	name = strings.TrimRight(hex.ReadString(head, &idx, fileStreamNameLen), "\x00")
+	offset = hex.ReadDoubleWord(head, &idx)
+	length = hex.ReadDoubleWord(head, &idx)
+	return name, offset, length, nil
+}
+
+func (f *FileStreamFrame) Encode() (pkt []byte) {
+	pkt = hex.WriteDoubleWord(pkt, FileStreamMagic)
+	pkt = writeFixedString(pkt, f.FileName, fileStreamNameLen)
+	pkt = hex.WriteDoubleWord(pkt, f.Offset)
+	pkt = hex.WriteDoubleWord(pkt, uint32(len(f.Data)))
+	return hex.WriteBytes(pkt, f.Data)
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/pkg/errors"
 
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
 )
 
@@ -20,12 +21,16 @@ type MsgHeader struct {
 	SerialNumber    uint16            `json:"serialNumber"`    // 消息流水号
 	Frag            *MsgFragmentation `json:"frag"`            // 消息包封装项
 
-	Idx int `json:"-"` // 读取的packet header下标ID
+	Idx     int             `json:"-"` // 读取的packet header下标ID
+	Charset charset.Charset `json:"-"` // 消息体中字符串的字符集，解码前由平台按设备配置指定
 }
 
 // 将[]byte解码成消息头结构体
 func (h *MsgHeader) Decode(pkt []byte) error {
 	var idx int
+	if len(pkt) < 4 {
+		return ErrDecodeHeader
+	}
 
 	h.MsgID = hex.ReadWord(pkt, &idx) // 消息id [0,2)位
 
@@ -35,6 +40,18 @@ func (h *MsgHeader) Decode(pkt []byte) error {
 		return ErrDecodeHeader
 	}
 
+	// 消息头长度不足时不再读取，避免越界
+	headerLen := 4 + 6 + 2
+	if h.Attr.VersionDesc == Version2019 {
+		headerLen = 4 + 1 + 10 + 2
+	}
+	if h.Attr.PacketFragmentedDesc {
+		headerLen += 4
+	}
+	if len(pkt) < headerLen {
+		return ErrDecodeHeader
+	}
+
 	if h.Attr.VersionDesc == Version2019 {
 		h.ProtocolVersion = hex.ReadByte(pkt, &idx) // 2019版本，协议版本号 第4位
 	}
@@ -213,9 +230,14 @@ func GenMsgHeader(d *Device, msgID, serialNumber uint16) *MsgHeader {
 			PacketFragmented: 0,
 			VersionSign:      versionDecode(d.VersionDesc),
 			Extra:            0,
+
+			EncryptionDesc:       EncryptionNone,
+			PacketFragmentedDesc: PacketFragmentedFalse,
+			VersionDesc:          d.VersionDesc, // 编码时按版本决定是否写入协议版本号
 		},
 		ProtocolVersion: d.ProtocolVersion,
 		PhoneNumber:     d.Phone,
 		SerialNumber:    serialNumber,
+		Charset:         d.Charset,
 	}
 }
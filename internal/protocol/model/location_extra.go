@@ -0,0 +1,223 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 位置附加信息ID
+const (
+	ExtraIDMileage uint8 = 0x01 // 里程，DWORD，1/10km
+	ExtraIDFuel    uint8 = 0x02 // 油量，WORD，1/10L
+	ExtraIDSpeed   uint8 = 0x03 // 行驶记录功能获取的速度，WORD，1/10km/h
+	ExtraIDGNSS    uint8 = 0x31 // GNSS定位卫星数，BYTE
+	ExtraIDADAS    uint8 = 0x64 // 高级驾驶辅助系统报警信息
+	ExtraIDDSM     uint8 = 0x65 // 驾驶员状态监测系统报警信息
+)
+
+// 主动安全报警标志状态
+const (
+	AlarmFlagNone  uint8 = 0x00 // 不可用
+	AlarmFlagStart uint8 = 0x01 // 开始标志
+	AlarmFlagEnd   uint8 = 0x02 // 结束标志
+)
+
+// 位置附加信息项
+type LocationExtra struct {
+	ID     uint8  `json:"id"`     // 附加信息ID
+	Length uint8  `json:"length"` // 附加信息长度
+	Data   []byte `json:"data"`   // 附加信息
+}
+
+func decodeLocationExtras(pkt []byte, idx *int) []*LocationExtra {
+	var extras []*LocationExtra
+	for *idx+2 <= len(pkt) {
+		extra := &LocationExtra{}
+		extra.ID = hex.ReadByte(pkt, idx)
+		extra.Length = hex.ReadByte(pkt, idx)
+		if *idx+int(extra.Length) > len(pkt) {
+			break // 长度不合法，丢弃剩余数据
+		}
+		extra.Data = hex.ReadBytes(pkt, idx, int(extra.Length))
+		extras = append(extras, extra)
+	}
+	return extras
+}
+
+func (e *LocationExtra) Encode(pkt []byte) []byte {
+	pkt = hex.WriteByte(pkt, e.ID)
+	pkt = hex.WriteByte(pkt, uint8(len(e.Data)))
+	return hex.WriteBytes(pkt, e.Data)
+}
+
+const (
+	terminalIDLen      = 7  // 主动安全报文中的终端ID长度
+	AlarmNumberLen     = 32 // 平台分配的报警编号长度
+	activeSafetyLength = 47 // ADAS和DSM报警信息长度
+)
+
+// 报警标识号，终端对每个报警生成，平台下发附件上传指令时原样带回
+type AlarmIdentity struct {
+	TerminalID      string `json:"terminalId"`      // 终端ID，7个字节
+	Time            string `json:"time"`            // YY-MM-DD-hh-mm-ss(GMT+8 时间)
+	SerialNumber    uint8  `json:"serialNumber"`    // 同一时间点报警的序号，从0循环累加
+	AttachmentCount uint8  `json:"attachmentCount"` // 报警对应的附件数量
+}
+
+func (a *AlarmIdentity) Decode(pkt []byte, idx *int) {
+	a.TerminalID = strings.TrimRight(hex.ReadString(pkt, idx, terminalIDLen), "\x00")
+	a.Time = hex.ReadBCD(pkt, idx, 6)
+	a.SerialNumber = hex.ReadByte(pkt, idx)
+	a.AttachmentCount = hex.ReadByte(pkt, idx)
+	*idx++ // 预留
+}
+
+func (a *AlarmIdentity) Encode(pkt []byte) []byte {
+	pkt = writeFixedString(pkt, a.TerminalID, terminalIDLen)
+	pkt = hex.WriteBCD(pkt, a.Time)
+	pkt = hex.WriteByte(pkt, a.SerialNumber)
+	pkt = hex.WriteByte(pkt, a.AttachmentCount)
+	return hex.WriteByte(pkt, 0) // 预留
+}
+
+// 用于在终端和平台两侧关联同一个报警
+func (a *AlarmIdentity) Key() string {
+	return fmt.Sprintf("%s_%s_%d", a.TerminalID, a.Time, a.SerialNumber)
+}
+
+// 0x64 高级驾驶辅助系统报警信息
+type ADASAlarm struct {
+	AlarmID         uint32         `json:"alarmId"`         // 报警ID，按照报警先后从0开始循环累加
+	FlagStatus      uint8          `json:"flagStatus"`      // 标志状态，0不可用，1开始，2结束
+	AlarmType       uint8          `json:"alarmType"`       // 报警/事件类型，1前向碰撞，2车道偏离，3车距过近，4行人碰撞，5频繁变道，6道路标识超限，7障碍物，0x10道路标志识别，0x11主动抓拍
+	AlarmLevel      uint8          `json:"alarmLevel"`      // 报警级别，1一级，2二级
+	FrontCarSpeed   uint8          `json:"frontCarSpeed"`   // 前车车速，km/h，仅类型1和2有效
+	FrontDistance   uint8          `json:"frontDistance"`   // 前车/行人距离，100ms，仅类型1、2和4有效
+	DeviationType   uint8          `json:"deviationType"`   // 偏离类型，1左侧偏离，2右侧偏离，仅类型2有效
+	RoadSignType    uint8          `json:"roadSignType"`    // 道路标志识别类型，1限速标志，2限高标志，3限重标志
+	RoadSignData    uint8          `json:"roadSignData"`    // 道路标志识别数据
+	Speed           uint8          `json:"speed"`           // 车速，km/h
+	Altitude        uint16         `json:"altitude"`        // 高程，m
+	Latitude        uint32         `json:"latitude"`        // 纬度，百万分之一度
+	Longitude       uint32         `json:"longitude"`       // 经度，百万分之一度
+	Time            string         `json:"time"`            // YY-MM-DD-hh-mm-ss(GMT+8 时间)
+	VehicleStatus   uint16         `json:"vehicleStatus"`   // 车辆状态
+	AlarmIdentifier *AlarmIdentity `json:"alarmIdentifier"` // 报警标识号
+}
+
+func (a *ADASAlarm) Decode(pkt []byte) error {
+	if len(pkt) < activeSafetyLength {
+		return ErrDecodeMsg
+	}
+	idx := 0
+	a.AlarmID = hex.ReadDoubleWord(pkt, &idx)
+	a.FlagStatus = hex.ReadByte(pkt, &idx)
+	a.AlarmType = hex.ReadByte(pkt, &idx)
+	a.AlarmLevel = hex.ReadByte(pkt, &idx)
+	a.FrontCarSpeed = hex.ReadByte(pkt, &idx)
+	a.FrontDistance = hex.ReadByte(pkt, &idx)
+	a.DeviationType = hex.ReadByte(pkt, &idx)
+	a.RoadSignType = hex.ReadByte(pkt, &idx)
+	a.RoadSignData = hex.ReadByte(pkt, &idx)
+	a.Speed = hex.ReadByte(pkt, &idx)
+	a.Altitude = hex.ReadWord(pkt, &idx)
+	a.Latitude = hex.ReadDoubleWord(pkt, &idx)
+	a.Longitude = hex.ReadDoubleWord(pkt, &idx)
+	a.Time = hex.ReadBCD(pkt, &idx, 6)
+	a.VehicleStatus = hex.ReadWord(pkt, &idx)
+	a.AlarmIdentifier = &AlarmIdentity{}
+	a.AlarmIdentifier.Decode(pkt, &idx)
+	return nil
+}
+
+func (a *ADASAlarm) Encode() (pkt []byte) {
+	pkt = hex.WriteDoubleWord(pkt, a.AlarmID)
+	pkt = hex.WriteByte(pkt, a.FlagStatus)
+	pkt = hex.WriteByte(pkt, a.AlarmType)
+	pkt = hex.WriteByte(pkt, a.AlarmLevel)
+	pkt = hex.WriteByte(pkt, a.FrontCarSpeed)
+	pkt = hex.WriteByte(pkt, a.FrontDistance)
+	pkt = hex.WriteByte(pkt, a.DeviationType)
+	pkt = hex.WriteByte(pkt, a.RoadSignType)
+	pkt = hex.WriteByte(pkt, a.RoadSignData)
+	pkt = hex.WriteByte(pkt, a.Speed)
+	pkt = hex.WriteWord(pkt, a.Altitude)
+	pkt = hex.WriteDoubleWord(pkt, a.Latitude)
+	pkt = hex.WriteDoubleWord(pkt, a.Longitude)
+	pkt = hex.WriteBCD(pkt, a.Time)
+	pkt = hex.WriteWord(pkt, a.VehicleStatus)
+	return a.AlarmIdentifier.Encode(pkt)
+}
+
+func (a *ADASAlarm) ToExtra() *LocationExtra {
+	data := a.Encode()
+	return &LocationExtra{ID: ExtraIDADAS, Length: uint8(len(data)), Data: data}
+}
+
+// 0x65 驾驶员状态监测系统报警信息
+type DSMAlarm struct {
+	AlarmID         uint32         `json:"alarmId"`         // 报警ID，按照报警先后从0开始循环累加
+	FlagStatus      uint8          `json:"flagStatus"`      // 标志状态，0不可用，1开始，2结束
+	AlarmType       uint8          `json:"alarmType"`       // 报警/事件类型，1疲劳驾驶，2接打电话，3抽烟，4分神驾驶，5驾驶员异常，0x10自动抓拍，0x11驾驶员变更
+	AlarmLevel      uint8          `json:"alarmLevel"`      // 报警级别，1一级，2二级
+	FatigueDegree   uint8          `json:"fatigueDegree"`   // 疲劳程度，1-10，仅类型1有效
+	Speed           uint8          `json:"speed"`           // 车速，km/h
+	Altitude        uint16         `json:"altitude"`        // 高程，m
+	Latitude        uint32         `json:"latitude"`        // 纬度，百万分之一度
+	Longitude       uint32         `json:"longitude"`       // 经度，百万分之一度
+	Time            string         `json:"time"`            // YY-MM-DD-hh-mm-ss(GMT+8 时间)
+	VehicleStatus   uint16         `json:"vehicleStatus"`   // 车辆状态
+	AlarmIdentifier *AlarmIdentity `json:"alarmIdentifier"` // 报警标识号
+}
+
+func (a *DSMAlarm) Decode(pkt []byte) error {
+	if len(pkt) < activeSafetyLength {
+		return ErrDecodeMsg
+	}
+	idx := 0
+	a.AlarmID = hex.ReadDoubleWord(pkt, &idx)
+	a.FlagStatus = hex.ReadByte(pkt, &idx)
+	a.AlarmType = hex.ReadByte(pkt, &idx)
+	a.AlarmLevel = hex.ReadByte(pkt, &idx)
+	a.FatigueDegree = hex.ReadByte(pkt, &idx)
+	idx += 4 // 预留
+	a.Speed = hex.ReadByte(pkt, &idx)
+	a.Altitude = hex.ReadWord(pkt, &idx)
+	a.Latitude = hex.ReadDoubleWord(pkt, &idx)
+	a.Longitude = hex.ReadDoubleWord(pkt, &idx)
+	a.Time = hex.ReadBCD(pkt, &idx, 6)
+	a.VehicleStatus = hex.ReadWord(pkt, &idx)
+	a.AlarmIdentifier = &AlarmIdentity{}
+	a.AlarmIdentifier.Decode(pkt, &idx)
+	return nil
+}
+
+func (a *DSMAlarm) Encode() (pkt []byte) {
+	pkt = hex.WriteDoubleWord(pkt, a.AlarmID)
+	pkt = hex.WriteByte(pkt, a.FlagStatus)
+	pkt = hex.WriteByte(pkt, a.AlarmType)
+	pkt = hex.WriteByte(pkt, a.AlarmLevel)
+	pkt = hex.WriteByte(pkt, a.FatigueDegree)
+	pkt = hex.WriteBytes(pkt, make([]byte, 4)) // 预留
+	pkt = hex.WriteByte(pkt, a.Speed)
+	pkt = hex.WriteWord(pkt, a.Altitude)
+	pkt = hex.WriteDoubleWord(pkt, a.Latitude)
+	pkt = hex.WriteDoubleWord(pkt, a.Longitude)
+	pkt = hex.WriteBCD(pkt, a.Time)
+	pkt = hex.WriteWord(pkt, a.VehicleStatus)
+	return a.AlarmIdentifier.Encode(pkt)
+}
+
+func (a *DSMAlarm) ToExtra() *LocationExtra {
+	data := a.Encode()
+	return &LocationExtra{ID: ExtraIDDSM, Length: uint8(len(data)), Data: data}
+}
+
+// 写入定长字符串，不足补0x00，超出截断
+func writeFixedString(pkt []byte, str string, n int) []byte {
+	arr := make([]byte, n)
+	copy(arr, str)
+	return hex.WriteBytes(pkt, arr)
+}
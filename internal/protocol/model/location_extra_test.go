@@ -0,0 +1,123 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsg0200_ActiveSafetyExtras(t *testing.T) {
+	identity := &AlarmIdentity{TerminalID: "1234567", Time: "230301123456", SerialNumber: 2, AttachmentCount: 3}
+	adas := &ADASAlarm{
+		AlarmID:         1,
+		FlagStatus:      AlarmFlagStart,
+		AlarmType:       0x02,
+		AlarmLevel:      1,
+		DeviationType:   1,
+		Speed:           60,
+		Latitude:        30242718,
+		Longitude:       120111154,
+		Time:            "230301123456",
+		AlarmIdentifier: identity,
+	}
+	dsm := &DSMAlarm{
+		AlarmID:         2,
+		FlagStatus:      AlarmFlagStart,
+		AlarmType:       0x01,
+		AlarmLevel:      2,
+		FatigueDegree:   8,
+		Speed:           60,
+		Time:            "230301123456",
+		AlarmIdentifier: identity,
+	}
+	tests := []struct {
+		name   string
+		extras []*LocationExtra
+	}{
+		{
+			name:   "case1: no extras",
+			extras: nil,
+		},
+		{
+			name:   "case2: adas and dsm alarm",
+			extras: []*LocationExtra{{ID: ExtraIDMileage, Length: 4, Data: []byte{0, 0, 1, 0}}, adas.ToExtra(), dsm.ToExtra()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Msg0200{Header: genMsgHeader(MsgID0200), Time: "230301123456", Extras: tt.extras}
+			pkt, err := m.Encode()
+			require.NoError(t, err)
+
+			header := &MsgHeader{}
+			require.NoError(t, header.Decode(pkt))
+			got := &Msg0200{}
+			require.NoError(t, got.Decode(&PacketData{Header: header, Body: pkt[header.Idx:]}))
+			require.Equal(t, tt.extras, got.Extras)
+
+			if tt.extras == nil {
+				return
+			}
+			gotADAS := &ADASAlarm{}
+			require.NoError(t, gotADAS.Decode(got.GetExtra(ExtraIDADAS).Data))
+			require.Equal(t, adas, gotADAS)
+			gotDSM := &DSMAlarm{}
+			require.NoError(t, gotDSM.Decode(got.GetExtra(ExtraIDDSM).Data))
+			require.Equal(t, dsm, gotDSM)
+			require.Equal(t, uint8(activeSafetyLength), got.GetExtra(ExtraIDDSM).Length)
+		})
+	}
+}
+
+func TestMsg9212_Decode(t *testing.T) {
+	m := &Msg9212{
+		Header:      genMsgHeader(MsgID9212),
+		FileName:    "00_64_6402_0_abc.jpg",
+		FileType:    AttachmentTypeImage,
+		Result:      UploadResultRetransmit,
+		Retransmits: []*DataRange{{Offset: 0, Length: 1024}, {Offset: 4096, Length: 512}},
+	}
+	pkt, err := m.Encode()
+	require.NoError(t, err)
+
+	header := &MsgHeader{}
+	require.NoError(t, header.Decode(pkt))
+	got := &Msg9212{}
+	require.NoError(t, got.Decode(&PacketData{Header: header, Body: pkt[header.Idx:]}))
+	require.Equal(t, m.FileName, got.FileName)
+	require.Equal(t, m.Result, got.Result)
+	require.Equal(t, m.Retransmits, got.Retransmits)
+}
+
+func TestMsg0704_Decode(t *testing.T) {
+	items := []*Msg0200{
+		{Latitude: 30242718, Longitude: 120111154, Speed: 600, Time: "230301123456"},
+		{Latitude: 30242818, Longitude: 120111254, Speed: 580, Time: "230301123506",
+			Extras: []*LocationExtra{{ID: ExtraIDMileage, Length: 4, Data: []byte{0, 0, 1, 0}}}},
+	}
+	m := &Msg0704{Header: genMsgHeader(MsgID0704), LocationType: LocationTypeBlindArea, Items: items}
+	pkt, err := m.Encode()
+	require.NoError(t, err)
+
+	header := &MsgHeader{}
+	require.NoError(t, header.Decode(pkt))
+	body := pkt[header.Idx:]
+	got := &Msg0704{}
+	require.NoError(t, got.Decode(&PacketData{Header: header, Body: body}))
+	require.Equal(t, uint16(2), got.Count)
+	require.Equal(t, LocationTypeBlindArea, got.LocationType)
+	require.Len(t, got.Items, 2)
+	length := 3
+	for i, item := range got.Items {
+		require.Equal(t, uint16(MsgID0200), item.Header.MsgID)
+		require.Equal(t, header.PhoneNumber, item.Header.PhoneNumber)
+		item.Header = nil
+		require.Equal(t, items[i], item)
+		length += LocationItemLength(items[i])
+	}
+	require.Equal(t, length, len(body))
+
+	// 数据项长度不合法
+	got = &Msg0704{}
+	require.ErrorIs(t, got.Decode(&PacketData{Header: header, Body: body[:10]}), ErrDecodeMsg)
+}
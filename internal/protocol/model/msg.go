@@ -7,10 +7,21 @@ import (
 const (
 	MsgID0002 = 0x0002
 	MsgID0100 = 0x0100
+	MsgID0102 = 0x0102
 	MsgID0200 = 0x0200
+	MsgID0704 = 0x0704
+	MsgID1210 = 0x1210
+	MsgID1211 = 0x1211
+	MsgID1212 = 0x1212
+	MsgID8001 = 0x8001
 	MsgID8004 = 0x8004
+	MsgID9208 = 0x9208
+	MsgID9212 = 0x9212
 )
 
+// 消息体长度占消息体属性的10位，超过时需要分包
+const MaxBodyLength = 1023
+
 var (
 	ErrDecodeMsg      = errors.New("Fail to decode msg")
 	ErrEncodeMsg      = errors.New("Fail to encode msg")
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/region"
 )
@@ -54,12 +55,22 @@ func (m *Msg0100) Decode(packet *PacketData) error {
 	m.DeviceID = strings.TrimRight(hex.ReadString(pkt, &idx, idLen), cutset)
 
 	m.PlateColor = hex.ReadByte(pkt, &idx)
-	m.PlateNumber = hex.ReadGBK(pkt, &idx, int(m.Header.Attr.BodyLength)-idx)
-	m.LocationDesc = region.Parse(fmt.Sprintf("%02d%04d", m.ProvinceID, m.CityID)).Name
+	plateLen := int(m.Header.Attr.BodyLength) - idx
+	if m.Header.Charset == charset.Auto {
+		// 未指定字符集时按车牌识别，后续消息沿用识别结果
+		m.Header.Charset = charset.Detect(pkt[idx : idx+plateLen])
+	}
+	m.PlateNumber = hex.ReadText(pkt, &idx, plateLen, m.Header.Charset)
+	m.LocationDesc = region.Parse(m.RegionCode()).Name
 
 	return nil
 }
 
+// GBT2260 6位行政区划代码
+func (m *Msg0100) RegionCode() string {
+	return fmt.Sprintf("%02d%04d", m.ProvinceID, m.CityID)
+}
+
 func (m *Msg0100) Encode() (pkt []byte, err error) {
 	pkt = hex.WriteWord(pkt, m.ProvinceID)
 	pkt = hex.WriteWord(pkt, m.CityID)
@@ -110,7 +121,7 @@ func (m *Msg0100) Encode() (pkt []byte, err error) {
 	pkt = append(pkt, id...)
 
 	pkt = hex.WriteByte(pkt, m.PlateColor)
-	pkt = hex.WriteGBK(pkt, m.PlateNumber)
+	pkt = hex.WriteText(pkt, m.PlateNumber, m.Header.Charset)
 
 	pkt, err = writeHeader(m, pkt)
 	return pkt, err
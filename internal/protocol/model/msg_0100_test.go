@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
 )
 
@@ -61,3 +62,38 @@ func TestMsg0100_Encode(t *testing.T) {
 		})
 	}
 }
+
+func TestMsg0100_DecodeCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		encode      charset.Charset
+		decode      charset.Charset
+		plate       string
+		wantCharset charset.Charset
+	}{
+		{name: "case1: detect gbk", encode: charset.GBK, decode: charset.Auto, plate: "京A12345", wantCharset: charset.GBK},
+		{name: "case2: detect utf8", encode: charset.UTF8, decode: charset.Auto, plate: "京A12345", wantCharset: charset.UTF8},
+		{name: "case3: detect gb18030", encode: charset.GB18030, decode: charset.Auto, plate: "㐀A12345", wantCharset: charset.GB18030},
+		{name: "case4: specified utf8", encode: charset.UTF8, decode: charset.UTF8, plate: "京A12345", wantCharset: charset.UTF8},
+		{name: "case5: detect gbk 鲁A", encode: charset.GBK, decode: charset.Auto, plate: "鲁A12345", wantCharset: charset.GBK},
+		{name: "case6: detect gbk 豫B", encode: charset.GBK, decode: charset.Auto, plate: "豫B12345", wantCharset: charset.GBK},
+		{name: "case7: detect utf8 鲁A", encode: charset.UTF8, decode: charset.Auto, plate: "鲁A12345", wantCharset: charset.UTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := genMsgHeader(0x0100)
+			header.Charset = tt.encode
+			m := &Msg0100{Header: header, ManufacturerID: "fake", DeviceMode: "mode", DeviceID: "1234ABC", PlateNumber: tt.plate}
+			pkt, err := m.Encode()
+			require.NoError(t, err)
+
+			h := &MsgHeader{}
+			require.NoError(t, h.Decode(pkt))
+			h.Charset = tt.decode
+			got := &Msg0100{}
+			require.NoError(t, got.Decode(&PacketData{Header: h, Body: pkt[h.Idx:]}))
+			require.Equal(t, tt.plate, got.PlateNumber)
+			require.Equal(t, tt.wantCharset, got.Header.Charset)
+		})
+	}
+}
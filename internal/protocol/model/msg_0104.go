@@ -1,9 +1,8 @@
 package model
 
 import (
-	"github.com/rs/zerolog/log"
-
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 // 查询终端参数应答
@@ -21,10 +20,10 @@ func (m *Msg0104) Decode(packet *PacketData) error {
 	pkt, idx := packet.Body, 0
 	m.AnswerSerialNumber = hex.ReadWord(pkt, &idx)
 	m.AnswerParamCnt = hex.ReadByte(pkt, &idx)
-	m.Parameters = &DeviceParams{}
+	m.Parameters = &DeviceParams{Charset: m.Header.Charset}
 	err := m.Parameters.Decode(m.Header.PhoneNumber, m.AnswerParamCnt, pkt[idx:])
 	if err != nil {
-		log.Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to decode device params")
+		logger.For(logger.ModuleCodec).Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to decode device params")
 		return ErrDecodeMsg
 	}
 	return nil
@@ -33,9 +32,10 @@ func (m *Msg0104) Decode(packet *PacketData) error {
 func (m *Msg0104) Encode() (pkt []byte, err error) {
 	pkt = hex.WriteWord(pkt, m.AnswerSerialNumber)
 	// AnswerParamCnt will be encode in DeviceParams
+	m.Parameters.Charset = m.Header.Charset
 	paramBytes, err := m.Parameters.Encode()
 	if err != nil {
-		log.Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to encode device params")
+		logger.For(logger.ModuleCodec).Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to encode device params")
 		return nil, ErrEncodeMsg
 	}
 	pkt = hex.WriteBytes(pkt, paramBytes)
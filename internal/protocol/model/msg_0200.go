@@ -15,11 +15,19 @@ type Msg0200 struct {
 	Speed      uint16     `json:"speed"`      // 速度，单位为0.1公里每小时(1/10km/h)
 	Direction  uint16     `json:"direction"`  // 方向，0-359，正北为 0，顺时针
 	Time       string     `json:"time"`       // YY-MM-DD-hh-mm-ss(GMT+8 时间)
+
+	Extras []*LocationExtra `json:"extras,omitempty"` // 位置附加信息项列表
 }
 
 func (m *Msg0200) Decode(packet *PacketData) error {
 	m.Header = packet.Header
-	pkt, idx := packet.Body, 0
+	m.decodeBody(packet.Body)
+	return nil
+}
+
+// 解析位置信息汇报消息体，0x0704的每个位置数据项也使用此格式
+func (m *Msg0200) decodeBody(pkt []byte) {
+	idx := 0
 	m.AlarmSign = hex.ReadDoubleWord(pkt, &idx)
 	m.StatusSign = hex.ReadDoubleWord(pkt, &idx)
 	m.Latitude = hex.ReadDoubleWord(pkt, &idx)
@@ -28,10 +36,16 @@ func (m *Msg0200) Decode(packet *PacketData) error {
 	m.Speed = hex.ReadWord(pkt, &idx)
 	m.Direction = hex.ReadWord(pkt, &idx)
 	m.Time = hex.ReadBCD(pkt, &idx, 6)
-	return nil
+	m.Extras = decodeLocationExtras(pkt, &idx)
 }
 
 func (m *Msg0200) Encode() (pkt []byte, err error) {
+	pkt = m.encodeBody(pkt)
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg0200) encodeBody(pkt []byte) []byte {
 	pkt = hex.WriteDoubleWord(pkt, m.AlarmSign)
 	pkt = hex.WriteDoubleWord(pkt, m.StatusSign)
 	pkt = hex.WriteDoubleWord(pkt, m.Latitude)
@@ -40,9 +54,20 @@ func (m *Msg0200) Encode() (pkt []byte, err error) {
 	pkt = hex.WriteWord(pkt, m.Speed)
 	pkt = hex.WriteWord(pkt, m.Direction)
 	pkt = hex.WriteBCD(pkt, m.Time)
+	for _, extra := range m.Extras {
+		pkt = extra.Encode(pkt)
+	}
+	return pkt
+}
 
-	pkt, err = writeHeader(m, pkt)
-	return pkt, err
+// 查找指定ID的附加信息项
+func (m *Msg0200) GetExtra(id uint8) *LocationExtra {
+	for _, extra := range m.Extras {
+		if extra.ID == id {
+			return extra
+		}
+	}
+	return nil
 }
 
 func (m *Msg0200) GetHeader() *MsgHeader {
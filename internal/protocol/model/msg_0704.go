@@ -0,0 +1,71 @@
+package model
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 位置数据类型
+const (
+	LocationTypeNormal    uint8 = 0 // 正常位置批量汇报
+	LocationTypeBlindArea uint8 = 1 // 盲区补报
+)
+
+// 位置基本信息长度，不含附加信息
+const locationBasicLength = 28
+
+// 定位数据批量上传
+type Msg0704 struct {
+	Header       *MsgHeader `json:"header"`
+	Count        uint16     `json:"count"`        // 数据项个数，大于0
+	LocationType uint8      `json:"locationType"` // 位置数据类型，0正常位置批量汇报，1盲区补报
+	Items        []*Msg0200 `json:"items"`        // 位置汇报数据项，每项为长度WORD加0x0200消息体
+}
+
+func (m *Msg0704) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	m.Count = hex.ReadWord(pkt, &idx)
+	m.LocationType = hex.ReadByte(pkt, &idx)
+	for i := 0; i < int(m.Count); i++ {
+		if idx+2 > len(pkt) {
+			return ErrDecodeMsg
+		}
+		length := int(hex.ReadWord(pkt, &idx))
+		if length < locationBasicLength || idx+length > len(pkt) {
+			return ErrDecodeMsg
+		}
+		// 数据项没有独立的消息头，复制批量消息的头部，避免应答修改MsgID时互相影响
+		header := *m.Header
+		header.MsgID = MsgID0200
+		item := &Msg0200{Header: &header}
+		item.decodeBody(hex.ReadBytes(pkt, &idx, length))
+		m.Items = append(m.Items, item)
+	}
+	return nil
+}
+
+func (m *Msg0704) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteWord(pkt, uint16(len(m.Items)))
+	pkt = hex.WriteByte(pkt, m.LocationType)
+	for _, item := range m.Items {
+		body := item.encodeBody(nil)
+		pkt = hex.WriteWord(pkt, uint16(len(body)))
+		pkt = hex.WriteBytes(pkt, body)
+	}
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+// 位置数据项编码后的长度，用于终端按消息体长度上限切分批次
+func LocationItemLength(item *Msg0200) int {
+	return 2 + len(item.encodeBody(nil))
+}
+
+func (m *Msg0704) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg0704) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
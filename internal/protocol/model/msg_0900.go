@@ -0,0 +1,47 @@
+package model
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 透传消息类型
+const (
+	PassthroughGNSS    uint8 = 0x00 // GNSS模块详细定位数据
+	PassthroughICCard  uint8 = 0x0B // 道路运输证IC卡信息，上行为认证请求，下行为认证中心的应答
+	PassthroughSerial1 uint8 = 0x41 // 串口1透传
+	PassthroughSerial2 uint8 = 0x42 // 串口2透传
+)
+
+// 数据上行透传，平台回复0x8001
+type Msg0900 struct {
+	Header          *MsgHeader `json:"header"`
+	PassthroughType uint8      `json:"passthroughType"` // 透传消息类型
+	Data            []byte     `json:"data"`            // 透传消息内容
+}
+
+func (m *Msg0900) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	if len(pkt) < 1 {
+		return ErrDecodeMsg
+	}
+	m.PassthroughType = hex.ReadByte(pkt, &idx)
+	m.Data = append([]byte(nil), pkt[idx:]...)
+	return nil
+}
+
+func (m *Msg0900) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, m.PassthroughType)
+	pkt = hex.WriteBytes(pkt, m.Data)
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg0900) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg0900) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
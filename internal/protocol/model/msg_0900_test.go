@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+func TestMsg0900_Encode(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *Msg0900
+		wantPkt []byte
+	}{
+		{
+			name: "case1: ic card authentication request",
+			msg: &Msg0900{
+				Header:          genMsgHeader(0x0900),
+				PassthroughType: PassthroughICCard,
+				Data:            []byte{0x01, 0x02, 0x03},
+			},
+			wantPkt: hex.Str2Byte("0900400401123456789012345678900001" + "0B010203"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPkt, err := tt.msg.Encode()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPkt, gotPkt)
+
+			got := &Msg0900{}
+			require.NoError(t, got.Decode(&PacketData{Header: tt.msg.Header, Body: gotPkt[17:]}))
+			require.Equal(t, tt.msg, got)
+
+			require.ErrorIs(t, got.Decode(&PacketData{Header: tt.msg.Header}), ErrDecodeMsg)
+		})
+	}
+}
+
+func TestMsg8900_Encode(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *Msg8900
+		wantPkt []byte
+	}{
+		{
+			name: "case1: ic card authentication response",
+			msg: &Msg8900{
+				Header:          genMsgHeader(0x8900),
+				PassthroughType: PassthroughICCard,
+				Data:            []byte{0xAA, 0xBB},
+			},
+			wantPkt: hex.Str2Byte("8900400301123456789012345678900001" + "0BAABB"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPkt, err := tt.msg.Encode()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPkt, gotPkt)
+
+			got := &Msg8900{}
+			require.NoError(t, got.Decode(&PacketData{Header: tt.msg.Header, Body: gotPkt[17:]}))
+			require.Equal(t, tt.msg, got)
+		})
+	}
+}
@@ -36,13 +36,16 @@ func (m *Msg1205) GetHeader() *MsgHeader {
 	return m.Header
 }
 
+// 应答资源列表查询0x9205，或回放请求0x9201
 func (m *Msg1205) GenOutgoing(incoming JT808Msg) error {
-	in, ok := incoming.(*Msg9205)
-	if !ok {
+	switch incoming.(type) {
+	case *Msg9205, *Msg9201:
+	default:
 		return ErrGenOutgoingMsg
 	}
-	m.AnswerSerialNumber = in.Header.SerialNumber
-	m.Header = in.Header
+	header := incoming.GetHeader()
+	m.AnswerSerialNumber = header.SerialNumber
+	m.Header = header
 	m.Header.MsgID = 0x1205
 
 	return nil
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 附件文件类型
+const (
+	AttachmentTypeImage uint8 = 0x00 // 图片
+	AttachmentTypeAudio uint8 = 0x01 // 音频
+	AttachmentTypeVideo uint8 = 0x02 // 视频
+	AttachmentTypeText  uint8 = 0x03 // 文本
+	AttachmentTypeOther uint8 = 0x04 // 其他
+)
+
+type AttachmentInfo struct {
+	FileName string `json:"fileName"` // 文件名称
+	FileSize uint32 `json:"fileSize"` // 文件大小
+}
+
+// 主动安全 报警附件信息消息，终端连接附件服务器后发送
+type Msg1210 struct {
+	Header        *MsgHeader        `json:"header"`
+	TerminalID    string            `json:"terminalId"`    // 终端ID，7个字节
+	AlarmIdentity *AlarmIdentity    `json:"alarmIdentity"` // 报警标识号
+	AlarmNumber   string            `json:"alarmNumber"`   // 平台给报警分配的唯一编号
+	InfoType      uint8             `json:"infoType"`      // 信息类型，0正常报警文件信息，1补传报警文件信息
+	Attachments   []*AttachmentInfo `json:"attachments"`   // 附件信息列表
+}
+
+func (m *Msg1210) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	m.TerminalID = strings.TrimRight(hex.ReadString(pkt, &idx, terminalIDLen), "\x00")
+	m.AlarmIdentity = &AlarmIdentity{}
+	m.AlarmIdentity.Decode(pkt, &idx)
+	m.AlarmNumber = strings.TrimRight(hex.ReadString(pkt, &idx, AlarmNumberLen), "\x00")
+	m.InfoType = hex.ReadByte(pkt, &idx)
+	cnt := int(hex.ReadByte(pkt, &idx))
+	m.Attachments = make([]*AttachmentInfo, 0, cnt)
+	for i := 0; i < cnt; i++ {
+		nameLen := hex.ReadByte(pkt, &idx)
+		m.Attachments = append(m.Attachments, &AttachmentInfo{
+			FileName: hex.ReadString(pkt, &idx, int(nameLen)),
+			FileSize: hex.ReadDoubleWord(pkt, &idx),
+		})
+	}
+	return nil
+}
+
+func (m *Msg1210) Encode() (pkt []byte, err error) {
+	pkt = writeFixedString(pkt, m.TerminalID, terminalIDLen)
+	pkt = m.AlarmIdentity.Encode(pkt)
+	pkt = writeFixedString(pkt, m.AlarmNumber, AlarmNumberLen)
+	pkt = hex.WriteByte(pkt, m.InfoType)
+	pkt = hex.WriteByte(pkt, uint8(len(m.Attachments)))
+	for _, a := range m.Attachments {
+		pkt = hex.WriteByte(pkt, uint8(len(a.FileName)))
+		pkt = hex.WriteString(pkt, a.FileName)
+		pkt = hex.WriteDoubleWord(pkt, a.FileSize)
+	}
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg1210) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg1210) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 主动安全 文件信息上传，每个附件开始传输前发送
+type Msg1211 struct {
+	Header   *MsgHeader `json:"header"`
+	FileName string     `json:"fileName"` // 文件名称
+	FileType uint8      `json:"fileType"` // 文件类型，0图片，1音频，2视频，3文本，4其他
+	FileSize uint32     `json:"fileSize"` // 文件大小
+}
+
+func (m *Msg1211) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	nameLen := hex.ReadByte(pkt, &idx)
+	m.FileName = hex.ReadString(pkt, &idx, int(nameLen))
+	m.FileType = hex.ReadByte(pkt, &idx)
+	m.FileSize = hex.ReadDoubleWord(pkt, &idx)
+	return nil
+}
+
+func (m *Msg1211) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, uint8(len(m.FileName)))
+	pkt = hex.WriteString(pkt, m.FileName)
+	pkt = hex.WriteByte(pkt, m.FileType)
+	pkt = hex.WriteDoubleWord(pkt, m.FileSize)
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg1211) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg1211) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
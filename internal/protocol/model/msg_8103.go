@@ -1,9 +1,8 @@
 package model
 
 import (
-	"github.com/rs/zerolog/log"
-
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 // 设置终端参数
@@ -18,10 +17,10 @@ func (m *Msg8103) Decode(packet *PacketData) error {
 	m.Header = packet.Header
 	pkt, idx := packet.Body, 0
 	m.ParamCnt = hex.ReadByte(pkt, &idx)
-	m.Parameters = &DeviceParams{}
+	m.Parameters = &DeviceParams{Charset: m.Header.Charset}
 	err := m.Parameters.Decode(m.Header.PhoneNumber, m.ParamCnt, pkt[idx:])
 	if err != nil {
-		log.Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to decode device params")
+		logger.For(logger.ModuleCodec).Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to decode device params")
 		return ErrDecodeMsg
 	}
 	return nil
@@ -30,9 +29,10 @@ func (m *Msg8103) Decode(packet *PacketData) error {
 // server端发送8103消息，编码为字节数组
 func (m *Msg8103) Encode() (pkt []byte, err error) {
 	// AnswerParamCnt will be encode in DeviceParams
+	m.Parameters.Charset = m.Header.Charset
 	paramBytes, err := m.Parameters.Encode()
 	if err != nil {
-		log.Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to encode device params")
+		logger.For(logger.ModuleCodec).Error().Err(err).Str("device", m.Header.PhoneNumber).Msg("Fail to encode device params")
 		return nil, ErrEncodeMsg
 	}
 	pkt = hex.WriteBytes(pkt, paramBytes)
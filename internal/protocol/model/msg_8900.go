@@ -0,0 +1,39 @@
+package model
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 数据下行透传，终端回复0x0001
+type Msg8900 struct {
+	Header          *MsgHeader `json:"header"`
+	PassthroughType uint8      `json:"passthroughType"` // 透传消息类型
+	Data            []byte     `json:"data"`            // 透传消息内容
+}
+
+func (m *Msg8900) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	if len(pkt) < 1 {
+		return ErrDecodeMsg
+	}
+	m.PassthroughType = hex.ReadByte(pkt, &idx)
+	m.Data = append([]byte(nil), pkt[idx:]...)
+	return nil
+}
+
+func (m *Msg8900) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, m.PassthroughType)
+	pkt = hex.WriteBytes(pkt, m.Data)
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg8900) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg8900) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
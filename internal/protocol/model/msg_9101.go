@@ -0,0 +1,60 @@
+package model
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 实时音视频传输请求的数据类型
+const (
+	AVDataTypeAV        uint8 = 0 // 音视频
+	AVDataTypeVideo     uint8 = 1 // 视频
+	AVDataTypeTalk      uint8 = 2 // 双向对讲
+	AVDataTypeMonitor   uint8 = 3 // 监听
+	AVDataTypeBroadcast uint8 = 4 // 中心广播
+	AVDataTypeRelay     uint8 = 5 // 透传
+)
+
+// JT1078 实时音视频传输请求，终端以0x0001应答后向指定服务器推流
+type Msg9101 struct {
+	Header         *MsgHeader `json:"header"`
+	ServerIP       string     `json:"serverIp"`       // 视频服务器IP地址或域名
+	TCPPort        uint16     `json:"tcpPort"`        // 视频服务器TCP端口，不使用TCP传输时为0
+	UDPPort        uint16     `json:"udpPort"`        // 视频服务器UDP端口，不使用UDP传输时为0
+	LogicChannelID uint8      `json:"logicChannelId"` // 逻辑通道号
+	DataType       uint8      `json:"dataType"`       // 数据类型，0音视频，1视频，2双向对讲，3监听，4中心广播，5透传
+	StreamType     uint8      `json:"streamType"`     // 码流类型，0主码流，1子码流
+}
+
+func (m *Msg9101) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	ipLen := hex.ReadByte(pkt, &idx)
+	m.ServerIP = hex.ReadString(pkt, &idx, int(ipLen))
+	m.TCPPort = hex.ReadWord(pkt, &idx)
+	m.UDPPort = hex.ReadWord(pkt, &idx)
+	m.LogicChannelID = hex.ReadByte(pkt, &idx)
+	m.DataType = hex.ReadByte(pkt, &idx)
+	m.StreamType = hex.ReadByte(pkt, &idx)
+	return nil
+}
+
+func (m *Msg9101) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, uint8(len(m.ServerIP)))
+	pkt = hex.WriteString(pkt, m.ServerIP)
+	pkt = hex.WriteWord(pkt, m.TCPPort)
+	pkt = hex.WriteWord(pkt, m.UDPPort)
+	pkt = hex.WriteByte(pkt, m.LogicChannelID)
+	pkt = hex.WriteByte(pkt, m.DataType)
+	pkt = hex.WriteByte(pkt, m.StreamType)
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg9101) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg9101) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+func TestMsg9101_Encode(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *Msg9101
+		wantPkt []byte
+	}{
+		{
+			name: "case1: realtime video over tcp",
+			msg: &Msg9101{
+				Header:         genMsgHeader(0x9101),
+				ServerIP:       "127.0.0.1",
+				TCPPort:        1078,
+				LogicChannelID: 1,
+				DataType:       AVDataTypeAV,
+				StreamType:     1,
+			},
+			wantPkt: hex.Str2Byte("9101401101123456789012345678900001" + "093132372E302E302E31043600000100" + "01"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPkt, err := tt.msg.Encode()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPkt, gotPkt)
+
+			got := &Msg9101{}
+			require.NoError(t, got.Decode(&PacketData{Header: tt.msg.Header, Body: gotPkt[17:]}))
+			require.Equal(t, tt.msg, got)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 音视频实时传输控制指令
+const (
+	AVControlClose        uint8 = 0 // 关闭音视频传输
+	AVControlSwitchStream uint8 = 1 // 切换码流
+	AVControlPause        uint8 = 2 // 暂停该通道所有流的发送
+	AVControlResume       uint8 = 3 // 恢复暂停前流的发送
+	AVControlCloseTalk    uint8 = 4 // 关闭双向对讲
+)
+
+// JT1078 音视频实时传输控制
+type Msg9102 struct {
+	Header         *MsgHeader `json:"header"`
+	LogicChannelID uint8      `json:"logicChannelId"` // 逻辑通道号
+	Command        uint8      `json:"command"`        // 控制指令，0关闭，1切换码流，2暂停，3恢复，4关闭双向对讲
+	CloseType      uint8      `json:"closeType"`      // 关闭类型，0关闭音视频，1只关闭音频，2只关闭视频
+	StreamType     uint8      `json:"streamType"`     // 切换的码流类型，0主码流，1子码流
+}
+
+func (m *Msg9102) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	m.LogicChannelID = hex.ReadByte(pkt, &idx)
+	m.Command = hex.ReadByte(pkt, &idx)
+	m.CloseType = hex.ReadByte(pkt, &idx)
+	m.StreamType = hex.ReadByte(pkt, &idx)
+	return nil
+}
+
+func (m *Msg9102) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, m.LogicChannelID)
+	pkt = hex.WriteByte(pkt, m.Command)
+	pkt = hex.WriteByte(pkt, m.CloseType)
+	pkt = hex.WriteByte(pkt, m.StreamType)
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg9102) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg9102) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
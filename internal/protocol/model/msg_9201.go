@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 回放方式
+const (
+	PlaybackModeNormal      uint8 = 0 // 正常回放
+	PlaybackModeFastForward uint8 = 1 // 快进回放
+	PlaybackModeRewind      uint8 = 2 // 关键帧快退回放
+	PlaybackModeKeyFrame    uint8 = 3 // 关键帧播放
+	PlaybackModeSingleFrame uint8 = 4 // 单帧上传
+)
+
+// JT1078 平台下发远程录像回放请求，终端以0x1205应答音视频资源列表后推流
+type Msg9201 struct {
+	Header         *MsgHeader `json:"header"`
+	ServerIP       string     `json:"serverIp"`       // 视频服务器IP地址或域名
+	TCPPort        uint16     `json:"tcpPort"`        // 视频服务器TCP端口，不使用TCP传输时为0
+	UDPPort        uint16     `json:"udpPort"`        // 视频服务器UDP端口，不使用UDP传输时为0
+	LogicChannelID uint8      `json:"logicChannelId"` // 逻辑通道号
+	MediaType      uint8      `json:"mediaType"`      // 音视频类型，0音视频，1音频，2视频，3视频或音视频
+	StreamType     uint8      `json:"streamType"`     // 码流类型，0主码流或子码流，1主码流，2子码流
+	StorageType    uint8      `json:"storageType"`    // 存储器类型，0主存储器或灾备存储器，1主存储器，2灾备存储器
+	PlaybackMode   uint8      `json:"playbackMode"`   // 回放方式，0正常，1快进，2关键帧快退，3关键帧播放，4单帧上传
+	Multiple       uint8      `json:"multiple"`       // 快进或快退倍数，0无效，1-5对应1、2、4、8、16倍
+	StartTime      *time.Time `json:"startTime"`      // 开始时间，单帧上传时表示单帧的时间
+	EndTime        *time.Time `json:"endTime"`        // 结束时间，为空时编码为全0，表示一直回放
+}
+
+func (m *Msg9201) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	ipLen := hex.ReadByte(pkt, &idx)
+	m.ServerIP = hex.ReadString(pkt, &idx, int(ipLen))
+	m.TCPPort = hex.ReadWord(pkt, &idx)
+	m.UDPPort = hex.ReadWord(pkt, &idx)
+	m.LogicChannelID = hex.ReadByte(pkt, &idx)
+	m.MediaType = hex.ReadByte(pkt, &idx)
+	m.StreamType = hex.ReadByte(pkt, &idx)
+	m.StorageType = hex.ReadByte(pkt, &idx)
+	m.PlaybackMode = hex.ReadByte(pkt, &idx)
+	m.Multiple = hex.ReadByte(pkt, &idx)
+	m.StartTime = hex.ReadTime(pkt, &idx)
+	m.EndTime = hex.ReadTime(pkt, &idx)
+	return nil
+}
+
+func (m *Msg9201) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, uint8(len(m.ServerIP)))
+	pkt = hex.WriteString(pkt, m.ServerIP)
+	pkt = hex.WriteWord(pkt, m.TCPPort)
+	pkt = hex.WriteWord(pkt, m.UDPPort)
+	pkt = hex.WriteByte(pkt, m.LogicChannelID)
+	pkt = hex.WriteByte(pkt, m.MediaType)
+	pkt = hex.WriteByte(pkt, m.StreamType)
+	pkt = hex.WriteByte(pkt, m.StorageType)
+	pkt = hex.WriteByte(pkt, m.PlaybackMode)
+	pkt = hex.WriteByte(pkt, m.Multiple)
+	pkt = hex.WriteTime(pkt, *m.StartTime)
+	if m.EndTime != nil {
+		pkt = hex.WriteTime(pkt, *m.EndTime)
+	} else {
+		pkt = hex.WriteBytes(pkt, make([]byte, 6))
+	}
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg9201) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg9201) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+func TestMsg9201_Encode(t *testing.T) {
+	start, _ := time.Parse("060102150405", "230301123456")
+	end := start.Add(time.Hour)
+	tests := []struct {
+		name    string
+		msg     *Msg9201
+		wantPkt []byte
+	}{
+		{
+			name: "case1: playback in time range",
+			msg: &Msg9201{
+				Header:         genMsgHeader(0x9201),
+				ServerIP:       "127.0.0.1",
+				TCPPort:        1078,
+				LogicChannelID: 1,
+				StreamType:     1,
+				StartTime:      &start,
+				EndTime:        &end,
+			},
+			wantPkt: hex.Str2Byte("9201402001123456789012345678900001" + "093132372E302E302E3104360000" +
+				"010001000000" + "230301123456" + "230301133456"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPkt, err := tt.msg.Encode()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPkt, gotPkt)
+
+			got := &Msg9201{}
+			require.NoError(t, got.Decode(&PacketData{Header: tt.msg.Header, Body: gotPkt[17:]}))
+			require.Equal(t, tt.msg, got)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 回放控制
+const (
+	PlaybackControlStart       uint8 = 0 // 开始回放
+	PlaybackControlPause       uint8 = 1 // 暂停回放
+	PlaybackControlEnd         uint8 = 2 // 结束回放
+	PlaybackControlFastForward uint8 = 3 // 快进回放
+	PlaybackControlRewind      uint8 = 4 // 关键帧快退回放
+	PlaybackControlSeek        uint8 = 5 // 拖动回放
+	PlaybackControlKeyFrame    uint8 = 6 // 关键帧播放
+)
+
+// JT1078 平台下发远程录像回放控制
+type Msg9202 struct {
+	Header         *MsgHeader `json:"header"`
+	LogicChannelID uint8      `json:"logicChannelId"` // 逻辑通道号
+	Control        uint8      `json:"control"`        // 回放控制，0开始，1暂停，2结束，3快进，4关键帧快退，5拖动，6关键帧播放
+	Multiple       uint8      `json:"multiple"`       // 快进或快退倍数，0无效，1-5对应1、2、4、8、16倍
+	SeekTime       *time.Time `json:"seekTime"`       // 拖动回放的位置，仅拖动回放时有效
+}
+
+func (m *Msg9202) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	m.LogicChannelID = hex.ReadByte(pkt, &idx)
+	m.Control = hex.ReadByte(pkt, &idx)
+	m.Multiple = hex.ReadByte(pkt, &idx)
+	m.SeekTime = hex.ReadTime(pkt, &idx)
+	return nil
+}
+
+func (m *Msg9202) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, m.LogicChannelID)
+	pkt = hex.WriteByte(pkt, m.Control)
+	pkt = hex.WriteByte(pkt, m.Multiple)
+	if m.SeekTime != nil {
+		pkt = hex.WriteTime(pkt, *m.SeekTime)
+	} else {
+		pkt = hex.WriteBytes(pkt, make([]byte, 6))
+	}
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg9202) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg9202) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
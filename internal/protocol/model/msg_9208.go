@@ -0,0 +1,51 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+// 主动安全 报警附件上传指令
+type Msg9208 struct {
+	Header        *MsgHeader     `json:"header"`
+	ServerIP      string         `json:"serverIp"`      // 附件服务器IP地址或域名
+	TCPPort       uint16         `json:"tcpPort"`       // 附件服务器TCP端口
+	UDPPort       uint16         `json:"udpPort"`       // 附件服务器UDP端口
+	AlarmIdentity *AlarmIdentity `json:"alarmIdentity"` // 报警标识号
+	AlarmNumber   string         `json:"alarmNumber"`   // 平台给报警分配的唯一编号，32个字节
+}
+
+func (m *Msg9208) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	ipLen := hex.ReadByte(pkt, &idx)
+	m.ServerIP = hex.ReadString(pkt, &idx, int(ipLen))
+	m.TCPPort = hex.ReadWord(pkt, &idx)
+	m.UDPPort = hex.ReadWord(pkt, &idx)
+	m.AlarmIdentity = &AlarmIdentity{}
+	m.AlarmIdentity.Decode(pkt, &idx)
+	m.AlarmNumber = strings.TrimRight(hex.ReadString(pkt, &idx, AlarmNumberLen), "\x00")
+	return nil
+}
+
+func (m *Msg9208) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, uint8(len(m.ServerIP)))
+	pkt = hex.WriteString(pkt, m.ServerIP)
+	pkt = hex.WriteWord(pkt, m.TCPPort)
+	pkt = hex.WriteWord(pkt, m.UDPPort)
+	pkt = m.AlarmIdentity.Encode(pkt)
+	pkt = writeFixedString(pkt, m.AlarmNumber, AlarmNumberLen)
+	pkt = hex.WriteBytes(pkt, make([]byte, 16)) // 预留
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg9208) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg9208) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
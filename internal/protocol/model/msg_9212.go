@@ -0,0 +1,74 @@
+package model
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+const (
+	UploadResultCompleted  uint8 = 0x00 // 完成
+	UploadResultRetransmit uint8 = 0x01 // 需要补传
+)
+
+// 需要补传的数据段
+type DataRange struct {
+	Offset uint32 `json:"offset"` // 数据偏移量
+	Length uint32 `json:"length"` // 数据长度
+}
+
+// 主动安全 文件上传完成消息应答
+type Msg9212 struct {
+	Header      *MsgHeader   `json:"header"`
+	FileName    string       `json:"fileName"`    // 文件名称
+	FileType    uint8        `json:"fileType"`    // 文件类型，0图片，1音频，2视频，3文本，4其他
+	Result      uint8        `json:"result"`      // 上传结果，0完成，1需要补传
+	Retransmits []*DataRange `json:"retransmits"` // 补传数据包列表
+}
+
+func (m *Msg9212) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	pkt, idx := packet.Body, 0
+	nameLen := hex.ReadByte(pkt, &idx)
+	m.FileName = hex.ReadString(pkt, &idx, int(nameLen))
+	m.FileType = hex.ReadByte(pkt, &idx)
+	m.Result = hex.ReadByte(pkt, &idx)
+	cnt := int(hex.ReadByte(pkt, &idx))
+	m.Retransmits = make([]*DataRange, 0, cnt)
+	for i := 0; i < cnt; i++ {
+		m.Retransmits = append(m.Retransmits, &DataRange{
+			Offset: hex.ReadDoubleWord(pkt, &idx),
+			Length: hex.ReadDoubleWord(pkt, &idx),
+		})
+	}
+	return nil
+}
+
+func (m *Msg9212) Encode() (pkt []byte, err error) {
+	pkt = hex.WriteByte(pkt, uint8(len(m.FileName)))
+	pkt = hex.WriteString(pkt, m.FileName)
+	pkt = hex.WriteByte(pkt, m.FileType)
+	pkt = hex.WriteByte(pkt, m.Result)
+	pkt = hex.WriteByte(pkt, uint8(len(m.Retransmits)))
+	for _, r := range m.Retransmits {
+		pkt = hex.WriteDoubleWord(pkt, r.Offset)
+		pkt = hex.WriteDoubleWord(pkt, r.Length)
+	}
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *Msg9212) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *Msg9212) GenOutgoing(incoming JT808Msg) error {
+	in, ok := incoming.(*Msg1212)
+	if !ok {
+		return ErrGenOutgoingMsg
+	}
+	m.FileName = in.FileName
+	m.FileType = in.FileType
+	m.Header = in.Header
+	m.Header.MsgID = MsgID9212
+	return nil
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+var ErrInvalidRawField = errors.New("Invalid raw field")
+
+// 未建模的消息，消息体为原始字节，用于对接新型号终端时透传测试
+type RawMsg struct {
+	Header *MsgHeader `json:"header"`
+	Body   []byte     `json:"body"`
+}
+
+func (m *RawMsg) Decode(packet *PacketData) error {
+	m.Header = packet.Header
+	m.Body = packet.Body
+	return nil
+}
+
+func (m *RawMsg) Encode() (pkt []byte, err error) {
+	if len(m.Body) > MaxBodyLength {
+		return nil, errors.Wrapf(ErrEncodeMsg, "body too long, length=%d", len(m.Body))
+	}
+	pkt = hex.WriteBytes(pkt, m.Body)
+
+	pkt, err = writeHeader(m, pkt)
+	return pkt, err
+}
+
+func (m *RawMsg) GetHeader() *MsgHeader {
+	return m.Header
+}
+
+func (m *RawMsg) GenOutgoing(_ JT808Msg) error {
+	return nil
+}
+
+// 原始消息体字段类型
+const (
+	RawFieldByte   = "byte"   // BYTE
+	RawFieldWord   = "word"   // WORD
+	RawFieldDword  = "dword"  // DWORD
+	RawFieldBytes  = "bytes"  // BYTE[n]，值为hex字符串
+	RawFieldBCD    = "bcd"    // BCD[n]，值为数字字符串
+	RawFieldString = "string" // STRING，按终端的字符集编码，指定length时补0x00到定长
+)
+
+// 按字段列表描述的消息体，依次编码
+type RawField struct {
+	Type   string          `json:"type"`
+	Value  json.RawMessage `json:"value"`
+	Length int             `json:"length,omitempty"` // 仅string有效，0表示不定长
+}
+
+func EncodeRawFields(fields []*RawField, cs charset.Charset) (pkt []byte, err error) {
+	for i, f := range fields {
+		pkt, err = f.encode(pkt, cs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "index=%d, type=%s", i, f.Type)
+		}
+	}
+	return pkt, nil
+}
+
+func (f *RawField) encode(pkt []byte, cs charset.Charset) ([]byte, error) {
+	typ := strings.ToLower(f.Type)
+	switch typ {
+	case RawFieldByte, RawFieldWord, RawFieldDword:
+		var num uint64
+		if err := json.Unmarshal(f.Value, &num); err != nil {
+			return nil, ErrInvalidRawField
+		}
+		switch {
+		case typ == RawFieldByte && num <= 0xff:
+			return hex.WriteByte(pkt, uint8(num)), nil
+		case typ == RawFieldWord && num <= 0xffff:
+			return hex.WriteWord(pkt, uint16(num)), nil
+		case typ == RawFieldDword && num <= 0xffffffff:
+			return hex.WriteDoubleWord(pkt, uint32(num)), nil
+		}
+		return nil, ErrInvalidRawField // 超出类型范围
+	case RawFieldBytes, RawFieldBCD, RawFieldString:
+	default:
+		return nil, ErrInvalidRawField
+	}
+
+	var str string
+	if err := json.Unmarshal(f.Value, &str); err != nil {
+		return nil, ErrInvalidRawField
+	}
+	switch typ {
+	case RawFieldBytes:
+		arr, err := DecodeHexString(str)
+		if err != nil {
+			return nil, ErrInvalidRawField
+		}
+		return hex.WriteBytes(pkt, arr), nil
+	case RawFieldBCD:
+		if len(str)%2 != 0 || strings.Trim(str, "0123456789") != "" {
+			return nil, ErrInvalidRawField
+		}
+		// 按原样写入，不做hex.WriteBCD的补位
+		return hex.WriteBytes(pkt, hex.Str2Byte(str)), nil
+	default:
+		strPkt, err := charset.Encode(cs, str)
+		if err != nil {
+			return nil, ErrInvalidRawField
+		}
+		if f.Length > 0 {
+			if len(strPkt) > f.Length {
+				return nil, ErrInvalidRawField
+			}
+			strPkt = append(strPkt, make([]byte, f.Length-len(strPkt))...)
+		}
+		return hex.WriteBytes(pkt, strPkt), nil
+	}
+}
+
+// 解析hex字符串，忽略空白字符，不区分大小写
+func DecodeHexString(str string) ([]byte, error) {
+	str = strings.Join(strings.Fields(str), "")
+	if len(str)%2 != 0 || strings.Trim(strings.ToLower(str), "0123456789abcdef") != "" {
+		return nil, ErrDecodeMsg
+	}
+	return hex.Str2Byte(str), nil
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+)
+
+func TestEncodeRawFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "case1: numbers",
+			fields: `[{"type":"byte","value":1},{"type":"word","value":258},{"type":"dword","value":16909060}]`,
+			want:   "01010201020304",
+		},
+		{
+			name:   "case2: bytes, bcd and fixed length string",
+			fields: `[{"type":"bytes","value":"ab CD"},{"type":"bcd","value":"230301"},{"type":"string","value":"京A","length":4}]`,
+			want:   "abcd230301bea94100",
+		},
+		{
+			name:    "case3: out of range",
+			fields:  `[{"type":"byte","value":256}]`,
+			wantErr: true,
+		},
+		{
+			name:    "case4: unknown type",
+			fields:  `[{"type":"float","value":1}]`,
+			wantErr: true,
+		},
+		{
+			name:    "case5: invalid hex",
+			fields:  `[{"type":"bytes","value":"abc"}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []*RawField
+			require.NoError(t, json.Unmarshal([]byte(tt.fields), &fields))
+			got, err := EncodeRawFields(fields, charset.Auto)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidRawField)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, hex.Byte2Str(got))
+		})
+	}
+}
+
+func TestRawMsg_Encode(t *testing.T) {
+	tests := []struct {
+		name   string
+		device *Device
+		want   string
+	}{
+		{
+			name:   "case1: 2019 version",
+			device: &Device{Phone: "12345678901234567890", VersionDesc: Version2019, ProtocolVersion: 1},
+			want:   "8105400201123456789012345678900007" + "0102",
+		},
+		{
+			name:   "case2: 2013 version",
+			device: &Device{Phone: "123456789012", VersionDesc: Version2013},
+			want:   "810500021234567890120007" + "0102",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &RawMsg{Header: GenMsgHeader(tt.device, 0x8105, 7), Body: []byte{1, 2}}
+			pkt, err := m.Encode()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, hex.Byte2Str(pkt))
+		})
+	}
+
+	m := &RawMsg{Header: GenMsgHeader(&Device{VersionDesc: Version2013}, 0x8105, 1), Body: make([]byte, MaxBodyLength+1)}
+	_, err := m.Encode()
+	require.ErrorIs(t, err, ErrEncodeMsg)
+}
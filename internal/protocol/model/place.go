@@ -0,0 +1,68 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// 停留点，车辆在同一位置低速停留超过一定时长
+type Stop struct {
+	Phone     string    `json:"phone"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+}
+
+func (s *Stop) Dwell() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// 地点类型
+const (
+	PlaceKindDepot = "depot" // 车场，长时间或跨夜停留
+	PlaceKindSite  = "site"  // 客户点等作业地点
+)
+
+// 停留点聚类得到的候选地点
+type CandidatePlace struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Kind         string    `json:"kind"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	Radius       float64   `json:"radius"`       // 覆盖所有停留点的半径，单位m
+	Visits       int       `json:"visits"`       // 停留次数
+	Vehicles     int       `json:"vehicles"`     // 停留过的车辆数
+	TypicalDwell int64     `json:"typicalDwell"` // 停留时长中位数，单位s
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+	GeofenceID   string    `json:"geofenceId,omitempty"` // 已提升为电子围栏时的围栏ID
+}
+
+// 圆形电子围栏
+type Geofence struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	Radius      float64   `json:"radius"`                // 单位m
+	CandidateID string    `json:"candidateId,omitempty"` // 由候选地点提升时的候选地点ID
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (g *Geofence) Contains(lat, lon float64) bool {
+	return Distance(g.Latitude, g.Longitude, lat, lon) <= g.Radius
+}
+
+const earthRadius = 6371000 // 单位m
+
+// 两个经纬度之间的球面距离，单位m
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -9,6 +9,8 @@ import (
 const (
 	TCPProto TransportProtocol = "TCP"
 	UDPProto TransportProtocol = "UDP"
+	// 通过HTTP上报位置的手机定位终端，没有长连接
+	HTTPProto TransportProtocol = "HTTP"
 )
 
 type TransportProtocol string
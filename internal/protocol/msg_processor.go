@@ -11,12 +11,21 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 
+	"github.com/fakeyanss/jt808-server-go/internal/authorizer"
+	"github.com/fakeyanss/jt808-server-go/internal/ban"
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hash"
 	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/export"
+	"github.com/fakeyanss/jt808-server-go/internal/icauth"
+	"github.com/fakeyanss/jt808-server-go/internal/outage"
+	"github.com/fakeyanss/jt808-server-go/internal/place"
 	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/quality"
 	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/internal/timeline"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 var (
@@ -34,10 +43,15 @@ type MsgProcessor interface {
 type processOptions map[uint16]*action
 
 type action struct {
-	genData func() *model.ProcessData                       // 定义生成消息的类型。由于go不支持type作为参数，所以这里直接初始化结构体
-	process func(context.Context, *model.ProcessData) error // 处理消息的逻辑。可以设置消息字段、根据消息做相应处理逻辑
+	genData func() *model.ProcessData                             // 定义生成消息的类型。由于go不支持type作为参数，所以这里直接初始化结构体
+	process func(context.Context, *model.ProcessData) error       // 处理消息的逻辑。可以设置消息字段、根据消息做相应处理逻辑
+	replay  func(*model.ProcessData, *storage.IngestRecord) error // 从WAL重放消息对存储的影响。非nil时，消息处理成功后、应答前写入WAL
+	// 由处理逻辑在判定之后写入WAL，用于需要记录处理结果的消息，原始数据包通过context传递
+	persistInProcess bool
 }
 
+type packetCtxKey struct{}
+
 // 表驱动，初始化消息处理方法组
 func initProcessOption() processOptions {
 	options := make(processOptions)
@@ -57,12 +71,15 @@ func initProcessOption() processOptions {
 			return &model.ProcessData{Incoming: &model.Msg0003{}, Outgoing: &model.Msg8001{}}
 		},
 		process: processMsg0003,
+		replay:  replayMsg0003,
 	}
 	options[0x0100] = &action{ // 注册
 		genData: func() *model.ProcessData {
 			return &model.ProcessData{Incoming: &model.Msg0100{}, Outgoing: &model.Msg8100{}}
 		},
-		process: processMsg0100,
+		process:          processMsg0100,
+		replay:           replayMsg0100,
+		persistInProcess: true, // 记录下发的鉴权码
 	}
 	options[0x0102] = &action{ // 鉴权
 		genData: func() *model.ProcessData {
@@ -74,12 +91,28 @@ func initProcessOption() processOptions {
 		genData: func() *model.ProcessData {
 			return &model.ProcessData{Incoming: &model.Msg0104{}} // 无需回复
 		},
+		process: processMsg0104,
+		replay:  replayMsg0104,
 	}
 	options[0x0200] = &action{ // 位置信息上报
 		genData: func() *model.ProcessData {
 			return &model.ProcessData{Incoming: &model.Msg0200{}, Outgoing: &model.Msg8001{}}
 		},
 		process: processMsg0200,
+		replay:  replayMsg0200,
+	}
+	options[0x0704] = &action{ // 定位数据批量上传
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg0704{}, Outgoing: &model.Msg8001{}}
+		},
+		process: processMsg0704,
+		replay:  replayMsg0704,
+	}
+	options[0x0900] = &action{ // 数据上行透传
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg0900{}, Outgoing: &model.Msg8001{}}
+		},
+		process: processMsg0900,
 	}
 	options[0x1205] = &action{ // 终端上传音视频资源列表
 		genData: func() *model.ProcessData {
@@ -110,12 +143,44 @@ func initProcessOption() processOptions {
 		},
 		process: processMsg8104,
 	}
+	options[0x8900] = &action{ // 数据下行透传
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg8900{}, Outgoing: &model.Msg0001{}}
+		},
+	}
+	options[0x9101] = &action{ // 实时音视频传输请求
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg9101{}, Outgoing: &model.Msg0001{}}
+		},
+	}
+	options[0x9102] = &action{ // 音视频实时传输控制
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg9102{}, Outgoing: &model.Msg0001{}}
+		},
+	}
+	options[0x9201] = &action{ // 远程录像回放请求
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg9201{}, Outgoing: &model.Msg1205{}}
+		},
+		process: processMsg9201,
+	}
+	options[0x9202] = &action{ // 远程录像回放控制
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg9202{}, Outgoing: &model.Msg0001{}}
+		},
+	}
 	options[0x9205] = &action{ // 查询终端音视频资源列表
 		genData: func() *model.ProcessData {
 			return &model.ProcessData{Incoming: &model.Msg9205{}, Outgoing: &model.Msg1205{}}
 		},
 		process: processMsg9205,
 	}
+	options[0x9208] = &action{ // 报警附件上传指令
+		genData: func() *model.ProcessData {
+			return &model.ProcessData{Incoming: &model.Msg9208{}, Outgoing: &model.Msg0001{}}
+		},
+		process: processMsg9208,
+	}
 
 	return options
 }
@@ -140,6 +205,10 @@ func NewJT808MsgProcessor() *JT808MsgProcessor {
 
 func (mp *JT808MsgProcessor) Process(ctx context.Context, pkt *model.PacketData) (*model.ProcessData, error) {
 	msgID := pkt.Header.MsgID
+	// 透传下发的消息可能等待任意类型的应答，包括尚未支持的消息ID
+	if !pkt.Header.IsFragmented() || pkt.SegCompleted {
+		dispatchReply(pkt)
+	}
 	if _, ok := mp.options[msgID]; !ok {
 		return nil, ErrMsgIDNotSupportted
 	}
@@ -157,20 +226,33 @@ func (mp *JT808MsgProcessor) Process(ctx context.Context, pkt *model.PacketData)
 	data := genDataFn()
 
 	in := data.Incoming
-	err := in.Decode(pkt)
+	err := decodeWithCharset(in, pkt)
 	if err != nil {
 		return nil, errors.Wrap(err, "Fail to decode packet to jtmsg")
 	}
 
-	if log.Logger.GetLevel() == zerolog.DebugLevel {
+	// 需要持久化的消息，处理成功并落盘之后才能应答
+	var persisted *model.PacketData
+	if act.replay != nil {
+		// 生成应答时会复用并改写消息头，先复制一份
+		header := *pkt.Header
+		attr := *pkt.Header.Attr
+		header.Attr = &attr
+		persisted = &model.PacketData{Header: &header, Body: pkt.Body, SegCompleted: true}
+		if act.persistInProcess {
+			ctx = context.WithValue(ctx, packetCtxKey{}, persisted)
+		}
+	}
+
+	l := logger.Ctx(ctx, logger.ModuleProcessor)
+	if l.GetLevel() <= zerolog.DebugLevel {
 		// print log of msg content
-		session := ctx.Value(model.SessionCtxKey{}).(*model.Session)
 		inJSON, err := json.Marshal(in)
 		if err != nil {
 			return nil, errors.Wrap(err, "Fail to serialize incoming msg to json")
 		}
 		// for debug
-		log.Debug().Str("id", session.ID).Str("RawMsgID", fmt.Sprintf("0x%04x", in.GetHeader().MsgID)).RawJSON("incoming", inJSON).Msg("Received jt808 msg.")
+		l.Debug().RawJSON("incoming", inJSON).Msg("Received jt808 msg.")
 	}
 
 	// 生成待回复的消息
@@ -183,14 +265,13 @@ func (mp *JT808MsgProcessor) Process(ctx context.Context, pkt *model.PacketData)
 
 		// print log of outgoing content
 		defer func() {
-			if out == nil || log.Logger.GetLevel() != zerolog.DebugLevel {
+			if out == nil || l.GetLevel() > zerolog.DebugLevel {
 				return
 			}
 
 			outJSON, _ := json.Marshal(out)
-			session := ctx.Value(model.SessionCtxKey{}).(*model.Session)
 			// for debug
-			log.Debug().Str("id", session.ID).Str("RawMsgID", fmt.Sprintf("0x%04x", out.GetHeader().MsgID)).RawJSON("outgoing", outJSON).
+			l.Debug().Str("outMsgId", fmt.Sprintf("0x%04x", out.GetHeader().MsgID)).RawJSON("outgoing", outJSON).
 				Msg("Generating jt808 outgoing msg.")
 		}()
 	}
@@ -204,6 +285,13 @@ func (mp *JT808MsgProcessor) Process(ctx context.Context, pkt *model.PacketData)
 	if err != nil {
 		return data, errors.Wrap(err, "Fail to process data")
 	}
+	// 处理失败的消息不应答，也不写入WAL，避免重启后重放
+	if persisted != nil && !act.persistInProcess {
+		err = persistPacket(persisted)
+		if err != nil {
+			return nil, errors.Wrap(err, "Fail to persist packet")
+		}
+	}
 	if data.Outgoing == nil {
 		return nil, nil // 此类型msg不需要回复
 	}
@@ -257,15 +345,45 @@ func processMsg0003(_ context.Context, data *model.ProcessData) error {
 	// 取消定时任务
 	timer := NewKeepaliveTimer()
 	timer.Cancel(device.Phone)
+	observeStatus(device.Phone, device.Status, model.DeviceStatusOffline, model.StatusReasonLogout)
 	// 清楚缓存
 	cache.DelDeviceByPhone(device.Phone)
 	// 为避免连接TIMEWAIT，应等待对方主动关闭
 	return nil
 }
 
+// 外部判定服务，为nil时使用本地规则判定注册和鉴权
+var authorizerInstance authorizer.Authorizer
+
+func SetAuthorizer(a authorizer.Authorizer) {
+	authorizerInstance = a
+}
+
+// 通知终端状态变化，用于导出和Grafana注释，大面积掉线期间不再单独通知终端掉线
+func observeStatus(phone string, from, to model.DeviceStatus, reason string) {
+	export.ObserveStatus(phone, from, to, reason)
+	if from != model.DeviceStatusOffline && to == model.DeviceStatusOffline {
+		if outage.ObserveOffline(phone) {
+			logger.For(logger.ModuleProcessor).Debug().Str("device", phone).Msg("Suppress offline notification during outage")
+			return
+		}
+	} else if from == model.DeviceStatusOffline && to != model.DeviceStatusOffline {
+		outage.ObserveOnline(phone)
+	}
+	timeline.ObserveStatus(phone, from, to, reason)
+}
+
 // 收到注册，应校验设备ID，如果可注册，则缓存设备信息并返回鉴权码
 func processMsg0100(ctx context.Context, data *model.ProcessData) error {
 	in := data.Incoming.(*model.Msg0100)
+	if inspectRegister(in) {
+		out := data.Outgoing.(*model.Msg8100)
+		out.Result = model.ResDeviceNotExist // 终端被隔离
+		return nil
+	}
+	if authorizerInstance != nil {
+		return registerByAuthorizer(ctx, data)
+	}
 
 	cache := storage.GetDeviceCache()
 	// 校验注册逻辑
@@ -282,31 +400,98 @@ func processMsg0100(ctx context.Context, data *model.ProcessData) error {
 	}
 
 	session := ctx.Value(model.SessionCtxKey{}).(*model.Session)
-	device := model.NewDevice(in, session)
-	out.AuthCode = genAuthCode(device) // 设置鉴权码
+	return completeRegister(ctx, data, genAuthCode(model.NewDevice(in, session)), "")
+}
 
-	cache.CacheDevice(device)
+// 由外部服务判定注册，外部服务是车辆档案的权威来源，重复注册时覆盖本地缓存
+func registerByAuthorizer(ctx context.Context, data *model.ProcessData) error {
+	in := data.Incoming.(*model.Msg0100)
+	out := data.Outgoing.(*model.Msg8100)
+	session := ctx.Value(model.SessionCtxKey{}).(*model.Session)
 
-	timer := NewKeepaliveTimer()
-	timer.Register(device.Phone)
+	decision, err := authorizerInstance.Register(ctx, in, authorizer.NewSessionInfo(session))
+	if err != nil {
+		return errors.Wrapf(err, "Fail to authorize register, phoneNumber=%s", in.Header.PhoneNumber)
+	}
+	out.Result = model.ResultCodeType(decision.Result)
+	if out.Result != model.ResSuccess {
+		return nil
+	}
+	authCode := decision.AuthCode
+	if authCode == "" {
+		authCode = genAuthCode(model.NewDevice(in, session))
+	}
+	return completeRegister(ctx, data, authCode, decision.VehicleName)
+}
+
+// 注册成功后先写入WAL再更新缓存，WAL记录下发的鉴权码，重放时恢复为同一个鉴权码
+func completeRegister(ctx context.Context, data *model.ProcessData, authCode, vehicleName string) error {
+	in := data.Incoming.(*model.Msg0100)
+	pkt := ctx.Value(packetCtxKey{}).(*model.PacketData)
+	if err := persistRegister(pkt, authCode, vehicleName); err != nil {
+		return errors.Wrap(err, "Fail to persist packet")
+	}
+	device := model.NewDevice(in, ctx.Value(model.SessionCtxKey{}).(*model.Session))
+	device.AuthCode = authCode
+	device.Name = vehicleName
+	registerDevice(device)
+	data.Outgoing.(*model.Msg8100).AuthCode = device.AuthCode
+	NewKeepaliveTimer().Register(device.Phone)
 	return nil
 }
 
+// 缓存注册成功的终端，手机号或车牌已被其他终端占用时覆盖。在线注册和WAL重放共用
+func registerDevice(device *model.Device) {
+	cache := storage.GetDeviceCache()
+	timer := NewKeepaliveTimer()
+	if old, err := cache.GetDeviceByPhone(device.Phone); err == nil {
+		timer.Cancel(old.Phone)
+		cache.DelDeviceByPhone(old.Phone)
+	}
+	if old, err := cache.GetDeviceByPlate(device.Plate); device.Plate != "" && err == nil {
+		timer.Cancel(old.Phone)
+		cache.DelDeviceByPhone(old.Phone)
+	}
+	cache.CacheDevice(device)
+}
+
 // 收到鉴权，应校验鉴权token
-func processMsg0102(_ context.Context, data *model.ProcessData) error {
+func processMsg0102(ctx context.Context, data *model.ProcessData) error {
 	in := data.Incoming.(*model.Msg0102)
 
 	cache := storage.GetDeviceCache()
 	device, err := cache.GetDeviceByPhone(in.Header.PhoneNumber)
-	// 缓存不存在，说明设备不合法，需要返回错误，让服务层处理关闭
 	if errors.Is(err, storage.ErrDeviceNotFound) {
-		return errors.Wrapf(err, "Fail to find device cache, phoneNumber=%s", in.Header.PhoneNumber)
+		if authorizerInstance == nil {
+			// 缓存不存在，说明设备不合法，需要返回错误，让服务层处理关闭
+			return errors.Wrapf(err, "Fail to find device cache, phoneNumber=%s", in.Header.PhoneNumber)
+		}
+		// 外部服务判定时，终端可能在其他节点注册过，鉴权通过后再缓存
+		device = &model.Device{
+			Phone:           in.Header.PhoneNumber,
+			Keepalive:       time.Minute * 1,
+			VersionDesc:     in.Header.Attr.VersionDesc,
+			ProtocolVersion: in.Header.ProtocolVersion,
+		}
 	}
 
 	out := data.Outgoing.(*model.Msg8001)
+	session := ctx.Value(model.SessionCtxKey{}).(*model.Session)
+	quarantined := inspectAuth(in, session, device)
 	// 校验鉴权逻辑
-	if in.AuthCode != genAuthCode(device) {
+	authorized := in.AuthCode == genAuthCode(device)
+	if quarantined {
+		authorized = false
+	} else if authorizerInstance != nil {
+		decision, err := authorizerInstance.Authenticate(ctx, in, authorizer.NewSessionInfo(session))
+		if err != nil {
+			return errors.Wrapf(err, "Fail to authorize authentication, phoneNumber=%s", in.Header.PhoneNumber)
+		}
+		authorized = model.ResultCode(decision.Result) == model.ResultSuccess
+	}
+	if !authorized {
 		out.Result = model.ResultFail
+		ban.Record(session.ID, ban.ReasonAuthFailure)
 		// 取消定时任务
 		timer := NewKeepaliveTimer()
 		timer.Cancel(device.Phone)
@@ -314,12 +499,25 @@ func processMsg0102(_ context.Context, data *model.ProcessData) error {
 		cache.DelDeviceByPhone(device.Phone)
 	} else {
 		// 鉴权通过
+		observeStatus(device.Phone, device.Status, model.DeviceStatusOnline, model.StatusReasonAuth)
 		device.Status = model.DeviceStatusOnline
 		device.LastestComTime = time.Now()
 		device.AuthCode = in.AuthCode
 		device.IMEI = in.IMEI
 		device.SoftwareVersion = in.SoftwareVersion
+		// 从WAL恢复的设备，或者重连的设备，需要绑定新的连接
+		rebind := device.SessionID != session.ID
+		if rebind {
+			device.SessionID = session.ID
+			device.TransProto = session.GetTransProto()
+			device.Conn = session.Conn
+		}
 		cache.CacheDevice(device)
+		if rebind {
+			timer := NewKeepaliveTimer()
+			timer.Cancel(device.Phone)
+			timer.Register(device.Phone)
+		}
 	}
 
 	return nil
@@ -336,14 +534,22 @@ func genAuthCode(d *model.Device) string {
 	return strconv.Itoa(int(hash.FNV32(codeBuilder.String())))
 }
 
-// 收到查询终端参数应答，无需回复，可以在这里做一个一个channel write，由其他地方阻塞式read来完成hook功能。
-func processMsg0104(_ context.Context, _ *model.ProcessData) error {
-	// todo: write channel
+// 收到查询终端参数应答，合并到参数缓存，用于与终端实际的上报行为对比
+func processMsg0104(_ context.Context, data *model.ProcessData) error {
+	in := data.Incoming.(*model.Msg0104)
+	paramCache := storage.GetDeviceParamsCache()
+	params, err := paramCache.GetDeviceParamsByPhone(in.Header.PhoneNumber)
+	if errors.Is(err, storage.ErrDeviceParamsNotFound) {
+		params = in.Parameters
+	} else {
+		params.Merge(in.Parameters)
+	}
+	paramCache.CacheDeviceParams(params)
 	return nil
 }
 
 // 收到位置信息汇报，回复通用应答
-func processMsg0200(_ context.Context, data *model.ProcessData) error {
+func processMsg0200(ctx context.Context, data *model.ProcessData) error {
 	in := data.Incoming.(*model.Msg0200)
 
 	cache := storage.GetDeviceCache()
@@ -359,17 +565,89 @@ func processMsg0200(_ context.Context, data *model.ProcessData) error {
 	if err != nil {
 		return errors.Wrapf(err, "Fail to decode device geo, phoneNumber=%s", device.Phone)
 	}
+	clock.ObserveDeviceTime(dg.Time)
 
 	if dg.Geo.ACCStatus == 0 { // ACC关闭，设备休眠
+		observeStatus(device.Phone, device.Status, model.DeviceStatusSleeping, model.StatusReasonACCOff)
 		device.Status = model.DeviceStatusSleeping
 		device.LastestComTime = time.Now()
 		cache.CacheDevice(device)
+	} else if device.Status == model.DeviceStatusSleeping { // ACC打开，设备唤醒
+		observeStatus(device.Phone, device.Status, model.DeviceStatusOnline, model.StatusReasonACCOn)
+		device.Status = model.DeviceStatusOnline
+		device.LastestComTime = time.Now()
+		cache.CacheDevice(device)
 	}
 
 	geoCache := storage.GetGeoCache()
 	rb := geoCache.GetGeoRingByPhone(device.Phone)
 	rb.Write(dg)
+	place.Observe(dg)
+	quality.Observe(dg)
+	export.ObservePosition(dg, in, export.SourceRealtime)
+	timeline.ObservePosition(dg)
+	if session, ok := ctx.Value(model.SessionCtxKey{}).(*model.Session); ok {
+		inspectLocation(dg, session.ID)
+	}
+
+	return nil
+}
+
+// 收到定位数据批量上传，回复通用应答。
+// 盲区补报的位置早于实时位置，按定位时间插入，不改变设备状态。
+func processMsg0704(ctx context.Context, data *model.ProcessData) error {
+	in := data.Incoming.(*model.Msg0704)
+	phone := in.Header.PhoneNumber
+
+	cache := storage.GetDeviceCache()
+	_, err := cache.GetDeviceByPhone(phone)
+	// 缓存不存在，说明设备不合法，需要返回错误，让服务层处理关闭
+	if errors.Is(err, storage.ErrDeviceNotFound) {
+		return errors.Wrapf(err, "Fail to find device cache, phoneNumber=%s", phone)
+	}
+
+	geos, err := decodeBatchGeos(in)
+	if err != nil {
+		return err
+	}
+	storage.GetGeoCache().InsertGeosByTime(phone, geos)
+	for i, dg := range geos {
+		place.Observe(dg)
+		export.ObservePosition(dg, in.Items[i], export.SourceBlindArea)
+	}
+	logger.Ctx(ctx, logger.ModuleProcessor).Debug().Uint8("locationType", in.LocationType).Int("count", len(geos)).
+		Msg("Received batch locations")
+	return nil
+}
+
+func decodeBatchGeos(in *model.Msg0704) ([]*model.DeviceGeo, error) {
+	geos := make([]*model.DeviceGeo, 0, len(in.Items))
+	for _, item := range in.Items {
+		dg := &model.DeviceGeo{}
+		err := dg.Decode(in.Header.PhoneNumber, item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Fail to decode device geo, phoneNumber=%s", in.Header.PhoneNumber)
+		}
+		geos = append(geos, dg)
+	}
+	return geos, nil
+}
 
+// 收到数据上行透传，IC卡认证请求转发给认证中心，认证中心的应答以0x8900异步下发
+func processMsg0900(ctx context.Context, data *model.ProcessData) error {
+	in := data.Incoming.(*model.Msg0900)
+	phone := in.Header.PhoneNumber
+	// 缓存不存在，说明设备不合法，需要返回错误，让服务层处理关闭
+	if _, err := storage.GetDeviceCache().GetDeviceByPhone(phone); errors.Is(err, storage.ErrDeviceNotFound) {
+		return errors.Wrapf(err, "Fail to find device cache, phoneNumber=%s", phone)
+	}
+	if in.PassthroughType != model.PassthroughICCard {
+		return nil
+	}
+	if !icauth.Forward(phone, in.Data) {
+		logger.Ctx(ctx, logger.ModuleProcessor).Warn().Msg("IC card authentication relay is disabled, reject the request")
+		data.Outgoing.(*model.Msg8001).Result = model.ResultNotSupported
+	}
 	return nil
 }
 
@@ -409,6 +687,9 @@ func processMsg8100(_ context.Context, data *model.ProcessData) error {
 
 	out.IMEI = device.IMEI
 	out.SoftwareVersion = device.SoftwareVersion
+	// 缓存鉴权码，断线重连后直接鉴权
+	device.AuthCode = in.AuthCode
+	cache.CacheDevice(device)
 
 	return nil
 }
@@ -484,6 +765,21 @@ func processMsg8104(_ context.Context, data *model.ProcessData) error {
 	return nil
 }
 
+// 收到回放请求，以请求的时间段应答资源列表(此时是作为client进程)
+func processMsg9201(_ context.Context, data *model.ProcessData) error {
+	in := data.Incoming.(*model.Msg9201)
+	out := data.Outgoing.(*model.Msg1205)
+	out.MediaCount = 1
+	out.LogicChannelID = in.LogicChannelID
+	out.StartTime = in.StartTime
+	out.EndTime = in.EndTime
+	out.MediaType = in.MediaType
+	out.StreamType = in.StreamType
+	out.StorageType = in.StorageType
+
+	return nil
+}
+
 func processMsg9205(_ context.Context, data *model.ProcessData) error {
 	in := data.Incoming.(*model.Msg9205)
 	out := data.Outgoing.(*model.Msg1205)
@@ -500,3 +796,22 @@ func processMsg9205(_ context.Context, data *model.ProcessData) error {
 
 	return nil
 }
+
+// 报警附件上传指令，由模拟器阻塞式读取后连接附件服务器上传
+var alarmAttachmentCh = make(chan *model.Msg9208, 64)
+
+func AlarmAttachmentRequests() <-chan *model.Msg9208 {
+	return alarmAttachmentCh
+}
+
+// 收到报警附件上传指令，回复通用应答(此时是作为client进程)
+func processMsg9208(ctx context.Context, data *model.ProcessData) error {
+	in := data.Incoming.(*model.Msg9208)
+	select {
+	case alarmAttachmentCh <- in:
+	default:
+		logger.Ctx(ctx, logger.ModuleProcessor).Warn().Str("alarmNumber", in.AlarmNumber).
+			Msg("Too many pending alarm attachment requests, drop it")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/authorizer"
+	"github.com/fakeyanss/jt808-server-go/internal/codec/charset"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/icauth"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+func TestProcessMsg0100_Authorizer(t *testing.T) {
+	stub := authorizer.NewStubAuthorizer()
+	SetAuthorizer(stub)
+	defer SetAuthorizer(nil)
+
+	phone, rejected := "223456789017", "223456789018"
+	stub.Reject(rejected, model.ResDeviceNotExist)
+	ctx := context.WithValue(context.Background(), model.SessionCtxKey{}, &model.Session{ID: "127.0.0.1:1234"})
+	header := func(msgID uint16, phone string) *model.MsgHeader {
+		return &model.MsgHeader{MsgID: msgID, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2013}, PhoneNumber: phone}
+	}
+	register := func(phone string) *model.Msg8100 {
+		data := &model.ProcessData{
+			Incoming: &model.Msg0100{Header: header(0x0100, phone), DeviceID: "1234567", PlateNumber: "京A" + phone[8:]},
+			Outgoing: &model.Msg8100{},
+		}
+		require.NoError(t, data.Outgoing.GenOutgoing(data.Incoming))
+		pkt := &model.PacketData{Header: data.Incoming.GetHeader()}
+		require.NoError(t, processMsg0100(context.WithValue(ctx, packetCtxKey{}, pkt), data))
+		return data.Outgoing.(*model.Msg8100)
+	}
+	auth := func(phone, authCode string) *model.Msg8001 {
+		data := &model.ProcessData{
+			Incoming: &model.Msg0102{Header: header(0x0102, phone), AuthCode: authCode},
+			Outgoing: &model.Msg8001{},
+		}
+		require.NoError(t, data.Outgoing.GenOutgoing(data.Incoming))
+		require.NoError(t, processMsg0102(ctx, data))
+		return data.Outgoing.(*model.Msg8001)
+	}
+
+	out := register(rejected)
+	require.Equal(t, model.ResDeviceNotExist, out.Result)
+	require.False(t, storage.GetDeviceCache().HasPhone(rejected))
+
+	out = register(phone)
+	require.Equal(t, model.ResSuccess, out.Result)
+	require.Equal(t, "stub"+phone, out.AuthCode)
+	// 外部服务判定时，重复注册覆盖本地缓存
+	out = register(phone)
+	require.Equal(t, model.ResSuccess, out.Result)
+
+	require.Equal(t, model.ResultFail, auth(phone, "wrong").Result)
+	require.False(t, storage.GetDeviceCache().HasPhone(phone))
+
+	// 本地没有缓存的终端，鉴权通过后缓存
+	require.Equal(t, model.ResultSuccess, auth(phone, "stub"+phone).Result)
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+	require.NoError(t, err)
+	require.Equal(t, model.DeviceStatusOnline, device.Status)
+
+	NewKeepaliveTimer().Cancel(phone)
+	storage.GetDeviceCache().DelDeviceByPhone(phone)
+}
+
+func TestProcessMsg0900_ICCard(t *testing.T) {
+	phone := "223456789019"
+	session := &model.Session{ID: "127.0.0.1:" + phone}
+	storage.StoreSession(session)
+	storage.GetDeviceCache().CacheDevice(&model.Device{Phone: phone, SessionID: session.ID, VersionDesc: model.Version2019})
+	defer storage.ClearSession(session.ID)
+	defer storage.GetDeviceCache().DelDeviceByPhone(phone)
+
+	passthrough := func(passthroughType uint8) *model.Msg8001 {
+		header := &model.MsgHeader{MsgID: 0x0900, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2019}, PhoneNumber: phone}
+		data := &model.ProcessData{
+			Incoming: &model.Msg0900{Header: header, PassthroughType: passthroughType, Data: []byte("card")},
+			Outgoing: &model.Msg8001{},
+		}
+		require.NoError(t, data.Outgoing.GenOutgoing(data.Incoming))
+		require.NoError(t, processMsg0900(context.Background(), data))
+		return data.Outgoing.(*model.Msg8001)
+	}
+
+	// 未启用认证中转
+	require.Equal(t, model.ResultNotSupported, passthrough(model.PassthroughICCard).Result)
+	require.Equal(t, model.ResultSuccess, passthrough(model.PassthroughSerial1).Result)
+
+	stub, err := icauth.NewStubServer(nil)
+	require.NoError(t, err)
+	defer stub.Close()
+	sent := make(chan model.JT808Msg, 1)
+	icauth.Start(&config.ICAuthConf{Addr: stub.Addr()}, func(_ string, msg model.JT808Msg) error {
+		sent <- msg
+		return nil
+	})
+	require.Equal(t, model.ResultSuccess, passthrough(model.PassthroughICCard).Result)
+	select {
+	case msg := <-sent:
+		require.Equal(t, icauth.StubResponse([]byte("card")), msg.(*model.Msg8900).Data)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "IC card authentication response not relayed")
+	}
+}
+
+func TestDecodeWithCharset(t *testing.T) {
+	require.NoError(t, SetCharsetConf(&config.CharsetConf{
+		Default:        "GBK",
+		ByManufacturer: map[string]string{"utf8m": "UTF-8"},
+		ByPhone:        map[string]string{"223456789021": "GB18030"},
+	}))
+	defer func() { require.NoError(t, SetCharsetConf(&config.CharsetConf{})) }()
+
+	encode := func(phone, manu, plate string, cs charset.Charset) *model.PacketData {
+		header := &model.MsgHeader{MsgID: 0x0100, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2013}, PhoneNumber: phone, Charset: cs}
+		m := &model.Msg0100{Header: header, ManufacturerID: manu, DeviceID: "1234567", PlateNumber: plate}
+		pkt, err := m.Encode()
+		require.NoError(t, err)
+		h := &model.MsgHeader{}
+		require.NoError(t, h.Decode(pkt))
+		return &model.PacketData{Header: h, Body: pkt[h.Idx:]}
+	}
+	tests := []struct {
+		name        string
+		pkt         *model.PacketData
+		wantPlate   string
+		wantCharset charset.Charset
+	}{
+		{name: "case1: default", pkt: encode("223456789019", "ABCDE", "京A00019", charset.GBK), wantPlate: "京A00019", wantCharset: charset.GBK},
+		{name: "case2: by manufacturer", pkt: encode("223456789020", "UTF8M", "京A00020", charset.UTF8), wantPlate: "京A00020", wantCharset: charset.UTF8},
+		{name: "case3: by phone", pkt: encode("223456789021", "UTF8M", "㐀A00021", charset.GB18030), wantPlate: "㐀A00021", wantCharset: charset.GB18030},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &model.Msg0100{}
+			require.NoError(t, decodeWithCharset(in, tt.pkt))
+			require.Equal(t, tt.wantPlate, in.PlateNumber)
+			require.Equal(t, tt.wantCharset, in.Header.Charset)
+		})
+	}
+
+	// 注册时确定的字符集，后续消息沿用
+	phone := "223456789020"
+	storage.GetDeviceCache().CacheDevice(&model.Device{Phone: phone, Charset: charset.UTF8})
+	defer storage.GetDeviceCache().DelDeviceByPhone(phone)
+	header := &model.MsgHeader{PhoneNumber: phone}
+	resolveCharset(header)
+	require.Equal(t, charset.UTF8, header.Charset)
+}
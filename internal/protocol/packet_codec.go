@@ -4,10 +4,10 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 
 	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
 	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 const (
@@ -23,6 +23,11 @@ var (
 	ErrEncodeType   = errors.New("Error data type")
 )
 
+// 无法解析的帧：空包、消息头或消息体格式错误。合法但未处理的消息ID不算，如多媒体分包、驾驶员身份信息等
+func IsUndecodable(err error) bool {
+	return errors.Is(err, ErrEmptyPacket) || errors.Is(err, model.ErrDecodeHeader) || errors.Is(err, model.ErrDecodeMsg)
+}
+
 type PacketCodec interface {
 	Decode([]byte) (*model.PacketData, error)
 
@@ -161,7 +166,7 @@ func (pc *JT808PacketCodec) verify(pkt []byte) ([]byte, error) {
 	if expected == actual {
 		return pkt[:n-1], nil
 	}
-	log.Debug().Msgf("verify expect=%v, but actual=%v", expected, actual)
+	logger.For(logger.ModuleCodec).Debug().Msgf("verify expect=%v, but actual=%v", expected, actual)
 	return nil, ErrVerifyFailed
 }
 
@@ -98,3 +98,15 @@ func TestJT808PacketCodec_genVerifier(t *testing.T) {
 		})
 	}
 }
+
+func TestIsUndecodable(t *testing.T) {
+	pc := NewJT808PacketCodec()
+	// 校验码正确，但消息头不完整
+	_, err := pc.Decode(hex.Str2Byte("7E0200000100037E"))
+	require.True(t, IsUndecodable(err), err)
+	_, err = pc.Decode(hex.Str2Byte("7E7E"))
+	require.True(t, IsUndecodable(err), err)
+
+	require.False(t, IsUndecodable(ErrMsgIDNotSupportted))
+	require.False(t, IsUndecodable(ErrVerifyFailed))
+}
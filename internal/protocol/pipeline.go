@@ -2,9 +2,11 @@ package protocol
 
 import (
 	"context"
+	"fmt"
 	"net"
 
 	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 )
 
 // tcp/udp 消息处理组
@@ -70,6 +72,12 @@ func decode() delegateFunc {
 		framePayload := ctx.Value(model.FrameCtxKey{}).(FramePayload)
 		packet, err := p.pc.Decode(framePayload)
 		nxtCtx := context.WithValue(ctx, model.PacketDecodeCtxKey{}, packet)
+		if packet != nil && packet.Header != nil {
+			nxtCtx = logger.WithFields(nxtCtx,
+				"phone", packet.Header.PhoneNumber,
+				"msgId", fmt.Sprintf("0x%04x", packet.Header.MsgID),
+				"serial", packet.Header.SerialNumber)
+		}
 		return nxtCtx, err
 	})
 }
@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"sync"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 消息体以应答流水号开头的终端应答消息，其他上行消息不参与匹配
+var replyMsgIDs = []uint16{0x0001, 0x0104, 0x0201, 0x0302, 0x0500, 0x0700, 0x0802, 0x0805, 0x1205}
+
+// 按<手机号, 应答流水号>匹配平台下发的消息
+type replyKey struct {
+	phone  string
+	serial uint16
+}
+
+type replyWaiter struct {
+	msgIDs []uint16 // 等待的应答消息ID
+	ch     chan *model.PacketData
+}
+
+var (
+	replyWaitersMutex sync.Mutex
+	replyWaiters      = make(map[replyKey]*replyWaiter)
+)
+
+// 等待终端对指定流水号消息的应答，需要在下发消息之前调用，返回的cancel用于结束等待。
+// msgIDs为期望的应答消息ID，不指定时等待任一终端应答消息
+func WaitReply(phone string, serial uint16, msgIDs ...uint16) (<-chan *model.PacketData, func()) {
+	if len(msgIDs) == 0 {
+		msgIDs = replyMsgIDs
+	}
+	key := replyKey{phone: phone, serial: serial}
+	w := &replyWaiter{msgIDs: msgIDs, ch: make(chan *model.PacketData, 1)}
+	replyWaitersMutex.Lock()
+	replyWaiters[key] = w
+	replyWaitersMutex.Unlock()
+	return w.ch, func() {
+		replyWaitersMutex.Lock()
+		defer replyWaitersMutex.Unlock()
+		if replyWaiters[key] == w {
+			delete(replyWaiters, key)
+		}
+	}
+}
+
+func (w *replyWaiter) accept(msgID uint16) bool {
+	for _, id := range w.msgIDs {
+		if id == msgID {
+			return true
+		}
+	}
+	return false
+}
+
+// 将终端应答交给等待方，按应答消息ID和应答流水号匹配，数据会复制一份，避免后续处理修改消息头
+func dispatchReply(pkt *model.PacketData) {
+	if len(pkt.Body) < 2 || pkt.Header.MsgID&0x8000 != 0 {
+		return // 平台下发的消息不是应答
+	}
+	idx := 0
+	key := replyKey{phone: pkt.Header.PhoneNumber, serial: hex.ReadWord(pkt.Body, &idx)}
+
+	replyWaitersMutex.Lock()
+	w, ok := replyWaiters[key]
+	ok = ok && w.accept(pkt.Header.MsgID)
+	if ok {
+		delete(replyWaiters, key)
+	}
+	replyWaitersMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	header := *pkt.Header
+	attr := *pkt.Header.Attr
+	header.Attr = &attr
+	w.ch <- &model.PacketData{
+		Header:       &header,
+		Body:         append([]byte(nil), pkt.Body...),
+		SegCompleted: true,
+	}
+}
+
+// 按已支持的消息类型解析，不支持的消息ID返回ErrMsgIDNotSupportted
+func (mp *JT808MsgProcessor) DecodeIncoming(pkt *model.PacketData) (model.JT808Msg, error) {
+	act, ok := mp.options[pkt.Header.MsgID]
+	if !ok || act.genData == nil {
+		return nil, ErrMsgIDNotSupportted
+	}
+	in := act.genData().Incoming
+	if err := decodeWithCharset(in, pkt); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func TestWaitReply(t *testing.T) {
+	phone := "12345678901234567890"
+	genPkt := func(msgID uint16, body string) *model.PacketData {
+		return &model.PacketData{
+			Header: &model.MsgHeader{MsgID: msgID, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2019}, PhoneNumber: phone},
+			Body:   hex.Str2Byte(body),
+		}
+	}
+	tests := []struct {
+		name      string
+		pkt       *model.PacketData
+		msgIDs    []uint16
+		wantReply bool
+		wantMsg   bool
+	}{
+		{
+			name:      "case1: general reply",
+			pkt:       genPkt(0x0001, "0007810500"),
+			wantReply: true,
+			wantMsg:   true,
+		},
+		{
+			name:      "case2: unsupported reply with expected msg id",
+			pkt:       genPkt(0x0f01, "000701"),
+			msgIDs:    []uint16{0x0f01},
+			wantReply: true,
+		},
+		{
+			name: "case3: serial not match",
+			pkt:  genPkt(0x0001, "0008810500"),
+		},
+		{
+			name: "case4: platform msg",
+			pkt:  genPkt(0x8001, "0007810500"),
+		},
+		{
+			name: "case5: uplink msg not a reply",
+			pkt:  genPkt(0x0200, "0007000000000000"),
+		},
+		{
+			name: "case6: unsupported msg without expected msg id",
+			pkt:  genPkt(0x0f01, "000701"),
+		},
+		{
+			name:   "case7: reply msg id not expected",
+			pkt:    genPkt(0x0001, "0007810500"),
+			msgIDs: []uint16{0x1205},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, cancel := WaitReply(phone, 7, tt.msgIDs...)
+			defer cancel()
+			dispatchReply(tt.pkt)
+			select {
+			case got := <-ch:
+				require.True(t, tt.wantReply)
+				require.Equal(t, tt.pkt.Header.MsgID, got.Header.MsgID)
+				require.Equal(t, tt.pkt.Body, got.Body)
+				msg, err := NewJT808MsgProcessor().DecodeIncoming(got)
+				require.Equal(t, tt.wantMsg, err == nil)
+				if tt.wantMsg {
+					require.Equal(t, uint16(7), msg.(*model.Msg0001).AnswerSerialNumber)
+				}
+			default:
+				require.False(t, tt.wantReply)
+			}
+		})
+	}
+}
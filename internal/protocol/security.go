@@ -0,0 +1,80 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/security"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 启用终端异常检测，终端被隔离时断开连接
+func EnableSecurity(conf *config.SecurityConf) {
+	security.Start(conf).Bus.Subscribe(kickQuarantined)
+}
+
+func kickQuarantined(e *security.Event) {
+	if e.Quarantined {
+		kickDevice(e.Phone, e.Type)
+	}
+}
+
+// 人工隔离终端，未启用异常检测时返回错误
+func QuarantineDevice(phone string) error {
+	detector := security.Default()
+	if detector == nil {
+		return security.ErrSecurityDisabled
+	}
+	detector.Quarantined.Add(&security.QuarantineEntry{Phone: phone, Reason: security.ReasonManual, Since: time.Now()})
+	kickDevice(phone, security.ReasonManual)
+	return nil
+}
+
+func kickDevice(phone, reason string) {
+	cache := storage.GetDeviceCache()
+	device, err := cache.GetDeviceByPhone(phone)
+	if err != nil {
+		return
+	}
+	NewKeepaliveTimer().Cancel(device.Phone)
+	cache.DelDeviceByPhone(device.Phone)
+	if device.Conn != nil {
+		device.Conn.Close()
+	}
+	log.Warn().Str("device", phone).Str("reason", reason).Msg("Quarantine device and close connection")
+}
+
+// 检测注册消息，返回终端是否被隔离
+func inspectRegister(in *model.Msg0100) bool {
+	detector := security.Default()
+	if detector == nil {
+		return false
+	}
+	detector.ObserveRegister(in)
+	return detector.Quarantined.Has(in.Header.PhoneNumber)
+}
+
+// 检测鉴权消息，device为鉴权前缓存的设备，返回终端是否被隔离
+func inspectAuth(in *model.Msg0102, session *model.Session, device *model.Device) bool {
+	detector := security.Default()
+	if detector == nil {
+		return false
+	}
+	var activeAddr string
+	if device.Status != model.DeviceStatusOffline && device.SessionID != "" && device.SessionID != session.ID {
+		if _, err := storage.GetSession(device.SessionID); err == nil {
+			activeAddr = device.SessionID
+		}
+	}
+	detector.ObserveAuth(in, session.ID, activeAddr)
+	return detector.Quarantined.Has(in.Header.PhoneNumber)
+}
+
+func inspectLocation(dg *model.DeviceGeo, sessionID string) {
+	if detector := security.Default(); detector != nil {
+		detector.ObserveLocation(dg, sessionID)
+	}
+}
@@ -0,0 +1,153 @@
+package protocol
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 手机定位终端没有jt808注册流程，由管理接口生成注册消息，制造商和型号用于重放时识别
+const (
+	trackerManufacturer = "PHONE"
+	trackerDeviceMode   = "OsmAnd"
+	trackerPhoneLen     = 12
+)
+
+var (
+	ErrBadTrackerPhone = errors.New("Tracker phone must be at most 12 digits")
+	ErrTrackerExists   = errors.New("Device or plate already registered")
+	ErrNotTracker      = errors.New("Device is not a phone tracker")
+)
+
+// 将手机定位终端的标识转为终端手机号，2013版本按12位左补0
+func NormalizeTrackerPhone(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" || len(id) > trackerPhoneLen {
+		return "", ErrBadTrackerPhone
+	}
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return "", ErrBadTrackerPhone
+		}
+	}
+	return strings.Repeat("0", trackerPhoneLen-len(id)) + id, nil
+}
+
+func trackerHeader(phone string, msgID uint16) *model.MsgHeader {
+	return &model.MsgHeader{
+		MsgID:       msgID,
+		Attr:        &model.MsgBodyAttr{VersionDesc: model.Version2013},
+		PhoneNumber: phone,
+	}
+}
+
+func isTracker(in *model.Msg0100) bool {
+	return in.ManufacturerID == trackerManufacturer && in.DeviceMode == trackerDeviceMode
+}
+
+// 将生成的消息编码后写入WAL，与终端上报的消息一起重放和复制
+func persistMsg(msg model.JT808Msg) error {
+	pc := NewJT808PacketCodec()
+	payload, err := pc.Encode(msg)
+	if err != nil {
+		return errors.Wrap(err, "Fail to encode msg")
+	}
+	pkt, err := pc.Decode(payload)
+	if err != nil {
+		return errors.Wrap(err, "Fail to decode msg")
+	}
+	return persistPacket(pkt)
+}
+
+// 登记手机定位终端，之后才能通过HTTP上报位置
+func RegisterTracker(id, plate string) (*model.Device, error) {
+	phone, err := NormalizeTrackerPhone(id)
+	if err != nil {
+		return nil, err
+	}
+	cache := storage.GetDeviceCache()
+	if cache.HasPhone(phone) || (plate != "" && cache.HasPlate(plate)) {
+		return nil, ErrTrackerExists
+	}
+	in := &model.Msg0100{
+		Header:         trackerHeader(phone, 0x0100),
+		ManufacturerID: trackerManufacturer,
+		DeviceMode:     trackerDeviceMode,
+		DeviceID:       phone[trackerPhoneLen-7:],
+		PlateNumber:    plate,
+	}
+	if err = persistMsg(in); err != nil {
+		return nil, errors.Wrapf(err, "Fail to persist tracker register, phoneNumber=%s", phone)
+	}
+	device := model.NewDevice(in, &model.Session{})
+	device.TransProto = model.HTTPProto
+	device.AuthCode = genAuthCode(device)
+	cache.CacheDevice(device)
+	return device, nil
+}
+
+// 注销手机定位终端，清除设备和位置缓存
+func RemoveTracker(id string) error {
+	phone, err := NormalizeTrackerPhone(id)
+	if err != nil {
+		return err
+	}
+	cache := storage.GetDeviceCache()
+	device, err := cache.GetDeviceByPhone(phone)
+	if err != nil {
+		return err
+	}
+	if device.TransProto != model.HTTPProto {
+		return ErrNotTracker
+	}
+	if err = persistMsg(&model.Msg0003{Header: trackerHeader(phone, 0x0003)}); err != nil {
+		return errors.Wrapf(err, "Fail to persist tracker logout, phoneNumber=%s", phone)
+	}
+	NewKeepaliveTimer().Cancel(phone)
+	observeStatus(phone, device.Status, model.DeviceStatusOffline, model.StatusReasonLogout)
+	cache.DelDeviceByPhone(phone)
+	storage.GetGeoCache().DelGeoByPhone(phone)
+	return nil
+}
+
+// 处理手机定位终端上报的位置，终端需已登记。keepalive为上报间隔的容忍时长，超时后离线
+func ProcessTrackerPosition(in *model.Msg0200, keepalive time.Duration) error {
+	phone, err := NormalizeTrackerPhone(in.Header.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	in.Header.PhoneNumber = phone
+	cache := storage.GetDeviceCache()
+	device, err := cache.GetDeviceByPhone(phone)
+	if err != nil {
+		return err
+	}
+	// 与jt808终端共用手机号时，不允许通过HTTP冒充
+	if device.TransProto != model.HTTPProto {
+		return ErrNotTracker
+	}
+	if err = persistMsg(in); err != nil {
+		return errors.Wrapf(err, "Fail to persist tracker position, phoneNumber=%s", phone)
+	}
+
+	online := device.Status != model.DeviceStatusOffline
+	if !online {
+		observeStatus(phone, device.Status, model.DeviceStatusOnline, model.StatusReasonTracker)
+	}
+	device.Status = model.DeviceStatusOnline
+	device.Keepalive = keepalive
+	device.LastestComTime = time.Now()
+	cache.CacheDevice(device)
+	if !online {
+		// 离线时保活任务已取消，重新上线后再注册
+		timer := NewKeepaliveTimer()
+		timer.Cancel(phone)
+		timer.Register(phone)
+	}
+	return processMsg0200(context.Background(), &model.ProcessData{Incoming: in, Outgoing: &model.Msg8001{}})
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+func TestNormalizeTrackerPhone(t *testing.T) {
+	phone, err := NormalizeTrackerPhone("13800138000")
+	require.NoError(t, err)
+	require.Equal(t, "013800138000", phone)
+	for _, id := range []string{"", "1380013800a", "1234567890123"} {
+		_, err = NormalizeTrackerPhone(id)
+		require.ErrorIs(t, err, ErrBadTrackerPhone)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	cache := storage.GetDeviceCache()
+	require.NoError(t, storage.OpenIngestLog(t.TempDir(), nil))
+
+	device, err := RegisterTracker("13900001111", "浙A12345")
+	require.NoError(t, err)
+	require.Equal(t, "013900001111", device.Phone)
+	require.Equal(t, model.HTTPProto, device.TransProto)
+	_, err = RegisterTracker("13900001111", "")
+	require.ErrorIs(t, err, ErrTrackerExists)
+
+	msg := &model.Msg0200{
+		Header:     trackerHeader("13900001111", 0x0200),
+		StatusSign: (&model.GeoMeta{ACCStatus: 1, LocationStatus: 1}).Encode(),
+		Latitude:   30242718,
+		Longitude:  120111154,
+		Time:       "230125145158",
+	}
+	require.NoError(t, ProcessTrackerPosition(msg, time.Minute))
+	device, err = cache.GetDeviceByPhone("013900001111")
+	require.NoError(t, err)
+	require.Equal(t, model.DeviceStatusOnline, device.Status)
+	geo, err := storage.GetGeoCache().GetGeoLatestByPhone("013900001111")
+	require.NoError(t, err)
+	require.Equal(t, 30.242718, geo.Location.Latitude)
+
+	// 未登记和jt808终端不能通过HTTP上报
+	msg.Header = trackerHeader("13900002222", 0x0200)
+	require.ErrorIs(t, ProcessTrackerPosition(msg, time.Minute), storage.ErrDeviceNotFound)
+	cache.CacheDevice(&model.Device{Phone: "013900003333", TransProto: model.TCPProto})
+	msg.Header = trackerHeader("13900003333", 0x0200)
+	require.ErrorIs(t, ProcessTrackerPosition(msg, time.Minute), ErrNotTracker)
+	require.ErrorIs(t, RemoveTracker("13900003333"), ErrNotTracker)
+	cache.DelDeviceByPhone("013900003333")
+
+	// 重启后从WAL恢复为手机定位终端
+	NewKeepaliveTimer().Cancel("013900001111")
+	cache.DelDeviceByPhone("013900001111")
+	storage.GetGeoCache().DelGeoByPhone("013900001111")
+	require.NoError(t, RecoverFromIngestLog())
+	device, err = cache.GetDeviceByPhone("013900001111")
+	require.NoError(t, err)
+	require.Equal(t, model.HTTPProto, device.TransProto)
+	require.Equal(t, "浙A12345", device.Plate)
+	_, err = storage.GetGeoCache().GetGeoLatestByPhone("013900001111")
+	require.NoError(t, err)
+
+	require.NoError(t, RemoveTracker("13900001111"))
+	require.NoError(t, storage.CloseIngestLog())
+	require.False(t, cache.HasPhone("013900001111"))
+}
@@ -0,0 +1,56 @@
+package quality
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+var ErrQualityDisabled = errors.New("Quality stats is disabled")
+
+var trackerInstance *Tracker
+
+func Start(conf *config.QualityConf) *Tracker {
+	trackerInstance = NewTracker(conf)
+	return trackerInstance
+}
+
+// 返回启用的统计，未启用时为nil
+func Default() *Tracker {
+	return trackerInstance
+}
+
+// 统计终端上报的实时位置，未启用时忽略
+func Observe(dg *model.DeviceGeo) {
+	if trackerInstance == nil {
+		return
+	}
+	trackerInstance.Observe(dg, clock.Now())
+}
+
+// 生成终端的质量报告，汇报间隔与缓存的终端参数对比
+func GetReport(phone string) (*Report, error) {
+	if trackerInstance == nil {
+		return nil, ErrQualityDisabled
+	}
+	return trackerInstance.Report(phone, cachedParams(phone))
+}
+
+// 质量分从低到高的终端排名，limit为0时返回全部
+func GetRanking(limit int) ([]*Report, error) {
+	if trackerInstance == nil {
+		return nil, ErrQualityDisabled
+	}
+	return trackerInstance.Ranking(cachedParams, limit), nil
+}
+
+func cachedParams(phone string) *model.DeviceParams {
+	params, err := storage.GetDeviceParamsCache().GetDeviceParamsByPhone(phone)
+	if err != nil {
+		return nil
+	}
+	return params
+}
@@ -0,0 +1,310 @@
+package quality
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var ErrQualityNotFound = errors.New("quality stats not found")
+
+const (
+	defaultMaxSpeed        = 200 // 单位km/h
+	defaultMinJumpDistance = 500 // 单位m
+	defaultFutureTolerance = 5 * time.Minute
+
+	maxIntervalSamples = 100 // 每种汇报模式保留的最近间隔数
+	maxConsecutiveJump = 3   // 连续漂移超过该次数时，认为上一个定位点本身有误，以当前点为准
+)
+
+// 汇报间隔对应的终端参数
+const (
+	ParamSleepReportInterval   uint32 = 0x0027 // 休眠时汇报时间间隔，单位s
+	ParamDefaultReportInterval uint32 = 0x0029 // 缺省时间汇报间隔，单位s
+)
+
+// 汇报模式，ACC开按缺省间隔，ACC关按休眠间隔
+const (
+	ModeDefault = "default"
+	ModeSleep   = "sleep"
+)
+
+// 卫星数分布的区间
+var satelliteBuckets = []struct {
+	name string
+	max  uint8
+}{
+	{"0-3", 3},
+	{"4-7", 7},
+	{"8-11", 11},
+	{"12+", math.MaxUint8},
+}
+
+const satelliteUnknown = "unknown"
+
+// 单台终端的数据质量
+type Report struct {
+	Phone           string            `json:"phone"`
+	Since           time.Time         `json:"since"`           // 开始统计的时间
+	Points          int               `json:"points"`          // 统计的位置点数
+	NoFix           int               `json:"noFix"`           // 未定位的点数
+	NoFixRate       float64           `json:"noFixRate"`       // 未定位的比例
+	Satellites      map[string]int    `json:"satellites"`      // 卫星数分布，unknown表示未上报0x31附加信息
+	FutureTime      int               `json:"futureTime"`      // 定位时间超前服务器时间的点数
+	DuplicateTime   int               `json:"duplicateTime"`   // 定位时间与上一个点相同的点数
+	OutOfOrder      int               `json:"outOfOrder"`      // 定位时间早于上一个点的点数
+	TimeAnomalyRate float64           `json:"timeAnomalyRate"` // 时间异常的比例
+	Jumps           int               `json:"jumps"`           // 判定为漂移被过滤的点数
+	JumpRate        float64           `json:"jumpRate"`        // 漂移在已定位点中的比例
+	Intervals       []*IntervalReport `json:"intervals"`       // 实际汇报间隔与参数的对比
+	Score           float64           `json:"score"`           // 质量分，0-100，越低越差
+}
+
+// 实际汇报间隔与参数的对比
+type IntervalReport struct {
+	Mode       string  `json:"mode"`
+	ParamID    uint32  `json:"paramId"`
+	Configured uint32  `json:"configured"` // 参数配置的间隔，单位s，0表示未查询到参数
+	Actual     float64 `json:"actual"`     // 实际间隔的中位数，单位s
+	Samples    int     `json:"samples"`
+	Deviation  float64 `json:"deviation"` // 实际间隔偏离配置的比例，未查询到参数时为0
+}
+
+type stats struct {
+	since           time.Time
+	points          int
+	noFix           int
+	satellites      map[string]int
+	future          int
+	duplicate       int
+	outOfOrder      int
+	jumps           int
+	consecutiveJump int
+	intervals       map[string][]float64 // <汇报模式, 最近的间隔>
+
+	last    *model.DeviceGeo // 上一个实时位置
+	lastFix *model.DeviceGeo // 上一个未被过滤的定位点
+}
+
+// 按终端统计实时位置的数据质量
+type Tracker struct {
+	maxSpeed        float64
+	minJumpDistance float64
+	futureTolerance time.Duration
+	minPoints       int
+
+	mutex   *sync.Mutex
+	devices map[string]*stats
+}
+
+func NewTracker(conf *config.QualityConf) *Tracker {
+	t := &Tracker{
+		maxSpeed:        conf.MaxSpeed,
+		minJumpDistance: conf.MinJumpDistance,
+		futureTolerance: time.Duration(conf.FutureTolerance) * time.Second,
+		minPoints:       conf.MinPoints,
+		mutex:           &sync.Mutex{},
+		devices:         make(map[string]*stats),
+	}
+	if t.maxSpeed <= 0 {
+		t.maxSpeed = defaultMaxSpeed
+	}
+	if t.minJumpDistance <= 0 {
+		t.minJumpDistance = defaultMinJumpDistance
+	}
+	if t.futureTolerance <= 0 {
+		t.futureTolerance = defaultFutureTolerance
+	}
+	return t
+}
+
+// 统计一个实时位置，now为服务器收到的时间。
+// 盲区补报的位置本就晚于实时位置到达，不参与统计。
+func (t *Tracker) Observe(dg *model.DeviceGeo, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	s, ok := t.devices[dg.Phone]
+	if !ok {
+		s = &stats{
+			since:      now,
+			satellites: make(map[string]int),
+			intervals:  make(map[string][]float64),
+		}
+		t.devices[dg.Phone] = s
+	}
+	s.points++
+
+	if dg.Satellites == nil {
+		s.satellites[satelliteUnknown]++
+	} else {
+		for _, b := range satelliteBuckets {
+			if *dg.Satellites <= b.max {
+				s.satellites[b.name]++
+				break
+			}
+		}
+	}
+
+	if hex.InDeviceZone(dg.Time).After(now.Add(t.futureTolerance)) {
+		s.future++
+	}
+	if s.last != nil {
+		dt := dg.Time.Sub(s.last.Time)
+		switch {
+		case dt == 0:
+			s.duplicate++
+		case dt < 0:
+			s.outOfOrder++
+		case geoMode(dg) == geoMode(s.last):
+			samples := append(s.intervals[geoMode(dg)], dt.Seconds())
+			if len(samples) > maxIntervalSamples {
+				samples = samples[len(samples)-maxIntervalSamples:]
+			}
+			s.intervals[geoMode(dg)] = samples
+		}
+	}
+	s.last = dg
+
+	if dg.Geo.LocationStatus == 0 {
+		s.noFix++
+		return
+	}
+	if t.isJump(s.lastFix, dg) && s.consecutiveJump < maxConsecutiveJump {
+		s.jumps++
+		s.consecutiveJump++
+		return
+	}
+	s.consecutiveJump = 0
+	s.lastFix = dg
+}
+
+// 相邻定位点的距离和推算速度都超过阈值时视为漂移
+func (t *Tracker) isJump(prev, cur *model.DeviceGeo) bool {
+	if prev == nil || !cur.Time.After(prev.Time) {
+		return false
+	}
+	distance := model.Distance(prev.Location.Latitude, prev.Location.Longitude, cur.Location.Latitude, cur.Location.Longitude)
+	speed := distance / 1000 / cur.Time.Sub(prev.Time).Hours()
+	return distance > t.minJumpDistance && speed > t.maxSpeed
+}
+
+func geoMode(dg *model.DeviceGeo) string {
+	if dg.Geo.ACCStatus == 0 {
+		return ModeSleep
+	}
+	return ModeDefault
+}
+
+// 生成终端的质量报告，params为缓存的终端参数，可以为nil
+func (t *Tracker) Report(phone string, params *model.DeviceParams) (*Report, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	s, ok := t.devices[phone]
+	if !ok {
+		return nil, ErrQualityNotFound
+	}
+	return s.report(phone, params), nil
+}
+
+// 按质量分从低到高排列，点数不足minPoints的终端不参与排名
+func (t *Tracker) Ranking(paramsOf func(phone string) *model.DeviceParams, limit int) []*Report {
+	t.mutex.Lock()
+	reports := make([]*Report, 0, len(t.devices))
+	for phone, s := range t.devices {
+		if s.points < t.minPoints {
+			continue
+		}
+		reports = append(reports, s.report(phone, paramsOf(phone)))
+	}
+	t.mutex.Unlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Score != reports[j].Score {
+			return reports[i].Score < reports[j].Score
+		}
+		return reports[i].Phone < reports[j].Phone
+	})
+	if limit > 0 && len(reports) > limit {
+		reports = reports[:limit]
+	}
+	return reports
+}
+
+// 清除终端的统计，如终端返修后重新统计
+func (t *Tracker) Reset(phone string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, ok := t.devices[phone]
+	delete(t.devices, phone)
+	return ok
+}
+
+func (s *stats) report(phone string, params *model.DeviceParams) *Report {
+	r := &Report{
+		Phone:         phone,
+		Since:         s.since,
+		Points:        s.points,
+		NoFix:         s.noFix,
+		Satellites:    make(map[string]int, len(s.satellites)),
+		FutureTime:    s.future,
+		DuplicateTime: s.duplicate,
+		OutOfOrder:    s.outOfOrder,
+		Jumps:         s.jumps,
+	}
+	for k, v := range s.satellites {
+		r.Satellites[k] = v
+	}
+	r.NoFixRate = ratio(s.noFix, s.points)
+	r.TimeAnomalyRate = ratio(s.future+s.duplicate+s.outOfOrder, s.points)
+	r.JumpRate = ratio(s.jumps, s.points-s.noFix)
+
+	maxDeviation := 0.0
+	for _, mode := range []string{ModeDefault, ModeSleep} {
+		samples := s.intervals[mode]
+		if len(samples) == 0 {
+			continue
+		}
+		ir := &IntervalReport{Mode: mode, ParamID: ParamDefaultReportInterval, Actual: median(samples), Samples: len(samples)}
+		if mode == ModeSleep {
+			ir.ParamID = ParamSleepReportInterval
+		}
+		if params != nil {
+			ir.Configured, _ = params.GetUint32(ir.ParamID)
+		}
+		if ir.Configured > 0 {
+			ir.Deviation = math.Abs(ir.Actual-float64(ir.Configured)) / float64(ir.Configured)
+			maxDeviation = math.Max(maxDeviation, ir.Deviation)
+		}
+		r.Intervals = append(r.Intervals, ir)
+	}
+
+	// 各项按权重扣分，间隔偏离超过1倍按1倍计
+	penalty := 0.3*r.NoFixRate + 0.3*r.TimeAnomalyRate + 0.2*r.JumpRate + 0.2*math.Min(maxDeviation, 1)
+	r.Score = math.Round((1-penalty)*10000) / 100
+	return r
+}
+
+func ratio(n, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+func median(samples []float64) float64 {
+	sorted := make([]float64, len(samples))
+	copy(sorted, samples)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,116 @@
+package quality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func genGeo(phone string, fix bool, lat, lon float64, satellites int, t time.Time) *model.DeviceGeo {
+	dg := &model.DeviceGeo{
+		Phone:    phone,
+		Geo:      &model.GeoMeta{ACCStatus: 1},
+		Location: &model.Location{Latitude: lat, Longitude: lon},
+		Drive:    &model.Drive{},
+		Time:     hex.FromDeviceZone(t), // 终端上报时间的字面值
+	}
+	if fix {
+		dg.Geo.LocationStatus = 1
+	}
+	if satellites >= 0 {
+		s := uint8(satellites)
+		dg.Satellites = &s
+	}
+	return dg
+}
+
+func TestTracker_Report(t *testing.T) {
+	phone := "13800000001"
+	now := time.Now()
+	base := now.Add(-time.Hour)
+	tracker := NewTracker(&config.QualityConf{MaxSpeed: 200, MinJumpDistance: 500, FutureTolerance: 300})
+
+	// 每30s一个点，向东缓慢移动
+	for i := 0; i < 10; i++ {
+		tracker.Observe(genGeo(phone, true, 30.0, 120.0+float64(i)*0.001, 9, base.Add(time.Duration(i)*30*time.Second)), now)
+	}
+	last := base.Add(9 * 30 * time.Second)
+	tracker.Observe(genGeo(phone, true, 31.0, 121.0, 9, last.Add(30*time.Second)), now)   // 30s跳变上百公里
+	tracker.Observe(genGeo(phone, false, 0, 0, 2, last.Add(60*time.Second)), now)         // 未定位
+	tracker.Observe(genGeo(phone, true, 30.0, 120.01, -1, last.Add(60*time.Second)), now) // 时间重复，未上报卫星数
+	tracker.Observe(genGeo(phone, true, 30.0, 120.01, 9, last.Add(-time.Minute)), now)    // 时间倒退
+	tracker.Observe(genGeo(phone, true, 30.0, 120.01, 9, now.Add(time.Hour)), now)        // 时间超前
+
+	params := &model.DeviceParams{Params: []*model.ParamData{
+		{ParamID: ParamDefaultReportInterval, ParamLen: 4, ParamValue: uint32(10)},
+	}}
+	r, err := tracker.Report(phone, params)
+	require.NoError(t, err)
+	require.Equal(t, 15, r.Points)
+	require.Equal(t, 1, r.NoFix)
+	require.Equal(t, map[string]int{"0-3": 1, "8-11": 13, "unknown": 1}, r.Satellites)
+	require.Equal(t, 1, r.DuplicateTime)
+	require.Equal(t, 1, r.OutOfOrder)
+	require.Equal(t, 1, r.FutureTime)
+	require.Equal(t, 1, r.Jumps)
+	require.InDelta(t, 1.0/14, r.JumpRate, 1e-9)
+
+	require.Len(t, r.Intervals, 1)
+	require.Equal(t, ModeDefault, r.Intervals[0].Mode)
+	require.Equal(t, uint32(10), r.Intervals[0].Configured)
+	require.Equal(t, float64(30), r.Intervals[0].Actual)
+	require.Equal(t, float64(2), r.Intervals[0].Deviation)
+	require.Less(t, r.Score, float64(80))
+
+	_, err = tracker.Report("13800000002", nil)
+	require.ErrorIs(t, err, ErrQualityNotFound)
+}
+
+func TestTracker_ConsecutiveJumps(t *testing.T) {
+	phone := "13800000001"
+	now := time.Now()
+	base := now.Add(-time.Hour)
+	tracker := NewTracker(&config.QualityConf{})
+
+	// 首个点本身漂移，之后的点连续被判定为漂移，超过次数后以新位置为准
+	tracker.Observe(genGeo(phone, true, 31.0, 121.0, 9, base), now)
+	for i := 1; i <= 6; i++ {
+		tracker.Observe(genGeo(phone, true, 30.0, 120.0, 9, base.Add(time.Duration(i)*10*time.Second)), now)
+	}
+	r, err := tracker.Report(phone, nil)
+	require.NoError(t, err)
+	require.Equal(t, maxConsecutiveJump, r.Jumps)
+	require.Equal(t, uint32(0), r.Intervals[0].Configured)
+	require.Equal(t, float64(0), r.Intervals[0].Deviation)
+}
+
+func TestTracker_Ranking(t *testing.T) {
+	now := time.Now()
+	base := now.Add(-time.Hour)
+	tracker := NewTracker(&config.QualityConf{MinPoints: 5})
+	for i := 0; i < 10; i++ {
+		ts := base.Add(time.Duration(i) * 10 * time.Second)
+		tracker.Observe(genGeo("good", true, 30.0, 120.0, 9, ts), now)
+		tracker.Observe(genGeo("bad", i%2 == 0, 30.0, 120.0, 2, ts), now)
+		if i < 3 {
+			tracker.Observe(genGeo("few", false, 0, 0, 0, ts), now) // 点数不足，不参与排名
+		}
+	}
+	paramsOf := func(string) *model.DeviceParams { return nil }
+
+	reports := tracker.Ranking(paramsOf, 0)
+	require.Len(t, reports, 2)
+	require.Equal(t, "bad", reports[0].Phone)
+	require.Equal(t, "good", reports[1].Phone)
+	require.Equal(t, float64(100), reports[1].Score)
+
+	require.Len(t, tracker.Ranking(paramsOf, 1), 1)
+	require.True(t, tracker.Reset("bad"))
+	require.False(t, tracker.Reset("bad"))
+	require.Len(t, tracker.Ranking(paramsOf, 0), 1)
+}
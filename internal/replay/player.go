@@ -0,0 +1,243 @@
+// Package replay 按历史定位时间的间隔回放终端轨迹，用于前端以直播的方式展示历史路线。
+package replay
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var (
+	ErrInvalidSpeed   = errors.New("Speed must be in (0, 1000]")
+	ErrInvalidCommand = errors.New("Invalid replay command")
+)
+
+const (
+	MaxSpeed = 1000
+	// 缩放后的间隔上限，避免长时间停车没有数据时客户端一直等待
+	maxDelay = 10 * time.Second
+)
+
+// 帧类型
+const (
+	FrameTypePosition = "position"
+	FrameTypeEvent    = "event"
+	FrameTypeState    = "state"
+	FrameTypeError    = "error"
+)
+
+// 事件类型
+const (
+	EventACCOn     = "acc_on"
+	EventACCOff    = "acc_off"
+	EventAlarm     = "alarm"      // 报警标志位新置位
+	EventStopStart = "stop_start" // 开始停留
+	EventStopEnd   = "stop_end"   // 结束停留
+)
+
+// 回放状态
+const (
+	StatusPlaying = "playing"
+	StatusPaused  = "paused"
+	StatusEnded   = "ended"
+)
+
+// 客户端指令
+const (
+	ActionPause  = "pause"
+	ActionResume = "resume"
+	ActionSeek   = "seek"  // 跳转到time
+	ActionSpeed  = "speed" // 调整倍速为speed
+)
+
+// 推送给客户端的一帧，终端时间按GMT+8换算
+type Frame struct {
+	Type  string           `json:"type"`
+	Time  time.Time        `json:"time"`
+	Geo   *model.DeviceGeo `json:"geo,omitempty"`
+	Event *Event           `json:"event,omitempty"`
+	State *State           `json:"state,omitempty"`
+	Err   string           `json:"err,omitempty"`
+}
+
+type Event struct {
+	Kind      string  `json:"kind"`
+	Name      string  `json:"name,omitempty"` // 报警名称，如overspeed
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// 回放进度，每次状态变化时推送
+type State struct {
+	Status string    `json:"status"`
+	Speed  float64   `json:"speed"`
+	Cursor time.Time `json:"cursor"` // 下一帧的时间，结束时为最后一帧的时间
+	Index  int       `json:"index"`  // 下一帧的序号
+	Total  int       `json:"total"`
+}
+
+// 客户端发送的指令
+type Command struct {
+	Action string     `json:"action"`
+	Time   *time.Time `json:"time,omitempty"`
+	Speed  float64    `json:"speed,omitempty"`
+}
+
+// 由位置和停留点生成按时间排序的帧，ACC变化和报警从相邻位置的状态位推导
+func BuildFrames(geos []*model.DeviceGeo, stops []*model.Stop) []*Frame {
+	frames := make([]*Frame, 0, len(geos)+2*len(stops))
+	var prev *model.DeviceGeo
+	for _, dg := range geos {
+		t := hex.InDeviceZone(dg.Time)
+		frames = append(frames, &Frame{Type: FrameTypePosition, Time: t, Geo: dg})
+		event := func(kind, name string) {
+			frames = append(frames, &Frame{Type: FrameTypeEvent, Time: t, Event: &Event{
+				Kind: kind, Name: name, Latitude: dg.Location.Latitude, Longitude: dg.Location.Longitude,
+			}})
+		}
+		if prev != nil && prev.Geo.ACCStatus != dg.Geo.ACCStatus {
+			if dg.Geo.ACCStatus == 1 {
+				event(EventACCOn, "")
+			} else {
+				event(EventACCOff, "")
+			}
+		}
+		// 首个位置的报警视为新置位，让客户端知道回放开始时的报警
+		raised := dg.AlarmSign
+		if prev != nil {
+			raised &^= prev.AlarmSign
+		}
+		for bit := 0; bit < 32; bit++ {
+			if raised&(1<<bit) != 0 {
+				event(EventAlarm, model.AlarmSignName(bit))
+			}
+		}
+		prev = dg
+	}
+	for _, s := range stops {
+		frames = append(frames,
+			&Frame{Type: FrameTypeEvent, Time: hex.InDeviceZone(s.Start), Event: &Event{Kind: EventStopStart, Latitude: s.Latitude, Longitude: s.Longitude}},
+			&Frame{Type: FrameTypeEvent, Time: hex.InDeviceZone(s.End), Event: &Event{Kind: EventStopEnd, Latitude: s.Latitude, Longitude: s.Longitude}},
+		)
+	}
+	sort.SliceStable(frames, func(i, j int) bool {
+		return frames[i].Time.Before(frames[j].Time)
+	})
+	return frames
+}
+
+// 按帧的时间间隔除以倍速推送，支持暂停、跳转和调整倍速
+type Player struct {
+	frames []*Frame
+	speed  float64
+	index  int
+	paused bool
+	seeked bool // 刚跳转过，下一帧立即推送
+}
+
+func NewPlayer(frames []*Frame, speed float64) (*Player, error) {
+	if speed <= 0 || speed > MaxSpeed {
+		return nil, ErrInvalidSpeed
+	}
+	return &Player{frames: frames, speed: speed}, nil
+}
+
+// 回放直到ctx结束或指令通道关闭，回放结束后仍可跳转重新开始。send返回错误时退出
+func (p *Player) Run(ctx context.Context, commands <-chan *Command, send func(*Frame) error) error {
+	if err := send(p.state()); err != nil {
+		return err
+	}
+	for {
+		var timer <-chan time.Time
+		if !p.paused && p.index < len(p.frames) {
+			timer = time.After(p.delay())
+		}
+		select {
+		case <-timer:
+			if err := send(p.frames[p.index]); err != nil {
+				return err
+			}
+			p.index++
+			p.seeked = false
+			if p.index == len(p.frames) {
+				if err := send(p.state()); err != nil {
+					return err
+				}
+			}
+		case cmd, ok := <-commands:
+			if !ok {
+				return nil
+			}
+			var frame *Frame
+			if err := p.handle(cmd); err != nil {
+				frame = &Frame{Type: FrameTypeError, Time: time.Now(), Err: err.Error()}
+			} else {
+				frame = p.state()
+			}
+			if err := send(frame); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *Player) handle(cmd *Command) error {
+	switch cmd.Action {
+	case ActionPause:
+		p.paused = true
+	case ActionResume:
+		p.paused = false
+	case ActionSeek:
+		if cmd.Time == nil {
+			return errors.Wrap(ErrInvalidCommand, "seek requires time")
+		}
+		p.seeked = true
+		p.index = sort.Search(len(p.frames), func(i int) bool {
+			return !p.frames[i].Time.Before(*cmd.Time)
+		})
+	case ActionSpeed:
+		if cmd.Speed <= 0 || cmd.Speed > MaxSpeed {
+			return ErrInvalidSpeed
+		}
+		p.speed = cmd.Speed
+	default:
+		return errors.Wrapf(ErrInvalidCommand, "action=%s", cmd.Action)
+	}
+	return nil
+}
+
+// 下一帧与上一帧的间隔按倍速缩放，开始或跳转后的第一帧立即推送
+func (p *Player) delay() time.Duration {
+	if p.index == 0 || p.seeked {
+		return 0
+	}
+	d := time.Duration(float64(p.frames[p.index].Time.Sub(p.frames[p.index-1].Time)) / p.speed)
+	if d > maxDelay {
+		d = maxDelay
+	}
+	return d
+}
+
+func (p *Player) state() *Frame {
+	s := &State{Status: StatusPlaying, Speed: p.speed, Index: p.index, Total: len(p.frames)}
+	switch {
+	case p.index >= len(p.frames):
+		s.Status = StatusEnded
+		if len(p.frames) > 0 {
+			s.Cursor = p.frames[len(p.frames)-1].Time
+		}
+	case p.paused:
+		s.Status = StatusPaused
+		s.Cursor = p.frames[p.index].Time
+	default:
+		s.Cursor = p.frames[p.index].Time
+	}
+	return &Frame{Type: FrameTypeState, Time: time.Now(), State: s}
+}
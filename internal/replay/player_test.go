@@ -0,0 +1,137 @@
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func genGeo(acc uint8, alarm uint32, t time.Time) *model.DeviceGeo {
+	return &model.DeviceGeo{
+		Phone:     "13800000001",
+		Geo:       &model.GeoMeta{ACCStatus: acc, LocationStatus: 1},
+		Location:  &model.Location{Latitude: 30, Longitude: 120},
+		Drive:     &model.Drive{},
+		Time:      t,
+		AlarmSign: alarm,
+	}
+}
+
+// 启动回放，返回指令通道和推送的帧
+func startPlayer(t *testing.T, frames []*Frame, speed float64) (chan<- *Command, <-chan *Frame) {
+	player, err := NewPlayer(frames, speed)
+	require.NoError(t, err)
+	commands := make(chan *Command)
+	out := make(chan *Frame)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = player.Run(ctx, commands, func(f *Frame) error {
+			select {
+			case out <- f:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+		close(out)
+	}()
+	return commands, out
+}
+
+func TestBuildFrames(t *testing.T) {
+	base := time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC)
+	geos := []*model.DeviceGeo{
+		genGeo(1, 0b01, base),
+		genGeo(1, 0b11, base.Add(time.Minute)),
+		genGeo(0, 0b11, base.Add(2*time.Minute)),
+	}
+	stops := []*model.Stop{{Start: base.Add(90 * time.Second), End: base.Add(3 * time.Minute)}}
+
+	frames := BuildFrames(geos, stops)
+	var kinds []string
+	for _, f := range frames {
+		if f.Type == FrameTypeEvent {
+			kinds = append(kinds, f.Event.Kind+":"+f.Event.Name)
+		} else {
+			kinds = append(kinds, f.Type)
+		}
+	}
+	require.Equal(t, []string{
+		"position", "alarm:emergency",
+		"position", "alarm:overspeed",
+		"stop_start:",
+		"position", "acc_off:",
+		"stop_end:",
+	}, kinds)
+	require.Equal(t, hex.InDeviceZone(base), frames[0].Time)
+}
+
+func TestPlayer_Commands(t *testing.T) {
+	base := time.Now()
+	var frames []*Frame
+	for i := 0; i < 4; i++ {
+		frames = append(frames, &Frame{Type: FrameTypePosition, Time: base.Add(time.Duration(i) * time.Hour)})
+	}
+	// 间隔1小时，缩放后超过上限，测试期间不会自动推送下一帧
+	commands, out := startPlayer(t, frames, 1)
+
+	require.Equal(t, StatusPlaying, (<-out).State.Status)
+	require.Equal(t, frames[0], <-out)
+
+	commands <- &Command{Action: ActionPause}
+	require.Equal(t, StatusPaused, (<-out).State.Status)
+
+	seek := base.Add(90 * time.Minute)
+	commands <- &Command{Action: ActionSeek, Time: &seek}
+	state := (<-out).State
+	require.Equal(t, StatusPaused, state.Status)
+	require.Equal(t, 2, state.Index)
+
+	commands <- &Command{Action: ActionSpeed, Speed: 0}
+	require.Equal(t, FrameTypeError, (<-out).Type)
+	commands <- &Command{Action: ActionSpeed, Speed: 60}
+	require.Equal(t, float64(60), (<-out).State.Speed)
+
+	// 跳转后的第一帧立即推送
+	commands <- &Command{Action: ActionResume}
+	require.Equal(t, StatusPlaying, (<-out).State.Status)
+	require.Equal(t, frames[2], <-out)
+
+	end := base.Add(10 * time.Hour)
+	commands <- &Command{Action: ActionSeek, Time: &end}
+	require.Equal(t, StatusEnded, (<-out).State.Status)
+
+	commands <- &Command{Action: "rewind"}
+	require.Equal(t, FrameTypeError, (<-out).Type)
+
+	close(commands)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestPlayer_ScaledDelay(t *testing.T) {
+	base := time.Now()
+	var frames []*Frame
+	for i := 0; i < 5; i++ {
+		frames = append(frames, &Frame{Type: FrameTypePosition, Time: base.Add(time.Duration(i) * time.Second)})
+	}
+	_, out := startPlayer(t, frames, 50)
+
+	<-out // playing
+	begin := time.Now()
+	for i := 0; i < len(frames); i++ {
+		require.Equal(t, frames[i], <-out)
+	}
+	elapsed := time.Since(begin)
+	require.GreaterOrEqual(t, elapsed, 80*time.Millisecond) // 4个1s的间隔按50倍速缩放
+	require.Less(t, elapsed, time.Second)
+	require.Equal(t, StatusEnded, (<-out).State.Status)
+
+	_, err := NewPlayer(frames, MaxSpeed+1)
+	require.ErrorIs(t, err, ErrInvalidSpeed)
+}
@@ -0,0 +1,173 @@
+// Package replication, 主备复制。
+//
+// 备节点连接主节点的复制端口，握手时带上检查点（已复制的主节点WAL seq），主节点从检查点之后开始持续推送WAL记录，
+// 空闲时推送心跳。备节点落盘并应用一批记录后回复确认，检查点随记录一起写入备节点的WAL，重启后从检查点续传。
+//
+// 握手:   magic[4] + version[1] + checkpoint[8] + tokenLen[2] + token
+// 应答:   status[1] + nextSeq[8]
+// 记录帧: 0x01 + seq[8] + dataLen[4] + data
+// 心跳帧: 0x02 + nextSeq[8]
+// 确认:   checkpoint[8]
+package replication
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+const (
+	magic   = "JTRP"
+	version = 1
+
+	frameRecord    byte = 0x01
+	frameHeartbeat byte = 0x02
+
+	maxTokenLen = 1024
+	maxDataLen  = 64 * 1024 * 1024
+)
+
+// 握手结果
+const (
+	statusOK byte = iota
+	statusUnauthorized
+	statusCheckpointExpired
+	statusDiverged
+)
+
+var (
+	ErrBadHandshake       = errors.New("Bad replication handshake")
+	ErrUnauthorized       = errors.New("Replication token mismatch")
+	ErrCheckpointExpired  = errors.New("Checkpoint expired on primary, standby needs a full resync")
+	ErrDiverged           = errors.New("Standby is ahead of primary")
+	ErrUnexpectedRecord   = errors.New("Unexpected replication record")
+	ErrBadFrame           = errors.New("Bad replication frame")
+	ErrNotStandby         = errors.New("Node is not a standby")
+	ErrAlreadyPromoted    = errors.New("Standby already promoted")
+	ErrReplicationStopped = errors.New("Replication stopped")
+)
+
+func statusErr(status byte) error {
+	switch status {
+	case statusOK:
+		return nil
+	case statusUnauthorized:
+		return ErrUnauthorized
+	case statusCheckpointExpired:
+		return ErrCheckpointExpired
+	case statusDiverged:
+		return ErrDiverged
+	}
+	return ErrBadHandshake
+}
+
+func writeHandshake(w io.Writer, checkpoint uint64, token string) error {
+	buf := make([]byte, 0, 4+1+8+2+len(token))
+	buf = append(buf, magic...)
+	buf = append(buf, version)
+	buf = binary.BigEndian.AppendUint64(buf, checkpoint)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(token)))
+	buf = append(buf, token...)
+	_, err := w.Write(buf)
+	return err
+}
+
+func readHandshake(r io.Reader) (checkpoint uint64, token string, err error) {
+	head := make([]byte, 4+1+8+2)
+	if _, err = io.ReadFull(r, head); err != nil {
+		return 0, "", errors.Wrap(err, "Fail to read handshake")
+	}
+	if string(head[:4]) != magic || head[4] != version {
+		return 0, "", ErrBadHandshake
+	}
+	checkpoint = binary.BigEndian.Uint64(head[5:13])
+	tokenLen := binary.BigEndian.Uint16(head[13:15])
+	if tokenLen > maxTokenLen {
+		return 0, "", ErrBadHandshake
+	}
+	buf := make([]byte, tokenLen)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return 0, "", errors.Wrap(err, "Fail to read handshake token")
+	}
+	return checkpoint, string(buf), nil
+}
+
+func writeHandshakeReply(w io.Writer, status byte, nextSeq uint64) error {
+	buf := binary.BigEndian.AppendUint64([]byte{status}, nextSeq)
+	_, err := w.Write(buf)
+	return err
+}
+
+func readHandshakeReply(r io.Reader) (uint64, error) {
+	buf := make([]byte, 9)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, errors.Wrap(err, "Fail to read handshake reply")
+	}
+	return binary.BigEndian.Uint64(buf[1:]), statusErr(buf[0])
+}
+
+func writeRecord(w *bufio.Writer, rec *wal.Record) error {
+	head := make([]byte, 0, 13)
+	head = append(head, frameRecord)
+	head = binary.BigEndian.AppendUint64(head, rec.Seq)
+	head = binary.BigEndian.AppendUint32(head, uint32(len(rec.Data)))
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	_, err := w.Write(rec.Data)
+	return err
+}
+
+func writeHeartbeat(w *bufio.Writer, nextSeq uint64) error {
+	_, err := w.Write(binary.BigEndian.AppendUint64([]byte{frameHeartbeat}, nextSeq))
+	return err
+}
+
+// 读取一帧，记录帧返回记录，心跳帧返回主节点的下一个seq
+func readFrame(r *bufio.Reader) (*wal.Record, uint64, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, 0, err
+	}
+	buf := make([]byte, 8)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return nil, 0, err
+	}
+	seq := binary.BigEndian.Uint64(buf)
+	switch typ {
+	case frameHeartbeat:
+		return nil, seq, nil
+	case frameRecord:
+	default:
+		return nil, 0, errors.Wrapf(ErrBadFrame, "type=%d", typ)
+	}
+	if _, err = io.ReadFull(r, buf[:4]); err != nil {
+		return nil, 0, err
+	}
+	dataLen := binary.BigEndian.Uint32(buf[:4])
+	if dataLen > maxDataLen {
+		return nil, 0, errors.Wrapf(ErrBadFrame, "dataLen=%d", dataLen)
+	}
+	rec := &wal.Record{Seq: seq, Data: make([]byte, dataLen)}
+	if _, err = io.ReadFull(r, rec.Data); err != nil {
+		return nil, 0, err
+	}
+	return rec, 0, nil
+}
+
+func writeAck(w io.Writer, checkpoint uint64) error {
+	_, err := w.Write(binary.BigEndian.AppendUint64(nil, checkpoint))
+	return err
+}
+
+func readAck(r io.Reader) (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
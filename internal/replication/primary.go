@@ -0,0 +1,230 @@
+package replication
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+const (
+	defaultHeartbeat = 5 // 单位s
+	handshakeTimeout = 10 * time.Second
+)
+
+// 复制的数据源，即入库消息的WAL
+type Source interface {
+	FirstSeq() uint64
+	NextSeq() uint64
+	Follow(from uint64, stop <-chan struct{}, fn func([]*wal.Record) error) error
+}
+
+// 主节点上连接的备节点
+type StandbyLink struct {
+	Addr      string    `json:"addr"`
+	Since     time.Time `json:"since"`
+	Sent      uint64    `json:"sent"`      // 已推送的最大seq
+	Acked     uint64    `json:"acked"`     // 备节点确认的检查点
+	AckedTime time.Time `json:"ackedTime"` // 最近一次确认的时间
+}
+
+type Primary struct {
+	source    Source
+	token     string
+	heartbeat time.Duration
+
+	listener net.Listener
+	mutex    *sync.Mutex
+	links    map[net.Conn]*StandbyLink
+}
+
+func NewPrimary(conf *config.ReplicationConf, source Source) *Primary {
+	p := &Primary{
+		source:    source,
+		token:     conf.Token,
+		heartbeat: time.Duration(conf.Heartbeat) * time.Second,
+		mutex:     &sync.Mutex{},
+		links:     make(map[net.Conn]*StandbyLink),
+	}
+	if p.heartbeat <= 0 {
+		p.heartbeat = defaultHeartbeat * time.Second
+	}
+	return p
+}
+
+func (p *Primary) Listen(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to listen replication addr, addr=%s", addr)
+	}
+	p.listener = l
+	return nil
+}
+
+func (p *Primary) Addr() net.Addr {
+	return p.listener.Addr()
+}
+
+// 接受备节点的连接，每个备节点独立推送
+func (p *Primary) Serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Fail to accept replication connection")
+			continue
+		}
+		routines.GoSafe(func() { p.serveConn(conn) })
+	}
+}
+
+func (p *Primary) Close() error {
+	err := p.listener.Close()
+	p.mutex.Lock()
+	for conn := range p.links {
+		conn.Close()
+	}
+	p.mutex.Unlock()
+	return err
+}
+
+// 按备节点地址排序的连接状态
+func (p *Primary) Links() []*StandbyLink {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	ans := make([]*StandbyLink, 0, len(p.links))
+	for _, link := range p.links {
+		l := *link
+		ans = append(ans, &l)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Addr < ans[j].Addr
+	})
+	return ans
+}
+
+func (p *Primary) serveConn(conn net.Conn) {
+	defer conn.Close()
+	addr := conn.RemoteAddr().String()
+	from, err := p.handshake(conn)
+	if err != nil {
+		log.Warn().Err(err).Str("standby", addr).Msg("Reject replication connection")
+		return
+	}
+
+	link := &StandbyLink{Addr: addr, Since: time.Now(), Acked: from - 1}
+	p.mutex.Lock()
+	p.links[conn] = link
+	p.mutex.Unlock()
+	defer func() {
+		p.mutex.Lock()
+		delete(p.links, conn)
+		p.mutex.Unlock()
+	}()
+	log.Info().Str("standby", addr).Uint64("from", from).Msg("Standby connected, start replication")
+
+	stop := make(chan struct{})
+	// 读取备节点的确认，超过3个心跳间隔没有确认时断开
+	routines.GoSafe(func() {
+		defer close(stop)
+		for {
+			_ = conn.SetReadDeadline(time.Now().Add(3 * p.heartbeat))
+			checkpoint, err := readAck(conn)
+			if err != nil {
+				return
+			}
+			p.mutex.Lock()
+			link.Acked, link.AckedTime = checkpoint, time.Now()
+			p.mutex.Unlock()
+		}
+	})
+
+	writer := bufio.NewWriter(conn)
+	writeMu := &sync.Mutex{}
+	routines.GoSafe(func() {
+		ticker := time.NewTicker(p.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			writeMu.Lock()
+			_ = conn.SetWriteDeadline(time.Now().Add(3 * p.heartbeat))
+			err := writeHeartbeat(writer, p.source.NextSeq())
+			if err == nil {
+				err = writer.Flush()
+			}
+			writeMu.Unlock()
+			if err != nil {
+				conn.Close()
+				return
+			}
+		}
+	})
+
+	err = p.source.Follow(from, stop, func(records []*wal.Record) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(3 * p.heartbeat))
+		for _, rec := range records {
+			if err := writeRecord(writer, rec); err != nil {
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		p.mutex.Lock()
+		link.Sent = records[len(records)-1].Seq
+		p.mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("standby", addr).Msg("Replication to standby interrupted")
+		return
+	}
+	log.Info().Str("standby", addr).Msg("Standby disconnected")
+}
+
+// 校验握手，返回要推送的第一个seq
+func (p *Primary) handshake(conn net.Conn) (uint64, error) {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+	checkpoint, token, err := readHandshake(conn)
+	if err != nil {
+		return 0, err
+	}
+	first, next := p.source.FirstSeq(), p.source.NextSeq()
+	from := checkpoint + 1
+	status := statusOK
+	switch {
+	case subtle.ConstantTimeCompare([]byte(token), []byte(p.token)) != 1:
+		status = statusUnauthorized
+	case checkpoint >= next:
+		status = statusDiverged // 主节点的WAL被重建过
+	case checkpoint == 0 && from < first:
+		from = first // 新的备节点从最早的记录开始，与主节点重启恢复的数据一致
+	case from < first:
+		status = statusCheckpointExpired // 中间的记录已被清理，续传会丢失数据
+	}
+	if err = writeHandshakeReply(conn, status, next); err != nil {
+		return 0, errors.Wrap(err, "Fail to reply handshake")
+	}
+	if status != statusOK {
+		return 0, statusErr(status)
+	}
+	return from, nil
+}
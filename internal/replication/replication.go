@@ -0,0 +1,117 @@
+package replication
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+)
+
+var ErrReplicationDisabled = errors.New("Replication is disabled")
+
+// 节点角色
+const (
+	RolePrimary = "primary"
+	RoleStandby = "standby"
+)
+
+// 复制节点的状态
+type Status struct {
+	Role     string         `json:"role"`
+	Standby  *StandbyState  `json:"standby,omitempty"`  // 备节点的复制进度，提升后保留最后的进度
+	Standbys []*StandbyLink `json:"standbys,omitempty"` // 主节点上连接的备节点
+}
+
+type Node struct {
+	conf   *config.ReplicationConf
+	source Source
+
+	mutex   *sync.Mutex
+	primary *Primary
+	standby *Standby
+}
+
+var nodeInstance *Node
+
+// 启动复制。主节点监听复制端口；备节点从checkpoint之后开始复制，提升后监听复制端口，
+// apply负责将复制的记录落盘并应用到缓存
+func Start(conf *config.ReplicationConf, source Source, checkpoint uint64, apply ApplyFunc) (*Node, error) {
+	n := &Node{conf: conf, source: source, mutex: &sync.Mutex{}}
+	if conf.Role == RoleStandby {
+		n.standby = NewStandby(conf, checkpoint, apply)
+		routines.GoSafe(n.standby.Run)
+	} else if err := n.servePrimary(); err != nil {
+		return nil, err
+	}
+	nodeInstance = n
+	return n, nil
+}
+
+// 返回启用的复制节点，未启用时为nil
+func Default() *Node {
+	return nodeInstance
+}
+
+// 备节点在提升前阻塞，主节点或未启用复制时直接返回
+func WaitPromoted() {
+	if nodeInstance == nil || nodeInstance.standby == nil {
+		return
+	}
+	log.Info().Msg("Standby waits for promotion before accepting devices")
+	<-nodeInstance.standby.Promoted()
+}
+
+func (n *Node) servePrimary() error {
+	if n.conf.Listen == "" {
+		return nil // 不接受备节点
+	}
+	p := NewPrimary(n.conf, n.source)
+	if err := p.Listen(n.conf.Listen); err != nil {
+		return err
+	}
+	routines.GoSafe(p.Serve)
+	n.mutex.Lock()
+	n.primary = p
+	n.mutex.Unlock()
+	log.Info().Str("addr", n.conf.Listen).Msg("Serve replication for standbys")
+	return nil
+}
+
+func (n *Node) Role() string {
+	if n.standby != nil {
+		select {
+		case <-n.standby.Promoted():
+		default:
+			return RoleStandby
+		}
+	}
+	return RolePrimary
+}
+
+// 将备节点提升为主节点，停止复制后开始接入终端
+func (n *Node) Promote() error {
+	if n.standby == nil {
+		return ErrNotStandby
+	}
+	if err := n.standby.Promote(); err != nil {
+		return err
+	}
+	return n.servePrimary()
+}
+
+func (n *Node) Status() *Status {
+	s := &Status{Role: n.Role()}
+	if n.standby != nil {
+		s.Standby = n.standby.State()
+	}
+	n.mutex.Lock()
+	p := n.primary
+	n.mutex.Unlock()
+	if p != nil {
+		s.Standbys = p.Links()
+	}
+	return s
+}
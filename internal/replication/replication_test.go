@@ -0,0 +1,150 @@
+package replication
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+// 内存中的备节点存储
+type memStore struct {
+	mutex   sync.Mutex
+	records []*wal.Record
+}
+
+func (m *memStore) apply(records []*wal.Record) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func (m *memStore) datas() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	ans := make([]string, 0, len(m.records))
+	for _, r := range m.records {
+		ans = append(ans, string(r.Data))
+	}
+	return ans
+}
+
+func startPrimary(t *testing.T, w *wal.WAL, token string) *Primary {
+	p := NewPrimary(&config.ReplicationConf{Token: token, Heartbeat: 1}, w)
+	require.NoError(t, p.Listen("127.0.0.1:0"))
+	go p.Serve()
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func appendAll(t *testing.T, w *wal.WAL, datas ...string) {
+	for _, d := range datas {
+		_, err := w.Append([]byte(d))
+		require.NoError(t, err)
+	}
+}
+
+func TestReplication_Stream(t *testing.T) {
+	w, err := wal.Open(t.TempDir(), &wal.Options{})
+	require.NoError(t, err)
+	defer w.Close()
+	appendAll(t, w, "a", "b")
+	p := startPrimary(t, w, "secret")
+
+	conf := &config.ReplicationConf{Primary: p.Addr().String(), Token: "secret", Heartbeat: 1, RetryInterval: 1}
+	store := &memStore{}
+	s := NewStandby(conf, 0, store.apply)
+	go s.Run()
+
+	appendAll(t, w, "c")
+	require.Eventually(t, func() bool { return len(store.datas()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"a", "b", "c"}, store.datas())
+	require.Eventually(t, func() bool {
+		links := p.Links()
+		return len(links) == 1 && links[0].Acked == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	state := s.State()
+	require.True(t, state.Connected)
+	require.Equal(t, uint64(3), state.Checkpoint)
+	require.Zero(t, state.Lag)
+
+	require.NoError(t, s.Promote())
+	require.ErrorIs(t, s.Promote(), ErrAlreadyPromoted)
+	<-s.Promoted()
+	require.True(t, s.State().Promoted)
+
+	// 提升后不再复制
+	appendAll(t, w, "d")
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, store.datas(), 3)
+}
+
+func TestReplication_Checkpoint(t *testing.T) {
+	w, err := wal.Open(t.TempDir(), &wal.Options{})
+	require.NoError(t, err)
+	defer w.Close()
+	appendAll(t, w, "a", "b", "c")
+	p := startPrimary(t, w, "")
+
+	// 从检查点之后续传
+	conf := &config.ReplicationConf{Primary: p.Addr().String(), Heartbeat: 1, RetryInterval: 1}
+	store := &memStore{}
+	s := NewStandby(conf, 2, store.apply)
+	go s.Run()
+	defer s.Promote()
+	require.Eventually(t, func() bool { return len(store.datas()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"c"}, store.datas())
+	require.Equal(t, uint64(3), store.records[0].Seq)
+}
+
+func TestReplication_Handshake(t *testing.T) {
+	w, err := wal.Open(t.TempDir(), &wal.Options{SegmentSize: 1, MaxSegments: 2})
+	require.NoError(t, err)
+	defer w.Close()
+	appendAll(t, w, "a", "b", "c", "d")
+	p := startPrimary(t, w, "secret")
+
+	cases := []struct {
+		token      string
+		checkpoint uint64
+		want       error
+	}{
+		{token: "wrong", checkpoint: 0, want: ErrUnauthorized},
+		{token: "secret", checkpoint: 1, want: ErrCheckpointExpired},
+		{token: "secret", checkpoint: 10, want: ErrDiverged},
+	}
+	for _, c := range cases {
+		conf := &config.ReplicationConf{Primary: p.Addr().String(), Token: c.token, Heartbeat: 1}
+		s := NewStandby(conf, c.checkpoint, (&memStore{}).apply)
+		require.ErrorIs(t, s.sync(), c.want)
+	}
+}
+
+func TestReplication_StopOnExpiredCheckpoint(t *testing.T) {
+	w, err := wal.Open(t.TempDir(), &wal.Options{SegmentSize: 1, MaxSegments: 2})
+	require.NoError(t, err)
+	defer w.Close()
+	appendAll(t, w, "a", "b", "c", "d")
+	p := startPrimary(t, w, "")
+
+	// 检查点已被清理，不再重试
+	conf := &config.ReplicationConf{Primary: p.Addr().String(), Heartbeat: 1, RetryInterval: 1}
+	s := NewStandby(conf, 1, (&memStore{}).apply)
+	go s.Run()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("standby keeps retrying after checkpoint expired")
+	}
+	state := s.State()
+	require.True(t, state.Resync)
+	require.Contains(t, state.Error, ErrCheckpointExpired.Error())
+
+	require.NoError(t, s.Promote())
+	require.True(t, s.State().Promoted)
+}
@@ -0,0 +1,240 @@
+package replication
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+const (
+	defaultRetryInterval = 5 // 单位s
+	maxApplyBatch        = 256
+)
+
+// 落盘并应用一批复制的记录，返回nil后记录视为已复制
+type ApplyFunc func([]*wal.Record) error
+
+// 备节点的复制状态
+type StandbyState struct {
+	Primary     string    `json:"primary"`
+	Connected   bool      `json:"connected"`
+	Checkpoint  uint64    `json:"checkpoint"`  // 已复制的主节点WAL seq
+	PrimaryNext uint64    `json:"primaryNext"` // 主节点下一条记录的seq
+	LastSync    time.Time `json:"lastSync"`    // 最近一次收到主节点数据的时间
+	Lag         uint64    `json:"lag"`         // 待复制的记录数
+	Error       string    `json:"error,omitempty"`
+	Resync      bool      `json:"resync"` // 已停止复制，需要清空WAL目录后重启，从主节点全量复制
+	Promoted    bool      `json:"promoted"`
+}
+
+type Standby struct {
+	token     string
+	heartbeat time.Duration
+	retry     time.Duration
+	apply     ApplyFunc
+
+	mutex     *sync.Mutex
+	state     StandbyState
+	conn      net.Conn
+	promoting bool
+	stop      chan struct{}
+	done      chan struct{}
+	promoted  chan struct{}
+}
+
+// checkpoint为本地已复制的主节点seq，从未复制过时为0
+func NewStandby(conf *config.ReplicationConf, checkpoint uint64, apply ApplyFunc) *Standby {
+	s := &Standby{
+		token:     conf.Token,
+		heartbeat: time.Duration(conf.Heartbeat) * time.Second,
+		retry:     time.Duration(conf.RetryInterval) * time.Second,
+		apply:     apply,
+		mutex:     &sync.Mutex{},
+		state:     StandbyState{Primary: conf.Primary, Checkpoint: checkpoint},
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		promoted:  make(chan struct{}),
+	}
+	if s.heartbeat <= 0 {
+		s.heartbeat = defaultHeartbeat * time.Second
+	}
+	if s.retry <= 0 {
+		s.retry = defaultRetryInterval * time.Second
+	}
+	return s
+}
+
+func (s *Standby) State() *StandbyState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	state := s.state
+	if state.PrimaryNext > state.Checkpoint+1 {
+		state.Lag = state.PrimaryNext - state.Checkpoint - 1
+	}
+	return &state
+}
+
+// 提升为主节点后关闭
+func (s *Standby) Promoted() <-chan struct{} {
+	return s.promoted
+}
+
+// 持续从主节点复制，断线后按重连间隔重试，直到提升为主节点。检查点失效时停止复制
+func (s *Standby) Run() {
+	defer close(s.done)
+	for {
+		err := s.sync()
+		// 检查点已被清理或与主节点不一致时，续传无法恢复，需要全量复制
+		resync := errors.Is(err, ErrCheckpointExpired) || errors.Is(err, ErrDiverged)
+		s.mutex.Lock()
+		s.state.Connected = false
+		s.conn = nil
+		if err != nil {
+			s.state.Error = err.Error()
+		}
+		s.state.Resync = resync
+		s.mutex.Unlock()
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		if resync {
+			log.Error().Err(err).Str("primary", s.state.Primary).
+				Msg("Stop replication, clear the standby wal directory and restart to resync from primary")
+			return
+		}
+		log.Warn().Err(err).Str("primary", s.state.Primary).Dur("retry", s.retry).Msg("Replication from primary interrupted")
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(s.retry):
+		}
+	}
+}
+
+func (s *Standby) sync() error {
+	conn, err := net.DialTimeout("tcp", s.state.Primary, handshakeTimeout)
+	if err != nil {
+		return errors.Wrap(err, "Fail to connect primary")
+	}
+	defer conn.Close()
+	s.mutex.Lock()
+	if s.promoting {
+		s.mutex.Unlock()
+		return ErrReplicationStopped
+	}
+	s.conn = conn
+	checkpoint := s.state.Checkpoint
+	s.mutex.Unlock()
+
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err = writeHandshake(conn, checkpoint, s.token); err != nil {
+		return errors.Wrap(err, "Fail to send handshake")
+	}
+	next, err := readHandshakeReply(conn)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	s.mutex.Lock()
+	s.state.Connected, s.state.PrimaryNext, s.state.LastSync, s.state.Error = true, next, time.Now(), ""
+	s.mutex.Unlock()
+	log.Info().Str("primary", s.state.Primary).Uint64("checkpoint", checkpoint).Uint64("primaryNext", next).
+		Msg("Connected to primary, start replication")
+
+	reader := bufio.NewReader(conn)
+	readFrameWithin := func() (*wal.Record, uint64, error) {
+		_ = conn.SetReadDeadline(time.Now().Add(3 * s.heartbeat))
+		return readFrame(reader)
+	}
+	for {
+		rec, next, err := readFrameWithin()
+		if err != nil {
+			return errors.Wrap(err, "Fail to read replication frame")
+		}
+		// 合并已到达的记录，一起落盘
+		var batch []*wal.Record
+		for {
+			if rec != nil {
+				batch = append(batch, rec)
+			} else if next > 0 {
+				s.mutex.Lock()
+				s.state.PrimaryNext = next
+				s.mutex.Unlock()
+			}
+			if len(batch) >= maxApplyBatch || reader.Buffered() == 0 {
+				break
+			}
+			if rec, next, err = readFrameWithin(); err != nil {
+				return errors.Wrap(err, "Fail to read replication frame")
+			}
+		}
+		if checkpoint, err = s.applyBatch(checkpoint, batch); err != nil {
+			return err
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(3 * s.heartbeat))
+		if err = writeAck(conn, checkpoint); err != nil {
+			return errors.Wrap(err, "Fail to send ack")
+		}
+	}
+}
+
+// 应用连续的记录，返回新的检查点
+func (s *Standby) applyBatch(checkpoint uint64, batch []*wal.Record) (uint64, error) {
+	records := batch[:0]
+	for _, rec := range batch {
+		if rec.Seq <= checkpoint {
+			continue // 重复推送的记录
+		}
+		if rec.Seq != checkpoint+1 && !(checkpoint == 0 && len(records) == 0) {
+			return checkpoint, errors.Wrapf(ErrUnexpectedRecord, "seq=%d, checkpoint=%d", rec.Seq, checkpoint)
+		}
+		records = append(records, rec)
+		checkpoint = rec.Seq
+	}
+	if len(records) > 0 {
+		if err := s.apply(records); err != nil {
+			return records[0].Seq - 1, errors.Wrap(err, "Fail to apply replication records")
+		}
+	}
+	s.mutex.Lock()
+	s.state.Checkpoint = checkpoint
+	s.state.LastSync = time.Now()
+	if s.state.PrimaryNext <= checkpoint {
+		s.state.PrimaryNext = checkpoint + 1
+	}
+	s.mutex.Unlock()
+	return checkpoint, nil
+}
+
+// 停止复制并提升为主节点，等待正在应用的记录完成后返回
+func (s *Standby) Promote() error {
+	s.mutex.Lock()
+	if s.promoting {
+		s.mutex.Unlock()
+		return ErrAlreadyPromoted
+	}
+	s.promoting = true
+	close(s.stop)
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.mutex.Unlock()
+
+	<-s.done
+	s.mutex.Lock()
+	s.state.Promoted = true
+	s.state.Error = ""
+	s.mutex.Unlock()
+	close(s.promoted)
+	log.Info().Uint64("checkpoint", s.State().Checkpoint).Msg("Standby promoted to primary")
+	return nil
+}
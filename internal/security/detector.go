@@ -0,0 +1,223 @@
+package security
+
+import (
+	"math"
+	"net"
+	"sync"
+
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+const (
+	defaultMaxSpeed        = 200  // 单位km/h
+	defaultMinJumpDistance = 5000 // 单位m
+	defaultMaxEvents       = 1000
+)
+
+// 同一手机号最近一次上报的身份信息和位置
+type profile struct {
+	deviceID        string
+	imei            string
+	manufacturerID  string
+	versionKnown    bool
+	versionDesc     model.VersionType
+	protocolVersion uint8
+
+	lastGeo     *model.DeviceGeo
+	lastSession string
+}
+
+type Detector struct {
+	quarantine      bool
+	maxSpeed        float64
+	minJumpDistance float64
+
+	mutex    *sync.Mutex
+	profiles map[string]*profile
+
+	Bus         *EventBus
+	Quarantined *Quarantine
+}
+
+func NewDetector(conf *config.SecurityConf) *Detector {
+	d := &Detector{
+		quarantine:      conf.Quarantine,
+		maxSpeed:        conf.MaxSpeed,
+		minJumpDistance: conf.MinJumpDistance,
+		mutex:           &sync.Mutex{},
+		profiles:        make(map[string]*profile),
+		Quarantined:     NewQuarantine(),
+	}
+	if d.maxSpeed <= 0 {
+		d.maxSpeed = defaultMaxSpeed
+	}
+	if d.minJumpDistance <= 0 {
+		d.minJumpDistance = defaultMinJumpDistance
+	}
+	maxEvents := conf.MaxEvents
+	if maxEvents <= 0 {
+		maxEvents = defaultMaxEvents
+	}
+	d.Bus = NewEventBus(maxEvents)
+	return d
+}
+
+func (d *Detector) getProfile(phone string) *profile {
+	p, ok := d.profiles[phone]
+	if !ok {
+		p = &profile{}
+		d.profiles[phone] = p
+	}
+	return p
+}
+
+// 注册时对比终端ID、制造商和协议版本
+func (d *Detector) ObserveRegister(in *model.Msg0100) {
+	phone := in.Header.PhoneNumber
+	var events []*Event
+	d.mutex.Lock()
+	p := d.getProfile(phone)
+	if p.deviceID != "" && p.deviceID != in.DeviceID {
+		events = append(events, newEvent(EventIdentityChanged, phone, map[string]any{
+			"field": "deviceId", "old": p.deviceID, "new": in.DeviceID,
+		}))
+	}
+	if p.manufacturerID != "" && p.manufacturerID != in.ManufacturerID {
+		events = append(events, newEvent(EventProfileChanged, phone, map[string]any{
+			"field": "manufacturerId", "old": p.manufacturerID, "new": in.ManufacturerID,
+		}))
+	}
+	if e := d.checkVersion(p, in.Header); e != nil {
+		events = append(events, e)
+	}
+	p.deviceID, p.manufacturerID = in.DeviceID, in.ManufacturerID
+	d.mutex.Unlock()
+	d.raise(events)
+}
+
+// 鉴权时对比IMEI和协议版本，activeAddr为该手机号仍然在线的连接地址，没有时为空
+func (d *Detector) ObserveAuth(in *model.Msg0102, remoteAddr, activeAddr string) {
+	phone := in.Header.PhoneNumber
+	var events []*Event
+	d.mutex.Lock()
+	p := d.getProfile(phone)
+	if in.IMEI != "" {
+		if p.imei != "" && p.imei != in.IMEI {
+			events = append(events, newEvent(EventIdentityChanged, phone, map[string]any{
+				"field": "imei", "old": p.imei, "new": in.IMEI,
+			}))
+		}
+		p.imei = in.IMEI
+	}
+	if e := d.checkVersion(p, in.Header); e != nil {
+		events = append(events, e)
+	}
+	d.mutex.Unlock()
+
+	// 同一主机的重连可能在旧连接关闭之前完成，只比较主机地址
+	if activeAddr != "" && host(activeAddr) != host(remoteAddr) {
+		events = append(events, newEvent(EventConcurrentSession, phone, map[string]any{
+			"activeAddr": activeAddr, "newAddr": remoteAddr,
+		}))
+	}
+	d.raise(events)
+}
+
+// 协议版本修订号只会随终端升级递增，修订号降低或版本描述变化视为异常
+func (d *Detector) checkVersion(p *profile, h *model.MsgHeader) *Event {
+	defer func() {
+		p.versionKnown, p.versionDesc, p.protocolVersion = true, h.Attr.VersionDesc, h.ProtocolVersion
+	}()
+	if !p.versionKnown {
+		return nil
+	}
+	if p.versionDesc == h.Attr.VersionDesc && p.protocolVersion <= h.ProtocolVersion {
+		return nil
+	}
+	return newEvent(EventProfileChanged, h.PhoneNumber, map[string]any{
+		"field":              "protocolVersion",
+		"oldVersionDesc":     p.versionDesc,
+		"newVersionDesc":     h.Attr.VersionDesc,
+		"oldProtocolVersion": p.protocolVersion,
+		"newProtocolVersion": h.ProtocolVersion,
+	})
+}
+
+// 对比不同连接上报的位置，推算速度超过maxSpeed且距离超过minJumpDistance时视为异常
+func (d *Detector) ObserveLocation(dg *model.DeviceGeo, sessionID string) {
+	if dg == nil || dg.Location == nil {
+		return
+	}
+	var events []*Event
+	d.mutex.Lock()
+	p := d.getProfile(dg.Phone)
+	last, lastSession := p.lastGeo, p.lastSession
+	if last == nil || !dg.Time.Before(last.Time) {
+		p.lastGeo, p.lastSession = dg, sessionID
+	}
+	d.mutex.Unlock()
+
+	if last != nil && lastSession != sessionID {
+		distance := model.Distance(last.Location.Latitude, last.Location.Longitude, dg.Location.Latitude, dg.Location.Longitude)
+		hours := math.Abs(dg.Time.Sub(last.Time).Hours())
+		speed := -1.0 // 定位时间相同，无法推算速度
+		if hours > 0 {
+			speed = math.Round(distance / 1000 / hours)
+		}
+		if distance > d.minJumpDistance && (speed < 0 || speed > d.maxSpeed) {
+			events = append(events, newEvent(EventImpossibleJump, dg.Phone, map[string]any{
+				"from":        []float64{last.Location.Latitude, last.Location.Longitude},
+				"to":          []float64{dg.Location.Latitude, dg.Location.Longitude},
+				"fromTime":    last.Time,
+				"toTime":      dg.Time,
+				"fromSession": lastSession,
+				"toSession":   sessionID,
+				"distance":    math.Round(distance),
+				"speed":       speed, // 单位km/h
+			}))
+		}
+	}
+	d.raise(events)
+}
+
+func (d *Detector) raise(events []*Event) {
+	for _, e := range events {
+		e.ID = d.Bus.nextID()
+		if d.quarantine {
+			e.Quarantined = true
+			d.Quarantined.Add(&QuarantineEntry{Phone: e.Phone, Reason: e.Type, EventID: e.ID, Since: e.Time})
+		}
+		d.Bus.Publish(e)
+	}
+}
+
+func newEvent(typ, phone string, evidence map[string]any) *Event {
+	return &Event{Type: typ, Phone: phone, Time: clock.Now(), Evidence: evidence}
+}
+
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return h
+}
+
+var detectorInstance *Detector
+
+// 启用异常检测，未启用时各检测入口直接返回
+func Start(conf *config.SecurityConf) *Detector {
+	detectorInstance = NewDetector(conf)
+	return detectorInstance
+}
+
+// 返回启用的检测器，未启用时为nil
+func Default() *Detector {
+	return detectorInstance
+}
+
+func IsQuarantined(phone string) bool {
+	return detectorInstance != nil && detectorInstance.Quarantined.Has(phone)
+}
@@ -0,0 +1,115 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func genHeader(phone string, ver model.VersionType, protoVer uint8) *model.MsgHeader {
+	return &model.MsgHeader{Attr: &model.MsgBodyAttr{VersionDesc: ver}, ProtocolVersion: protoVer, PhoneNumber: phone}
+}
+
+func genGeo(phone string, lat, lon float64, t time.Time) *model.DeviceGeo {
+	return &model.DeviceGeo{Phone: phone, Location: &model.Location{Latitude: lat, Longitude: lon}, Time: t}
+}
+
+func eventTypes(events []*Event) []string {
+	ans := []string{}
+	for _, e := range events {
+		ans = append(ans, e.Type)
+	}
+	return ans
+}
+
+func TestDetector(t *testing.T) {
+	phone := "13800000001"
+	base := time.Now()
+	tests := []struct {
+		name    string
+		observe func(d *Detector)
+		want    []string
+	}{
+		{
+			name: "case1: same identity",
+			observe: func(d *Detector) {
+				d.ObserveRegister(&model.Msg0100{Header: genHeader(phone, model.Version2019, 1), ManufacturerID: "M1", DeviceID: "D1"})
+				d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2019, 1), IMEI: "I1"}, "1.1.1.1:1", "")
+				d.ObserveRegister(&model.Msg0100{Header: genHeader(phone, model.Version2019, 2), ManufacturerID: "M1", DeviceID: "D1"})
+				d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2019, 2), IMEI: "I1"}, "1.1.1.1:2", "1.1.1.1:1")
+			},
+			want: []string{},
+		},
+		{
+			name: "case2: device id and imei changed",
+			observe: func(d *Detector) {
+				d.ObserveRegister(&model.Msg0100{Header: genHeader(phone, model.Version2019, 1), ManufacturerID: "M1", DeviceID: "D1"})
+				d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2019, 1), IMEI: "I1"}, "1.1.1.1:1", "")
+				d.ObserveRegister(&model.Msg0100{Header: genHeader(phone, model.Version2019, 1), ManufacturerID: "M1", DeviceID: "D2"})
+				d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2019, 1), IMEI: "I2"}, "1.1.1.1:1", "")
+			},
+			want: []string{EventIdentityChanged, EventIdentityChanged},
+		},
+		{
+			name: "case3: manufacturer and version changed",
+			observe: func(d *Detector) {
+				d.ObserveRegister(&model.Msg0100{Header: genHeader(phone, model.Version2019, 2), ManufacturerID: "M1", DeviceID: "D1"})
+				d.ObserveRegister(&model.Msg0100{Header: genHeader(phone, model.Version2019, 1), ManufacturerID: "M2", DeviceID: "D1"})
+				d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2013, 0)}, "1.1.1.1:1", "")
+			},
+			want: []string{EventProfileChanged, EventProfileChanged, EventProfileChanged},
+		},
+		{
+			name: "case4: concurrent session",
+			observe: func(d *Detector) {
+				d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2013, 0)}, "2.2.2.2:1", "1.1.1.1:1")
+			},
+			want: []string{EventConcurrentSession},
+		},
+		{
+			name: "case5: impossible jump across sessions",
+			observe: func(d *Detector) {
+				d.ObserveLocation(genGeo(phone, 30.0, 120.0, base), "s1")
+				d.ObserveLocation(genGeo(phone, 30.1, 120.0, base.Add(time.Minute)), "s1") // 同一连接内不检测
+				d.ObserveLocation(genGeo(phone, 39.9, 116.4, base.Add(2*time.Minute)), "s2")
+				d.ObserveLocation(genGeo(phone, 39.9, 116.4, base.Add(48*time.Hour)), "s3") // 时间足够长
+			},
+			want: []string{EventImpossibleJump},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(&config.SecurityConf{})
+			tt.observe(d)
+			require.Equal(t, tt.want, eventTypes(d.Bus.List(phone)))
+			require.False(t, d.Quarantined.Has(phone))
+		})
+	}
+}
+
+func TestDetector_Quarantine(t *testing.T) {
+	phone := "13800000002"
+	d := NewDetector(&config.SecurityConf{Quarantine: true})
+	var received []*Event
+	d.Bus.Subscribe(func(e *Event) { received = append(received, e) })
+
+	d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2019, 1), IMEI: "I1"}, "1.1.1.1:1", "")
+	require.False(t, d.Quarantined.Has(phone))
+	d.ObserveAuth(&model.Msg0102{Header: genHeader(phone, model.Version2019, 1), IMEI: "I2"}, "2.2.2.2:1", "1.1.1.1:1")
+
+	require.Len(t, received, 2)
+	require.True(t, received[0].Quarantined)
+	require.Equal(t, map[string]any{"field": "imei", "old": "I1", "new": "I2"}, received[0].Evidence)
+	require.True(t, d.Quarantined.Has(phone))
+	entries := d.Quarantined.List()
+	require.Len(t, entries, 1)
+	require.Equal(t, EventIdentityChanged, entries[0].Reason) // 保留最早的隔离原因
+	require.Equal(t, received[0].ID, entries[0].EventID)
+
+	require.True(t, d.Quarantined.Release(phone))
+	require.False(t, d.Quarantined.Has(phone))
+}
@@ -0,0 +1,87 @@
+// Package security, 终端仿冒和克隆的异常检测。
+//
+// 终端身份只依赖消息头中的手机号，克隆SIM卡或仿冒终端时平台无法直接发现。
+// 检测器对比同一手机号前后的身份信息、连接和位置，发现异常时发布安全事件，并可按配置隔离终端。
+package security
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 安全事件类型
+const (
+	EventIdentityChanged   = "identity_changed"   // 同一手机号的IMEI或终端ID变化
+	EventConcurrentSession = "concurrent_session" // 同一手机号同时从两个地址鉴权
+	EventImpossibleJump    = "impossible_jump"    // 跨连接的位置跳变超出车辆可能的速度
+	EventProfileChanged    = "profile_changed"    // 协议版本或制造商突变
+)
+
+type Event struct {
+	ID          uint64         `json:"id"`
+	Type        string         `json:"type"`
+	Phone       string         `json:"phone"`
+	Time        time.Time      `json:"time"`
+	Evidence    map[string]any `json:"evidence"`    // 异常的证据，如变化前后的值
+	Quarantined bool           `json:"quarantined"` // 是否因该事件隔离了终端
+}
+
+// 安全事件总线，保留最近的事件，并通知订阅者
+type EventBus struct {
+	mutex       *sync.Mutex
+	seq         atomic.Uint64
+	maxEvents   int
+	events      []*Event
+	subscribers []func(*Event)
+}
+
+func NewEventBus(maxEvents int) *EventBus {
+	return &EventBus{mutex: &sync.Mutex{}, maxEvents: maxEvents}
+}
+
+// 订阅安全事件，回调在发布事件的协程中执行，不应阻塞
+func (b *EventBus) Subscribe(fn func(*Event)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+func (b *EventBus) nextID() uint64 {
+	return b.seq.Add(1)
+}
+
+// 发布事件，未分配ID时自动分配
+func (b *EventBus) Publish(e *Event) {
+	if e.ID == 0 {
+		e.ID = b.nextID()
+	}
+	b.mutex.Lock()
+	b.events = append(b.events, e)
+	if b.maxEvents > 0 && len(b.events) > b.maxEvents {
+		b.events = b.events[len(b.events)-b.maxEvents:]
+	}
+	subscribers := b.subscribers
+	b.mutex.Unlock()
+
+	log.Warn().Str("device", e.Phone).Str("type", e.Type).Interface("evidence", e.Evidence).
+		Bool("quarantined", e.Quarantined).Msg("Security event")
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
+
+// 按时间从早到晚返回事件，phone为空时返回全部终端的事件
+func (b *EventBus) List(phone string) []*Event {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ans := make([]*Event, 0, len(b.events))
+	for _, e := range b.events {
+		if phone == "" || e.Phone == phone {
+			ans = append(ans, e)
+		}
+	}
+	return ans
+}
@@ -0,0 +1,66 @@
+package security
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrSecurityDisabled = errors.New("Security detection is disabled")
+
+// 人工隔离的原因
+const ReasonManual = "manual"
+
+// 被隔离的终端，隔离期间注册和鉴权都会被拒绝，直到人工解除
+type QuarantineEntry struct {
+	Phone   string    `json:"phone"`
+	Reason  string    `json:"reason"` // 触发隔离的事件类型，人工隔离时为manual
+	EventID uint64    `json:"eventId,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+type Quarantine struct {
+	mutex   *sync.Mutex
+	entries map[string]*QuarantineEntry
+}
+
+func NewQuarantine() *Quarantine {
+	return &Quarantine{mutex: &sync.Mutex{}, entries: make(map[string]*QuarantineEntry)}
+}
+
+func (q *Quarantine) Add(entry *QuarantineEntry) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if _, ok := q.entries[entry.Phone]; ok {
+		return // 保留最早的隔离原因
+	}
+	q.entries[entry.Phone] = entry
+}
+
+func (q *Quarantine) Release(phone string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	_, ok := q.entries[phone]
+	delete(q.entries, phone)
+	return ok
+}
+
+func (q *Quarantine) Has(phone string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	_, ok := q.entries[phone]
+	return ok
+}
+
+func (q *Quarantine) List() []*QuarantineEntry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	ans := make([]*QuarantineEntry, 0, len(q.entries))
+	for _, e := range q.entries {
+		ans = append(ans, e)
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Since.Before(ans[j].Since) })
+	return ans
+}
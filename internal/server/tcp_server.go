@@ -10,9 +10,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
+	"github.com/fakeyanss/jt808-server-go/internal/ban"
 	"github.com/fakeyanss/jt808-server-go/internal/protocol"
 	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
 	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 	"github.com/fakeyanss/jt808-server-go/pkg/routines"
 )
 
@@ -45,6 +47,9 @@ func (serv *TCPServer) Start() {
 		conn, err := serv.listener.Accept()
 		if err != nil {
 			log.Error().Err(err).Msg("Fail to do listener accept")
+		} else if ban.IsBanned(conn.RemoteAddr().String()) {
+			log.Debug().Str("id", conn.RemoteAddr().String()).Msg("Reject connection from banned ip")
+			conn.Close()
 		} else {
 			session := serv.accept(conn)
 			routines.GoSafe(func() { serv.serve(session) })
@@ -90,16 +95,24 @@ func (serv *TCPServer) serve(session *model.Session) {
 	for {
 		// 记录value ctx
 		ctx := context.WithValue(context.Background(), model.SessionCtxKey{}, session)
+		ctx = logger.WithFields(ctx, "session", session.ID)
 
 		err := pg.ProcessConnRead(ctx)
 
 		if err == nil {
+			if ban.IsBanned(session.ID) {
+				return // 鉴权失败次数过多被封禁
+			}
 			continue
 		}
 
 		log.Error().Err(err).Str("id", session.ID).Msg("Failed to serve session")
 
 		switch {
+		case errors.Is(err, protocol.ErrVerifyFailed) && ban.Record(session.ID, ban.ReasonChecksumError),
+			protocol.IsUndecodable(err) && ban.Record(session.ID, ban.ReasonUndecodable),
+			errors.Is(err, protocol.ErrMsgIDNotSupportted) && ban.Record(session.ID, ban.ReasonUnsupported):
+			return // 错误消息过多被封禁
 		case errors.Is(err, io.EOF), errors.Is(err, io.ErrClosedPipe), errors.Is(err, net.ErrClosed), errors.Is(err, storage.ErrDeviceNotFound):
 			return // close connection when EOF or closed
 		default:
@@ -109,12 +122,13 @@ func (serv *TCPServer) serve(session *model.Session) {
 }
 
 // 发送消息到终端设备, 外部调用
-func (serv *TCPServer) Send(id string, msg model.JT808Msg) {
+// 下发消息，连接已关闭或写入失败时返回错误
+func (serv *TCPServer) Send(id string, msg model.JT808Msg) error {
 	// session := serv.sessions[id]
 	session, err := storage.GetSession(id)
-	if err != nil && errors.Is(err, storage.ErrSessionClosed) {
+	if err != nil {
 		log.Warn().Str("id", id).Msg("Fail to get session from cache, maybe conn was closed.")
-		return
+		return errors.Wrapf(err, "Fail to get session, id=%s", id)
 	}
 
 	pg := protocol.NewPipeline(session.Conn)
@@ -125,7 +139,7 @@ func (serv *TCPServer) Send(id string, msg model.JT808Msg) {
 	err = pg.ProcessConnWrite(ctx)
 
 	if err == nil {
-		return
+		return nil
 	}
 
 	if errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed) {
@@ -133,4 +147,5 @@ func (serv *TCPServer) Send(id string, msg model.JT808Msg) {
 	}
 
 	log.Error().Err(err).Str("device", id).Msg("Failed to send jtmsg to device")
+	return errors.Wrapf(err, "Fail to send jtmsg, id=%s", id)
 }
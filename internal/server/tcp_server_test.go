@@ -5,7 +5,10 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
 )
 
 func TestTCPServer_serve(t *testing.T) {
@@ -33,3 +36,10 @@ func TestTCPServer_serve(t *testing.T) {
 		})
 	}
 }
+
+func TestTCPServer_Send(t *testing.T) {
+	serv := &TCPServer{mutex: &sync.Mutex{}}
+	msg := &model.Msg8001{Header: &model.MsgHeader{MsgID: 0x8001, Attr: &model.MsgBodyAttr{}}}
+	err := serv.Send("127.0.0.1:1", msg)
+	require.ErrorIs(t, err, storage.ErrSessionClosed)
+}
@@ -14,6 +14,7 @@ var ErrDeviceNotFound = errors.New("device not found")
 type DeviceCache struct {
 	cacheByPlate map[string]*model.Device
 	cacheByPhone map[string]*model.Device
+	index        map[string]*searchEntry // <手机号, 搜索字段>
 	mutex        *sync.Mutex
 }
 
@@ -25,6 +26,7 @@ func GetDeviceCache() *DeviceCache {
 		deviceCacheSingleton = &DeviceCache{
 			cacheByPlate: make(map[string]*model.Device),
 			cacheByPhone: make(map[string]*model.Device),
+			index:        make(map[string]*searchEntry),
 			mutex:        &sync.Mutex{},
 		}
 	})
@@ -64,8 +66,11 @@ func (cache *DeviceCache) HasPhone(phone string) bool {
 }
 
 func (cache *DeviceCache) cacheDevice(d *model.Device) {
-	cache.cacheByPlate[d.Plate] = d
+	if d.Plate != "" { // 仅鉴权未注册的终端没有车牌
+		cache.cacheByPlate[d.Plate] = d
+	}
 	cache.cacheByPhone[d.Phone] = d
+	cache.index[d.Phone] = newSearchEntry(d)
 }
 
 func (cache *DeviceCache) CacheDevice(d *model.Device) {
@@ -88,6 +93,7 @@ func (cache *DeviceCache) delDevice(carPlate, phone *string) {
 	}
 	delete(cache.cacheByPlate, d.Plate)
 	delete(cache.cacheByPhone, d.Phone)
+	delete(cache.index, d.Phone)
 }
 
 func (cache *DeviceCache) DelDeviceByCarPlate(carPlate string) {
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/plate"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 搜索的字段
+const (
+	SearchFieldPlate = "plate"
+	SearchFieldPhone = "phone"
+	SearchFieldID    = "id"
+	SearchFieldName  = "name"
+)
+
+// 终端的搜索字段，缓存时归一化
+type searchEntry struct {
+	device *model.Device
+	keys   []*searchKey
+}
+
+type searchKey struct {
+	field string
+	value string
+}
+
+type SearchResult struct {
+	Device *model.Device `json:"device"`
+	Field  string        `json:"field"` // 匹配到的字段
+	Match  string        `json:"match"` // exact、prefix、substring
+	match  plate.MatchType
+}
+
+// 按字段的优先级排列，车牌同时索引拼音形式
+func newSearchEntry(d *model.Device) *searchEntry {
+	e := &searchEntry{device: d}
+	add := func(field, value string) {
+		if value != "" {
+			e.keys = append(e.keys, &searchKey{field: field, value: value})
+		}
+	}
+	p := plate.Normalize(d.Plate)
+	add(SearchFieldPlate, p)
+	add(SearchFieldPlate, plate.Pinyin(p))
+	add(SearchFieldPhone, d.Phone)
+	add(SearchFieldID, plate.Normalize(d.ID))
+	add(SearchFieldName, plate.Normalize(d.Name))
+	return e
+}
+
+// 按车牌、手机号、终端ID和车辆名称搜索终端，支持前缀、子串和车牌省份简称的拼音。
+// 结果按精确、前缀、子串匹配排序，同一终端只返回最匹配的字段
+func (cache *DeviceCache) Search(query string, limit int) []*SearchResult {
+	q := plate.Normalize(query)
+	cache.mutex.Lock()
+	ans := []*SearchResult{}
+	for _, e := range cache.index {
+		var best *SearchResult
+		for _, k := range e.keys {
+			m := plate.Match(q, k.value)
+			if m != plate.MatchNone && (best == nil || m < best.match) {
+				best = &SearchResult{Device: e.device, Field: k.field, Match: m.String(), match: m}
+			}
+		}
+		if best != nil {
+			ans = append(ans, best)
+		}
+	}
+	cache.mutex.Unlock()
+
+	sort.Slice(ans, func(i, j int) bool {
+		if ans[i].match != ans[j].match {
+			return ans[i].match < ans[j].match
+		}
+		if ans[i].Device.Plate != ans[j].Device.Plate {
+			return ans[i].Device.Plate < ans[j].Device.Plate
+		}
+		return ans[i].Device.Phone < ans[j].Device.Phone
+	})
+	if limit > 0 && len(ans) > limit {
+		ans = ans[:limit]
+	}
+	return ans
+}
@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -50,6 +52,54 @@ func (cache *GeoCache) GetGeoLatestByPhone(phone string) (*model.DeviceGeo, erro
 	return nil, ErrGisNotFound
 }
 
+// 按定位时间插入位置，用于盲区补报等晚于实时位置到达的数据。
+// 环形缓冲只保留时间最新的RingCapacity条，Latest始终是时间最新的位置。
+func (cache *GeoCache) InsertGeosByTime(phone string, geos []*model.DeviceGeo) {
+	rb := cache.GetGeoRingByPhone(phone)
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	merged := make([]*model.DeviceGeo, 0, int(rb.Size)+len(geos))
+	for _, v := range rb.All() {
+		if dg, ok := v.(*model.DeviceGeo); ok {
+			merged = append(merged, dg)
+		}
+	}
+	merged = append(merged, geos...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.Before(merged[j].Time)
+	})
+	if len(merged) > int(rb.Size) {
+		merged = merged[len(merged)-int(rb.Size):]
+	}
+
+	// 原地重建，避免持有旧引用的写入方写到已丢弃的缓冲
+	for i := range rb.Container {
+		rb.Container[i] = nil
+	}
+	rb.Writer = 0
+	for _, dg := range merged {
+		rb.Write(dg)
+	}
+}
+
+// 返回定位时间在[start, end]内的位置，按时间排序。时间按终端上报的字面值比较
+func (cache *GeoCache) ListGeosBetween(phone string, start, end time.Time) []*model.DeviceGeo {
+	rb := cache.GetGeoRingByPhone(phone)
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	var ans []*model.DeviceGeo
+	for _, v := range rb.All() {
+		if dg, ok := v.(*model.DeviceGeo); ok && !dg.Time.Before(start) && !dg.Time.After(end) {
+			ans = append(ans, dg)
+		}
+	}
+	sort.SliceStable(ans, func(i, j int) bool {
+		return ans[i].Time.Before(ans[j].Time)
+	})
+	return ans
+}
+
 func (cache *GeoCache) DelGeoByPhone(phone string) {
 	cache.mutex.Lock()
 	defer cache.mutex.Unlock()
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+// 入库消息的WAL记录，保存解码前的消息头和消息体，重放时重新解码
+type IngestRecord struct {
+	RecvTime time.Time        `json:"recvTime"`
+	Header   *model.MsgHeader `json:"header"`
+	Body     []byte           `json:"body"`
+	Seq      uint64           `json:"seq,omitempty"` // 备节点复制的记录在主节点WAL中的seq
+
+	AuthCode    string `json:"authCode,omitempty"`    // 注册成功时下发的鉴权码
+	VehicleName string `json:"vehicleName,omitempty"` // 注册时外部服务返回的车辆名称
+}
+
+var ingestLog *wal.WAL
+
+// 已复制的主节点WAL seq，即备节点的检查点
+var replicatedSeq atomic.Uint64
+
+// 记录终端状态的消息，WAL清理segment前重新写入仍然有效的状态，避免重启后终端需要重新注册
+var stateMsgIDs = map[uint16]bool{
+	0x0100: true, // 注册
+	0x0104: true, // 查询终端参数应答
+}
+
+const msgIDLogout = 0x0003
+
+var (
+	stateMutex   sync.Mutex
+	stateRecords = make(map[string]map[uint16]*IngestRecord) // <手机号, <消息ID, 最新的记录>>
+)
+
+// 打开入库消息的WAL，未调用时AppendIngestRecord不做任何事
+func OpenIngestLog(dir string, opts *wal.Options) error {
+	o := wal.Options{}
+	if opts != nil {
+		o = *opts
+	}
+	o.Snapshot = snapshotState
+	w, err := wal.Open(dir, &o)
+	if err != nil {
+		return errors.Wrap(err, "Fail to open ingest log")
+	}
+	ingestLog = w
+	return nil
+}
+
+func IngestLogEnabled() bool {
+	return ingestLog != nil
+}
+
+// 返回入库消息的WAL，用于主备复制
+func IngestLog() *wal.WAL {
+	return ingestLog
+}
+
+func ReplicatedSeq() uint64 {
+	return replicatedSeq.Load()
+}
+
+func observeReplicated(rec *IngestRecord) {
+	if rec.Seq > replicatedSeq.Load() {
+		replicatedSeq.Store(rec.Seq)
+	}
+}
+
+// 跟踪终端的最新状态记录，注销后删除
+func observeState(rec *IngestRecord) {
+	msgID, phone := rec.Header.MsgID, rec.Header.PhoneNumber
+	if msgID != msgIDLogout && !stateMsgIDs[msgID] {
+		return
+	}
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if msgID == msgIDLogout {
+		delete(stateRecords, phone)
+		return
+	}
+	if stateRecords[phone] == nil {
+		stateRecords[phone] = make(map[uint16]*IngestRecord)
+	}
+	state := *rec
+	state.Seq = 0 // 快照是本节点写入的记录
+	stateRecords[phone][msgID] = &state
+}
+
+// 缓存中仍然存在的终端的状态记录，按手机号和消息ID排序，注册在前。已被清除的终端不再保留
+func snapshotState() [][]byte {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	phones := make([]string, 0, len(stateRecords))
+	for phone := range stateRecords {
+		if !GetDeviceCache().HasPhone(phone) {
+			delete(stateRecords, phone)
+			continue
+		}
+		phones = append(phones, phone)
+	}
+	sort.Strings(phones)
+	var datas [][]byte
+	for _, phone := range phones {
+		msgIDs := make([]int, 0, len(stateRecords[phone]))
+		for msgID := range stateRecords[phone] {
+			msgIDs = append(msgIDs, int(msgID))
+		}
+		sort.Ints(msgIDs)
+		for _, msgID := range msgIDs {
+			data, err := json.Marshal(stateRecords[phone][uint16(msgID)])
+			if err != nil {
+				continue // 写入时已经序列化过，不会失败
+			}
+			datas = append(datas, data)
+		}
+	}
+	return datas
+}
+
+// 写入一条记录，返回时记录已落盘
+func AppendIngestRecord(rec *IngestRecord) error {
+	if ingestLog == nil {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "Fail to serialize ingest record")
+	}
+	if _, err = ingestLog.Append(data); err != nil {
+		return err
+	}
+	observeState(rec)
+	return nil
+}
+
+// 按顺序写入多条记录，返回时全部落盘，用于备节点写入复制的记录和批量导入。
+// 已经写入过的复制记录会跳过，备节点重试同一批记录时不会重复写入
+func AppendIngestRecords(recs []*IngestRecord) error {
+	if ingestLog == nil {
+		return nil
+	}
+	appended := replicatedSeq.Load()
+	fresh := make([]*IngestRecord, 0, len(recs))
+	datas := make([][]byte, 0, len(recs))
+	for _, rec := range recs {
+		if rec.Seq != 0 && rec.Seq <= appended {
+			continue
+		}
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return errors.Wrap(err, "Fail to serialize ingest record")
+		}
+		fresh = append(fresh, rec)
+		datas = append(datas, data)
+	}
+	if len(datas) == 0 {
+		return nil
+	}
+	if _, err := ingestLog.AppendBatch(datas); err != nil {
+		return err
+	}
+	for _, rec := range fresh {
+		observeReplicated(rec)
+		observeState(rec)
+	}
+	return nil
+}
+
+// 按写入顺序重放全部记录
+func ReplayIngestLog(fn func(*IngestRecord) error) error {
+	if ingestLog == nil {
+		return nil
+	}
+	return ingestLog.Replay(0, func(_ uint64, data []byte) error {
+		rec := &IngestRecord{}
+		if err := json.Unmarshal(data, rec); err != nil {
+			return errors.Wrap(err, "Fail to deserialize ingest record")
+		}
+		observeReplicated(rec)
+		observeState(rec)
+		return fn(rec)
+	})
+}
+
+func CloseIngestLog() error {
+	if ingestLog == nil {
+		return nil
+	}
+	err := ingestLog.Close()
+	ingestLog = nil
+	replicatedSeq.Store(0)
+	stateMutex.Lock()
+	stateRecords = make(map[string]map[uint16]*IngestRecord)
+	stateMutex.Unlock()
+	return err
+}
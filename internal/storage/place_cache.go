@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var (
+	ErrCandidatePlaceNotFound = errors.New("candidate place not found")
+	ErrGeofenceNotFound       = errors.New("geofence not found")
+)
+
+// 停留点、候选地点和电子围栏
+type PlaceCache struct {
+	stopsByPhone map[string][]*model.Stop
+	candidates   map[string]*model.CandidatePlace
+	geofences    map[string]*model.Geofence
+	geofenceFile string // 电子围栏的持久化文件，为空时只保存在内存中
+	mutex        *sync.Mutex
+}
+
+var placeCacheSingleton *PlaceCache
+var placeCacheInitOnce sync.Once
+
+func GetPlaceCache() *PlaceCache {
+	placeCacheInitOnce.Do(func() {
+		placeCacheSingleton = &PlaceCache{
+			stopsByPhone: make(map[string][]*model.Stop),
+			candidates:   make(map[string]*model.CandidatePlace),
+			geofences:    make(map[string]*model.Geofence),
+			mutex:        &sync.Mutex{},
+		}
+	})
+	return placeCacheSingleton
+}
+
+func (cache *PlaceCache) AddStop(s *model.Stop) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.stopsByPhone[s.Phone] = append(cache.stopsByPhone[s.Phone], s)
+}
+
+// 返回结束时间不早于since的停留点，同时清理更早的停留点
+func (cache *PlaceCache) ListStopsSince(since time.Time) []*model.Stop {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	var ans []*model.Stop
+	for phone, stops := range cache.stopsByPhone {
+		kept := stops[:0]
+		for _, s := range stops {
+			if !s.End.Before(since) {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == 0 {
+			delete(cache.stopsByPhone, phone)
+			continue
+		}
+		cache.stopsByPhone[phone] = kept
+		ans = append(ans, kept...)
+	}
+	return ans
+}
+
+// 返回终端与[start, end]有交集的停留点，不清理数据
+func (cache *PlaceCache) ListStopsBetween(phone string, start, end time.Time) []*model.Stop {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	var ans []*model.Stop
+	for _, s := range cache.stopsByPhone[phone] {
+		if !s.End.Before(start) && !s.Start.After(end) {
+			ans = append(ans, s)
+		}
+	}
+	return ans
+}
+
+// 替换全部候选地点，保留已提升的围栏关联
+func (cache *PlaceCache) ReplaceCandidates(places []*model.CandidatePlace) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	promoted := make(map[string]string, len(cache.geofences)) // 重启后候选地点为空，按围栏记录的候选地点ID恢复关联
+	for _, g := range cache.geofences {
+		if g.CandidateID != "" {
+			promoted[g.CandidateID] = g.ID
+		}
+	}
+	candidates := make(map[string]*model.CandidatePlace, len(places))
+	for _, p := range places {
+		if id, ok := promoted[p.ID]; ok {
+			p.GeofenceID = id
+		}
+		candidates[p.ID] = p
+	}
+	cache.candidates = candidates
+}
+
+// 按停留次数从多到少排列
+func (cache *PlaceCache) ListCandidates() []*model.CandidatePlace {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	ans := make([]*model.CandidatePlace, 0, len(cache.candidates))
+	for _, p := range cache.candidates {
+		ans = append(ans, p)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		if ans[i].Visits != ans[j].Visits {
+			return ans[i].Visits > ans[j].Visits
+		}
+		return ans[i].ID < ans[j].ID
+	})
+	return ans
+}
+
+func (cache *PlaceCache) GetCandidate(id string) (*model.CandidatePlace, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if p, ok := cache.candidates[id]; ok {
+		return p, nil
+	}
+	return nil, ErrCandidatePlaceNotFound
+}
+
+// 将候选地点提升为电子围栏
+func (cache *PlaceCache) PromoteCandidate(id string, g *model.Geofence) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	p, ok := cache.candidates[id]
+	if !ok {
+		return ErrCandidatePlaceNotFound
+	}
+	g.CandidateID = id
+	cache.geofences[g.ID] = g
+	if err := cache.saveGeofences(); err != nil {
+		delete(cache.geofences, g.ID)
+		return err
+	}
+	p.GeofenceID = g.ID
+	return nil
+}
+
+func (cache *PlaceCache) CacheGeofence(g *model.Geofence) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	old, ok := cache.geofences[g.ID]
+	cache.geofences[g.ID] = g
+	if err := cache.saveGeofences(); err != nil {
+		if ok {
+			cache.geofences[g.ID] = old
+		} else {
+			delete(cache.geofences, g.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (cache *PlaceCache) ListGeofences() []*model.Geofence {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	ans := make([]*model.Geofence, 0, len(cache.geofences))
+	for _, g := range cache.geofences {
+		ans = append(ans, g)
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].CreatedAt.Before(ans[j].CreatedAt) })
+	return ans
+}
+
+func (cache *PlaceCache) GetGeofence(id string) (*model.Geofence, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if g, ok := cache.geofences[id]; ok {
+		return g, nil
+	}
+	return nil, ErrGeofenceNotFound
+}
+
+func (cache *PlaceCache) DelGeofence(id string) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	g, ok := cache.geofences[id]
+	if !ok {
+		return nil
+	}
+	delete(cache.geofences, id)
+	if err := cache.saveGeofences(); err != nil {
+		cache.geofences[id] = g
+		return err
+	}
+	if p, ok := cache.candidates[g.CandidateID]; ok {
+		p.GeofenceID = ""
+	}
+	return nil
+}
+
+// 从文件加载电子围栏，之后围栏的变更都会写回该文件，文件不存在时视为没有围栏
+func (cache *PlaceCache) LoadGeofences(path string) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.geofenceFile = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read geofence file, path=%s", path)
+	}
+	var geofences []*model.Geofence
+	if err = json.Unmarshal(data, &geofences); err != nil {
+		return errors.Wrapf(err, "Fail to decode geofence file, path=%s", path)
+	}
+	for _, g := range geofences {
+		cache.geofences[g.ID] = g
+	}
+	return nil
+}
+
+// 先写临时文件再重命名，避免写入中途退出损坏已有的文件
+func (cache *PlaceCache) saveGeofences() error {
+	if cache.geofenceFile == "" {
+		return nil
+	}
+	geofences := make([]*model.Geofence, 0, len(cache.geofences))
+	for _, g := range cache.geofences {
+		geofences = append(geofences, g)
+	}
+	sort.Slice(geofences, func(i, j int) bool { return geofences[i].ID < geofences[j].ID })
+	data, err := json.MarshalIndent(geofences, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Fail to encode geofences")
+	}
+	if err = os.MkdirAll(filepath.Dir(cache.geofenceFile), 0o755); err != nil {
+		return errors.Wrapf(err, "Fail to create geofence dir, path=%s", cache.geofenceFile)
+	}
+	tmp := cache.geofenceFile + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.Wrapf(err, "Fail to write geofence file, path=%s", tmp)
+	}
+	if err = os.Rename(tmp, cache.geofenceFile); err != nil {
+		return errors.Wrapf(err, "Fail to rename geofence file, path=%s", cache.geofenceFile)
+	}
+	return nil
+}
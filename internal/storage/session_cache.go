@@ -5,9 +5,9 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 
 	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 	"github.com/fakeyanss/jt808-server-go/pkg/routines"
 )
 
@@ -38,7 +38,7 @@ func getSessionCache() *SessionCache {
 func monitorSessionCnt() {
 	routines.GoSafe(func() {
 		for {
-			log.Debug().Int("total_conn_cnt", countSession()).Msg("Monitoring total conn count")
+			logger.For(logger.ModuleStorage).Debug().Int("total_conn_cnt", countSession()).Msg("Monitoring total conn count")
 			time.Sleep(monitorSessionCntInterval)
 		}
 	})
@@ -0,0 +1,310 @@
+// Package timeline 在内存中按时间记录车队在线数、报警和终端状态变化，供Grafana等看板查询。
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+var (
+	ErrUnknownTarget = errors.New("Unknown target")
+	ErrTooManyPoints = errors.New("Too many points, increase the interval")
+)
+
+const (
+	defaultRetention = 24 * time.Hour
+	defaultMaxEvents = 100000
+	maxBuckets       = 10000 // 单个查询最多的聚合点数
+)
+
+// 事件类型
+const (
+	KindAlarm    = "alarm"
+	KindStatus   = "status"
+	KindIncident = "incident" // 平台级故障，phone为故障的分组
+)
+
+// 车队指标
+const (
+	TargetFleetOnline   = "fleet.online"
+	TargetFleetSleeping = "fleet.sleeping"
+	TargetFleetOffline  = "fleet.offline"
+	TargetFleetAlarms   = "fleet.alarms" // 每个间隔内新增的报警数
+)
+
+// 终端指标，完整的指标名为device.<metric>.<phone>
+const deviceTargetPrefix = "device."
+
+var deviceMetrics = []string{"speed", "fuel", "mileage", "altitude", "satellites", "alarms"}
+
+// 报警或状态变化
+type Event struct {
+	Kind   string    `json:"kind"`
+	Phone  string    `json:"phone"`
+	Time   time.Time `json:"time"`
+	Name   string    `json:"name"`             // 报警名称，状态变化如online->sleeping，或故障的开始和恢复
+	Reason string    `json:"reason,omitempty"` // 状态变化的原因或故障的描述
+}
+
+// 车队各状态的终端数
+type Sample struct {
+	Time     time.Time
+	Online   int
+	Sleeping int
+	Offline  int
+}
+
+// 时间序列的一个点，按Grafana的格式为[值, 毫秒时间戳]
+type Point [2]float64
+
+func newPoint(v float64, t time.Time) Point {
+	return Point{v, float64(t.UnixMilli())}
+}
+
+// 保留最近retention内的车队采样和事件
+type Recorder struct {
+	retention time.Duration
+	maxEvents int
+
+	mutex      *sync.Mutex
+	samples    []*Sample
+	events     []*Event
+	alarmSigns map[string]uint32 // <手机号, 上一个实时位置的报警标志位>
+}
+
+func NewRecorder(conf *config.GrafanaConf) *Recorder {
+	r := &Recorder{
+		retention:  time.Duration(conf.Retention) * time.Hour,
+		maxEvents:  conf.MaxEvents,
+		mutex:      &sync.Mutex{},
+		alarmSigns: make(map[string]uint32),
+	}
+	if r.retention <= 0 {
+		r.retention = defaultRetention
+	}
+	if r.maxEvents <= 0 {
+		r.maxEvents = defaultMaxEvents
+	}
+	return r
+}
+
+// 记录实时位置中新置位的报警，持续置位的报警只记录一次
+func (r *Recorder) ObservePosition(dg *model.DeviceGeo) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	raised := dg.AlarmSign &^ r.alarmSigns[dg.Phone]
+	r.alarmSigns[dg.Phone] = dg.AlarmSign
+	for bit := 0; bit < 32; bit++ {
+		if raised&(1<<bit) != 0 {
+			r.addEvent(&Event{Kind: KindAlarm, Phone: dg.Phone, Time: hex.InDeviceZone(dg.Time), Name: model.AlarmSignName(bit)})
+		}
+	}
+}
+
+// 记录终端状态变化，状态未变化时忽略
+func (r *Recorder) ObserveStatus(phone string, from, to model.DeviceStatus, reason string, now time.Time) {
+	if from == to {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if to == model.DeviceStatusOffline {
+		delete(r.alarmSigns, phone) // 重新上线后的报警重新记录
+	}
+	r.addEvent(&Event{Kind: KindStatus, Phone: phone, Time: now, Name: from.String() + "->" + to.String(), Reason: reason})
+}
+
+// 记录平台级故障的开始或恢复
+func (r *Recorder) ObserveIncident(group, name, reason string, t time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.addEvent(&Event{Kind: KindIncident, Phone: group, Time: t, Name: name, Reason: reason})
+}
+
+func (r *Recorder) addEvent(e *Event) {
+	r.events = append(r.events, e)
+	expire := clock.Now().Add(-r.retention)
+	for len(r.events) > r.maxEvents || (len(r.events) > 0 && r.events[0].Time.Before(expire)) {
+		r.events = r.events[1:]
+	}
+}
+
+// 按缓存中终端的状态采样
+func (r *Recorder) Sample(now time.Time) {
+	s := &Sample{Time: now}
+	for _, d := range storage.GetDeviceCache().ListDevice() {
+		switch d.Status {
+		case model.DeviceStatusOnline:
+			s.Online++
+		case model.DeviceStatusSleeping:
+			s.Sleeping++
+		default:
+			s.Offline++
+		}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.samples = append(r.samples, s)
+	expire := now.Add(-r.retention)
+	i := sort.Search(len(r.samples), func(i int) bool {
+		return !r.samples[i].Time.Before(expire)
+	})
+	r.samples = r.samples[i:]
+}
+
+// 可查询的指标，prefix为空时返回全部
+func (r *Recorder) Targets(prefix string) []string {
+	targets := []string{TargetFleetOnline, TargetFleetSleeping, TargetFleetOffline, TargetFleetAlarms}
+	devices := storage.GetDeviceCache().ListDevice()
+	phones := make([]string, 0, len(devices))
+	for _, d := range devices {
+		phones = append(phones, d.Phone)
+	}
+	sort.Strings(phones)
+	for _, metric := range deviceMetrics {
+		for _, phone := range phones {
+			targets = append(targets, deviceTargetPrefix+metric+"."+phone)
+		}
+	}
+	ans := targets[:0]
+	for _, t := range targets {
+		if strings.HasPrefix(t, prefix) {
+			ans = append(ans, t)
+		}
+	}
+	return ans
+}
+
+// 查询指标在[from, to]内的时间序列，报警数按interval聚合
+func (r *Recorder) Series(target string, from, to time.Time, interval time.Duration) ([]Point, error) {
+	switch target {
+	case TargetFleetOnline, TargetFleetSleeping, TargetFleetOffline:
+		return r.fleetSeries(target, from, to), nil
+	case TargetFleetAlarms:
+		return r.alarmCounts("", from, to, interval)
+	}
+	parts := strings.SplitN(target, ".", 3)
+	if len(parts) != 3 || parts[0]+"." != deviceTargetPrefix {
+		return nil, errors.Wrapf(ErrUnknownTarget, "target=%s", target)
+	}
+	metric, phone := parts[1], parts[2]
+	if metric == "alarms" {
+		return r.alarmCounts(phone, from, to, interval)
+	}
+	value, ok := geoValue(metric)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownTarget, "target=%s", target)
+	}
+	// 缓存中的定位时间是终端上报时间的字面值
+	geos := storage.GetGeoCache().ListGeosBetween(phone, hex.FromDeviceZone(from), hex.FromDeviceZone(to))
+	points := make([]Point, 0, len(geos))
+	for _, dg := range geos {
+		if v, ok := value(dg); ok {
+			points = append(points, newPoint(v, hex.InDeviceZone(dg.Time)))
+		}
+	}
+	return points, nil
+}
+
+func (r *Recorder) fleetSeries(target string, from, to time.Time) []Point {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var points []Point
+	for _, s := range r.samples {
+		if s.Time.Before(from) || s.Time.After(to) {
+			continue
+		}
+		v := s.Online
+		if target == TargetFleetSleeping {
+			v = s.Sleeping
+		} else if target == TargetFleetOffline {
+			v = s.Offline
+		}
+		points = append(points, newPoint(float64(v), s.Time))
+	}
+	return points
+}
+
+// 按interval统计新增报警数，phone为空时统计全部终端，没有报警的间隔为0
+func (r *Recorder) alarmCounts(phone string, from, to time.Time, interval time.Duration) ([]Point, error) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	start := from.Truncate(interval)
+	n := int(to.Sub(start)/interval) + 1
+	if n > maxBuckets {
+		return nil, errors.Wrapf(ErrTooManyPoints, "points=%d", n)
+	}
+	counts := make([]int, n)
+	for _, e := range r.Events(KindAlarm, phone, from, to) {
+		counts[int(e.Time.Sub(start)/interval)]++
+	}
+	points := make([]Point, n)
+	for i, c := range counts {
+		points[i] = newPoint(float64(c), start.Add(time.Duration(i)*interval))
+	}
+	return points, nil
+}
+
+// 查询[from, to]内的事件，kind和phone为空时不过滤，按时间排序
+func (r *Recorder) Events(kind, phone string, from, to time.Time) []*Event {
+	r.mutex.Lock()
+	var ans []*Event
+	for _, e := range r.events {
+		if (kind == "" || e.Kind == kind) && (phone == "" || e.Phone == phone) && !e.Time.Before(from) && !e.Time.After(to) {
+			ans = append(ans, e)
+		}
+	}
+	r.mutex.Unlock()
+	sort.SliceStable(ans, func(i, j int) bool {
+		return ans[i].Time.Before(ans[j].Time)
+	})
+	return ans
+}
+
+func geoValue(metric string) (func(dg *model.DeviceGeo) (float64, bool), bool) {
+	switch metric {
+	case "speed":
+		return func(dg *model.DeviceGeo) (float64, bool) { return dg.Drive.Speed, true }, true
+	case "altitude":
+		return func(dg *model.DeviceGeo) (float64, bool) { return float64(dg.Location.Altitude), true }, true
+	case "fuel":
+		return func(dg *model.DeviceGeo) (float64, bool) { return optional(dg.Fuel) }, true
+	case "mileage":
+		return func(dg *model.DeviceGeo) (float64, bool) { return optional(dg.Mileage) }, true
+	case "satellites":
+		return func(dg *model.DeviceGeo) (float64, bool) {
+			if dg.Satellites == nil {
+				return 0, false
+			}
+			return float64(*dg.Satellites), true
+		}, true
+	}
+	return nil, false
+}
+
+func optional(v *float64) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
+// 事件的标题，用于Grafana注释
+func (e *Event) Title() string {
+	if e.Kind == KindAlarm {
+		return fmt.Sprintf("%s alarm %s", e.Phone, e.Name)
+	}
+	return fmt.Sprintf("%s %s (%s)", e.Phone, e.Name, e.Reason)
+}
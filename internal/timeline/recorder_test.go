@@ -0,0 +1,113 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+func genGeo(phone string, speed float64, fuel *float64, alarm uint32, t time.Time) *model.DeviceGeo {
+	return &model.DeviceGeo{
+		Phone:     phone,
+		Geo:       &model.GeoMeta{ACCStatus: 1, LocationStatus: 1},
+		Location:  &model.Location{Latitude: 30, Longitude: 120},
+		Drive:     &model.Drive{Speed: speed},
+		Time:      hex.FromDeviceZone(t),
+		AlarmSign: alarm,
+		Fuel:      fuel,
+	}
+}
+
+func TestRecorder_Alarms(t *testing.T) {
+	r := NewRecorder(&config.GrafanaConf{})
+	base := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+
+	r.ObservePosition(genGeo("13800000001", 0, nil, 0b10, base))                    // 超速
+	r.ObservePosition(genGeo("13800000001", 0, nil, 0b10, base.Add(time.Second)))   // 持续超速
+	r.ObservePosition(genGeo("13800000001", 0, nil, 0b11, base.Add(2*time.Minute))) // 紧急报警
+	r.ObservePosition(genGeo("13800000002", 0, nil, 0b10, base.Add(2*time.Minute)))
+	r.ObserveStatus("13800000001", model.DeviceStatusOnline, model.DeviceStatusOffline, model.StatusReasonKeepaliveExpired, base.Add(3*time.Minute))
+	r.ObserveStatus("13800000001", model.DeviceStatusOffline, model.DeviceStatusOffline, model.StatusReasonLogout, base.Add(3*time.Minute))
+	r.ObservePosition(genGeo("13800000001", 0, nil, 0b10, base.Add(4*time.Minute))) // 重新上线后再次记录
+
+	events := r.Events(KindAlarm, "13800000001", base, base.Add(time.Hour))
+	require.Len(t, events, 3)
+	require.Equal(t, "overspeed", events[0].Name)
+	require.Equal(t, "emergency", events[1].Name)
+
+	status := r.Events(KindStatus, "", base, base.Add(time.Hour))
+	require.Len(t, status, 1)
+	require.Equal(t, "online->offline", status[0].Name)
+	require.Equal(t, model.StatusReasonKeepaliveExpired, status[0].Reason)
+
+	points, err := r.Series(TargetFleetAlarms, base, base.Add(5*time.Minute), 2*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []Point{
+		newPoint(1, base),
+		newPoint(2, base.Add(2*time.Minute)),
+		newPoint(1, base.Add(4*time.Minute)),
+	}, points)
+
+	points, err = r.Series("device.alarms.13800000002", base, base.Add(4*time.Minute), 5*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []Point{newPoint(1, base)}, points)
+
+	_, err = r.Series(TargetFleetAlarms, base.Add(-365*24*time.Hour), base, time.Second)
+	require.ErrorIs(t, err, ErrTooManyPoints)
+}
+
+func TestRecorder_MaxEvents(t *testing.T) {
+	r := NewRecorder(&config.GrafanaConf{MaxEvents: 2})
+	now := time.Now()
+	r.ObserveStatus("1", model.DeviceStatusOffline, model.DeviceStatusOnline, model.StatusReasonAuth, now.Add(-48*time.Hour)) // 超过保留时长
+	r.ObserveStatus("2", model.DeviceStatusOffline, model.DeviceStatusOnline, model.StatusReasonAuth, now)
+	require.Len(t, r.Events("", "", now.Add(-72*time.Hour), now), 1)
+	r.ObserveStatus("3", model.DeviceStatusOffline, model.DeviceStatusOnline, model.StatusReasonAuth, now)
+	r.ObserveStatus("4", model.DeviceStatusOffline, model.DeviceStatusOnline, model.StatusReasonAuth, now)
+	events := r.Events("", "", now.Add(-time.Hour), now)
+	require.Len(t, events, 2)
+	require.Equal(t, "3", events[0].Phone)
+}
+
+func TestRecorder_Series(t *testing.T) {
+	phone := "13800000009"
+	cache := storage.GetDeviceCache()
+	cache.CacheDevice(&model.Device{Phone: phone, Plate: "京A00009", Status: model.DeviceStatusOnline})
+	defer cache.DelDeviceByPhone(phone)
+	defer storage.GetGeoCache().DelGeoByPhone(phone)
+
+	r := NewRecorder(&config.GrafanaConf{})
+	base := time.Now().Truncate(time.Second).Add(-time.Hour)
+	fuel := 52.5
+	rb := storage.GetGeoCache().GetGeoRingByPhone(phone)
+	rb.Write(genGeo(phone, 30, &fuel, 0, base))
+	rb.Write(genGeo(phone, 40, nil, 0, base.Add(time.Minute)))
+	rb.Write(genGeo(phone, 50, nil, 0, base.Add(2*time.Hour))) // 超出查询范围
+
+	points, err := r.Series("device.speed."+phone, base, base.Add(time.Hour), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []Point{newPoint(30, base), newPoint(40, base.Add(time.Minute))}, points)
+	points, err = r.Series("device.fuel."+phone, base, base.Add(time.Hour), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []Point{newPoint(52.5, base)}, points)
+
+	_, err = r.Series("device.temperature."+phone, base, base.Add(time.Hour), time.Minute)
+	require.ErrorIs(t, err, ErrUnknownTarget)
+	_, err = r.Series("cpu", base, base.Add(time.Hour), time.Minute)
+	require.ErrorIs(t, err, ErrUnknownTarget)
+
+	r.Sample(base)
+	r.Sample(base.Add(time.Minute))
+	points, err = r.Series(TargetFleetOnline, base, base.Add(time.Hour), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []Point{newPoint(1, base), newPoint(1, base.Add(time.Minute))}, points)
+
+	require.Contains(t, r.Targets(""), "device.speed."+phone)
+	require.Equal(t, []string{TargetFleetOnline}, r.Targets("fleet.on"))
+}
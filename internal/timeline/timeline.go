@@ -0,0 +1,71 @@
+package timeline
+
+import (
+	"time"
+
+	"github.com/fakeyanss/gron"
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var ErrTimelineDisabled = errors.New("Grafana datasource is disabled")
+
+const (
+	defaultSampleInterval = 60 // 单位s
+	sampleJobID           = "timeline-sample"
+)
+
+var recorderInstance *Recorder
+
+// 启动记录，按sampleInterval采样车队在线数
+func Start(conf *config.GrafanaConf) *Recorder {
+	recorderInstance = NewRecorder(conf)
+	interval := conf.SampleInterval
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+	cron := gron.New()
+	cron.Add(gron.Every(time.Duration(interval)*time.Second), recorderInstance)
+	cron.Start()
+	return recorderInstance
+}
+
+// 返回启用的记录，未启用时为nil
+func Default() *Recorder {
+	return recorderInstance
+}
+
+func (r *Recorder) JobID() string {
+	return sampleJobID
+}
+
+func (r *Recorder) Run() {
+	r.Sample(clock.Now())
+}
+
+// 记录终端上报的实时位置，未启用时忽略
+func ObservePosition(dg *model.DeviceGeo) {
+	if recorderInstance == nil {
+		return
+	}
+	recorderInstance.ObservePosition(dg)
+}
+
+// 记录终端状态变化，未启用时忽略
+func ObserveStatus(phone string, from, to model.DeviceStatus, reason string) {
+	if recorderInstance == nil {
+		return
+	}
+	recorderInstance.ObserveStatus(phone, from, to, reason, clock.Now())
+}
+
+// 记录平台级故障，未启用时忽略
+func ObserveIncident(group, name, reason string, t time.Time) {
+	if recorderInstance == nil {
+		return
+	}
+	recorderInstance.ObserveIncident(group, name, reason, t)
+}
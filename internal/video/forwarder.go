@@ -0,0 +1,418 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+var (
+	ErrVideoDisabled   = errors.New("Video forwarding is disabled")
+	ErrSessionNotFound = errors.New("Stream session not found")
+	ErrReplyTimeout    = errors.New("Wait terminal reply timeout")
+	ErrTerminalRefused = errors.New("Terminal refused the video request")
+)
+
+const (
+	defaultReplyTimeout = 10 * time.Second
+	defaultSessionTTL   = time.Hour
+	sweepJobID          = "video-session-sweep"
+)
+
+// 视频会话类型
+const (
+	KindRealtime = "realtime"
+	KindPlayback = "playback"
+)
+
+// 下发消息给终端，通常是server.TCPServer.Send
+type SendFunc func(sessionID string, msg model.JT808Msg) error
+
+// 监管平台请求实时视频，终端用手机号或车牌号指定
+type RealtimeReq struct {
+	Platform   string `json:"platform" binding:"required"` // 发起请求的监管平台
+	Phone      string `json:"phone"`
+	Plate      string `json:"plate"`
+	Channel    uint8  `json:"channel"`    // 逻辑通道号
+	DataType   uint8  `json:"dataType"`   // 0音视频，1视频，3监听
+	StreamType uint8  `json:"streamType"` // 0主码流，1子码流
+}
+
+// 监管平台请求录像回放
+type PlaybackReq struct {
+	Platform    string     `json:"platform" binding:"required"`
+	Phone       string     `json:"phone"`
+	Plate       string     `json:"plate"`
+	Channel     uint8      `json:"channel"`
+	MediaType   uint8      `json:"mediaType"`   // 0音视频，1音频，2视频，3视频或音视频
+	StreamType  uint8      `json:"streamType"`  // 0主码流或子码流，1主码流，2子码流
+	StorageType uint8      `json:"storageType"` // 0主存储器或灾备存储器，1主存储器，2灾备存储器
+	StartTime   *time.Time `json:"startTime" binding:"required"`
+	EndTime     *time.Time `json:"endTime"` // 为空表示一直回放
+}
+
+// 面向监管平台的视频会话
+type Session struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Platform   string     `json:"platform"`
+	Phone      string     `json:"phone"`
+	Plate      string     `json:"plate"`
+	Channel    uint8      `json:"channel"`
+	DataType   uint8      `json:"dataType"` // 实时视频为数据类型，回放为音视频类型
+	StreamType uint8      `json:"streamType"`
+	StartTime  *time.Time `json:"startTime,omitempty"` // 回放的时间段
+	EndTime    *time.Time `json:"endTime,omitempty"`
+	ServerIP   string     `json:"serverIp"`   // 监管平台拉流的地址
+	ServerPort uint16     `json:"serverPort"` // 监管平台拉流的端口
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpireAt   time.Time  `json:"expireAt"`
+}
+
+// 同一终端通道的实时视频只推一路流，多个监管平台共享
+func (s *Session) streamKey() string {
+	return fmt.Sprintf("%s-%d-%d-%d", s.Phone, s.Channel, s.DataType, s.StreamType)
+}
+
+// 终端通道上的一路实时视频流，在下发0x9101之前占位，避免并发请求重复打开或在关闭的同时打开
+type stream struct {
+	refs    int           // 已建立和正在建立的会话数
+	closing bool          // 最后一个会话已结束，正在通知终端关闭
+	done    chan struct{} // 打开或关闭完成后关闭
+	err     error         // 打开失败的原因
+}
+
+// 将监管平台的视频请求转换为0x9101/0x9201下发给终端，并跟踪视频会话
+type Forwarder struct {
+	conf         *config.VideoConf
+	send         SendFunc
+	replyTimeout time.Duration
+	sessionTTL   time.Duration
+
+	mutex    *sync.Mutex
+	sessions map[string]*Session
+	streams  map[string]*stream // <streamKey, 实时视频流>
+	nextID   uint64
+}
+
+func NewForwarder(conf *config.VideoConf, send SendFunc) *Forwarder {
+	f := &Forwarder{
+		conf:         conf,
+		send:         send,
+		replyTimeout: time.Duration(conf.ReplyTimeout) * time.Second,
+		sessionTTL:   time.Duration(conf.SessionTTL) * time.Second,
+		mutex:        &sync.Mutex{},
+		sessions:     make(map[string]*Session),
+		streams:      make(map[string]*stream),
+	}
+	if f.replyTimeout <= 0 {
+		f.replyTimeout = defaultReplyTimeout
+	}
+	if f.sessionTTL <= 0 {
+		f.sessionTTL = defaultSessionTTL
+	}
+	return f
+}
+
+// 请求实时视频，同一通道已经在推流时直接复用
+func (f *Forwarder) StartRealtime(ctx context.Context, req *RealtimeReq) (*Session, error) {
+	device, err := findDevice(req.Phone, req.Plate)
+	if err != nil {
+		return nil, err
+	}
+	s := f.newSession(KindRealtime, req.Platform, device)
+	s.Channel = req.Channel
+	s.DataType = req.DataType
+	s.StreamType = req.StreamType
+
+	key := s.streamKey()
+	st, opening, err := f.acquireStream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if opening {
+		err = f.request(ctx, device, 0x9101, func(header *model.MsgHeader) model.JT808Msg {
+			return &model.Msg9101{
+				Header:         header,
+				ServerIP:       f.conf.ServerIP,
+				TCPPort:        f.conf.TCPPort,
+				UDPPort:        f.conf.UDPPort,
+				LogicChannelID: req.Channel,
+				DataType:       req.DataType,
+				StreamType:     req.StreamType,
+			}
+		})
+		f.openedStream(key, st, err)
+	} else {
+		// 等待其他请求打开，最长为应答超时时间
+		<-st.done
+		err = st.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.addSession(s)
+	return s, nil
+}
+
+// 请求录像回放，每个请求单独推流
+func (f *Forwarder) StartPlayback(ctx context.Context, req *PlaybackReq) (*Session, error) {
+	device, err := findDevice(req.Phone, req.Plate)
+	if err != nil {
+		return nil, err
+	}
+	s := f.newSession(KindPlayback, req.Platform, device)
+	s.Channel = req.Channel
+	s.DataType = req.MediaType
+	s.StreamType = req.StreamType
+	s.StartTime = req.StartTime
+	s.EndTime = req.EndTime
+
+	err = f.request(ctx, device, 0x9201, func(header *model.MsgHeader) model.JT808Msg {
+		return &model.Msg9201{
+			Header:         header,
+			ServerIP:       f.conf.ServerIP,
+			TCPPort:        f.conf.TCPPort,
+			UDPPort:        f.conf.UDPPort,
+			LogicChannelID: req.Channel,
+			MediaType:      req.MediaType,
+			StreamType:     req.StreamType,
+			StorageType:    req.StorageType,
+			PlaybackMode:   model.PlaybackModeNormal,
+			StartTime:      req.StartTime,
+			EndTime:        req.EndTime,
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.addSession(s)
+	return s, nil
+}
+
+// 结束视频会话。实时视频在最后一个监管平台结束后才通知终端关闭
+func (f *Forwarder) Stop(ctx context.Context, id string) error {
+	f.mutex.Lock()
+	s, ok := f.sessions[id]
+	if !ok {
+		f.mutex.Unlock()
+		return ErrSessionNotFound
+	}
+	delete(f.sessions, id)
+	var st *stream
+	if s.Kind == KindRealtime {
+		st = f.streams[s.streamKey()]
+		st.refs--
+		if st.refs > 0 {
+			f.mutex.Unlock()
+			return nil
+		}
+		// 关闭完成前，同一通道的新请求等待后重新打开
+		st.closing = true
+		st.done = make(chan struct{})
+		defer f.closedStream(s.streamKey(), st)
+	}
+	f.mutex.Unlock()
+
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(s.Phone)
+	if err != nil {
+		return nil // 终端已下线，流随连接断开
+	}
+	if s.Kind == KindRealtime {
+		err = f.request(ctx, device, 0x9102, func(header *model.MsgHeader) model.JT808Msg {
+			return &model.Msg9102{Header: header, LogicChannelID: s.Channel, Command: model.AVControlClose}
+		})
+	} else {
+		err = f.request(ctx, device, 0x9202, func(header *model.MsgHeader) model.JT808Msg {
+			return &model.Msg9202{Header: header, LogicChannelID: s.Channel, Control: model.PlaybackControlEnd}
+		})
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("device", s.Phone).Str("session", s.ID).Msg("Fail to close video stream")
+	}
+	return nil
+}
+
+// 控制录像回放，control为0x9202定义的回放控制，结束回放与Stop相同
+func (f *Forwarder) ControlPlayback(ctx context.Context, id string, control, multiple uint8, seekTime *time.Time) error {
+	if control == model.PlaybackControlEnd {
+		return f.Stop(ctx, id)
+	}
+	f.mutex.Lock()
+	s, ok := f.sessions[id]
+	f.mutex.Unlock()
+	if !ok || s.Kind != KindPlayback {
+		return ErrSessionNotFound
+	}
+	device, err := storage.GetDeviceCache().GetDeviceByPhone(s.Phone)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to find device cache, phone=%s", s.Phone)
+	}
+	return f.request(ctx, device, 0x9202, func(header *model.MsgHeader) model.JT808Msg {
+		return &model.Msg9202{Header: header, LogicChannelID: s.Channel, Control: control, Multiple: multiple, SeekTime: seekTime}
+	})
+}
+
+// 查询视频会话，platform为空时返回全部
+func (f *Forwarder) List(platform string) []*Session {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	ans := make([]*Session, 0, len(f.sessions))
+	for _, s := range f.sessions {
+		if platform == "" || s.Platform == platform {
+			ans = append(ans, s)
+		}
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].CreatedAt.Before(ans[j].CreatedAt)
+	})
+	return ans
+}
+
+func (f *Forwarder) JobID() string {
+	return sweepJobID
+}
+
+// 结束超过sessionTTL的会话，避免监管平台未结束请求时终端一直推流
+func (f *Forwarder) Run() {
+	now := time.Now()
+	var expired []string
+	f.mutex.Lock()
+	for id, s := range f.sessions {
+		if now.After(s.ExpireAt) {
+			expired = append(expired, id)
+		}
+	}
+	f.mutex.Unlock()
+	for _, id := range expired {
+		ctx, cancel := context.WithTimeout(context.Background(), f.replyTimeout)
+		_ = f.Stop(ctx, id)
+		cancel()
+	}
+}
+
+func (f *Forwarder) newSession(kind, platform string, device *model.Device) *Session {
+	now := time.Now()
+	s := &Session{
+		Kind:       kind,
+		Platform:   platform,
+		Phone:      device.Phone,
+		Plate:      device.Plate,
+		ServerIP:   f.conf.PullIP,
+		ServerPort: f.conf.PullPort,
+		CreatedAt:  now,
+		ExpireAt:   now.Add(f.sessionTTL),
+	}
+	if s.ServerIP == "" {
+		s.ServerIP = f.conf.ServerIP
+	}
+	if s.ServerPort == 0 {
+		s.ServerPort = f.conf.TCPPort
+	}
+	return s
+}
+
+func (f *Forwarder) addSession(s *Session) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.nextID++
+	s.ID = fmt.Sprintf("%s-%d", s.Kind, f.nextID)
+	f.sessions[s.ID] = s
+}
+
+// 占用实时视频流，返回true表示流尚未打开，由调用方下发0x9101后调用openedStream
+func (f *Forwarder) acquireStream(ctx context.Context, key string) (*stream, bool, error) {
+	for {
+		f.mutex.Lock()
+		st, ok := f.streams[key]
+		if !ok {
+			st = &stream{refs: 1, done: make(chan struct{})}
+			f.streams[key] = st
+			f.mutex.Unlock()
+			return st, true, nil
+		}
+		if !st.closing {
+			st.refs++
+			f.mutex.Unlock()
+			return st, false, nil
+		}
+		done := st.done
+		f.mutex.Unlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// 流打开完成，失败时释放占位，等待的请求返回同样的错误
+func (f *Forwarder) openedStream(key string, st *stream, err error) {
+	f.mutex.Lock()
+	st.err = err
+	if err != nil {
+		delete(f.streams, key)
+	}
+	f.mutex.Unlock()
+	close(st.done)
+}
+
+func (f *Forwarder) closedStream(key string, st *stream) {
+	f.mutex.Lock()
+	delete(f.streams, key)
+	f.mutex.Unlock()
+	close(st.done)
+}
+
+// 下发消息并等待终端应答，0x0001结果非成功时返回ErrTerminalRefused
+func (f *Forwarder) request(ctx context.Context, device *model.Device, msgID uint16, gen func(*model.MsgHeader) model.JT808Msg) error {
+	session, err := storage.GetSession(device.SessionID)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to find device session, phone=%s", device.Phone)
+	}
+	msg := gen(model.GenMsgHeader(device, msgID, session.GetNextSerialNum()))
+	serial := msg.GetHeader().SerialNumber
+	replyCh, cancel := protocol.WaitReply(device.Phone, serial, 0x0001, 0x1205)
+	defer cancel()
+	if err = f.send(session.ID, msg); err != nil {
+		return errors.Wrapf(err, "Fail to send msg, msgId=0x%04x", msgID)
+	}
+
+	select {
+	case pkt := <-replyCh:
+		if pkt.Header.MsgID != 0x0001 {
+			return nil // 回放请求以0x1205应答
+		}
+		reply := &model.Msg0001{}
+		if err = reply.Decode(pkt); err != nil {
+			return errors.Wrap(err, "Fail to decode terminal reply")
+		}
+		if model.ResultCode(reply.Result) != model.ResultSuccess {
+			return errors.Wrapf(ErrTerminalRefused, "msgId=0x%04x, result=%d", msgID, reply.Result)
+		}
+		return nil
+	case <-time.After(f.replyTimeout):
+		return errors.Wrapf(ErrReplyTimeout, "msgId=0x%04x", msgID)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func findDevice(phone, plate string) (*model.Device, error) {
+	cache := storage.GetDeviceCache()
+	if phone != "" {
+		return cache.GetDeviceByPhone(phone)
+	}
+	return cache.GetDeviceByPlate(plate)
+}
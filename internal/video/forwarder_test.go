@@ -0,0 +1,169 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 模拟终端，记录下发的消息，并按result回复通用应答
+type fakeTerminal struct {
+	mutex  sync.Mutex
+	sent   []model.JT808Msg
+	result uint8
+}
+
+func (ft *fakeTerminal) send(_ string, msg model.JT808Msg) error {
+	ft.mutex.Lock()
+	ft.sent = append(ft.sent, msg)
+	ft.mutex.Unlock()
+	header := msg.GetHeader()
+	reply := &model.PacketData{
+		Header: &model.MsgHeader{MsgID: 0x0001, Attr: &model.MsgBodyAttr{VersionDesc: model.Version2019}, PhoneNumber: header.PhoneNumber},
+	}
+	reply.Body = hex.WriteWord(reply.Body, header.SerialNumber)
+	reply.Body = hex.WriteWord(reply.Body, header.MsgID)
+	reply.Body = hex.WriteByte(reply.Body, ft.result)
+	_, _ = protocol.NewJT808MsgProcessor().Process(context.Background(), reply)
+	return nil
+}
+
+func setupDevice(t *testing.T, phone, plate string) {
+	session := &model.Session{ID: "127.0.0.1:" + phone}
+	storage.StoreSession(session)
+	storage.GetDeviceCache().CacheDevice(&model.Device{
+		Phone:       phone,
+		Plate:       plate,
+		SessionID:   session.ID,
+		VersionDesc: model.Version2019,
+		Status:      model.DeviceStatusOnline,
+	})
+	t.Cleanup(func() {
+		storage.GetDeviceCache().DelDeviceByPhone(phone)
+		storage.ClearSession(session.ID)
+	})
+}
+
+func TestForwarder_Realtime(t *testing.T) {
+	setupDevice(t, "13800000001", "京A00001")
+	ft := &fakeTerminal{}
+	f := NewForwarder(&config.VideoConf{ServerIP: "10.0.0.1", TCPPort: 1078, PullIP: "media.example.com", PullPort: 8080}, ft.send)
+	ctx := context.Background()
+
+	s1, err := f.StartRealtime(ctx, &RealtimeReq{Platform: "gov-a", Plate: "京A00001", Channel: 1})
+	require.NoError(t, err)
+	require.Equal(t, "13800000001", s1.Phone)
+	require.Equal(t, "media.example.com", s1.ServerIP)
+	require.Equal(t, uint16(8080), s1.ServerPort)
+	require.Len(t, ft.sent, 1)
+	msg := ft.sent[0].(*model.Msg9101)
+	require.Equal(t, "10.0.0.1", msg.ServerIP)
+	require.Equal(t, uint16(1078), msg.TCPPort)
+	require.Equal(t, uint8(1), msg.LogicChannelID)
+
+	// 同一通道的流被多个平台共享
+	s2, err := f.StartRealtime(ctx, &RealtimeReq{Platform: "gov-b", Phone: "13800000001", Channel: 1})
+	require.NoError(t, err)
+	require.Len(t, ft.sent, 1)
+	require.Len(t, f.List(""), 2)
+	require.Len(t, f.List("gov-b"), 1)
+
+	// 最后一个平台结束后才关闭
+	require.NoError(t, f.Stop(ctx, s1.ID))
+	require.Len(t, ft.sent, 1)
+	require.NoError(t, f.Stop(ctx, s2.ID))
+	require.Len(t, ft.sent, 2)
+	require.Equal(t, model.AVControlClose, ft.sent[1].(*model.Msg9102).Command)
+	require.ErrorIs(t, f.Stop(ctx, s2.ID), ErrSessionNotFound)
+
+	_, err = f.StartRealtime(ctx, &RealtimeReq{Platform: "gov-a", Plate: "京A99999"})
+	require.ErrorIs(t, err, storage.ErrDeviceNotFound)
+
+	// 终端拒绝
+	ft.result = 1
+	_, err = f.StartRealtime(ctx, &RealtimeReq{Platform: "gov-a", Phone: "13800000001", Channel: 2})
+	require.ErrorIs(t, err, ErrTerminalRefused)
+	require.Empty(t, f.List(""))
+}
+
+func TestForwarder_Playback(t *testing.T) {
+	setupDevice(t, "13800000002", "京A00002")
+	ft := &fakeTerminal{}
+	f := NewForwarder(&config.VideoConf{ServerIP: "10.0.0.1", TCPPort: 1078, SessionTTL: 1}, ft.send)
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	s, err := f.StartPlayback(ctx, &PlaybackReq{Platform: "gov-a", Phone: "13800000002", Channel: 1, StartTime: &start})
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", s.ServerIP)
+	require.Equal(t, uint16(1078), s.ServerPort)
+	msg := ft.sent[0].(*model.Msg9201)
+	require.Equal(t, &start, msg.StartTime)
+	require.Nil(t, msg.EndTime)
+
+	// 超时的会话被清理，并结束回放
+	s.ExpireAt = time.Now().Add(-time.Second)
+	f.Run()
+	require.Empty(t, f.List(""))
+	require.Equal(t, model.PlaybackControlEnd, ft.sent[1].(*model.Msg9202).Control)
+}
+
+func TestForwarder_ReplyTimeout(t *testing.T) {
+	setupDevice(t, "13800000003", "京A00003")
+	f := NewForwarder(&config.VideoConf{}, func(string, model.JT808Msg) error { return nil })
+	f.replyTimeout = 10 * time.Millisecond
+
+	_, err := f.StartRealtime(context.Background(), &RealtimeReq{Platform: "gov-a", Phone: "13800000003"})
+	require.ErrorIs(t, err, ErrReplyTimeout)
+}
+
+func TestForwarder_RealtimeConcurrent(t *testing.T) {
+	setupDevice(t, "13800000004", "京A00004")
+	ft := &fakeTerminal{}
+	f := NewForwarder(&config.VideoConf{}, ft.send)
+	ctx := context.Background()
+
+	// 并发请求同一通道，只下发一次0x9101
+	var wg sync.WaitGroup
+	ids := make([]string, 10)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s, err := f.StartRealtime(ctx, &RealtimeReq{Platform: fmt.Sprintf("gov-%d", i), Phone: "13800000004", Channel: 1})
+			require.NoError(t, err)
+			ids[i] = s.ID
+		}(i)
+	}
+	wg.Wait()
+	require.Len(t, ft.sent, 1)
+
+	// 关闭的同时重新请求，终端最后收到的是打开的指令
+	for _, id := range ids[1:] {
+		require.NoError(t, f.Stop(ctx, id))
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, f.Stop(ctx, ids[0]))
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := f.StartRealtime(ctx, &RealtimeReq{Platform: "gov-a", Phone: "13800000004", Channel: 1})
+		require.NoError(t, err)
+	}()
+	wg.Wait()
+	require.Len(t, f.List(""), 1)
+	_, ok := ft.sent[len(ft.sent)-1].(*model.Msg9101)
+	require.True(t, ok)
+}
@@ -0,0 +1,27 @@
+package video
+
+import (
+	"time"
+
+	"github.com/fakeyanss/gron"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+)
+
+const sweepInterval = time.Minute
+
+var forwarderInstance *Forwarder
+
+// 启动视频转发，定时清理超时的会话
+func Start(conf *config.VideoConf, send SendFunc) *Forwarder {
+	forwarderInstance = NewForwarder(conf, send)
+	cron := gron.New()
+	cron.Add(gron.Every(sweepInterval), forwarderInstance)
+	cron.Start()
+	return forwarderInstance
+}
+
+// 返回启用的视频转发，未启用时为nil
+func Default() *Forwarder {
+	return forwarderInstance
+}
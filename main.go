@@ -8,8 +8,25 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/fakeyanss/jt808-server-go/internal/api"
+	"github.com/fakeyanss/jt808-server-go/internal/authorizer"
+	"github.com/fakeyanss/jt808-server-go/internal/ban"
+	"github.com/fakeyanss/jt808-server-go/internal/blobstore"
+	"github.com/fakeyanss/jt808-server-go/internal/clock"
 	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/export"
+	"github.com/fakeyanss/jt808-server-go/internal/icauth"
+	"github.com/fakeyanss/jt808-server-go/internal/jt809"
+	"github.com/fakeyanss/jt808-server-go/internal/osmand"
+	"github.com/fakeyanss/jt808-server-go/internal/outage"
+	"github.com/fakeyanss/jt808-server-go/internal/place"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/quality"
+	"github.com/fakeyanss/jt808-server-go/internal/replication"
 	"github.com/fakeyanss/jt808-server-go/internal/server"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/internal/timeline"
+	"github.com/fakeyanss/jt808-server-go/internal/video"
+	"github.com/fakeyanss/jt808-server-go/pkg/blob"
 	"github.com/fakeyanss/jt808-server-go/pkg/logger"
 	"github.com/fakeyanss/jt808-server-go/pkg/routines"
 )
@@ -37,7 +54,111 @@ func main() {
 		fmt.Println(banner)
 	}
 
+	if cfg.Server.Charset != nil {
+		if err := protocol.SetCharsetConf(cfg.Server.Charset); err != nil {
+			log.Error().Err(err).Msg("Fail to parse charset config")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Server.TestMode != nil && cfg.Server.TestMode.TrustDeviceTime {
+		clock.TrustDeviceTime(true)
+		log.Warn().Msg("Test mode, time based logic follows device time")
+	}
+
+	if cfg.Server.Ban != nil && cfg.Server.Ban.Enable {
+		if err := ban.Start(cfg.Server.Ban); err != nil {
+			log.Error().Err(err).Msg("Fail to start ip ban")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Server.Security != nil && cfg.Server.Security.Enable {
+		protocol.EnableSecurity(cfg.Server.Security)
+	}
+
+	if cfg.Server.Quality != nil && cfg.Server.Quality.Enable {
+		quality.Start(cfg.Server.Quality)
+	}
+
+	if cfg.Server.Grafana != nil && cfg.Server.Grafana.Enable {
+		timeline.Start(cfg.Server.Grafana)
+	}
+
+	if cfg.Server.Outage != nil && cfg.Server.Outage.Enable {
+		outage.Start(cfg.Server.Outage)
+	}
+
+	if cfg.Server.Blob != nil && cfg.Server.Blob.Enable {
+		if _, err := blobstore.Start(cfg.Server.Blob); err != nil {
+			log.Error().Err(err).Str("backend", cfg.Server.Blob.Backend).Msg("Fail to start blob store")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Server.Export != nil && cfg.Server.Export.Enable {
+		var archive blob.Store
+		if cfg.Server.Export.Archive {
+			if archive = blobstore.Default(); archive == nil {
+				log.Error().Msg("Export archive requires the blob store, enable server.blob first")
+				os.Exit(1)
+			}
+		}
+		export.Start(cfg.Server.Export, archive)
+	}
+
+	// 先于WAL恢复启动，恢复的位置也参与停留点识别
+	if cfg.Server.Place != nil && cfg.Server.Place.Enable {
+		place.Start(cfg.Server.Place)
+	}
+
+	replicationEnabled := cfg.Server.Replication != nil && cfg.Server.Replication.Enable
+	walEnabled := cfg.Server.WAL != nil && cfg.Server.WAL.Enable
+	if replicationEnabled && !walEnabled {
+		log.Error().Msg("Replication requires the ingest log, enable server.wal first")
+		os.Exit(1)
+	}
+
+	if walEnabled {
+		err := storage.OpenIngestLog(cfg.Server.WAL.Directory, cfg.ParseWALConf())
+		if err != nil {
+			log.Error().Err(err).Str("dir", cfg.Server.WAL.Directory).Msg("Fail to open ingest log")
+			os.Exit(1)
+		}
+		err = protocol.RecoverFromIngestLog()
+		if err != nil {
+			log.Error().Err(err).Msg("Fail to recover from ingest log")
+			os.Exit(1)
+		}
+	}
+
+	if replicationEnabled {
+		_, err := replication.Start(cfg.Server.Replication, storage.IngestLog(), storage.ReplicatedSeq(),
+			protocol.ApplyReplicatedRecords)
+		if err != nil {
+			log.Error().Err(err).Msg("Fail to start replication")
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Server.Authorizer != nil && cfg.Server.Authorizer.Enable {
+		protocol.SetAuthorizer(authorizer.NewHTTPAuthorizer(cfg.Server.Authorizer))
+		log.Info().Str("url", cfg.Server.Authorizer.URL).Bool("failOpen", cfg.Server.Authorizer.FailOpen).
+			Msg("Delegate register and authentication to authorizer")
+	}
+
 	serv := server.NewTCPServer()
+	routines.GoSafe(func() { api.Run(serv, cfg) })
+
+	if cfg.Server.ICAuth != nil && cfg.Server.ICAuth.Enable {
+		icauth.Start(cfg.Server.ICAuth, serv.Send)
+		log.Info().Str("addr", cfg.Server.ICAuth.Addr).Str("backup", cfg.Server.ICAuth.Backup).
+			Msg("Relay IC card authentication to authentication server")
+	}
+
+	// 备节点提升为主节点后才接入终端
+	replication.WaitPromoted()
+
 	addr := ":" + cfg.Server.Port.TCPPort
 	err := serv.Listen(addr)
 	if err != nil {
@@ -46,7 +167,24 @@ func main() {
 	}
 	routines.GoSafe(func() { serv.Start() })
 
-	routines.GoSafe(func() { api.Run(serv, cfg) })
+	if cfg.Server.Video != nil && cfg.Server.Video.Enable {
+		forwarder := video.Start(cfg.Server.Video, serv.Send)
+		if conf := cfg.Server.Video.JT809; conf != nil && conf.Enable {
+			if _, err := jt809.Start(conf, forwarder); err != nil {
+				log.Error().Err(err).Msg("Fail to start jt809 link")
+				os.Exit(1)
+			}
+			log.Info().Str("addr", conf.Addr).Uint16("downLinkPort", conf.DownLinkPort).
+				Msg("Serve video requests from supervision platform over jt809")
+		}
+	}
+
+	if cfg.Server.OsmAnd != nil && cfg.Server.OsmAnd.Enable {
+		if _, err := osmand.Start(cfg.Server.OsmAnd); err != nil {
+			log.Error().Err(err).Msg("Fail to start osmand ingest")
+			os.Exit(1)
+		}
+	}
 
 	select {} // block here
 }
@@ -0,0 +1,104 @@
+// Package blob, 对象存储抽象，用于保存多媒体文件、录像和归档的历史数据。
+//
+// 提供本地文件系统和S3兼容（AWS S3、MinIO等）两种实现。对象按key寻址，key使用/分隔层级，
+// 下载通过有时效的预签名URL完成，不经过业务服务转发。
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotFound        = errors.New("Object not found")
+	ErrBadKey          = errors.New("Bad object key")
+	ErrBadSignature    = errors.New("Bad presigned signature")
+	ErrPresignExpired  = errors.New("Presigned url expired")
+	ErrPresignDisabled = errors.New("Presign is not supported")
+)
+
+const maxKeyLen = 1024
+
+type Object struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+type Store interface {
+	// 写入对象，已存在时覆盖。数据较大时分片上传，r读完后才返回
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*Object, error)
+	// 删除对象，不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// 按key排序列出前缀下的所有对象
+	List(ctx context.Context, prefix string) ([]*Object, error)
+	// 生成有效期为ttl的下载地址
+	PresignGet(key string, ttl time.Duration) (string, error)
+}
+
+// 校验key，不允许空的层级、.开头的层级和控制字符，避免本地存储时越出目录
+func ValidateKey(key string) error {
+	if key == "" || len(key) > maxKeyLen {
+		return errors.Wrapf(ErrBadKey, "key=%s", key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || strings.HasPrefix(seg, ".") {
+			return errors.Wrapf(ErrBadKey, "key=%s", key)
+		}
+	}
+	for _, c := range key {
+		if c < 0x20 || c == 0x7f || c == '\\' {
+			return errors.Wrapf(ErrBadKey, "key=%s", key)
+		}
+	}
+	return nil
+}
+
+// 删除前缀下修改时间早于now-maxAge的对象，返回删除的个数
+func Expire(ctx context.Context, s Store, prefix string, maxAge time.Duration, now time.Time) (int, error) {
+	objects, err := s.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	deadline := now.Add(-maxAge)
+	n := 0
+	for _, obj := range objects {
+		if !obj.ModTime.Before(deadline) {
+			continue
+		}
+		if err = s.Delete(ctx, obj.Key); err != nil {
+			return n, errors.Wrapf(err, "Fail to delete expired object, key=%s", obj.Key)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// 按RFC3986编码，保留/，用于路径和签名
+func encodePath(p string) string {
+	return uriEncode(p, false)
+}
+
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const uploadingDir = ".uploading" // 写入中的临时文件，key不能以.开头，不会与对象冲突
+
+// 本地文件系统存储，预签名地址由业务服务校验后返回文件
+type LocalStore struct {
+	dir     string
+	baseURL string // 下载地址前缀，预签名地址为 baseURL/key?expires=&signature=
+	secret  []byte
+}
+
+func NewLocalStore(dir, baseURL string, secret []byte) (*LocalStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, uploadingDir), 0o755); err != nil {
+		return nil, errors.Wrapf(err, "Fail to create blob dir, dir=%s", dir)
+	}
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), secret: secret}, nil
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// 先写入临时文件再改名，读取方不会看到写了一半的对象
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(s.dir, uploadingDir), "put-*")
+	if err != nil {
+		return errors.Wrap(err, "Fail to create blob temp file")
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Fail to write blob, key=%s", key)
+	}
+	path := s.path(key)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrapf(err, "Fail to create blob dir, key=%s", key)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrapf(err, "Fail to rename blob, key=%s", key)
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(ErrNotFound, "key=%s", key)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to open blob, key=%s", key)
+	}
+	return f, nil
+}
+
+func (s *LocalStore) Stat(_ context.Context, key string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(s.path(key))
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, errors.Wrapf(ErrNotFound, "key=%s", key)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to stat blob, key=%s", key)
+	}
+	return &Object{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// 删除文件后清理空目录
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	path := s.path(key)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "Fail to delete blob, key=%s", key)
+	}
+	for dir := filepath.Dir(path); dir != filepath.Clean(s.dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break // 目录非空
+		}
+	}
+	return nil
+}
+
+func (s *LocalStore) List(_ context.Context, prefix string) ([]*Object, error) {
+	var objects []*Object
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == uploadingDir {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, &Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to list blobs, prefix=%s", prefix)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+func (s *LocalStore) PresignGet(key string, ttl time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return s.baseURL + "/" + encodePath(key) + "?expires=" + expires + "&signature=" + s.sign(key, expires), nil
+}
+
+// 校验预签名下载地址的参数
+func (s *LocalStore) VerifyPresigned(key, expires, signature string, now time.Time) error {
+	if !hmac.Equal([]byte(s.sign(key, expires)), []byte(signature)) {
+		return ErrBadSignature
+	}
+	ts, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if now.Unix() > ts {
+		return ErrPresignExpired
+	}
+	return nil
+}
+
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,91 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"media/a.jpg", "archive/v1/date=2023-01-01/part-00000.parquet", "a b/中文.jpg"} {
+		require.NoError(t, ValidateKey(key), key)
+	}
+	for _, key := range []string{"", "/a", "a//b", "a/", "../a", "a/./b", ".uploading/x", "a\\b", "a\nb"} {
+		require.ErrorIs(t, ValidateKey(key), ErrBadKey, key)
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(dir, "http://localhost:8008/media/download/", []byte("secret"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "media/013800138000/1.jpg", strings.NewReader("image")))
+	require.NoError(t, s.Put(ctx, "media/013800138000/1.jpg", strings.NewReader("image2")))
+	require.NoError(t, s.Put(ctx, "archive/part-00000.parquet", strings.NewReader("parquet")))
+	obj, err := s.Stat(ctx, "media/013800138000/1.jpg")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), obj.Size)
+	r, err := s.Get(ctx, "media/013800138000/1.jpg")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	require.Equal(t, "image2", string(data))
+	_, err = s.Get(ctx, "media/none.jpg")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Stat(ctx, "media/013800138000")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	objects, err := s.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	require.Equal(t, "archive/part-00000.parquet", objects[0].Key)
+
+	link, err := s.PresignGet("media/013800138000/1.jpg", time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	require.Equal(t, "/media/download/media/013800138000/1.jpg", u.Path)
+	expires, sig := u.Query().Get("expires"), u.Query().Get("signature")
+	require.NoError(t, s.VerifyPresigned("media/013800138000/1.jpg", expires, sig, time.Now()))
+	require.ErrorIs(t, s.VerifyPresigned("archive/part-00000.parquet", expires, sig, time.Now()), ErrBadSignature)
+	require.ErrorIs(t, s.VerifyPresigned("media/013800138000/1.jpg", expires, sig, time.Now().Add(2*time.Minute)),
+		ErrPresignExpired)
+
+	// 删除后清理空目录
+	require.NoError(t, s.Delete(ctx, "media/013800138000/1.jpg"))
+	require.NoError(t, s.Delete(ctx, "media/013800138000/1.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "media"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestExpire(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(dir, "", nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	now := time.Now()
+	for _, key := range []string{"media/old.jpg", "media/new.jpg", "archive/old.parquet"} {
+		require.NoError(t, s.Put(ctx, key, strings.NewReader("x")))
+	}
+	old := now.Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "media/old.jpg"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "archive/old.parquet"), old, old))
+
+	n, err := Expire(ctx, s, "media/", 24*time.Hour, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	objects, err := s.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	require.Equal(t, "archive/old.parquet", objects[0].Key)
+	require.Equal(t, "media/new.jpg", objects[1].Key)
+}
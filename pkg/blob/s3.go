@@ -0,0 +1,335 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MinPartSize     = 5 * 1024 * 1024 // S3要求除最后一片外的分片不小于5MB
+	defaultPartSize = 16 * 1024 * 1024
+	maxParts        = 10000
+)
+
+var ErrS3Request = errors.New("S3 request failed")
+
+type S3Options struct {
+	Endpoint  string // 如 https://s3.amazonaws.com、http://127.0.0.1:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool  // 使用 endpoint/bucket/key 形式的地址，MinIO需要开启
+	PartSize  int64 // 分片上传的分片大小，不足一片的数据直接上传
+	Client    *http.Client
+}
+
+// S3兼容的对象存储，只依赖标准库，实现了业务需要的接口子集
+type S3Store struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	partSize  int64
+	client    *http.Client
+	signer    *signer
+	now       func() time.Time
+}
+
+func NewS3Store(opts *S3Options) (*S3Store, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, errors.Errorf("Bad s3 endpoint, endpoint=%s", opts.Endpoint)
+	}
+	if opts.Bucket == "" {
+		return nil, errors.New("Missing s3 bucket")
+	}
+	s := &S3Store{
+		endpoint:  endpoint,
+		bucket:    opts.Bucket,
+		pathStyle: opts.PathStyle,
+		partSize:  opts.PartSize,
+		client:    opts.Client,
+		signer:    &signer{accessKey: opts.AccessKey, secretKey: opts.SecretKey, region: opts.Region},
+		now:       time.Now,
+	}
+	if s.signer.region == "" {
+		s.signer.region = "us-east-1"
+	}
+	if s.partSize <= 0 {
+		s.partSize = defaultPartSize
+	}
+	if s.partSize < MinPartSize {
+		s.partSize = MinPartSize
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	return s, nil
+}
+
+// 对象地址，key为空时为bucket地址
+func (s *S3Store) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if s.pathStyle {
+		path += "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	if key != "" || !s.pathStyle {
+		path += "/" + key
+	}
+	u.Path = path
+	u.RawPath = encodePath(path)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// 发送签名后的请求，非2xx时返回错误，404返回ErrNotFound
+func (s *S3Store) do(ctx context.Context, method, key string, query url.Values, body []byte,
+	header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to create s3 request")
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.ContentLength = int64(len(body))
+	payloadHash := emptyPayload
+	if len(body) > 0 {
+		payloadHash = hashHex(body)
+	}
+	s.signer.sign(req, payloadHash, s.now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to request s3, method=%s, key=%s", method, key)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.Wrapf(ErrNotFound, "key=%s", key)
+	}
+	e := &s3Error{}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = xml.Unmarshal(data, e)
+	return nil, errors.Wrapf(ErrS3Request, "method=%s, key=%s, status=%d, code=%s, message=%s",
+		method, key, resp.StatusCode, e.Code, e.Message)
+}
+
+func (s *S3Store) doAndClose(ctx context.Context, method, key string, query url.Values, body []byte,
+	header http.Header) (*http.Response, error) {
+	resp, err := s.do(ctx, method, key, query, body, header)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp, nil
+}
+
+// 不足一个分片的数据直接上传，否则分片上传，失败时取消上传
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	first, err := readPart(r, s.partSize)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read blob data, key=%s", key)
+	}
+	if int64(len(first)) < s.partSize {
+		_, err = s.doAndClose(ctx, http.MethodPut, key, nil, first, nil)
+		return err
+	}
+
+	uploadID, err := s.createMultipart(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err = s.uploadParts(ctx, key, uploadID, first, r); err != nil {
+		// 取消失败时未完成的分片由存储的生命周期规则清理
+		_, _ = s.doAndClose(context.Background(), http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+		return err
+	}
+	return nil
+}
+
+// 读取一个分片，数据较少时不会按分片大小分配内存
+func readPart(r io.Reader, size int64) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(r, size))
+}
+
+type initiateMultipartResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+func (s *S3Store) createMultipart(ctx context.Context, key string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	result := &initiateMultipartResult{}
+	if err = xml.NewDecoder(resp.Body).Decode(result); err != nil || result.UploadID == "" {
+		return "", errors.Wrapf(ErrS3Request, "Fail to initiate multipart upload, key=%s", key)
+	}
+	return result.UploadID, nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name         `xml:"CompleteMultipartUpload"`
+	Parts   []*completedPart `xml:"Part"`
+}
+
+func (s *S3Store) uploadParts(ctx context.Context, key, uploadID string, part []byte, r io.Reader) error {
+	complete := &completeMultipartUpload{}
+	for n := 1; len(part) > 0; n++ {
+		if n > maxParts {
+			return errors.Wrapf(ErrS3Request, "Too many parts, key=%s", key)
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+		resp, err := s.doAndClose(ctx, http.MethodPut, key, query, part, nil)
+		if err != nil {
+			return err
+		}
+		complete.Parts = append(complete.Parts, &completedPart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+		if int64(len(part)) < s.partSize {
+			break
+		}
+		if part, err = readPart(r, s.partSize); err != nil {
+			return errors.Wrapf(err, "Fail to read blob data, key=%s", key)
+		}
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return errors.Wrap(err, "Fail to serialize complete multipart upload")
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body,
+		http.Header{"Content-Type": {"application/xml"}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// 合并分片失败时也可能返回200，错误在响应体中
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read complete multipart response, key=%s", key)
+	}
+	e := &s3Error{}
+	if xml.Unmarshal(data, e) == nil && e.Code != "" {
+		return errors.Wrapf(ErrS3Request, "Fail to complete multipart upload, key=%s, code=%s, message=%s",
+			key, e.Code, e.Message)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.doAndClose(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &Object{Key: key, Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	_, err := s.doAndClose(ctx, http.MethodDelete, key, nil, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// 使用ListObjectsV2分页列出
+func (s *S3Store) List(ctx context.Context, prefix string) ([]*Object, error) {
+	var objects []*Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := &listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Fail to decode s3 list result, prefix=%s", prefix)
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, &Object{Key: c.Key, Size: c.Size, ModTime: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+// S3预签名有效期最长7天
+func (s *S3Store) PresignGet(key string, ttl time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > 7*24*time.Hour {
+		return "", errors.Wrapf(ErrS3Request, "Bad presign ttl, ttl=%s", ttl)
+	}
+	return s.signer.presign(s.objectURL(key, nil), ttl, s.now()), nil
+}
@@ -0,0 +1,87 @@
+// Package cluster, 基于密度的聚类(DBSCAN)。
+//
+// 点之间的距离由调用方提供。DBSCAN的邻域查询为O(n^2)，适合单次数千个点以内的场景；
+// DBSCANGrid按调用方划分的网格查询邻域，点分布稀疏时接近O(n)。
+package cluster
+
+import "sort"
+
+// 噪声点的簇编号
+const Noise = -1
+
+// 对n个点聚类，距离不超过eps的点互为邻居，邻居数(含自身)不少于minPts的点为核心点。
+// 返回每个点的簇编号，从0开始连续编号，不属于任何簇的点为Noise。
+func DBSCAN(n int, eps float64, minPts int, dist func(i, j int) float64) []int {
+	return dbscan(n, minPts, func(i int) []int {
+		var ans []int
+		for j := 0; j < n; j++ {
+			if dist(i, j) <= eps {
+				ans = append(ans, j)
+			}
+		}
+		return ans
+	})
+}
+
+// 同DBSCAN，cell返回点所在的网格坐标，网格边长不能小于eps，邻域只在相邻的9个网格中查询。
+// 结果与DBSCAN一致。
+func DBSCANGrid(n int, eps float64, minPts int, dist func(i, j int) float64, cell func(i int) (x, y int)) []int {
+	type cellKey struct{ x, y int }
+	cells := make([]cellKey, n)
+	grid := make(map[cellKey][]int)
+	for i := 0; i < n; i++ {
+		x, y := cell(i)
+		cells[i] = cellKey{x, y}
+		grid[cells[i]] = append(grid[cells[i]], i)
+	}
+	return dbscan(n, minPts, func(i int) []int {
+		var ans []int
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				for _, j := range grid[cellKey{cells[i].x + dx, cells[i].y + dy}] {
+					if dist(i, j) <= eps {
+						ans = append(ans, j)
+					}
+				}
+			}
+		}
+		sort.Ints(ans) // 与DBSCAN的扩展顺序一致
+		return ans
+	})
+}
+
+func dbscan(n int, minPts int, neighbors func(i int) []int) []int {
+	const unvisited = -2
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = unvisited
+	}
+
+	clusterID := 0
+	for i := 0; i < n; i++ {
+		if labels[i] != unvisited {
+			continue
+		}
+		seeds := neighbors(i)
+		if len(seeds) < minPts {
+			labels[i] = Noise
+			continue
+		}
+		labels[i] = clusterID
+		for k := 0; k < len(seeds); k++ {
+			j := seeds[k]
+			if labels[j] == Noise {
+				labels[j] = clusterID // 边界点
+			}
+			if labels[j] != unvisited {
+				continue
+			}
+			labels[j] = clusterID
+			if jn := neighbors(j); len(jn) >= minPts {
+				seeds = append(seeds, jn...) // 核心点，继续扩展
+			}
+		}
+		clusterID++
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBSCAN(t *testing.T) {
+	type point struct{ x, y float64 }
+	tests := []struct {
+		name   string
+		points []point
+		eps    float64
+		minPts int
+		want   []int
+	}{
+		{
+			name:   "case1: empty",
+			points: nil,
+			eps:    1,
+			minPts: 2,
+			want:   []int{},
+		},
+		{
+			name:   "case2: two clusters and noise",
+			points: []point{{0, 0}, {0.5, 0}, {0, 0.5}, {10, 10}, {10.5, 10}, {5, 5}},
+			eps:    1,
+			minPts: 2,
+			want:   []int{0, 0, 0, 1, 1, Noise},
+		},
+		{
+			name:   "case3: chain connected by core points",
+			points: []point{{0, 0}, {0.9, 0}, {1.8, 0}, {2.7, 0}},
+			eps:    1,
+			minPts: 2,
+			want:   []int{0, 0, 0, 0},
+		},
+		{
+			name:   "case4: border point joins cluster",
+			points: []point{{0, 0}, {0.5, 0}, {-0.5, 0}, {1.4, 0}},
+			eps:    1,
+			minPts: 3,
+			want:   []int{0, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dist := func(i, j int) float64 {
+				return math.Hypot(tt.points[i].x-tt.points[j].x, tt.points[i].y-tt.points[j].y)
+			}
+			cell := func(i int) (int, int) {
+				return int(math.Floor(tt.points[i].x / tt.eps)), int(math.Floor(tt.points[i].y / tt.eps))
+			}
+			require.Equal(t, tt.want, DBSCAN(len(tt.points), tt.eps, tt.minPts, dist))
+			require.Equal(t, tt.want, DBSCANGrid(len(tt.points), tt.eps, tt.minPts, dist, cell))
+		})
+	}
+}
+
+func TestDBSCANGrid_SameAsDBSCAN(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	xs, ys := make([]float64, 2000), make([]float64, 2000)
+	for i := range xs {
+		xs[i], ys[i] = r.Float64()*100-50, r.Float64()*100-50
+	}
+	dist := func(i, j int) float64 { return math.Hypot(xs[i]-xs[j], ys[i]-ys[j]) }
+	eps := 1.5
+	cell := func(i int) (int, int) { return int(math.Floor(xs[i] / eps)), int(math.Floor(ys[i] / eps)) }
+	require.Equal(t, DBSCAN(len(xs), eps, 4, dist), DBSCANGrid(len(xs), eps, 4, dist, cell))
+}
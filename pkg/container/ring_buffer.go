@@ -51,10 +51,20 @@ func (r *RingBuffer) Read() any {
 
 // Returns the latest element in the RingBuffer
 func (r *RingBuffer) Latest() any {
-	if r.Writer == 0 {
-		return nil
+	// writer回绕到0时，最新元素在末尾
+	return r.Container[(atomic.LoadInt32(&r.Writer)-1+r.Size)%r.Size]
+}
+
+// Returns all elements from the oldest to the latest, empty slots are skipped
+func (r *RingBuffer) All() []any {
+	current := atomic.LoadInt32(&r.Writer)
+	all := make([]any, 0, r.Size)
+	for i := int32(0); i < r.Size; i++ {
+		if v := r.Container[(current+i)%r.Size]; v != nil {
+			all = append(all, v)
+		}
 	}
-	return r.Container[(atomic.LoadInt32(&r.Writer)-1)%r.Size]
+	return all
 }
 
 // Returns the oldest element in RingBuffer
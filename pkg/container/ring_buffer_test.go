@@ -127,34 +127,74 @@ func TestRingBuffer_Read(t *testing.T) {
 	}
 }
 
-// func TestRingBuffer_Latest(t *testing.T) {
-// 	type fields struct {
-// 		Size      int32
-// 		Container []any
-// 		Reader    int32
-// 		Writer    int32
-// 	}
-// 	tests := []struct {
-// 		name   string
-// 		fields fields
-// 		want   any
-// 	}{
-// 		// TODO: Add test cases.
-// 	}
-// 	for _, tt := range tests {
-// 		t.Run(tt.name, func(t *testing.T) {
-// 			r := &RingBuffer{
-// 				Size:      tt.fields.Size,
-// 				Container: tt.fields.Container,
-// 				Reader:    tt.fields.Reader,
-// 				Writer:    tt.fields.Writer,
-// 			}
-// 			if got := r.Latest(); !reflect.DeepEqual(got, tt.want) {
-// 				t.Errorf("RingBuffer.Latest() = %v, want %v", got, tt.want)
-// 			}
-// 		})
-// 	}
-// }
+func TestRingBuffer_Latest(t *testing.T) {
+	type fields struct {
+		Size      int32
+		Container []any
+		Reader    int32
+		Writer    int32
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   any
+	}{
+		{
+			name:   "case1: empty ringbuffer",
+			fields: fields{Size: 3, Container: make([]any, 3)},
+			want:   nil,
+		},
+		{
+			name:   "case2: latest in the middle",
+			fields: fields{Size: 3, Container: []any{1, 2, nil}, Writer: 2},
+			want:   2,
+		},
+		{
+			name:   "case3: writer wraps to zero",
+			fields: fields{Size: 3, Container: []any{1, 2, 3}, Writer: 0},
+			want:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RingBuffer{
+				Size:      tt.fields.Size,
+				Container: tt.fields.Container,
+				Reader:    tt.fields.Reader,
+				Writer:    tt.fields.Writer,
+			}
+			require.Equal(t, tt.want, r.Latest())
+		})
+	}
+}
+
+func TestRingBuffer_All(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []any
+		want   []any
+	}{
+		{
+			name:   "case1: not full",
+			writes: []any{1, 2},
+			want:   []any{1, 2},
+		},
+		{
+			name:   "case2: overwritten",
+			writes: []any{1, 2, 3, 4, 5},
+			want:   []any{3, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRingBuffer(3)
+			for _, v := range tt.writes {
+				r.Write(v)
+			}
+			require.Equal(t, tt.want, r.All())
+		})
+	}
+}
 
 // func TestRingBuffer_Oldest(t *testing.T) {
 // 	type fields struct {
@@ -47,6 +47,14 @@ type Config struct {
 
 	// MaxAge the max age in days to keep a logfile
 	MaxAge int
+
+	// ModuleLevels overrides LogLevel for the named modules
+	ModuleLevels map[string]int8
+
+	// SampleBurst and SamplePeriod limit debug messages of each module to SampleBurst per SamplePeriod,
+	// sampling is disabled if either is zero
+	SampleBurst  uint32
+	SamplePeriod time.Duration
 }
 
 type Logger struct {
@@ -80,6 +88,7 @@ func Configure(config *Config) *Logger {
 	logger := zerolog.New(mw).Level(zerolog.Level(config.LogLevel)).With().Caller().Timestamp().Logger()
 
 	zerologGlobalConfiguration()
+	configureModules(&logger, config)
 
 	// err := rewriteStderrFile(path.Join(config.Directory, config.Filename))
 	// if err != nil {
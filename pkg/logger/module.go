@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrUnknownLevel  = errors.New("Unknown log level")
+	ErrUnknownModule = errors.New("Unknown log module")
+)
+
+// 内置模块
+const (
+	ModuleFraming   = "framing"   // 帧读写
+	ModuleCodec     = "codec"     // 报文和消息编解码
+	ModuleProcessor = "processor" // 消息处理
+	ModuleAPI       = "api"       // http接口
+	ModuleStorage   = "storage"   // 缓存和持久化
+)
+
+// 重复的debug日志按模块采样，每个周期最多输出Burst条
+type Sampling struct {
+	Burst  uint32
+	Period time.Duration
+}
+
+type module struct {
+	name   string
+	level  atomic.Int32
+	cached atomic.Pointer[zerolog.Logger]
+}
+
+var (
+	modulesMutex = &sync.RWMutex{}
+	modules      = make(map[string]*module)
+	baseLogger   atomic.Pointer[zerolog.Logger] // Configure生成的logger，未配置时使用log.Logger
+	defaultLevel atomic.Int32
+	sampling     atomic.Pointer[Sampling]
+)
+
+func init() {
+	defaultLevel.Store(int32(zerolog.DebugLevel))
+	for _, name := range []string{ModuleFraming, ModuleCodec, ModuleProcessor, ModuleAPI, ModuleStorage} {
+		getModule(name)
+	}
+}
+
+func getModule(name string) *module {
+	modulesMutex.RLock()
+	m, ok := modules[name]
+	modulesMutex.RUnlock()
+	if ok {
+		return m
+	}
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+	if m, ok = modules[name]; !ok {
+		m = &module{name: name}
+		m.level.Store(defaultLevel.Load())
+		modules[name] = m
+	}
+	return m
+}
+
+// 配置各模块的日志级别和采样，未指定级别的模块使用全局级别
+func configureModules(base *zerolog.Logger, config *Config) {
+	baseLogger.Store(base)
+	defaultLevel.Store(int32(config.LogLevel))
+	if config.SampleBurst > 0 && config.SamplePeriod > 0 {
+		sampling.Store(&Sampling{Burst: config.SampleBurst, Period: config.SamplePeriod})
+	} else {
+		sampling.Store(nil)
+	}
+
+	modulesMutex.RLock()
+	for _, m := range modules {
+		m.level.Store(int32(config.LogLevel))
+	}
+	modulesMutex.RUnlock()
+	for name, level := range config.ModuleLevels {
+		getModule(name).level.Store(int32(level))
+	}
+	invalidate()
+}
+
+func invalidate() {
+	modulesMutex.RLock()
+	defer modulesMutex.RUnlock()
+	for _, m := range modules {
+		m.cached.Store(nil)
+	}
+}
+
+// 返回模块的logger，日志带module字段
+func For(name string) *zerolog.Logger {
+	m := getModule(name)
+	if l := m.cached.Load(); l != nil {
+		return l
+	}
+	base := baseLogger.Load()
+	if base == nil {
+		base = &log.Logger
+	}
+	l := base.Level(zerolog.Level(m.level.Load())).With().Str("module", name).Logger()
+	if s := sampling.Load(); s != nil {
+		// 只采样debug及以下级别，保证告警和错误日志完整
+		burst := &zerolog.BurstSampler{Burst: s.Burst, Period: s.Period}
+		l = l.Sample(zerolog.LevelSampler{TraceSampler: burst, DebugSampler: burst})
+	}
+	m.cached.Store(&l)
+	return &l
+}
+
+// 运行时调整模块的日志级别，只能调整Modules中已有的模块
+func SetLevel(name string, level string) error {
+	if err := ValidateLevel(name, level); err != nil {
+		return err
+	}
+	lvl, _ := ParseLevel(level)
+	m := getModule(name)
+	m.level.Store(int32(lvl))
+	m.cached.Store(nil)
+	return nil
+}
+
+// 校验模块名和日志级别，用于批量调整前先校验全部参数
+func ValidateLevel(name string, level string) error {
+	modulesMutex.RLock()
+	_, ok := modules[name]
+	modulesMutex.RUnlock()
+	if !ok {
+		return errors.Wrapf(ErrUnknownModule, "module=%s", name)
+	}
+	_, err := ParseLevel(level)
+	return err
+}
+
+// 返回各模块当前的日志级别
+func Levels() map[string]string {
+	modulesMutex.RLock()
+	defer modulesMutex.RUnlock()
+	ans := make(map[string]string, len(modules))
+	for name, m := range modules {
+		ans[name] = strings.ToUpper(zerolog.Level(m.level.Load()).String())
+	}
+	return ans
+}
+
+// 返回已注册的模块名，按名称排列
+func Modules() []string {
+	modulesMutex.RLock()
+	defer modulesMutex.RUnlock()
+	ans := make([]string, 0, len(modules))
+	for name := range modules {
+		ans = append(ans, name)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// 解析日志级别，不区分大小写
+func ParseLevel(level string) (zerolog.Level, error) {
+	lvl, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil || level == "" {
+		return zerolog.NoLevel, errors.Wrapf(ErrUnknownLevel, "level=%s", level)
+	}
+	return lvl, nil
+}
+
+type fieldsCtxKey struct{}
+
+// 在context中追加日志字段，通过Ctx获取的logger都会带上这些字段
+func WithFields(ctx context.Context, kv ...any) context.Context {
+	prev, _ := ctx.Value(fieldsCtxKey{}).([]any)
+	fields := make([]any, 0, len(prev)+len(kv))
+	fields = append(fields, prev...)
+	fields = append(fields, kv...)
+	return context.WithValue(ctx, fieldsCtxKey{}, fields)
+}
+
+// 返回带context字段的模块logger
+func Ctx(ctx context.Context, name string) *zerolog.Logger {
+	l := For(name)
+	fields, _ := ctx.Value(fieldsCtxKey{}).([]any)
+	if len(fields) == 0 {
+		return l
+	}
+	withFields := l.With().Fields(fields).Logger()
+	return &withFields
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestModules(t *testing.T, config *Config) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	base := zerolog.New(buf).Level(zerolog.Level(config.LogLevel))
+	configureModules(&base, config)
+	t.Cleanup(func() { configureModules(nil, &Config{LogLevel: int8(zerolog.DebugLevel)}) })
+	return buf
+}
+
+func readLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var ans []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		m := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		ans = append(ans, m)
+	}
+	buf.Reset()
+	return ans
+}
+
+func TestModuleLevels(t *testing.T) {
+	buf := setupTestModules(t, &Config{
+		LogLevel:     int8(zerolog.InfoLevel),
+		ModuleLevels: map[string]int8{ModuleProcessor: int8(zerolog.DebugLevel)},
+	})
+
+	For(ModuleFraming).Debug().Msg("framing debug")
+	For(ModuleFraming).Info().Msg("framing info")
+	For(ModuleProcessor).Debug().Msg("processor debug")
+	lines := readLines(t, buf)
+	require.Len(t, lines, 2)
+	require.Equal(t, "framing", lines[0]["module"])
+	require.Equal(t, "framing info", lines[0][zerolog.MessageFieldName])
+	require.Equal(t, "processor debug", lines[1][zerolog.MessageFieldName])
+
+	// 运行时调整
+	require.NoError(t, SetLevel(ModuleFraming, "DEBUG"))
+	require.NoError(t, SetLevel(ModuleProcessor, "warn"))
+	For(ModuleFraming).Debug().Msg("framing debug")
+	For(ModuleProcessor).Info().Msg("processor info")
+	lines = readLines(t, buf)
+	require.Len(t, lines, 1)
+	require.Equal(t, "framing debug", lines[0][zerolog.MessageFieldName])
+	require.Equal(t, "DEBUG", Levels()[ModuleFraming])
+	require.Equal(t, "WARN", Levels()[ModuleProcessor])
+	require.Equal(t, "INFO", Levels()[ModuleAPI])
+
+	require.ErrorIs(t, SetLevel(ModuleFraming, "verbose"), ErrUnknownLevel)
+	require.ErrorIs(t, SetLevel("procesor", "debug"), ErrUnknownModule)
+	require.NotContains(t, Modules(), "procesor")
+}
+
+func TestModuleSampling(t *testing.T) {
+	buf := setupTestModules(t, &Config{
+		LogLevel:     int8(zerolog.DebugLevel),
+		SampleBurst:  3,
+		SamplePeriod: time.Hour,
+	})
+	for i := 0; i < 10; i++ {
+		For(ModuleFraming).Debug().Msg("Received frame.")
+		For(ModuleFraming).Error().Msg("Fail")
+	}
+	var debug, errs int
+	for _, line := range readLines(t, buf) {
+		if line["level"] == "debug" {
+			debug++
+		} else {
+			errs++
+		}
+	}
+	require.Equal(t, 3, debug)
+	require.Equal(t, 10, errs) // 错误日志不采样
+}
+
+func TestCtx(t *testing.T) {
+	buf := setupTestModules(t, &Config{LogLevel: int8(zerolog.DebugLevel)})
+	ctx := WithFields(context.Background(), "session", "127.0.0.1:1234")
+	ctx2 := WithFields(ctx, "phone", "13012345678", "msgId", "0x0200", "serial", 7)
+
+	Ctx(ctx2, ModuleProcessor).Info().Msg("with fields")
+	Ctx(ctx, ModuleProcessor).Info().Msg("parent fields")
+	Ctx(context.Background(), ModuleProcessor).Info().Msg("no fields")
+	lines := readLines(t, buf)
+	require.Len(t, lines, 3)
+	require.Equal(t, "127.0.0.1:1234", lines[0]["session"])
+	require.Equal(t, "13012345678", lines[0]["phone"])
+	require.Equal(t, "0x0200", lines[0]["msgId"])
+	require.Equal(t, float64(7), lines[0]["serial"])
+	require.Equal(t, "127.0.0.1:1234", lines[1]["session"])
+	require.NotContains(t, lines[1], "phone")
+	require.NotContains(t, lines[2], "session")
+}
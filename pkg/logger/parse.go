@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrBadLogLine = errors.New("Bad log line")
+
+// 日志文件中的一行
+type Entry struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]string
+}
+
+// 解析Configure写入文件的一行日志，支持console和json两种格式
+func ParseLine(line string) (*Entry, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		return parseJSONLine(line)
+	}
+	return parseConsoleLine(line)
+}
+
+func parseJSONLine(line string) (*Entry, error) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return nil, errors.Wrap(ErrBadLogLine, err.Error())
+	}
+	e := &Entry{Fields: make(map[string]string, len(raw))}
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			s = fmt.Sprint(v)
+		}
+		switch k {
+		case "time":
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, errors.Wrap(ErrBadLogLine, err.Error())
+			}
+			e.Time = t
+		case "level":
+			e.Level = s
+		case "m", "message":
+			e.Message = s
+		default:
+			e.Fields[k] = s
+		}
+	}
+	if e.Time.IsZero() {
+		return nil, ErrBadLogLine
+	}
+	return e, nil
+}
+
+// 格式: 时间 | 级别 | 调用行 > 消息 | k=v k=v
+func parseConsoleLine(line string) (*Entry, error) {
+	parts := strings.SplitN(line, " | ", 3)
+	if len(parts) < 3 {
+		return nil, ErrBadLogLine
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errors.Wrap(ErrBadLogLine, err.Error())
+	}
+	e := &Entry{
+		Time:   t,
+		Level:  strings.ToLower(strings.TrimSpace(parts[1])),
+		Fields: make(map[string]string),
+	}
+	rest := strings.TrimLeft(parts[2], "| ")
+	if i := strings.Index(rest, " > "); i >= 0 {
+		rest = rest[i+3:]
+	}
+	msg, fields, _ := strings.Cut(rest, " |")
+	e.Message = strings.TrimSpace(msg)
+	for _, kv := range strings.Fields(fields) {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			e.Fields[k] = v
+		}
+	}
+	return e, nil
+}
+
+// 返回日志目录下filename及其滚动归档的路径，按写入的先后排序，当前文件在最后
+func LogFiles(dir, filename string) ([]string, error) {
+	ext := filepath.Ext(filename)
+	prefix := strings.TrimSuffix(filename, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to read log directory, dir=%s", dir)
+	}
+	type backup struct {
+		path string
+		t    time.Time
+	}
+	var backups []backup
+	var current string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if name == filename {
+			current = filepath.Join(dir, name)
+			continue
+		}
+		// lumberjack归档: name-2006-01-02T15-04-05.000.ext，压缩时再加.gz
+		ts := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		if !strings.HasPrefix(ts, prefix) {
+			continue
+		}
+		t, err := time.Parse("2006-01-02T15-04-05.000", ts[len(prefix):])
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), t: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].t.Before(backups[j].t)
+	})
+	ans := make([]string, 0, len(backups)+1)
+	for _, b := range backups {
+		ans = append(ans, b.path)
+	}
+	if current != "" {
+		ans = append(ans, current)
+	}
+	return ans, nil
+}
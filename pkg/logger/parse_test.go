@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	zerologGlobalConfiguration()
+	payload := []byte{0x7e, 0x02, 0x00, 0x7e}
+	for _, asJSON := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		var l zerolog.Logger
+		if asJSON {
+			l = zerolog.New(buf)
+		} else {
+			l = zerolog.New(newConsoleWriter(buf))
+		}
+		l = l.With().Caller().Timestamp().Str("session", "abc").Logger()
+		l.Debug().Int("frame_len", len(payload)).Hex("frame_payload", payload).Msg("Received frame.")
+
+		e, err := ParseLine(buf.String())
+		require.NoError(t, err)
+		require.Equal(t, "debug", e.Level)
+		require.Equal(t, "Received frame.", e.Message)
+		require.Equal(t, "7e02007e", e.Fields["frame_payload"])
+		require.Equal(t, "4", e.Fields["frame_len"])
+		require.Equal(t, "abc", e.Fields["session"])
+		require.WithinDuration(t, time.Now(), e.Time, time.Minute)
+	}
+
+	_, err := ParseLine("Start with configuration configs/default.yaml")
+	require.ErrorIs(t, err, ErrBadLogLine)
+}
+
+func TestLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"app.log",
+		"app-2023-02-01T00-00-00.000.log",
+		"app-2023-01-01T00-00-00.000.log.gz",
+		"other.log",
+	}
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	files, err := LogFiles(dir, "app.log")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "app-2023-01-01T00-00-00.000.log.gz"),
+		filepath.Join(dir, "app-2023-02-01T00-00-00.000.log"),
+		filepath.Join(dir, "app.log"),
+	}, files)
+}
@@ -0,0 +1,136 @@
+package wal
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// 持续读取已落盘记录的读取器，按文件偏移读取，不会读到正在写入的批次
+type follower struct {
+	w        *WAL
+	next     uint64 // 下一条要返回的seq
+	file     *os.File
+	segStart uint64 // 当前segment的起始seq
+	offset   int64
+}
+
+// 从seq >= from的记录开始持续读取已落盘的记录，每次回调当前可读的一批记录（最多MaxBatch条），
+// 没有新记录时阻塞等待。stop关闭时返回nil，WAL关闭时返回ErrClosed，回调返回错误时原样返回
+func (w *WAL) Follow(from uint64, stop <-chan struct{}, fn func([]*Record) error) error {
+	if from == 0 {
+		from = 1
+	}
+	f := &follower{w: w, next: from}
+	defer f.close()
+	for {
+		w.mutex.Lock()
+		limit := w.nextSeq
+		notify := w.notify
+		segments := make([]uint64, len(w.segments))
+		copy(segments, w.segments)
+		w.mutex.Unlock()
+
+		if f.next < limit {
+			records, err := f.read(segments, limit, w.opts.MaxBatch)
+			if err != nil {
+				return err
+			}
+			if err = fn(records); err != nil {
+				return err
+			}
+			continue
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-w.closeCh:
+			return ErrClosed
+		case <-notify:
+		}
+	}
+}
+
+// 读取seq < limit的记录，调用前需保证f.next < limit
+func (f *follower) read(segments []uint64, limit uint64, max int) ([]*Record, error) {
+	var records []*Record
+	for len(records) < max && f.next < limit {
+		if f.file == nil {
+			if err := f.open(segments); err != nil {
+				return nil, err
+			}
+		}
+		rec, size, err := readRecordAt(f.file, f.offset)
+		if errors.Is(err, io.EOF) {
+			// 当前segment已读完，记录在后面的segment中
+			f.close()
+			if f.segStart == segments[len(segments)-1] {
+				return nil, ErrCorruptRecord
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.offset += size
+		if rec.Seq < f.next {
+			continue
+		}
+		records = append(records, rec)
+		f.next = rec.Seq + 1
+	}
+	return records, nil
+}
+
+// 打开包含f.next的segment
+func (f *follower) open(segments []uint64) error {
+	i := len(segments) - 1
+	for i >= 0 && segments[i] > f.next {
+		i--
+	}
+	if i < 0 || segments[i] == f.segStart {
+		return errors.Wrapf(ErrSeqRemoved, "seq=%d", f.next)
+	}
+	file, err := os.Open(f.w.segmentPath(segments[i]))
+	if os.IsNotExist(err) {
+		return errors.Wrapf(ErrSeqRemoved, "seq=%d", f.next)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Fail to open wal segment")
+	}
+	f.file = file
+	f.segStart = segments[i]
+	f.offset = 0
+	return nil
+}
+
+func (f *follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// 读取offset处的记录，不足一条完整记录时返回io.EOF
+func readRecordAt(file *os.File, offset int64) (*Record, int64, error) {
+	head := make([]byte, recordHeadLen)
+	if n, _ := file.ReadAt(head, offset); n < recordHeadLen {
+		return nil, 0, io.EOF
+	}
+	checksum := binary.BigEndian.Uint32(head[0:4])
+	dataLen := binary.BigEndian.Uint32(head[4:8])
+	rec := &Record{Seq: binary.BigEndian.Uint64(head[8:16]), Data: make([]byte, dataLen)}
+	if n, _ := file.ReadAt(rec.Data, offset+recordHeadLen); n < int(dataLen) {
+		return nil, 0, io.EOF
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(head[8:16])
+	crc.Write(rec.Data)
+	if crc.Sum32() != checksum {
+		return nil, 0, ErrCorruptRecord
+	}
+	return rec, recordHeadLen + int64(dataLen), nil
+}
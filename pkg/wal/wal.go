@@ -0,0 +1,498 @@
+// Package wal, 追加写的预写日志(Write-Ahead Log)。
+//
+// 日志按segment文件切分，每条记录格式为:
+//
+//	crc32[4] + dataLen[4] + seq[8] + data[dataLen]
+//
+// crc32覆盖seq和data。Append会阻塞直到记录被fsync落盘，多个并发的Append
+// 会被合并为一次写入和一次fsync(group commit)，以减少落盘次数。
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+)
+
+const (
+	segmentSuffix  = ".wal"
+	recordHeadLen  = 4 + 4 + 8
+	defaultMaxSize = 64 * 1024 * 1024
+)
+
+var (
+	ErrClosed        = errors.New("wal closed")
+	ErrCorruptRecord = errors.New("wal record corrupted")
+	ErrSeqRemoved    = errors.New("wal record removed") // 要读取的记录所在的segment已被清理
+
+	errStopReplay = errors.New("stop replay")
+)
+
+type Options struct {
+	SyncInterval time.Duration // 组提交时等待更多记录的最长时间，0表示只合并已排队的记录
+	MaxBatch     int           // 单次组提交的最大记录数
+	SegmentSize  int64         // 单个segment文件的大小上限，超过后切换新文件
+	MaxSegments  int           // 保留的segment文件个数，0表示不清理
+	// 清理segment前调用，返回的记录写在新segment的开头并落盘后才清理，用于保留旧segment中仍然有效的状态。
+	// 调用时持有WAL的锁，不能在其中读写WAL
+	Snapshot func() [][]byte
+}
+
+// 已落盘的记录
+type Record struct {
+	Seq  uint64
+	Data []byte
+}
+
+type appendReq struct {
+	data []byte
+	seq  uint64
+	done chan error
+}
+
+type WAL struct {
+	dir  string
+	opts Options
+
+	mutex    *sync.Mutex // 保护segment文件的切换和读取
+	file     *os.File
+	writer   *bufio.Writer
+	fileSize int64
+	segments []uint64 // 按起始seq排序的segment列表
+	nextSeq  uint64
+	notify   chan struct{} // 每次提交后关闭并替换，用于唤醒Follow
+
+	reqCh   chan *appendReq
+	closeMu *sync.RWMutex // 保证Close之后不会再有请求进入reqCh
+	closed  bool
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+// 打开dir下的WAL，如果最后一个segment末尾存在写了一半的记录，会被截断
+func Open(dir string, opts *Options) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return nil, errors.Wrapf(err, "Fail to create wal directory, dir=%s", dir)
+	}
+	w := &WAL{
+		dir:     dir,
+		mutex:   &sync.Mutex{},
+		nextSeq: 1,
+		notify:  make(chan struct{}),
+		reqCh:   make(chan *appendReq, 1024),
+		closeMu: &sync.RWMutex{},
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.MaxBatch <= 0 {
+		w.opts.MaxBatch = 256
+	}
+	if w.opts.SegmentSize <= 0 {
+		w.opts.SegmentSize = defaultMaxSize
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	w.segments = segments
+	if len(segments) == 0 {
+		err = w.openSegment(w.nextSeq)
+	} else {
+		err = w.recoverTail()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	routines.GoSafe(w.loop)
+	return w, nil
+}
+
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to read wal directory, dir=%s", dir)
+	}
+	segments := []uint64{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue // 非wal文件，忽略
+		}
+		segments = append(segments, seq)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+func (w *WAL) segmentPath(firstSeq uint64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%020d%s", firstSeq, segmentSuffix))
+}
+
+func (w *WAL) openSegment(firstSeq uint64) error {
+	f, err := os.OpenFile(w.segmentPath(firstSeq), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "Fail to open wal segment")
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "Fail to stat wal segment")
+	}
+	if len(w.segments) == 0 || w.segments[len(w.segments)-1] != firstSeq {
+		w.segments = append(w.segments, firstSeq)
+	}
+	w.file = f
+	w.writer = bufio.NewWriter(f)
+	w.fileSize = stat.Size()
+	return nil
+}
+
+// 扫描最后一个segment，得到下一个seq，并截断末尾不完整的记录
+func (w *WAL) recoverTail() error {
+	last := w.segments[len(w.segments)-1]
+	f, err := os.Open(w.segmentPath(last))
+	if err != nil {
+		return errors.Wrap(err, "Fail to open wal segment")
+	}
+	w.nextSeq = last
+	var validSize int64
+	err = readRecords(f, func(seq uint64, data []byte) error {
+		w.nextSeq = seq + 1
+		validSize += int64(recordHeadLen + len(data))
+		return nil
+	})
+	f.Close()
+	if err != nil && !errors.Is(err, ErrCorruptRecord) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if err != nil {
+		// 进程崩溃时最后一批记录可能没写完整，这些记录没有被确认过，可以直接丢弃
+		if err = os.Truncate(w.segmentPath(last), validSize); err != nil {
+			return errors.Wrap(err, "Fail to truncate torn wal record")
+		}
+	}
+	return w.openSegment(last)
+}
+
+// 依次读取记录，遇到文件结尾返回nil
+func readRecords(r io.Reader, fn func(uint64, []byte) error) error {
+	br := bufio.NewReader(r)
+	head := make([]byte, recordHeadLen)
+	for {
+		_, err := io.ReadFull(br, head)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return io.ErrUnexpectedEOF
+		}
+		checksum := binary.BigEndian.Uint32(head[0:4])
+		dataLen := binary.BigEndian.Uint32(head[4:8])
+		seq := binary.BigEndian.Uint64(head[8:16])
+		data := make([]byte, dataLen)
+		if _, err = io.ReadFull(br, data); err != nil {
+			return io.ErrUnexpectedEOF
+		}
+		crc := crc32.NewIEEE()
+		crc.Write(head[8:16])
+		crc.Write(data)
+		if crc.Sum32() != checksum {
+			return ErrCorruptRecord
+		}
+		if err = fn(seq, data); err != nil {
+			return err
+		}
+	}
+}
+
+func encodeRecord(seq uint64, data []byte) []byte {
+	buf := make([]byte, recordHeadLen+len(data))
+	binary.BigEndian.PutUint32(buf[4:8], uint32(len(data)))
+	binary.BigEndian.PutUint64(buf[8:16], seq)
+	copy(buf[recordHeadLen:], data)
+	binary.BigEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[8:]))
+	return buf
+}
+
+// 追加一条记录，返回记录的seq。方法会阻塞到记录fsync落盘之后
+func (w *WAL) Append(data []byte) (uint64, error) {
+	seqs, err := w.AppendBatch([][]byte{data})
+	if err != nil {
+		return 0, err
+	}
+	return seqs[0], nil
+}
+
+// 按顺序追加多条记录，返回各记录的seq，记录可以在同一次组提交中落盘
+func (w *WAL) AppendBatch(datas [][]byte) ([]uint64, error) {
+	reqs := make([]*appendReq, len(datas))
+	w.closeMu.RLock()
+	if w.closed {
+		w.closeMu.RUnlock()
+		return nil, ErrClosed
+	}
+	for i, data := range datas {
+		reqs[i] = &appendReq{data: data, done: make(chan error, 1)}
+		w.reqCh <- reqs[i]
+	}
+	w.closeMu.RUnlock()
+	seqs := make([]uint64, len(reqs))
+	var err error
+	for i, req := range reqs {
+		if e := <-req.done; e != nil && err == nil {
+			err = e
+		}
+		seqs[i] = req.seq
+	}
+	return seqs, err
+}
+
+// 组提交循环，每一批记录只做一次fsync
+func (w *WAL) loop() {
+	defer close(w.doneCh)
+	for {
+		var first *appendReq
+		select {
+		case <-w.closeCh:
+			w.drain()
+			return
+		case first = <-w.reqCh:
+		}
+		batch := w.collect(first)
+		err := w.commit(batch)
+		for _, req := range batch {
+			req.done <- err
+		}
+	}
+}
+
+func (w *WAL) collect(first *appendReq) []*appendReq {
+	batch := []*appendReq{first}
+	var timeout <-chan time.Time
+	if w.opts.SyncInterval > 0 {
+		timer := time.NewTimer(w.opts.SyncInterval)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for len(batch) < w.opts.MaxBatch {
+		select {
+		case req := <-w.reqCh:
+			batch = append(batch, req)
+			continue
+		default:
+		}
+		if timeout == nil {
+			break // 不等待，只合并已排队的请求
+		}
+		select {
+		case req := <-w.reqCh:
+			batch = append(batch, req)
+			continue
+		case <-timeout:
+		}
+		break
+	}
+	return batch
+}
+
+// 关闭时处理剩余的请求
+func (w *WAL) drain() {
+	for {
+		select {
+		case req := <-w.reqCh:
+			req.done <- ErrClosed
+		default:
+			return
+		}
+	}
+}
+
+func (w *WAL) commit(batch []*appendReq) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.fileSize >= w.opts.SegmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	datas := make([][]byte, len(batch))
+	for i, req := range batch {
+		datas[i] = req.data
+	}
+	firstSeq, err := w.writeRecords(datas)
+	if err != nil {
+		return err
+	}
+	for i, req := range batch {
+		req.seq = firstSeq + uint64(i)
+	}
+	close(w.notify)
+	w.notify = make(chan struct{})
+	return nil
+}
+
+// 写入记录并落盘，返回第一条记录的seq。失败时回滚seq并丢弃已写入的部分，
+// 重新打开segment，避免bufio.Writer保留写入错误导致之后的写入都失败
+func (w *WAL) writeRecords(datas [][]byte) (uint64, error) {
+	firstSeq, size := w.nextSeq, w.fileSize
+	seq := firstSeq
+	err := func() error {
+		for _, data := range datas {
+			record := encodeRecord(seq, data)
+			if _, err := w.writer.Write(record); err != nil {
+				return errors.Wrap(err, "Fail to write wal record")
+			}
+			seq++
+			size += int64(len(record))
+		}
+		if err := w.writer.Flush(); err != nil {
+			return errors.Wrap(err, "Fail to flush wal segment")
+		}
+		if err := w.file.Sync(); err != nil {
+			return errors.Wrap(err, "Fail to sync wal segment")
+		}
+		return nil
+	}()
+	if err != nil {
+		if e := w.reopenSegment(w.fileSize); e != nil {
+			return 0, errors.Wrapf(err, "Fail to reopen wal segment, err=%s", e)
+		}
+		return 0, err
+	}
+	w.nextSeq, w.fileSize = seq, size
+	return firstSeq, nil
+}
+
+// 将当前segment截断到size后重新打开
+func (w *WAL) reopenSegment(size int64) error {
+	last := w.segments[len(w.segments)-1]
+	_ = w.file.Close()
+	if err := os.Truncate(w.segmentPath(last), size); err != nil {
+		return errors.Wrap(err, "Fail to truncate wal segment")
+	}
+	return w.openSegment(last)
+}
+
+func (w *WAL) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return errors.Wrap(err, "Fail to close wal segment")
+	}
+	if err := w.openSegment(w.nextSeq); err != nil {
+		return err
+	}
+	if w.opts.MaxSegments <= 0 || len(w.segments) <= w.opts.MaxSegments {
+		return nil
+	}
+	if err := w.writeSnapshot(); err != nil {
+		return err
+	}
+	for len(w.segments) > w.opts.MaxSegments {
+		if err := os.Remove(w.segmentPath(w.segments[0])); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "Fail to remove expired wal segment")
+		}
+		w.segments = w.segments[1:]
+	}
+	return nil
+}
+
+// 将快照记录写入当前segment并落盘
+func (w *WAL) writeSnapshot() error {
+	if w.opts.Snapshot == nil {
+		return nil
+	}
+	if _, err := w.writeRecords(w.opts.Snapshot()); err != nil {
+		return errors.Wrap(err, "Fail to write wal snapshot")
+	}
+	return nil
+}
+
+// 按顺序重放seq >= from的记录
+func (w *WAL) Replay(from uint64, fn func(seq uint64, data []byte) error) error {
+	w.mutex.Lock()
+	segments := make([]uint64, len(w.segments))
+	copy(segments, w.segments)
+	limit := w.nextSeq // 只读取已经落盘的记录，避免读到正在写入的批次
+	w.mutex.Unlock()
+
+	for i, first := range segments {
+		if i+1 < len(segments) && segments[i+1] <= from {
+			continue // 整个segment都在from之前
+		}
+		f, err := os.Open(w.segmentPath(first))
+		if os.IsNotExist(err) {
+			continue // 已被清理
+		}
+		if err != nil {
+			return errors.Wrap(err, "Fail to open wal segment")
+		}
+		err = readRecords(f, func(seq uint64, data []byte) error {
+			if seq >= limit {
+				return errStopReplay
+			}
+			if seq < from {
+				return nil
+			}
+			return fn(seq, data)
+		})
+		f.Close()
+		if errors.Is(err, errStopReplay) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 最早一条未被清理的记录的seq
+func (w *WAL) FirstSeq() uint64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.segments[0]
+}
+
+// 下一条记录将使用的seq
+func (w *WAL) NextSeq() uint64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.nextSeq
+}
+
+func (w *WAL) Close() error {
+	w.closeMu.Lock()
+	if w.closed {
+		w.closeMu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.closeMu.Unlock()
+
+	close(w.closeCh)
+	<-w.doneCh
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.file.Close()
+}
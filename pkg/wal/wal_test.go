@@ -0,0 +1,251 @@
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func collectAll(t *testing.T, w *WAL, from uint64) map[uint64]string {
+	got := make(map[uint64]string)
+	err := w.Replay(from, func(seq uint64, data []byte) error {
+		got[seq] = string(data)
+		return nil
+	})
+	require.NoError(t, err)
+	return got
+}
+
+func TestWAL_AppendAndReplay(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  *Options
+		datas []string
+		from  uint64
+		want  map[uint64]string
+	}{
+		{
+			name:  "case1: replay all records after reopen",
+			opts:  &Options{},
+			datas: []string{"a", "b", "c"},
+			from:  1,
+			want:  map[uint64]string{1: "a", 2: "b", 3: "c"},
+		},
+		{
+			name:  "case2: replay from the middle",
+			opts:  &Options{},
+			datas: []string{"a", "b", "c"},
+			from:  3,
+			want:  map[uint64]string{3: "c"},
+		},
+		{
+			name:  "case3: replay across segments",
+			opts:  &Options{SegmentSize: 1},
+			datas: []string{"a", "b", "c"},
+			from:  2,
+			want:  map[uint64]string{2: "b", 3: "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := Open(dir, tt.opts)
+			require.NoError(t, err)
+			for _, d := range tt.datas {
+				_, err = w.Append([]byte(d))
+				require.NoError(t, err)
+			}
+			require.NoError(t, w.Close())
+
+			w, err = Open(dir, tt.opts)
+			require.NoError(t, err)
+			defer w.Close()
+			require.Equal(t, tt.want, collectAll(t, w, tt.from))
+			require.Equal(t, uint64(len(tt.datas)+1), w.NextSeq())
+		})
+	}
+}
+
+func TestWAL_TruncateTornTail(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, nil)
+	require.NoError(t, err)
+	_, err = w.Append([]byte("complete"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// 模拟崩溃时写了一半的记录
+	f, err := os.OpenFile(w.segmentPath(1), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.Write(encodeRecord(2, []byte("torn"))[:recordHeadLen+2])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	w, err = Open(dir, nil)
+	require.NoError(t, err)
+	defer w.Close()
+	seq, err := w.Append([]byte("next"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), seq)
+	require.Equal(t, map[uint64]string{1: "complete", 2: "next"}, collectAll(t, w, 1))
+}
+
+func TestWAL_GroupCommit(t *testing.T) {
+	w, err := Open(t.TempDir(), &Options{MaxBatch: 16})
+	require.NoError(t, err)
+	defer w.Close()
+
+	n := 200
+	var wg sync.WaitGroup
+	seqs := make(chan uint64, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			seq, err := w.Append([]byte(fmt.Sprintf("record-%d", i)))
+			require.NoError(t, err)
+			seqs <- seq
+		}(i)
+	}
+	wg.Wait()
+	close(seqs)
+
+	uniq := make(map[uint64]struct{})
+	for seq := range seqs {
+		uniq[seq] = struct{}{}
+	}
+	require.Len(t, uniq, n)
+	require.Len(t, collectAll(t, w, 1), n)
+}
+
+func TestWAL_MaxSegments(t *testing.T) {
+	w, err := Open(t.TempDir(), &Options{SegmentSize: 1, MaxSegments: 2})
+	require.NoError(t, err)
+	defer w.Close()
+	for _, d := range []string{"a", "b", "c", "d"} {
+		_, err = w.Append([]byte(d))
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[uint64]string{3: "c", 4: "d"}, collectAll(t, w, 1))
+}
+
+func TestWAL_SnapshotBeforePrune(t *testing.T) {
+	calls := 0
+	w, err := Open(t.TempDir(), &Options{SegmentSize: 1, MaxSegments: 2, Snapshot: func() [][]byte {
+		calls++
+		return [][]byte{[]byte("state")}
+	}})
+	require.NoError(t, err)
+	defer w.Close()
+	for _, d := range []string{"a", "b", "c"} {
+		_, err = w.Append([]byte(d))
+		require.NoError(t, err)
+	}
+	// 写入c时清理a所在的segment，快照写在c之前
+	require.Equal(t, 1, calls)
+	require.Equal(t, map[uint64]string{2: "b", 3: "state", 4: "c"}, collectAll(t, w, 1))
+}
+
+// 写入n个字节后返回错误，模拟磁盘写满
+type failingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if len(p) <= fw.n {
+		fw.n -= len(p)
+		return fw.w.Write(p)
+	}
+	written, _ := fw.w.Write(p[:fw.n])
+	fw.n = 0
+	return written, errors.New("no space left on device")
+}
+
+func TestWAL_WriteErrorRollback(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, nil)
+	require.NoError(t, err)
+	_, err = w.Append([]byte("a"))
+	require.NoError(t, err)
+
+	// 写入一半时失败，已分配的seq回滚，之后的写入不受影响
+	w.mutex.Lock()
+	w.writer = bufio.NewWriterSize(&failingWriter{w: w.file, n: 5}, 16)
+	w.mutex.Unlock()
+	_, err = w.AppendBatch([][]byte{[]byte("bbbbbbbbbbbbbbbbbbbb"), []byte("c")})
+	require.Error(t, err)
+	seq, err := w.Append([]byte("d"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), seq)
+	require.Equal(t, map[uint64]string{1: "a", 2: "d"}, collectAll(t, w, 1))
+	require.NoError(t, w.Close())
+
+	// 失败时写入的部分已被截断，重新打开后不会被当作损坏的记录
+	w, err = Open(dir, nil)
+	require.NoError(t, err)
+	defer w.Close()
+	seq, err = w.Append([]byte("e"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), seq)
+	require.Equal(t, map[uint64]string{1: "a", 2: "d", 3: "e"}, collectAll(t, w, 1))
+}
+
+func TestWAL_AppendAfterClose(t *testing.T) {
+	w, err := Open(t.TempDir(), nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = w.Append([]byte("late"))
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestWAL_Follow(t *testing.T) {
+	w, err := Open(t.TempDir(), &Options{SegmentSize: 20, MaxBatch: 2})
+	require.NoError(t, err)
+	defer w.Close()
+	_, err = w.AppendBatch([][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	require.NoError(t, err)
+
+	stop := make(chan struct{})
+	got := make(chan *Record, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Follow(2, stop, func(records []*Record) error {
+			require.LessOrEqual(t, len(records), 2)
+			for _, r := range records {
+				got <- r
+			}
+			return nil
+		})
+	}()
+	// 先读取已有的记录，再等待跨segment的新记录
+	for _, d := range []string{"d", "e"} {
+		_, err = w.Append([]byte(d))
+		require.NoError(t, err)
+	}
+	for i, want := range []string{"b", "c", "d", "e"} {
+		r := <-got
+		require.Equal(t, uint64(i+2), r.Seq)
+		require.Equal(t, want, string(r.Data))
+	}
+	close(stop)
+	require.NoError(t, <-done)
+}
+
+func TestWAL_FollowRemoved(t *testing.T) {
+	w, err := Open(t.TempDir(), &Options{SegmentSize: 1, MaxSegments: 2})
+	require.NoError(t, err)
+	defer w.Close()
+	for _, d := range []string{"a", "b", "c", "d"} {
+		_, err = w.Append([]byte(d))
+		require.NoError(t, err)
+	}
+	err = w.Follow(1, nil, func([]*Record) error { return nil })
+	require.ErrorIs(t, err, ErrSeqRemoved)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+	"github.com/fakeyanss/jt808-server-go/test/datagen"
+)
+
+// 批量生成历史数据，用于存储和查询的压测。
+// 输出为jsonl导入文件；指定-wal时注册和位置汇报写入服务端的入库WAL，服务端启动时自动导入。
+func main() {
+	conf := datagen.DefaultBulkConf()
+	var start, outDir, walDir string
+	flag.IntVar(&conf.DeviceCount, "devices", conf.DeviceCount, "device count")
+	flag.IntVar(&conf.Days, "days", conf.Days, "days of history")
+	flag.StringVar(&start, "start", conf.Start.Format("2006-01-02"), "first day of history, format 2006-01-02")
+	flag.Int64Var(&conf.Seed, "seed", conf.Seed, "random seed, same seed generates same data")
+	flag.DurationVar(&conf.MovingInterval, "moving-interval", conf.MovingInterval, "location report interval when driving")
+	flag.DurationVar(&conf.ParkingInterval, "parking-interval", conf.ParkingInterval, "location report interval when parking")
+	flag.IntVar(&conf.Workers, "workers", conf.Workers, "concurrent devices")
+	flag.StringVar(&outDir, "o", "./data/bulk/", "output dir of jsonl files")
+	flag.StringVar(&walDir, "wal", "", "write register and location into the ingest log dir of server")
+	flag.Parse()
+
+	var err error
+	conf.Start, err = time.ParseInLocation("2006-01-02", start, time.Local)
+	if err != nil {
+		exit(err)
+	}
+
+	jsonSink, err := datagen.NewJSONLinesSink(outDir)
+	if err != nil {
+		exit(err)
+	}
+	var sink datagen.BulkSink = jsonSink
+	if walDir != "" {
+		if err = storage.OpenIngestLog(walDir, &wal.Options{}); err != nil {
+			exit(err)
+		}
+		defer storage.CloseIngestLog()
+		sink = &datagen.IngestLogSink{Others: jsonSink}
+	}
+
+	begin := time.Now()
+	err = datagen.GenBulk(conf, sink)
+	if closeErr := jsonSink.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		exit(err)
+	}
+	fmt.Printf("Generate %d devices x %d days in %v, output=%s\n", conf.DeviceCount, conf.Days, time.Since(begin), outDir)
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "%+v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/client"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+	"github.com/fakeyanss/jt808-server-go/test/datagen"
+)
+
+// 触发一次主动安全报警的命令
+type alarmCmd struct {
+	extraID   uint8
+	alarmType uint8
+}
+
+var (
+	alarmCmdMutex sync.Mutex
+	alarmCmdChs   []chan *alarmCmd
+
+	pendingAlarms sync.Map // 已产生、等待平台下发附件上传指令的报警，<报警标识号, *datagen.ActiveSafetyAlarm>
+)
+
+func subscribeAlarmCmd() chan *alarmCmd {
+	alarmCmdMutex.Lock()
+	defer alarmCmdMutex.Unlock()
+	ch := make(chan *alarmCmd, 8)
+	alarmCmdChs = append(alarmCmdChs, ch)
+	return ch
+}
+
+// 从标准输入读取命令，每行一个，对所有模拟终端生效：
+//
+//	adas [type]  产生ADAS报警，type缺省时随机
+//	dsm [type]   产生DSM报警，type缺省时随机
+func readAlarmCmd(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		cmd := &alarmCmd{}
+		switch strings.ToLower(fields[0]) {
+		case "adas":
+			cmd.extraID, cmd.alarmType = model.ExtraIDADAS, randAlarmType(datagen.ADASAlarmTypes)
+		case "dsm":
+			cmd.extraID, cmd.alarmType = model.ExtraIDDSM, randAlarmType(datagen.DSMAlarmTypes)
+		default:
+			log.Warn().Str("cmd", scanner.Text()).Msg("Unknown alarm command, usage: adas|dsm [type]")
+			continue
+		}
+		if len(fields) > 1 {
+			t, err := strconv.ParseUint(fields[1], 0, 8)
+			if err != nil {
+				log.Warn().Str("cmd", scanner.Text()).Msg("Invalid alarm type")
+				continue
+			}
+			cmd.alarmType = uint8(t)
+		}
+
+		alarmCmdMutex.Lock()
+		for _, ch := range alarmCmdChs {
+			select {
+			case ch <- cmd:
+			default: // 终端处理不过来时丢弃
+			}
+		}
+		alarmCmdMutex.Unlock()
+	}
+}
+
+func randAlarmType(types []uint8) uint8 {
+	return types[rand.Intn(len(types))]
+}
+
+// 定时或按命令产生ADAS/DSM报警，随0x0200上报
+func reportActiveSafetyAlarm(ctx context.Context, cli *client.TCPClient, conf *config.ActiveSafetyConf, cmdCh <-chan *alarmCmd) {
+	deviceGeoConf := ctx.Value(DeviceGeoConfCtxKey{}).(*config.DeviceGeoConf)
+	geoCache := storage.GetGeoCache()
+
+	var tick <-chan time.Time
+	if conf.AlarmInterval > 0 {
+		ticker := time.NewTicker(time.Duration(conf.AlarmInterval) * time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var alarmID uint32
+	for {
+		var cmd *alarmCmd
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			if rand.Intn(2) == 0 {
+				cmd = &alarmCmd{extraID: model.ExtraIDADAS, alarmType: randAlarmType(datagen.ADASAlarmTypes)}
+			} else {
+				cmd = &alarmCmd{extraID: model.ExtraIDDSM, alarmType: randAlarmType(datagen.DSMAlarmTypes)}
+			}
+		case cmd = <-cmdCh:
+		}
+
+		device := getDevice(ctx)
+		deviceGeo, err := geoCache.GetGeoLatestByPhone(device.Phone)
+		if err != nil || device.Status != model.DeviceStatusOnline {
+			continue
+		}
+		alarm := datagen.NewActiveSafetyAlarm(conf, device, cmd.extraID, cmd.alarmType, alarmID)
+		alarmID++
+		pendingAlarms.Store(alarm.Identity.Key(), alarm)
+		cli.Send(datagen.GenMsg0200WithAlarm(deviceGeoConf, device, deviceGeo, alarm))
+		log.Info().Str("device", device.Phone).Str("alarmIdentity", alarm.Identity.Key()).
+			Msgf("Report active safety alarm, extraId=0x%02x, alarmType=0x%02x", alarm.ExtraID, alarm.AlarmType)
+	}
+}
+
+// 处理平台下发的0x9208，连接附件服务器上传报警附件
+func uploadAlarmAttachments(conf *config.ActiveSafetyConf) {
+	uploader := &client.AttachmentUploader{
+		ChunkSize:     conf.ChunkSize * 1024,
+		GapRate:       conf.GapRate,
+		Timeout:       time.Duration(conf.Timeout) * time.Second,
+		MaxRetransmit: conf.MaxRetransmit,
+	}
+	for req := range protocol.AlarmAttachmentRequests() {
+		req := req
+		phone := req.Header.PhoneNumber
+		device, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+		if err != nil {
+			log.Warn().Err(err).Str("device", phone).Msg("Fail to find device for alarm attachment request")
+			continue
+		}
+		key := req.AlarmIdentity.Key()
+		var alarm *datagen.ActiveSafetyAlarm
+		if v, ok := pendingAlarms.LoadAndDelete(key); ok {
+			alarm = v.(*datagen.ActiveSafetyAlarm)
+		} else {
+			// 平台可能针对模拟器重启前的报警下发指令，按ADAS报警生成附件
+			alarm = &datagen.ActiveSafetyAlarm{ExtraID: model.ExtraIDADAS, AlarmType: 0x01, Identity: req.AlarmIdentity}
+		}
+		routines.GoSafe(func() {
+			files := datagen.GenAlarmAttachments(conf, alarm, req.AlarmNumber)
+			err := uploader.Upload(req, device.ID, files)
+			if err != nil {
+				log.Error().Err(err).Str("device", phone).Str("alarmNumber", req.AlarmNumber).Msg("Fail to upload alarm attachments")
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
 	"github.com/fakeyanss/jt808-server-go/internal/client"
@@ -30,12 +31,21 @@ type (
 	DeviceGeoConfCtxKey struct{}
 
 	DevicePhoneCtxKey struct{}
+
+	IdentityCtxKey struct{}
+
+	MinSendIntervalCtxKey struct{}
 )
 
 func buildDevice(ctx context.Context, cli *client.TCPClient) *model.Device {
 	deviceConf := ctx.Value(DeviceConfCtxKey{}).(*config.DeviceConf)
 	cache := storage.GetDeviceCache()
-	device := datagen.GenDevice(deviceConf)
+	var device *model.Device
+	if identity, ok := ctx.Value(IdentityCtxKey{}).(*datagen.Identity); ok {
+		device = datagen.GenDeviceWithIdentity(deviceConf, identity)
+	} else {
+		device = datagen.GenDevice(deviceConf)
+	}
 	device.SessionID = cli.Session.ID
 	device.TransProto = model.TCPProto
 	device.Conn = cli.Session.Conn
@@ -57,16 +67,43 @@ func register(ctx context.Context, cli *client.TCPClient) {
 	device := getDevice(ctx)
 	deviceConf := ctx.Value(DeviceConfCtxKey{}).(*config.DeviceConf)
 	msg := datagen.GenMsg0100(deviceConf, device)
+	if identity, ok := ctx.Value(IdentityCtxKey{}).(*datagen.Identity); ok {
+		msg.PlateColor = identity.PlateColor
+	}
 	cli.Send(msg)
 }
 
+// 有鉴权码时直接鉴权，超时未上线则重新注册
+func login(ctx context.Context, cli *client.TCPClient) {
+	device := getDevice(ctx)
+	if device.AuthCode == "" {
+		register(ctx, cli)
+		return
+	}
+	cli.Send(datagen.GenMsg0102(device))
+	routines.GoSafe(func() {
+		time.Sleep(reauthTimeout)
+		if isOnline(ctx) {
+			return
+		}
+		device := getDevice(ctx)
+		device.AuthCode = ""
+		storage.GetDeviceCache().CacheDevice(device)
+		log.Warn().Str("device", device.Phone).Msg("Fail to auth with the given auth code, register again")
+		register(ctx, cli)
+	})
+}
+
 func keepalive(ctx context.Context, cli *client.TCPClient) {
 	device := getDevice(ctx)
 	deviceConf := ctx.Value(DeviceConfCtxKey{}).(*config.DeviceConf)
 	msg := datagen.GenMsg0002(device)
 
 	for {
-		cli.Send(msg)
+		// 离线期间不发送心跳，等待重连
+		if isOnline(ctx) {
+			cli.Send(msg)
+		}
 		select {
 		case <-ctx.Done():
 			return
@@ -87,23 +124,49 @@ func buildDeviceGeo(ctx context.Context) {
 func reportLocation(ctx context.Context, cli *client.TCPClient) {
 	deviceGeoConf := ctx.Value(DeviceGeoConfCtxKey{}).(*config.DeviceGeoConf)
 	geoCache := storage.GetGeoCache()
+	buf := getLocationBuffer(ctx)
 
+	// 汇报间隔按虚拟时间计算，加速后不短于最小发送间隔
+	interval := datagen.GetClock().RealDuration(time.Duration(deviceGeoConf.LocationReportInterval)*time.Second,
+		getMinSendInterval(ctx))
 	for {
 		device := getDevice(ctx)
 		deviceGeo, err := geoCache.GetGeoLatestByPhone(device.Phone)
 		if err == nil {
 			msg := datagen.GenMsg0200(deviceGeoConf, device, deviceGeo)
-			cli.Send(msg)
+			if buf != nil && device.Status != model.DeviceStatusOnline {
+				buf.push(msg) // 离线时缓存，上线后盲区补报
+			} else {
+				cli.Send(msg)
+			}
 		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(time.Duration(deviceGeoConf.LocationReportInterval) * time.Second):
+		case <-time.After(interval):
+		}
+	}
+}
+
+func getMinSendInterval(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(MinSendIntervalCtxKey{}).(time.Duration)
+	return d
+}
+
+func newClock(conf *config.VirtualClockConf) (*datagen.Clock, error) {
+	var start time.Time
+	if conf.Start != "" {
+		var err error
+		start, err = time.ParseInLocation("2006-01-02 15:04:05", conf.Start, time.Local)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Fail to parse clock start, start=%s", conf.Start)
 		}
 	}
+	return datagen.NewClock(start, conf.Speed), nil
 }
 
-func dialAndSend(cfg *config.Config, cliWg *sync.WaitGroup) {
+// identity为nil时按正则生成终端身份
+func dialAndSend(cfg *config.Config, cliWg *sync.WaitGroup, identity *datagen.Identity) {
 	cli := client.NewTCPClient()
 	addr := cfg.Client.Conn.RemoteAddr
 	for retry := 1; ; retry++ {
@@ -119,21 +182,38 @@ func dialAndSend(cfg *config.Config, cliWg *sync.WaitGroup) {
 		log.Error().Err(err).Str("addr", addr).Msgf("%s, retry", errDialMsg)
 		time.Sleep(retryIntervalInSecond * time.Second)
 	}
-	routines.GoSafe(func() {
-		defer cliWg.Done()
-		cli.Start()
-	})
+	offlineConf := cfg.Client.Offline
+	offlineEnabled := offlineConf != nil && offlineConf.Enable
+	if !offlineEnabled {
+		routines.GoSafe(func() {
+			defer cliWg.Done()
+			cli.Start()
+		})
+	}
 
 	routines.GoSafe(func() {
 		ctx := context.WithValue(context.Background(), DeviceConfCtxKey{}, cfg.Client.Device)
+		if identity != nil {
+			ctx = context.WithValue(ctx, IdentityCtxKey{}, identity)
+		}
 		d := buildDevice(ctx, cli)
 		ctx = context.WithValue(ctx, DevicePhoneCtxKey{}, d.Phone)
 		ctx = context.WithValue(ctx, DeviceGeoConfCtxKey{}, cfg.Client.DeviceGeo)
+		if cfg.Client.Clock != nil {
+			ctx = context.WithValue(ctx, MinSendIntervalCtxKey{}, time.Duration(cfg.Client.Clock.MinSendInterval)*time.Millisecond)
+		}
 		buildDeviceGeo(ctx)
+		if offlineEnabled {
+			ctx = context.WithValue(ctx, LocationBufferCtxKey{}, newLocationBuffer(offlineConf.BufferSize))
+			routines.GoSafe(func() {
+				defer cliWg.Done()
+				keepConnected(ctx, cli, addr, offlineConf)
+			})
+		}
 
 		var wg sync.WaitGroup
 		wg.Add(1)
-		register(ctx, cli)
+		login(ctx, cli)
 
 		routines.GoSafe(func() {
 			// device status checker
@@ -157,6 +237,13 @@ func dialAndSend(cfg *config.Config, cliWg *sync.WaitGroup) {
 			wg.Wait()
 			reportLocation(ctx, cli)
 		})
+		if cfg.Client.ActiveSafety != nil && cfg.Client.ActiveSafety.Enable {
+			cmdCh := subscribeAlarmCmd()
+			routines.GoSafe(func() {
+				wg.Wait()
+				reportActiveSafetyAlarm(ctx, cli, cfg.Client.ActiveSafety, cmdCh)
+			})
+		}
 	})
 }
 
@@ -170,11 +257,45 @@ func main() {
 	logCfg := cfg.ParseLogConf()
 	log.Logger = *logger.Configure(logCfg).Logger
 
+	if clockConf := cfg.Client.Clock; clockConf != nil {
+		clock, err := newClock(clockConf)
+		if err != nil {
+			log.Error().Err(err).Msg("Fail to parse virtual clock config")
+			os.Exit(1)
+		}
+		datagen.SetClock(clock)
+		log.Info().Float64("speed", clock.Speed()).Time("start", clock.Now()).Msg("Generate messages with virtual clock")
+	}
+
+	var identities []*datagen.Identity
+	if identityFile := cfg.Client.Device.IdentityFile; identityFile != "" {
+		var err error
+		identities, err = datagen.LoadIdentities(identityFile)
+		if err != nil {
+			log.Error().Err(err).Msg("Fail to load device identities")
+			os.Exit(1)
+		}
+		if len(identities) < cfg.Client.Concurrency {
+			log.Error().Int("identities", len(identities)).Int("concurrency", cfg.Client.Concurrency).
+				Msg("Identity file has fewer rows than concurrency")
+			os.Exit(1)
+		}
+	}
+
 	var cliWg sync.WaitGroup
 	cliWg.Add(cfg.Client.Concurrency)
 
+	if cfg.Client.ActiveSafety != nil && cfg.Client.ActiveSafety.Enable {
+		routines.GoSafe(func() { readAlarmCmd(os.Stdin) })
+		routines.GoSafe(func() { uploadAlarmAttachments(cfg.Client.ActiveSafety) })
+	}
+
 	for i := 0; i < cfg.Client.Concurrency; i++ {
-		dialAndSend(cfg, &cliWg)
+		var identity *datagen.Identity
+		if identities != nil {
+			identity = identities[i]
+		}
+		dialAndSend(cfg, &cliWg, identity)
 	}
 
 	cliWg.Wait()
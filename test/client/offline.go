@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/client"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/routines"
+	"github.com/fakeyanss/jt808-server-go/test/datagen"
+)
+
+// 重连后等待鉴权通过的超时时间
+const reauthTimeout = 30 * time.Second
+
+type LocationBufferCtxKey struct{}
+
+// 离线期间缓存的位置，重连鉴权后以0x0704盲区补报
+type locationBuffer struct {
+	mutex *sync.Mutex
+	size  int
+	items []*model.Msg0200
+}
+
+func newLocationBuffer(size int) *locationBuffer {
+	return &locationBuffer{mutex: &sync.Mutex{}, size: size}
+}
+
+// 缓存满时丢弃最早的位置
+func (b *locationBuffer) push(items ...*model.Msg0200) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.items = append(b.items, items...)
+	if len(b.items) > b.size {
+		b.items = b.items[len(b.items)-b.size:]
+	}
+}
+
+// 补报中断时，未发送的位置放回缓存头部，保持时间顺序
+func (b *locationBuffer) pushFront(items []*model.Msg0200) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.items = append(items, b.items...)
+	if len(b.items) > b.size {
+		b.items = b.items[len(b.items)-b.size:]
+	}
+}
+
+func (b *locationBuffer) drain() []*model.Msg0200 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	items := b.items
+	b.items = nil
+	return items
+}
+
+func getLocationBuffer(ctx context.Context) *locationBuffer {
+	buf, _ := ctx.Value(LocationBufferCtxKey{}).(*locationBuffer)
+	return buf
+}
+
+func isOnline(ctx context.Context) bool {
+	return getDevice(ctx).Status == model.DeviceStatusOnline
+}
+
+func markOffline(ctx context.Context) {
+	device := getDevice(ctx)
+	device.Status = model.DeviceStatusOffline
+	storage.GetDeviceCache().CacheDevice(device)
+}
+
+// 保持连接，断线后按间隔重连，重新鉴权并补报离线期间的位置
+func keepConnected(ctx context.Context, cli *client.TCPClient, addr string, conf *config.OfflineConf) {
+	var offlineUntil atomic.Int64 // 主动断线时，保持离线直到该时间，unix毫秒
+	if conf.DisconnectEvery > 0 {
+		routines.GoSafe(func() { simulateDisconnect(ctx, cli, conf, &offlineUntil) })
+	}
+
+	reconnectInterval := time.Duration(conf.ReconnectInterval) * time.Second
+	for {
+		cli.Start() // 连接断开时返回
+		markOffline(ctx)
+		log.Warn().Str("device", getDevice(ctx).Phone).Msg("Disconnected from server, buffering locations")
+
+		wait := reconnectInterval
+		if d := time.Until(time.UnixMilli(offlineUntil.Load())); d > wait {
+			wait = d
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		for {
+			err := cli.Dial(addr)
+			if err == nil {
+				break
+			}
+			log.Error().Err(err).Str("addr", addr).Msg("Fail to redial to the tcp addr, retry")
+			time.Sleep(reconnectInterval)
+		}
+
+		device := getDevice(ctx)
+		device.SessionID = cli.Session.ID
+		device.Conn = cli.Session.Conn
+		storage.GetDeviceCache().CacheDevice(device)
+		routines.GoSafe(func() { reauthAndFlush(ctx, cli, conf) })
+	}
+}
+
+// 上线DisconnectEvery秒后主动断线，模拟进入盲区
+func simulateDisconnect(ctx context.Context, cli *client.TCPClient, conf *config.OfflineConf, offlineUntil *atomic.Int64) {
+	for {
+		if !isOnline(ctx) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(conf.DisconnectEvery) * time.Second):
+		}
+		if !isOnline(ctx) {
+			continue
+		}
+		// 先标记离线，避免其他协程继续向即将关闭的连接发送
+		markOffline(ctx)
+		offlineUntil.Store(time.Now().Add(time.Duration(conf.DisconnectDuration) * time.Second).UnixMilli())
+		log.Info().Str("device", getDevice(ctx).Phone).Int("duration", conf.DisconnectDuration).Msg("Simulate entering blind area")
+		cli.Stop()
+	}
+}
+
+// 使用缓存的鉴权码重新鉴权，上线后将缓存的位置按批次补报
+func reauthAndFlush(ctx context.Context, cli *client.TCPClient, conf *config.OfflineConf) {
+	device := getDevice(ctx)
+	if device.AuthCode == "" {
+		register(ctx, cli) // 尚未注册成功就断线了
+	} else {
+		cli.Send(datagen.GenMsg0102(device))
+	}
+
+	deadline := time.Now().Add(reauthTimeout)
+	for !isOnline(ctx) {
+		if time.Now().After(deadline) {
+			// 鉴权码可能已失效，清除后下次重连重新注册
+			device = getDevice(ctx)
+			device.AuthCode = ""
+			storage.GetDeviceCache().CacheDevice(device)
+			log.Warn().Str("device", device.Phone).Msg("Fail to reauth, reconnect and register again")
+			cli.Stop()
+			return
+		}
+		time.Sleep(time.Second)
+	}
+
+	buf := getLocationBuffer(ctx)
+	items := buf.drain()
+	batches := datagen.GenMsg0704Batches(getDevice(ctx), model.LocationTypeBlindArea, items, conf.BatchSize)
+	for i, batch := range batches {
+		if !isOnline(ctx) {
+			// 补报过程中再次断线，剩余位置等待下次上线
+			var rest []*model.Msg0200
+			for _, b := range batches[i:] {
+				rest = append(rest, b.Items...)
+			}
+			buf.pushFront(rest)
+			return
+		}
+		cli.Send(batch)
+	}
+	if len(items) > 0 {
+		log.Info().Str("device", device.Phone).Int("locations", len(items)).Int("batches", len(batches)).
+			Msg("Uploaded blind area locations")
+	}
+}
@@ -0,0 +1,114 @@
+package datagen
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/fakeyanss/jt808-server-go/internal/client"
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 报警类型，取值见ADASAlarm和DSMAlarm的AlarmType
+var (
+	ADASAlarmTypes = []uint8{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+	DSMAlarmTypes  = []uint8{0x01, 0x02, 0x03, 0x04, 0x05}
+)
+
+// 模拟器产生的一个主动安全报警
+type ActiveSafetyAlarm struct {
+	ExtraID   uint8 // 0x64 ADAS, 0x65 DSM
+	AlarmType uint8
+	AlarmID   uint32
+	Identity  *model.AlarmIdentity
+}
+
+func NewActiveSafetyAlarm(conf *config.ActiveSafetyConf, device *model.Device, extraID, alarmType uint8, alarmID uint32) *ActiveSafetyAlarm {
+	return &ActiveSafetyAlarm{
+		ExtraID:   extraID,
+		AlarmType: alarmType,
+		AlarmID:   alarmID,
+		Identity: &model.AlarmIdentity{
+			TerminalID:      device.ID,
+			Time:            hex.FormatTime(GetClock().Now()),
+			SerialNumber:    uint8(alarmID),
+			AttachmentCount: uint8(conf.ImageCount + conf.VideoCount),
+		},
+	}
+}
+
+// 生成带0x64或0x65附加信息的位置汇报
+func GenMsg0200WithAlarm(conf *config.DeviceGeoConf, device *model.Device, deviceGeo *model.DeviceGeo, alarm *ActiveSafetyAlarm) *model.Msg0200 {
+	m := GenMsg0200(conf, device, deviceGeo)
+	m.Time = alarm.Identity.Time
+	speed := uint8(m.Speed / model.SpeedAccuracy)
+	status := uint16(m.StatusSign)
+	switch alarm.ExtraID {
+	case model.ExtraIDADAS:
+		adas := &model.ADASAlarm{
+			AlarmID:         alarm.AlarmID,
+			FlagStatus:      model.AlarmFlagNone,
+			AlarmType:       alarm.AlarmType,
+			AlarmLevel:      1,
+			Speed:           speed,
+			Altitude:        m.Altitude,
+			Latitude:        m.Latitude,
+			Longitude:       m.Longitude,
+			Time:            m.Time,
+			VehicleStatus:   status,
+			AlarmIdentifier: alarm.Identity,
+		}
+		switch alarm.AlarmType {
+		case 0x01, 0x03: // 前向碰撞，车距过近
+			adas.FrontCarSpeed, adas.FrontDistance = speed, 12
+		case 0x02: // 车道偏离
+			adas.DeviationType = 1
+		case 0x06: // 道路标识超限
+			adas.RoadSignType, adas.RoadSignData = 1, 60
+		}
+		m.Extras = append(m.Extras, adas.ToExtra())
+	case model.ExtraIDDSM:
+		dsm := &model.DSMAlarm{
+			AlarmID:         alarm.AlarmID,
+			FlagStatus:      model.AlarmFlagNone,
+			AlarmType:       alarm.AlarmType,
+			AlarmLevel:      1,
+			Speed:           speed,
+			Altitude:        m.Altitude,
+			Latitude:        m.Latitude,
+			Longitude:       m.Longitude,
+			Time:            m.Time,
+			VehicleStatus:   status,
+			AlarmIdentifier: alarm.Identity,
+		}
+		if alarm.AlarmType == 0x01 { // 疲劳驾驶
+			dsm.FatigueDegree = 5
+		}
+		m.Extras = append(m.Extras, dsm.ToExtra())
+	}
+	return m
+}
+
+// 生成报警的图片和视频附件，内容为随机数据，只保留文件格式的头尾标识
+func GenAlarmAttachments(conf *config.ActiveSafetyConf, alarm *ActiveSafetyAlarm, alarmNumber string) []*client.AttachmentFile {
+	var files []*client.AttachmentFile
+	seq := 0
+	gen := func(fileType uint8, ext string, size int, head, tail []byte) {
+		// 文件命名规则：文件类型_通道号_报警类型_序号_报警编号.后缀名
+		name := fmt.Sprintf("%02d_%02x_%02x%02x_%d_%s.%s", fileType, alarm.ExtraID, alarm.ExtraID, alarm.AlarmType, seq, alarmNumber, ext)
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		copy(data, head)
+		copy(data[len(data)-len(tail):], tail)
+		files = append(files, &client.AttachmentFile{Name: name, Type: fileType, Data: data})
+		seq++
+	}
+	for i := 0; i < conf.ImageCount; i++ {
+		gen(model.AttachmentTypeImage, "jpg", conf.ImageSize*1024, []byte{0xff, 0xd8, 0xff, 0xe0}, []byte{0xff, 0xd9})
+	}
+	for i := 0; i < conf.VideoCount; i++ {
+		gen(model.AttachmentTypeVideo, "mp4", conf.VideoSize*1024, []byte{0, 0, 0, 0x18, 'f', 't', 'y', 'p', 'm', 'p', '4', '2'}, nil)
+	}
+	return files
+}
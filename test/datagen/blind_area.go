@@ -0,0 +1,36 @@
+package datagen
+
+import (
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 重连后使用缓存的鉴权码重新鉴权
+func GenMsg0102(device *model.Device) *model.Msg0102 {
+	return &model.Msg0102{
+		Header:          genMsgHeader(0x0102, device),
+		AuthCodeLen:     uint8(len(device.AuthCode)),
+		AuthCode:        device.AuthCode,
+		IMEI:            device.IMEI,
+		SoftwareVersion: device.SoftwareVersion,
+	}
+}
+
+// 将缓存的位置按时间顺序切分为0x0704批量上传消息。
+// 每批不超过batchSize条，且消息体不超过model.MaxBodyLength，避免分包。
+func GenMsg0704Batches(device *model.Device, locationType uint8, items []*model.Msg0200, batchSize int) []*model.Msg0704 {
+	var batches []*model.Msg0704
+	var cur *model.Msg0704
+	length := 0
+	for _, item := range items {
+		itemLen := model.LocationItemLength(item)
+		if cur == nil || len(cur.Items) >= batchSize || length+itemLen > model.MaxBodyLength {
+			cur = &model.Msg0704{Header: genMsgHeader(model.MsgID0704, device), LocationType: locationType}
+			batches = append(batches, cur)
+			length = 3 // 数据项个数WORD + 位置数据类型BYTE
+		}
+		cur.Items = append(cur.Items, item)
+		cur.Count = uint16(len(cur.Items))
+		length += itemLen
+	}
+	return batches
+}
@@ -0,0 +1,64 @@
+package datagen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func TestGenMsg0704Batches(t *testing.T) {
+	device := &model.Device{Phone: "13012345678", VersionDesc: model.Version2019}
+	genItems := func(n, extraLen int) []*model.Msg0200 {
+		items := make([]*model.Msg0200, n)
+		for i := range items {
+			items[i] = &model.Msg0200{Time: "230301123456"}
+			if extraLen > 0 {
+				items[i].Extras = []*model.LocationExtra{{ID: 0xE0, Length: uint8(extraLen), Data: make([]byte, extraLen)}}
+			}
+		}
+		return items
+	}
+	tests := []struct {
+		name      string
+		items     []*model.Msg0200
+		batchSize int
+		want      []int
+	}{
+		{
+			name:      "case1: split by batch size",
+			items:     genItems(25, 0),
+			batchSize: 10,
+			want:      []int{10, 10, 5},
+		},
+		{
+			name:      "case2: split by body length",
+			items:     genItems(10, 200), // 每项2+28+2+200=232字节
+			batchSize: 10,
+			want:      []int{4, 4, 2},
+		},
+		{
+			name:      "case3: empty",
+			items:     nil,
+			batchSize: 10,
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := GenMsg0704Batches(device, model.LocationTypeBlindArea, tt.items, tt.batchSize)
+			var got []int
+			for _, b := range batches {
+				got = append(got, len(b.Items))
+				require.Equal(t, uint16(len(b.Items)), b.Count)
+				require.Equal(t, model.LocationTypeBlindArea, b.LocationType)
+				pkt, err := b.Encode()
+				require.NoError(t, err)
+				require.LessOrEqual(t, int(b.Header.Attr.BodyLength), model.MaxBodyLength)
+				require.NotEmpty(t, pkt)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
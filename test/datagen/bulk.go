@@ -0,0 +1,444 @@
+package datagen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+// 0x0200报警标志位
+const (
+	AlarmEmergency uint32 = 1 << 0 // 紧急报警
+	AlarmOverspeed uint32 = 1 << 1 // 超速报警
+	AlarmFatigue   uint32 = 1 << 2 // 疲劳驾驶报警
+)
+
+const earthRadiusKm = 6371.0
+
+var bulkProvinces = []string{"京", "津", "沪", "渝", "冀", "豫", "云", "辽", "黑", "湘", "皖", "鲁", "新", "苏", "浙", "赣", "鄂", "桂", "甘", "晋", "蒙", "陕", "吉", "闽", "贵", "粤", "青", "藏", "川", "宁", "琼"}
+
+var bulkDriverNames = []string{"张伟", "王芳", "李娜", "刘洋", "陈杰", "杨磊", "赵军", "黄勇", "周涛", "吴斌", "徐强", "孙鹏"}
+
+// 批量生成历史数据的配置
+type BulkConf struct {
+	DeviceCount     int           // 设备个数
+	Start           time.Time     // 历史数据开始时间
+	Days            int           // 历史数据天数
+	Seed            int64         // 随机种子，相同种子生成相同数据
+	MovingInterval  time.Duration // 行驶时位置汇报间隔
+	ParkingInterval time.Duration // 停车时位置汇报间隔
+	TripsPerDay     int           // 每天最多行程数
+	SpeedLimit      float64       // 超速阈值，km/h
+	FatigueDuration time.Duration // 连续驾驶超过此时长产生疲劳报警
+	DriverChangeP   float64       // 每天更换驾驶员的概率
+	SnapshotP       float64       // 每小时行驶中定时拍照的概率
+	Workers         int           // 并发生成的设备数，sink需要并发安全
+}
+
+func DefaultBulkConf() *BulkConf {
+	return &BulkConf{
+		DeviceCount:     1000,
+		Start:           time.Now().AddDate(0, -3, 0).Truncate(24 * time.Hour),
+		Days:            90,
+		Seed:            1,
+		MovingInterval:  30 * time.Second,
+		ParkingInterval: 5 * time.Minute,
+		TripsPerDay:     4,
+		SpeedLimit:      100,
+		FatigueDuration: 4 * time.Hour,
+		DriverChangeP:   0.2,
+		SnapshotP:       0.3,
+		Workers:         runtime.NumCPU(),
+	}
+}
+
+// 一次完整的行程
+type BulkTrip struct {
+	Phone      string          `json:"phone"`
+	StartTime  time.Time       `json:"startTime"`
+	EndTime    time.Time       `json:"endTime"`
+	Start      *model.Location `json:"start"`
+	End        *model.Location `json:"end"`
+	DistanceKm float64         `json:"distanceKm"`
+	MaxSpeed   float64         `json:"maxSpeed"`
+	DriverName string          `json:"driverName"`
+}
+
+// 两次行程之间的停车
+type BulkStop struct {
+	Phone     string          `json:"phone"`
+	StartTime time.Time       `json:"startTime"`
+	EndTime   time.Time       `json:"endTime"`
+	Location  *model.Location `json:"location"`
+}
+
+type BulkRegister struct {
+	Time time.Time      `json:"time"`
+	Msg  *model.Msg0100 `json:"msg"`
+}
+
+type BulkAlarm struct {
+	Phone     string          `json:"phone"`
+	Time      time.Time       `json:"time"`
+	AlarmSign uint32          `json:"alarmSign"`
+	Location  *model.Location `json:"location"`
+}
+
+type BulkDriverChange struct {
+	Phone      string    `json:"phone"`
+	Time       time.Time `json:"time"`
+	DriverName string    `json:"driverName"`
+	LicenseNo  string    `json:"licenseNo"` // 从业资格证编码
+}
+
+type BulkMedia struct {
+	Phone string    `json:"phone"`
+	Time  time.Time `json:"time"`
+	model.DeviceMedia
+}
+
+// 批量数据的写入目标
+type BulkSink interface {
+	WriteRegister(*BulkRegister) error
+	WriteLocation(*model.Msg0200) error
+	WriteTrip(*BulkTrip) error
+	WriteStop(*BulkStop) error
+	WriteAlarm(*BulkAlarm) error
+	WriteDriverChange(*BulkDriverChange) error
+	WriteMedia(*BulkMedia) error
+}
+
+// 单个设备的模拟状态
+type bulkVehicle struct {
+	conf   *BulkConf
+	r      *rand.Rand
+	sink   BulkSink
+	device *model.Device
+
+	lat, lon     float64
+	depots       [][2]float64 // 常去的场站和客户点
+	driver       string
+	drivingSince time.Time
+	alarmSign    uint32
+	serial       uint16
+	clock        time.Time // 模拟的当前时间
+}
+
+// 生成DeviceCount个设备在[Start, Start+Days)内的历史数据并写入sink。
+// 同一设备的数据按时间顺序写入，不同设备之间交错。
+func GenBulk(conf *BulkConf, sink BulkSink) error {
+	workers := conf.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	nos := make(chan int)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for no := range nos {
+				if err := newBulkVehicle(conf, sink, no).run(); err != nil {
+					errs <- errors.Wrapf(err, "Fail to generate bulk data, deviceNo=%d", no)
+					return
+				}
+			}
+		}()
+	}
+
+	var err error
+loop:
+	for i := 0; i < conf.DeviceCount; i++ {
+		select {
+		case nos <- i:
+		case err = <-errs:
+			break loop
+		}
+	}
+	close(nos)
+	wg.Wait()
+	if err == nil && len(errs) > 0 {
+		err = <-errs
+	}
+	return err
+}
+
+func newBulkVehicle(conf *BulkConf, sink BulkSink, no int) *bulkVehicle {
+	r := rand.New(rand.NewSource(conf.Seed + int64(no)))
+	device := &model.Device{
+		ID:          fmt.Sprintf("%07d", no),
+		Plate:       fmt.Sprintf("%s%c%05d", bulkProvinces[r.Intn(len(bulkProvinces))], 'A'+rune(r.Intn(26)), no%100000),
+		Phone:       bulkDevicePhone(no),
+		Keepalive:   time.Minute,
+		IMEI:        fmt.Sprintf("86%013d", no),
+		VersionDesc: model.Version2019,
+	}
+	v := &bulkVehicle{
+		conf:   conf,
+		r:      r,
+		sink:   sink,
+		device: device,
+		lat:    22 + r.Float64()*18, // 大致覆盖国内范围
+		lon:    105 + r.Float64()*17,
+	}
+	// 以出生点为中心，随机分布几个互相间隔至少2km的常去地点
+	n := 3 + r.Intn(4)
+	for len(v.depots) < n {
+		lat, lon := v.lat+(r.Float64()-0.5)*0.6, v.lon+(r.Float64()-0.5)*0.6
+		near := false
+		for _, d := range v.depots {
+			near = near || haversineKm(lat, lon, d[0], d[1]) < 2
+		}
+		if !near {
+			v.depots = append(v.depots, [2]float64{lat, lon})
+		}
+	}
+	v.lat, v.lon = v.depots[0][0], v.depots[0][1]
+	v.driver = bulkDriverNames[r.Intn(len(bulkDriverNames))]
+	v.clock = conf.Start
+	return v
+}
+
+func bulkDevicePhone(no int) string {
+	return fillDevicePhone(fmt.Sprintf("13%09d", no), 20)
+}
+
+func (v *bulkVehicle) run() error {
+	msg := &model.Msg0100{
+		Header:         v.header(model.MsgID0100),
+		ProvinceID:     11,
+		CityID:         100,
+		ManufacturerID: "fakeyanss",
+		DeviceMode:     "bulkgen",
+		DeviceID:       v.device.ID,
+		PlateColor:     2,
+		PlateNumber:    v.device.Plate,
+	}
+	if err := v.sink.WriteRegister(&BulkRegister{Time: v.clock, Msg: msg}); err != nil {
+		return err
+	}
+	for d := 0; d < v.conf.Days; d++ {
+		if err := v.runDay(v.conf.Start.AddDate(0, 0, d)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 每天从6点到22点之间安排若干次行程，其余时间停车
+func (v *bulkVehicle) runDay(day time.Time) error {
+	now := day.Add(6*time.Hour + time.Duration(v.r.Intn(120))*time.Minute)
+	if now.Before(v.clock) {
+		now = v.clock // 前一天的行程跨过了零点
+	}
+	dayEnd := day.Add(22 * time.Hour)
+
+	if v.r.Float64() < v.conf.DriverChangeP {
+		v.driver = bulkDriverNames[v.r.Intn(len(bulkDriverNames))]
+		err := v.sink.WriteDriverChange(&BulkDriverChange{
+			Phone:      v.device.Phone,
+			Time:       now,
+			DriverName: v.driver,
+			LicenseNo:  fmt.Sprintf("%018d", v.r.Int63n(1e18)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := v.park(now); err != nil {
+		return err
+	}
+	trips := 1 + v.r.Intn(v.conf.TripsPerDay)
+	for i := 0; i < trips && v.clock.Before(dayEnd); i++ {
+		if err := v.drive(); err != nil {
+			return err
+		}
+		dwell := time.Duration(15+v.r.Intn(180)) * time.Minute
+		if err := v.park(v.clock.Add(dwell)); err != nil {
+			return err
+		}
+	}
+	return v.park(day.Add(24 * time.Hour))
+}
+
+// 停车到to时刻，期间ACC关闭，按停车间隔汇报位置
+func (v *bulkVehicle) park(to time.Time) error {
+	from := v.clock
+	if !to.After(from) {
+		return nil
+	}
+	v.drivingSince = time.Time{}
+	err := v.sink.WriteStop(&BulkStop{
+		Phone:     v.device.Phone,
+		StartTime: from,
+		EndTime:   to,
+		Location:  v.location(),
+	})
+	if err != nil {
+		return err
+	}
+	for t := from; t.Before(to); t = t.Add(v.conf.ParkingInterval) {
+		if err = v.report(t, 0, 0, false); err != nil {
+			return err
+		}
+	}
+	v.clock = to
+	return nil
+}
+
+// 行驶到另一个常去地点
+func (v *bulkVehicle) drive() error {
+	dst := v.depots[v.r.Intn(len(v.depots))]
+	for haversineKm(v.lat, v.lon, dst[0], dst[1]) < 1 {
+		dst = v.depots[v.r.Intn(len(v.depots))]
+	}
+	trip := &BulkTrip{
+		Phone:      v.device.Phone,
+		StartTime:  v.clock,
+		Start:      v.location(),
+		DriverName: v.driver,
+	}
+	v.drivingSince = v.clock
+	cruise := 40 + v.r.Float64()*60
+	speed := 20.0
+	now := v.clock
+	for {
+		dist := haversineKm(v.lat, v.lon, dst[0], dst[1])
+		if dist < 0.2 {
+			break
+		}
+		// 速度围绕巡航速度随机游走，相邻两次汇报不会突变
+		speed = math.Min(140, math.Max(5, speed+(cruise-speed)*0.2+v.r.NormFloat64()*4))
+		step := speed * v.conf.MovingInterval.Hours()
+		ratio := math.Min(1, step/dist)
+		direction := bearing(v.lat, v.lon, dst[0], dst[1])
+		v.lat += (dst[0] - v.lat) * ratio
+		v.lon += (dst[1] - v.lon) * ratio
+		now = now.Add(v.conf.MovingInterval)
+		trip.DistanceKm += math.Min(step, dist)
+		trip.MaxSpeed = math.Max(trip.MaxSpeed, speed)
+		if err := v.report(now, speed, direction, true); err != nil {
+			return err
+		}
+	}
+	v.clock = now
+	trip.EndTime = now
+	trip.End = v.location()
+	return v.sink.WriteTrip(trip)
+}
+
+// 生成一条位置汇报，报警从无到有时同时写入报警和报警关联的多媒体
+func (v *bulkVehicle) report(t time.Time, speed float64, direction uint16, moving bool) error {
+	var alarm uint32
+	if moving && speed > v.conf.SpeedLimit {
+		alarm |= AlarmOverspeed
+	} else if moving && v.alarmSign&AlarmOverspeed != 0 && speed > v.conf.SpeedLimit-10 {
+		alarm |= AlarmOverspeed // 降到阈值以下10km/h才解除，避免报警抖动
+	}
+	if moving && !v.drivingSince.IsZero() && t.Sub(v.drivingSince) > v.conf.FatigueDuration {
+		alarm |= AlarmFatigue
+	}
+	if moving && v.r.Float64() < 0.00005 {
+		alarm |= AlarmEmergency
+	}
+	raised := alarm &^ v.alarmSign
+	v.alarmSign = alarm
+
+	geo := &model.GeoMeta{
+		LocationStatus:       1,
+		GPSLocationStatus:    1,
+		BeidouLocationStatus: 1,
+	}
+	if moving {
+		geo.ACCStatus = 1
+		geo.DrivingStatus = 1
+	}
+	msg := &model.Msg0200{
+		Header:     v.header(model.MsgID0200),
+		AlarmSign:  alarm,
+		StatusSign: geo.Encode(),
+		Latitude:   uint32(v.lat * model.LocationAccuracy),
+		Longitude:  uint32(v.lon * model.LocationAccuracy),
+		Altitude:   uint16(20 + v.r.Intn(30)),
+		Speed:      uint16(speed * model.SpeedAccuracy),
+		Direction:  direction,
+		Time:       hex.FormatTime(t),
+	}
+	if err := v.sink.WriteLocation(msg); err != nil {
+		return err
+	}
+
+	if raised != 0 {
+		err := v.sink.WriteAlarm(&BulkAlarm{Phone: v.device.Phone, Time: t, AlarmSign: raised, Location: v.location()})
+		if err != nil {
+			return err
+		}
+		return v.writeMedia(t, raised, 2) // 报警录像
+	}
+	if moving && v.r.Float64() < v.conf.SnapshotP*v.conf.MovingInterval.Hours() {
+		return v.writeMedia(t, 0, 0) // 定时拍照
+	}
+	return nil
+}
+
+func (v *bulkVehicle) writeMedia(t time.Time, alarmSign uint32, mediaType uint8) error {
+	start, end := t.Add(-10*time.Second), t.Add(10*time.Second)
+	size := uint32(50*1024 + v.r.Intn(200*1024))
+	if mediaType == 2 {
+		size = uint32(2*1024*1024 + v.r.Intn(8*1024*1024))
+	}
+	return v.sink.WriteMedia(&BulkMedia{
+		Phone: v.device.Phone,
+		Time:  t,
+		DeviceMedia: model.DeviceMedia{
+			DeviceMediaQuery: model.DeviceMediaQuery{
+				LogicChannelID: uint8(1 + v.r.Intn(4)),
+				StartTime:      &start,
+				EndTime:        &end,
+				AlarmSign:      alarmSign,
+				MediaType:      mediaType,
+				StreamType:     1,
+				StorageType:    1,
+			},
+			Size: size,
+		},
+	})
+}
+
+func (v *bulkVehicle) header(msgID uint16) *model.MsgHeader {
+	v.serial++
+	h := genMsgHeader(msgID, v.device)
+	h.SerialNumber = v.serial
+	return h
+}
+
+func (v *bulkVehicle) location() *model.Location {
+	return &model.Location{Latitude: v.lat, Longitude: v.lon}
+}
+
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// 两点之间的方位角，0-359，正北为0，顺时针
+func bearing(lat1, lon1, lat2, lon2 float64) uint16 {
+	rad := math.Pi / 180
+	y := math.Sin((lon2-lon1)*rad) * math.Cos(lat2*rad)
+	x := math.Cos(lat1*rad)*math.Sin(lat2*rad) - math.Sin(lat1*rad)*math.Cos(lat2*rad)*math.Cos((lon2-lon1)*rad)
+	deg := math.Atan2(y, x) / rad
+	return uint16(math.Mod(deg+360, 360))
+}
@@ -0,0 +1,163 @@
+package datagen
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+// 导入文件名，每种数据一个jsonl文件
+const (
+	BulkFileRegister     = "register.jsonl"
+	BulkFileLocation     = "location.jsonl"
+	BulkFileTrip         = "trip.jsonl"
+	BulkFileStop         = "stop.jsonl"
+	BulkFileAlarm        = "alarm.jsonl"
+	BulkFileDriverChange = "driver_change.jsonl"
+	BulkFileMedia        = "media.jsonl"
+)
+
+var bulkFiles = []string{BulkFileRegister, BulkFileLocation, BulkFileTrip, BulkFileStop, BulkFileAlarm, BulkFileDriverChange, BulkFileMedia}
+
+// 将数据写入目录下的jsonl导入文件，每行一条记录
+type JSONLinesSink struct {
+	mutex   *sync.Mutex
+	files   map[string]*os.File
+	writers map[string]*bufio.Writer
+}
+
+func NewJSONLinesSink(dir string) (*JSONLinesSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "Fail to create bulk data dir")
+	}
+	s := &JSONLinesSink{
+		mutex:   &sync.Mutex{},
+		files:   make(map[string]*os.File),
+		writers: make(map[string]*bufio.Writer),
+	}
+	for _, name := range bulkFiles {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			s.Close()
+			return nil, errors.Wrapf(err, "Fail to create bulk data file, name=%s", name)
+		}
+		s.files[name] = f
+		s.writers[name] = bufio.NewWriterSize(f, 1<<20)
+	}
+	return s, nil
+}
+
+func (s *JSONLinesSink) write(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to serialize bulk record, name=%s", name)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	w := s.writers[name]
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+func (s *JSONLinesSink) WriteRegister(r *BulkRegister) error  { return s.write(BulkFileRegister, r) }
+func (s *JSONLinesSink) WriteLocation(m *model.Msg0200) error { return s.write(BulkFileLocation, m) }
+func (s *JSONLinesSink) WriteTrip(t *BulkTrip) error          { return s.write(BulkFileTrip, t) }
+func (s *JSONLinesSink) WriteStop(t *BulkStop) error          { return s.write(BulkFileStop, t) }
+func (s *JSONLinesSink) WriteAlarm(a *BulkAlarm) error        { return s.write(BulkFileAlarm, a) }
+func (s *JSONLinesSink) WriteMedia(m *BulkMedia) error        { return s.write(BulkFileMedia, m) }
+
+func (s *JSONLinesSink) WriteDriverChange(c *BulkDriverChange) error {
+	return s.write(BulkFileDriverChange, c)
+}
+
+func (s *JSONLinesSink) Close() error {
+	var firstErr error
+	for name, f := range s.files {
+		if w, ok := s.writers[name]; ok {
+			if err := w.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// 将注册和位置汇报编码成原始报文写入入库WAL，服务端启动时重放即完成导入。
+// 服务端没有存储的数据（行程、报警、驾驶员、多媒体）交给Others，为空则丢弃。
+// WAL每次写入都会等待落盘，配合BulkConf.Workers并发写入以利用批量提交。
+type IngestLogSink struct {
+	Others BulkSink
+}
+
+func (s *IngestLogSink) append(m model.JT808Msg, recvTime time.Time) error {
+	pkt, err := m.Encode()
+	if err != nil {
+		return errors.Wrap(err, "Fail to encode bulk msg")
+	}
+	headerPkt, err := m.GetHeader().Encode()
+	if err != nil {
+		return errors.Wrap(err, "Fail to encode bulk msg header")
+	}
+	return storage.AppendIngestRecord(&storage.IngestRecord{
+		RecvTime: recvTime,
+		Header:   m.GetHeader(),
+		Body:     pkt[len(headerPkt):],
+	})
+}
+
+func (s *IngestLogSink) WriteRegister(r *BulkRegister) error {
+	return s.append(r.Msg, r.Time)
+}
+
+func (s *IngestLogSink) WriteLocation(m *model.Msg0200) error {
+	return s.append(m, hex.ParseTime(m.Time))
+}
+
+func (s *IngestLogSink) WriteTrip(t *BulkTrip) error {
+	if s.Others == nil {
+		return nil
+	}
+	return s.Others.WriteTrip(t)
+}
+
+func (s *IngestLogSink) WriteStop(t *BulkStop) error {
+	if s.Others == nil {
+		return nil
+	}
+	return s.Others.WriteStop(t)
+}
+
+func (s *IngestLogSink) WriteAlarm(a *BulkAlarm) error {
+	if s.Others == nil {
+		return nil
+	}
+	return s.Others.WriteAlarm(a)
+}
+
+func (s *IngestLogSink) WriteDriverChange(c *BulkDriverChange) error {
+	if s.Others == nil {
+		return nil
+	}
+	return s.Others.WriteDriverChange(c)
+}
+
+func (s *IngestLogSink) WriteMedia(m *BulkMedia) error {
+	if s.Others == nil {
+		return nil
+	}
+	return s.Others.WriteMedia(m)
+}
@@ -0,0 +1,159 @@
+package datagen
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/codec/hex"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+)
+
+type memorySink struct {
+	mutex     sync.Mutex
+	registers []*BulkRegister
+	locations []*model.Msg0200
+	trips     []*BulkTrip
+	stops     []*BulkStop
+	alarms    []*BulkAlarm
+	drivers   []*BulkDriverChange
+	medias    []*BulkMedia
+}
+
+func (s *memorySink) WriteRegister(r *BulkRegister) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.registers = append(s.registers, r)
+	return nil
+}
+
+func (s *memorySink) WriteLocation(m *model.Msg0200) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.locations = append(s.locations, m)
+	return nil
+}
+
+func (s *memorySink) WriteTrip(t *BulkTrip) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.trips = append(s.trips, t)
+	return nil
+}
+
+func (s *memorySink) WriteStop(t *BulkStop) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stops = append(s.stops, t)
+	return nil
+}
+
+func (s *memorySink) WriteAlarm(a *BulkAlarm) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.alarms = append(s.alarms, a)
+	return nil
+}
+
+func (s *memorySink) WriteDriverChange(c *BulkDriverChange) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.drivers = append(s.drivers, c)
+	return nil
+}
+
+func (s *memorySink) WriteMedia(m *BulkMedia) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.medias = append(s.medias, m)
+	return nil
+}
+
+func testBulkConf(devices, days, workers int) *BulkConf {
+	conf := DefaultBulkConf()
+	conf.DeviceCount = devices
+	conf.Days = days
+	conf.Start = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	conf.Workers = workers
+	return conf
+}
+
+func TestGenBulk(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    *BulkConf
+		devices int
+	}{
+		{
+			name:    "case1: single worker",
+			conf:    testBulkConf(3, 7, 1),
+			devices: 3,
+		},
+		{
+			name:    "case2: concurrent workers",
+			conf:    testBulkConf(8, 2, 4),
+			devices: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memorySink{}
+			require.NoError(t, GenBulk(tt.conf, sink))
+			require.Len(t, sink.registers, tt.devices)
+			require.NotEmpty(t, sink.trips)
+			require.NotEmpty(t, sink.stops)
+
+			// 同一设备的位置汇报按时间递增，且落在生成范围内
+			end := tt.conf.Start.AddDate(0, 0, tt.conf.Days+1)
+			last := make(map[string]time.Time)
+			for _, m := range sink.locations {
+				ts := hex.ParseTime(m.Time)
+				require.False(t, ts.Before(last[m.Header.PhoneNumber]), "location out of order")
+				require.True(t, ts.Before(end))
+				last[m.Header.PhoneNumber] = ts
+			}
+			require.Len(t, last, tt.devices)
+
+			for _, trip := range sink.trips {
+				require.True(t, trip.EndTime.After(trip.StartTime))
+				require.Greater(t, trip.DistanceKm, 0.0)
+			}
+		})
+	}
+}
+
+func TestGenBulk_SameSeed(t *testing.T) {
+	s1, s2 := &memorySink{}, &memorySink{}
+	require.NoError(t, GenBulk(testBulkConf(2, 3, 1), s1))
+	require.NoError(t, GenBulk(testBulkConf(2, 3, 1), s2))
+	require.Equal(t, s1.trips, s2.trips)
+	require.Equal(t, s1.alarms, s2.alarms)
+	require.Equal(t, len(s1.locations), len(s2.locations))
+}
+
+func TestIngestLogSink(t *testing.T) {
+	others := &memorySink{}
+	require.NoError(t, storage.OpenIngestLog(t.TempDir(), nil))
+	require.NoError(t, GenBulk(testBulkConf(2, 1, 2), &IngestLogSink{Others: others}))
+	require.NotEmpty(t, others.trips)
+	require.Empty(t, others.locations)
+
+	require.NoError(t, protocol.RecoverFromIngestLog())
+	require.NoError(t, storage.CloseIngestLog())
+
+	for no := 0; no < 2; no++ {
+		phone := bulkDevicePhone(no)
+		_, err := storage.GetDeviceCache().GetDeviceByPhone(phone)
+		require.NoError(t, err)
+		geo, err := storage.GetGeoCache().GetGeoLatestByPhone(phone)
+		require.NoError(t, err)
+		require.NotZero(t, geo.Location.Latitude)
+
+		storage.GetDeviceCache().DelDeviceByPhone(phone)
+		storage.GetGeoCache().DelGeoByPhone(phone)
+	}
+}
@@ -0,0 +1,59 @@
+package datagen
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// 模拟终端的虚拟时钟，从start开始按speed倍速流逝，用于加速测试疲劳驾驶、按天统计等依赖时间的逻辑
+type Clock struct {
+	origin time.Time // 虚拟时间的起点
+	begin  time.Time // 对应的真实时间
+	speed  float64
+}
+
+// start为零值时从当前时间开始，speed不大于0时按1倍速
+func NewClock(start time.Time, speed float64) *Clock {
+	now := time.Now()
+	if start.IsZero() {
+		start = now
+	}
+	if speed <= 0 {
+		speed = 1
+	}
+	return &Clock{origin: start, begin: now, speed: speed}
+}
+
+func (c *Clock) Now() time.Time {
+	elapsed := time.Since(c.begin)
+	return c.origin.Add(time.Duration(float64(elapsed) * c.speed))
+}
+
+func (c *Clock) Speed() float64 {
+	return c.speed
+}
+
+// 虚拟时长对应的真实时长，不短于min，用于限制加速后的发送频率
+func (c *Clock) RealDuration(virtual, min time.Duration) time.Duration {
+	d := time.Duration(float64(virtual) / c.speed)
+	if d < min {
+		return min
+	}
+	return d
+}
+
+var clockInstance atomic.Pointer[Clock]
+
+func init() {
+	clockInstance.Store(NewClock(time.Time{}, 1))
+}
+
+// 设置生成消息时使用的时钟
+func SetClock(c *Clock) {
+	clockInstance.Store(c)
+}
+
+// 生成消息时使用的时钟，默认与真实时间一致
+func GetClock() *Clock {
+	return clockInstance.Load()
+}
@@ -0,0 +1,25 @@
+package datagen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2023, 1, 1, 8, 0, 0, 0, time.Local)
+	c := NewClock(start, 3600)
+	time.Sleep(10 * time.Millisecond)
+	// 真实的10ms约为虚拟的36s
+	elapsed := c.Now().Sub(start)
+	require.GreaterOrEqual(t, elapsed, 36*time.Second)
+	require.Less(t, elapsed, time.Hour)
+
+	require.Equal(t, time.Second, c.RealDuration(time.Hour, 100*time.Millisecond))
+	require.Equal(t, 100*time.Millisecond, c.RealDuration(10*time.Second, 100*time.Millisecond))
+
+	c = NewClock(time.Time{}, 0)
+	require.Equal(t, float64(1), c.Speed())
+	require.WithinDuration(t, time.Now(), c.Now(), time.Second)
+}
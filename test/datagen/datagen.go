@@ -171,7 +171,7 @@ func GenMsg0200(conf *config.DeviceGeoConf, device *model.Device, deviceGeo *mod
 		Speed:      uint16((deviceGeo.Drive.Speed + speedOffset) * model.SpeedAccuracy),
 		Direction:  uint16(nextDirection),
 	}
-	m.Time = hex.FormatTime(time.Now())
+	m.Time = hex.FormatTime(GetClock().Now())
 
 	// uint降至0后，再-1变为uint最大值。这里直接重设一个速度。
 	if m.Latitude > 90*model.LocationAccuracy {
@@ -0,0 +1,142 @@
+package datagen
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+var ErrBadIdentityFile = errors.New("Bad identity file")
+
+// 模拟终端的固定身份，对应身份文件中的一行
+type Identity struct {
+	Phone           string
+	ID              string
+	Plate           string
+	PlateColor      byte
+	IMEI            string
+	ProtocolVersion string // 2019、2013或2011，为空时使用device.protocolVersion
+	AuthCode        string // 已有的鉴权码，非空时直接鉴权
+}
+
+// 身份文件的列，首行为列名，authCode和version可以省略
+var identityColumns = []string{"phone", "id", "plate", "plateColor", "imei", "version", "authCode"}
+
+// 读取固定身份的CSV文件
+func LoadIdentities(path string) ([]*Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to open identity file, path=%s", path)
+	}
+	defer f.Close()
+	identities, err := ReadIdentities(f)
+	return identities, errors.Wrapf(err, "path=%s", path)
+}
+
+func ReadIdentities(r io.Reader) ([]*Identity, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(ErrBadIdentityFile, "missing header")
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, name := range identityColumns[:5] {
+		if _, ok := index[name]; !ok {
+			return nil, errors.Wrapf(ErrBadIdentityFile, "missing column %s", name)
+		}
+	}
+
+	var identities []*Identity
+	phones := make(map[string]bool)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(ErrBadIdentityFile, err.Error())
+		}
+		col := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		line, _ := reader.FieldPos(0)
+		identity := &Identity{
+			Phone:           col("phone"),
+			ID:              col("id"),
+			Plate:           col("plate"),
+			IMEI:            col("imei"),
+			ProtocolVersion: col("version"),
+			AuthCode:        col("authCode"),
+		}
+		if identity.Phone == "" {
+			return nil, errors.Wrapf(ErrBadIdentityFile, "empty phone, line=%d", line)
+		}
+		if phones[identity.Phone] {
+			return nil, errors.Wrapf(ErrBadIdentityFile, "duplicate phone %s, line=%d", identity.Phone, line)
+		}
+		phones[identity.Phone] = true
+		if color := col("plateColor"); color != "" {
+			c, err := strconv.ParseUint(color, 10, 8)
+			if err != nil {
+				return nil, errors.Wrapf(ErrBadIdentityFile, "bad plateColor %s, line=%d", color, line)
+			}
+			identity.PlateColor = byte(c)
+		}
+		switch identity.ProtocolVersion {
+		case "", "2019", "2013", "2011":
+		default:
+			return nil, errors.Wrapf(ErrBadIdentityFile, "bad version %s, line=%d", identity.ProtocolVersion, line)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// 使用固定身份生成终端，身份文件中没有的字段按配置生成
+func GenDeviceWithIdentity(deviceConf *config.DeviceConf, identity *Identity) *model.Device {
+	device := &model.Device{
+		ID:              identity.ID,
+		Plate:           identity.Plate,
+		Keepalive:       time.Duration(deviceConf.Keepalive) * time.Second,
+		IMEI:            identity.IMEI,
+		SoftwareVersion: "fakeyanss.github.io",
+		AuthCode:        identity.AuthCode,
+	}
+	version := identity.ProtocolVersion
+	if version == "" {
+		version = deviceConf.ProtocolVersion
+	}
+	switch version {
+	case "2019":
+		device.VersionDesc = model.Version2019
+		device.Phone = fillDevicePhone(identity.Phone, 20)
+	case "2013":
+		device.VersionDesc = model.Version2013
+		device.Phone = fillDevicePhone(identity.Phone, 12)
+	case "2011":
+		device.VersionDesc = model.Version2011
+		device.Phone = fillDevicePhone(identity.Phone, 12)
+	default:
+		device.Phone = identity.Phone
+	}
+	log.Debug().Str("device", device.Phone).Msgf("Generate device from identity=%+v", device)
+	return device
+}
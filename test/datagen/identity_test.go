@@ -0,0 +1,57 @@
+package datagen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fakeyanss/jt808-server-go/internal/config"
+	"github.com/fakeyanss/jt808-server-go/internal/protocol/model"
+)
+
+func TestReadIdentities(t *testing.T) {
+	csv := `# 注释行
+imei,phone,id,plate,plateColor,authCode
+860000000000001,13012345678,1234567,京A12345,1,
+860000000000002, 13012345679,1234568,京A12346,2,abc123
+`
+	identities, err := ReadIdentities(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	require.Equal(t, &Identity{
+		Phone:      "13012345678",
+		ID:         "1234567",
+		Plate:      "京A12345",
+		PlateColor: 1,
+		IMEI:       "860000000000001",
+	}, identities[0])
+	require.Equal(t, "13012345679", identities[1].Phone)
+	require.Equal(t, "abc123", identities[1].AuthCode)
+
+	conf := &config.DeviceConf{ProtocolVersion: "2013", Keepalive: 20}
+	device := GenDeviceWithIdentity(conf, identities[1])
+	require.Equal(t, "013012345679", device.Phone)
+	require.Equal(t, model.Version2013, device.VersionDesc)
+	require.Equal(t, "abc123", device.AuthCode)
+	require.Equal(t, "京A12346", device.Plate)
+
+	bad := []string{
+		"phone,id,plate,imei\n13012345678,1,京A1,2\n",                                     // 缺少plateColor列
+		"phone,id,plate,plateColor,imei\n13012345678,1,京A1,1,2\n13012345678,1,京A1,1,2\n", // 手机号重复
+		"phone,id,plate,plateColor,imei,version\n13012345678,1,京A1,1,2,2020\n",           // 协议版本错误
+		"phone,id,plate,plateColor,imei\n13012345678,1,京A1,red,2\n",                      // 车牌颜色错误
+	}
+	for _, b := range bad {
+		_, err = ReadIdentities(strings.NewReader(b))
+		require.ErrorIs(t, err, ErrBadIdentityFile, b)
+	}
+}
+
+func TestLoadIdentities(t *testing.T) {
+	identities, err := LoadIdentities("../client/configs/identities.csv")
+	require.NoError(t, err)
+	require.Len(t, identities, 3)
+	require.Equal(t, "2019", identities[0].ProtocolVersion)
+	require.Equal(t, byte(2), identities[1].PlateColor)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/fakeyanss/jt808-server-go/internal/protocol"
+	"github.com/fakeyanss/jt808-server-go/internal/storage"
+	"github.com/fakeyanss/jt808-server-go/pkg/logger"
+	"github.com/fakeyanss/jt808-server-go/pkg/wal"
+)
+
+const (
+	recvFrameMsg = "Received frame."
+	batchSize    = 256
+)
+
+type recovery struct {
+	since, until time.Time
+	pending      []*storage.IngestRecord // 批量写入WAL，避免每帧等待一次落盘
+
+	lines    int
+	frames   int
+	imported int
+	skipped  int // 服务端不会持久化的消息
+	failed   int // 无法解码的帧
+}
+
+// 从DEBUG级别的日志中恢复数据。
+// 解析日志（含滚动归档）中收到的帧，按服务端入库的规则写入WAL，服务端启动时重放即完成导入，
+// 导入的注册、位置等数据使用日志时间作为接收时间。
+// 不指定文件时读取-dir下的-file及其归档。
+func main() {
+	var dir, file, walDir, from, to string
+	flag.StringVar(&dir, "dir", "./logs/", "log directory")
+	flag.StringVar(&file, "file", "jt808-server-go.log", "log file name, rotated archives are read as well")
+	flag.StringVar(&walDir, "wal", "./data/wal/", "ingest log dir of server")
+	flag.StringVar(&from, "from", "", "import frames logged since the day, format 2006-01-02")
+	flag.StringVar(&to, "to", "", "import frames logged before the day, format 2006-01-02")
+	flag.Parse()
+
+	var err error
+	s := &recovery{}
+	if s.since, err = parseDay(from); err != nil {
+		exit(err)
+	}
+	if s.until, err = parseDay(to); err != nil {
+		exit(err)
+	}
+	files := flag.Args()
+	if len(files) == 0 {
+		if files, err = logger.LogFiles(dir, file); err != nil {
+			exit(err)
+		}
+	}
+	if len(files) == 0 {
+		exit(errors.Errorf("No log file found, dir=%s, file=%s", dir, file))
+	}
+
+	if err = storage.OpenIngestLog(walDir, &wal.Options{}); err != nil {
+		exit(err)
+	}
+	defer storage.CloseIngestLog()
+
+	begin := time.Now()
+	for _, path := range files {
+		imported := s.imported
+		if err = s.recoverFile(path); err != nil {
+			exit(err)
+		}
+		fmt.Printf("Read %s, imported %d frames\n", path, s.imported-imported)
+	}
+	fmt.Printf("Recover from %d files in %v: lines=%d, frames=%d, imported=%d, skipped=%d, failed=%d, wal=%s\n",
+		len(files), time.Since(begin), s.lines, s.frames, s.imported, s.skipped, s.failed, walDir)
+}
+
+func parseDay(day string) (time.Time, error) {
+	if day == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	return t, errors.Wrapf(err, "Fail to parse day, day=%s", day)
+}
+
+func (s *recovery) recoverFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to open log file, path=%s", path)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to open gzip log file, path=%s", path)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	// 日志中可能有很长的行，不使用bufio.Scanner
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			s.lines++
+			if importErr := s.recoverLine(line); importErr != nil {
+				return importErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return s.flush()
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Fail to read log file, path=%s", path)
+		}
+	}
+}
+
+// 只有写入WAL失败时返回错误
+func (s *recovery) recoverLine(line string) error {
+	if !strings.Contains(line, recvFrameMsg) {
+		return nil
+	}
+	e, err := logger.ParseLine(line)
+	if err != nil || e.Message != recvFrameMsg {
+		return nil
+	}
+	if e.Time.Before(s.since) || (!s.until.IsZero() && !e.Time.Before(s.until)) {
+		return nil
+	}
+	s.frames++
+	payload, err := hex.DecodeString(e.Fields["frame_payload"])
+	if err != nil || len(payload) == 0 {
+		s.failed++
+		return nil
+	}
+	pkt, err := protocol.DecodeIngestFrame(payload)
+	if errors.Is(err, protocol.ErrFrameNotIngested) {
+		s.skipped++
+		return nil
+	}
+	if err != nil {
+		s.failed++
+		return nil
+	}
+	s.pending = append(s.pending, &storage.IngestRecord{
+		RecvTime: e.Time,
+		Header:   pkt.Header,
+		Body:     pkt.Body,
+	})
+	if len(s.pending) >= batchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *recovery) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	if err := storage.AppendIngestRecords(s.pending); err != nil {
+		return err
+	}
+	s.imported += len(s.pending)
+	s.pending = s.pending[:0]
+	return nil
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "%+v\n", err)
+	os.Exit(1)
+}